@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bitterfq/data-ingestion-go/internal/auth"
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: apikey [-db path] [-tenant id] [-role role] <command>
+
+commands:
+  create <name>      create a key for -tenant with -role and print it; only its
+                     hash is stored, so it cannot be shown again
+  list               list the keys of -tenant, revoked ones included
+  revoke <id>        stop a key from authenticating`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	dbPath := flag.String("db", "data/data.db", "path to the sqlite database")
+	tenant := flag.String("tenant", "", "tenant the key acts for")
+	role := flag.String("role", string(auth.Reader), "role of a new key: reader, writer or admin")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	arg := func() string {
+		if flag.NArg() < 2 {
+			log.Fatalf("%s requires an argument", flag.Arg(0))
+		}
+		return flag.Arg(1)
+	}
+	needTenant := func() {
+		if *tenant == "" {
+			log.Fatalf("%s requires -tenant", flag.Arg(0))
+		}
+	}
+	ctx := context.Background()
+
+	switch cmd := flag.Arg(0); cmd {
+	case "create":
+		needTenant()
+		name := arg()
+		r, err := auth.ParseRole(*role)
+		if err != nil {
+			log.Fatal(err)
+		}
+		conn := open(*dbPath)
+		defer conn.Close()
+		id, key, err := auth.NewKey()
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = db.New(conn).CreateAPIKey(ctx, db.CreateAPIKeyParams{
+			KeyID:    id,
+			KeyHash:  auth.HashKey(key),
+			Name:     name,
+			TenantID: *tenant,
+			Role:     string(r),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(os.Stderr, "created key %s for %s as %s; it is shown only once\n", id, *tenant, r)
+		fmt.Println(key)
+	case "list":
+		needTenant()
+		conn := open(*dbPath)
+		defer conn.Close()
+		keys, err := db.New(conn).ListAPIKeys(ctx, *tenant)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, k := range keys {
+			revoked := ""
+			if k.RevokedAt.Valid {
+				revoked = "revoked " + k.RevokedAt.Time.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-16s %-20s %-6s %s %s\n", k.KeyID, k.Name, k.Role, k.CreatedAt.Format("2006-01-02 15:04:05"), revoked)
+		}
+		if len(keys) == 0 {
+			fmt.Println("no keys")
+		}
+	case "revoke":
+		id := arg()
+		conn := open(*dbPath)
+		defer conn.Close()
+		n, err := db.New(conn).RevokeAPIKey(ctx, id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if n == 0 {
+			log.Fatalf("no live key %s", id)
+		}
+		fmt.Printf("revoked %s\n", id)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", cmd)
+		usage()
+		os.Exit(2)
+	}
+}
+
+// open opens an existing database; opening a missing path would create an
+// empty one without the api_key table.
+func open(path string) *sql.DB {
+	if _, err := os.Stat(path); err != nil {
+		log.Fatal(err)
+	}
+	conn, err := database.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return conn
+}
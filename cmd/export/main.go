@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/sqldump"
+)
+
+func main() {
+	dbPath := flag.String("db", "data/data.db", "path to the sqlite database")
+	dialect := flag.String("dialect", "sqlite", "output dialect: sqlite, postgres or snowflake")
+	tables := flag.String("tables", "", "comma-separated tables to export (default the dim tables and part_supplier)")
+	batch := flag.Int("batch", sqldump.DefaultBatchSize, "rows per INSERT statement")
+	drop := flag.Bool("drop", false, "emit DROP TABLE IF EXISTS before each CREATE TABLE")
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+
+	d, err := sqldump.ParseDialect(*dialect)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conn, err := database.Open("file:" + *dbPath + "?mode=ro")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	opts := sqldump.Options{
+		Dialect:    d,
+		BatchSize:  *batch,
+		DropTables: *drop,
+	}
+	if *tables != "" {
+		opts.Tables = strings.Split(*tables, ",")
+	}
+
+	if err := sqldump.Dump(context.Background(), conn, w, opts); err != nil {
+		log.Fatal(err)
+	}
+}
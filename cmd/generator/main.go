@@ -3,96 +3,87 @@ package main
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/bitterfq/data-ingestion-go/internal/bulkload"
 	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/iceberg"
+	"github.com/bitterfq/data-ingestion-go/internal/logging"
+	"github.com/bitterfq/data-ingestion-go/internal/metrics"
+	"github.com/bitterfq/data-ingestion-go/internal/objectstore"
 	"github.com/bitterfq/data-ingestion-go/internal/parts"
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
 	"github.com/bitterfq/data-ingestion-go/internal/suppliers"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/bitterfq/data-ingestion-go/internal/tracing"
 )
 
+// generatorActor is recorded as modified_by on every row the generator loads.
+var generatorActor = sql.NullString{String: "generator", Valid: true}
+
+// fatal logs err and exits.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "err", err)
+	os.Exit(1)
+}
+
 func main() {
 
-	// 1. connect to db
-	conn, err := sql.Open("sqlite3", "data/data.db")
+	ctx := context.Background() //look into this
+
+	// LOG_LEVEL and LOG_FORMAT configure the logger
+	logger, err := logging.FromEnv()
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer conn.Close()
+	slog.SetDefault(logger)
 
-	schema, err := os.ReadFile("internal/database/schema.sql")
+	// OTEL_TRACES_EXPORTER picks where spans go; the run is one trace
+	shutdown, err := tracing.Setup(ctx, tracing.ConfigFromEnv("generator"), os.Stdout)
 	if err != nil {
-		log.Fatal("failed to read schema file:", err)
+		fatal(logger, "failed to set up tracing", err)
 	}
+	defer shutdown(context.Background())
+	ctx, span := tracing.Tracer(nil, "cmd/generator").Start(ctx, "generator.run")
+	defer span.End()
 
-	conn.Exec(string(schema))
+	// 1. connect to db and apply pending migrations; DATABASE_DSN picks the backend
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		dsn = storage.DefaultDSN
+	}
+	m := metrics.New()
+	store, err := storage.Open(ctx, dsn, storage.Options{Load: bulkload.Options{DeferIndexes: true}, Logger: logger, Metrics: m})
+	if err != nil {
+		fatal(logger, "failed to open database", err)
+	}
+	defer store.Close()
 
-	q := db.New(conn)
-	ctx := context.Background() //look into this
 	tenant := "tenant_acme"
 
 	// 2. generate suppliers
+	start := time.Now()
 	sups := suppliers.GenerateSuppliers(tenant, 10000)
+	m.ObserveGenerator("suppliers", "generate", len(sups), time.Since(start))
+	logger.Info("generated suppliers", "tenant_id", tenant, "rows", len(sups), "duration", time.Since(start))
 
-	suppliers.SupplierWriter("data/suppliers.csv", sups)
+	suppliers.SupplierWriter(logger, "data/suppliers.csv", sups)
 
-	// 3. insert suppliers in a transaction
-	tx, err := conn.BeginTx(ctx, nil)
-	if err != nil {
-		log.Fatal(err)
+	// 3. bulk insert suppliers in a transaction
+	supRows := make([]db.CreateSupplierParams, len(sups))
+	for i, sup := range sups {
+		supRows[i] = bulkload.SupplierParams(sup)
+		supRows[i].ModifiedBy = generatorActor
 	}
-	qtx := q.WithTx(tx)
-
-	for _, sup := range sups {
-		_, err := qtx.CreateSupplier(ctx, db.CreateSupplierParams{
-			SupplierID:        sup.SupplierID,
-			SupplierCode:      sql.NullString{String: sup.SupplierCode, Valid: sup.SupplierCode != ""},
-			TenantID:          sup.TenantID,
-			LegalName:         sup.LegalName,
-			DbaName:           sql.NullString{String: sup.DBAName, Valid: sup.DBAName != ""},
-			Country:           sql.NullString{String: sup.Country, Valid: sup.Country != ""},
-			Region:            sql.NullString{String: sup.Region, Valid: sup.Region != ""},
-			AddressLine1:      sql.NullString{String: sup.AddressLine1, Valid: sup.AddressLine1 != ""},
-			AddressLine2:      sql.NullString{String: sup.AddressLine2, Valid: sup.AddressLine2 != ""},
-			City:              sql.NullString{String: sup.City, Valid: sup.City != ""},
-			State:             sql.NullString{String: sup.State, Valid: sup.State != ""},
-			PostalCode:        sql.NullString{String: sup.PostalCode, Valid: sup.PostalCode != ""},
-			ContactEmail:      sql.NullString{String: sup.ContactEmail, Valid: sup.ContactEmail != ""},
-			ContactPhone:      sql.NullString{String: sup.ContactPhone, Valid: sup.ContactPhone != ""},
-			PreferredCurrency: sql.NullString{String: sup.PreferredCurrency, Valid: sup.PreferredCurrency != ""},
-			Incoterms:         sql.NullString{String: sup.Incoterms, Valid: sup.Incoterms != ""},
-			LeadTimeDaysAvg:   sql.NullInt64{Int64: int64(sup.LeadTimeDaysAvg), Valid: true},
-			LeadTimeDaysP95:   sql.NullInt64{Int64: int64(sup.LeadTimeDaysP95), Valid: true},
-			OnTimeDeliveryRate: sql.NullFloat64{
-				Float64: sup.OnTimeDeliveryRate,
-				Valid:   true,
-			},
-			DefectRatePpm:        sql.NullInt64{Int64: int64(sup.DefectRatePPM), Valid: true},
-			CapacityUnitsPerWeek: sql.NullInt64{Int64: int64(sup.CapacityUnitsPerWeek), Valid: true},
-			RiskScore:            sql.NullFloat64{Float64: sup.RiskScore, Valid: true},
-			FinancialRiskTier:    sql.NullString{String: sup.FinancialRiskTier, Valid: sup.FinancialRiskTier != ""},
-			Certifications:       sql.NullString{String: fmt.Sprintf("%v", sup.Certifications), Valid: len(sup.Certifications) > 0},
-			ComplianceFlags:      sql.NullString{String: fmt.Sprintf("%v", sup.ComplianceFlags), Valid: len(sup.ComplianceFlags) > 0},
-			ApprovedStatus:       sql.NullString{String: sup.ApprovedStatus, Valid: sup.ApprovedStatus != ""},
-			Contracts:            sql.NullString{String: fmt.Sprintf("%v", sup.Contracts), Valid: len(sup.Contracts) > 0},
-			TermsVersion:         sql.NullString{String: sup.TermsVersion, Valid: sup.TermsVersion != ""},
-			Lat:                  sql.NullFloat64{Float64: sup.GeoCoords.Lat, Valid: true},
-			Lon:                  sql.NullFloat64{Float64: sup.GeoCoords.Lon, Valid: true},
-			DataSource:           sql.NullString{String: sup.DataSource, Valid: sup.DataSource != ""},
-			SourceTimestamp:      sql.NullTime{Time: sup.SourceTimestamp, Valid: true},
-			IngestionTimestamp:   sql.NullTime{Time: sup.IngestionTimestamp, Valid: true},
-			SchemaVersion:        sql.NullString{String: sup.SchemaVersion, Valid: sup.SchemaVersion != ""},
-		})
-		if err != nil {
-			log.Fatal("failed to insert supplier:", sup.SupplierID, err)
-		}
-	}
-	if err := tx.Commit(); err != nil {
-		log.Fatal(err)
+	// the store logs each load's counts
+	stats, err := store.LoadSuppliers(ctx, supRows)
+	if err != nil {
+		fatal(logger, "failed to insert suppliers", err)
 	}
-	fmt.Println("Inserted suppliers:", len(sups))
+	observeLoad(m, "suppliers", stats)
 
 	// 4. collect supplier IDs
 	var supplierIDs []string
@@ -101,48 +92,85 @@ func main() {
 	}
 
 	// 5. generate parts
+	start = time.Now()
 	partsList := parts.GenerateParts(10000, tenant, supplierIDs)
-	parts.PartsWriter("data/parts.csv", partsList)
+	m.ObserveGenerator("parts", "generate", len(partsList), time.Since(start))
+	logger.Info("generated parts", "tenant_id", tenant, "rows", len(partsList), "duration", time.Since(start))
+	parts.PartsWriter(logger, "data/parts.csv", partsList)
 
-	// 6. insert parts in a transaction
-	tx, err = conn.BeginTx(ctx, nil)
-	if err != nil {
-		log.Fatal(err)
+	// 6. bulk insert parts in a transaction
+	partRows := make([]db.CreatePartParams, len(partsList))
+	var links []db.AddPartSupplierParams
+	for i, part := range partsList {
+		partRows[i] = bulkload.PartParams(part)
+		partRows[i].ModifiedBy = generatorActor
+		links = append(links, bulkload.PartSupplierParams(part)...)
+	}
+	if stats, err = store.LoadParts(ctx, partRows); err != nil {
+		fatal(logger, "failed to insert parts", err)
 	}
-	qtx = q.WithTx(tx)
-
-	for _, part := range partsList {
-		_, err := qtx.CreatePart(ctx, db.CreatePartParams{
-			PartID:               part.PartID,
-			TenantID:             part.TenantID,
-			PartNumber:           part.PartNumber,
-			Description:          part.Description,
-			Category:             sql.NullString{String: part.Category, Valid: part.Category != ""},
-			LifecycleStatus:      sql.NullString{String: part.LifecycleStatus, Valid: part.LifecycleStatus != ""},
-			Uom:                  sql.NullString{String: part.Uom, Valid: part.Uom != ""},
-			SpecHash:             sql.NullString{String: part.SpecHash, Valid: part.SpecHash != ""},
-			BomCompatibility:     sql.NullString{String: fmt.Sprintf("%v", part.BomCompatibility), Valid: len(part.BomCompatibility) > 0},
-			DefaultSupplierID:    sql.NullString{String: part.DefaultSupplierID, Valid: part.DefaultSupplierID != ""},
-			QualifiedSupplierIds: sql.NullString{String: fmt.Sprintf("%v", part.QualifiedSupplierIDs), Valid: len(part.QualifiedSupplierIDs) > 0},
-			UnitCost:             sql.NullFloat64{Float64: part.UnitCost, Valid: true},
-			Moq:                  sql.NullInt64{Int64: int64(part.Moq), Valid: true},
-			LeadTimeDaysAvg:      sql.NullInt64{Int64: int64(part.LeadTimeDaysAvg), Valid: true},
-			LeadTimeDaysP95:      sql.NullInt64{Int64: int64(part.LeadTimeDaysP95), Valid: true},
-			QualityGrade:         sql.NullString{String: part.QualityGrade, Valid: part.QualityGrade != ""},
-			ComplianceFlags:      sql.NullString{String: fmt.Sprintf("%v", part.ComplianceFlags), Valid: len(part.ComplianceFlags) > 0},
-			HazardClass:          sql.NullString{String: part.HazardClass, Valid: part.HazardClass != ""},
-			LastPriceChange:      sql.NullTime{Time: part.LastPriceChange, Valid: true},
-			DataSource:           sql.NullString{String: part.DataSource, Valid: part.DataSource != ""},
-			SourceTimestamp:      sql.NullTime{Time: part.SourceTimestamp, Valid: true},
-			IngestionTimestamp:   sql.NullTime{Time: part.IngestionTimestamp, Valid: true},
-			SchemaVersion:        sql.NullString{String: part.SchemaVersion, Valid: part.SchemaVersion != ""},
-		})
+	observeLoad(m, "parts", stats)
+
+	if stats, err = store.LoadPartSuppliers(ctx, links); err != nil {
+		fatal(logger, "failed to insert part suppliers", err)
+	}
+	observeLoad(m, "part_suppliers", stats)
+
+	// 7. append a snapshot to the local iceberg tables if a warehouse is configured
+	if warehouse := os.Getenv("ICEBERG_WAREHOUSE"); warehouse != "" {
+		supTable, err := iceberg.Open(filepath.Join(warehouse, "dim_supplier_v1"), iceberg.SupplierSchema, "tenant_id")
 		if err != nil {
-			log.Fatal("failed to insert part:", part.PartID, err)
+			fatal(logger, "failed to open suppliers table", err)
 		}
+		snap, err := supTable.Append(iceberg.SupplierRows(sups))
+		if err != nil {
+			fatal(logger, "failed to append suppliers snapshot", err)
+		}
+		logger.Info("appended iceberg snapshot", "table", "dim_supplier_v1", "snapshot_id", snap.SnapshotID, "rows", len(sups))
+
+		partTable, err := iceberg.Open(filepath.Join(warehouse, "dim_part_v1"), iceberg.PartSchema, "tenant_id")
+		if err != nil {
+			fatal(logger, "failed to open parts table", err)
+		}
+		snap, err = partTable.Append(iceberg.PartRows(partsList))
+		if err != nil {
+			fatal(logger, "failed to append parts snapshot", err)
+		}
+		logger.Info("appended iceberg snapshot", "table", "dim_part_v1", "snapshot_id", snap.SnapshotID, "rows", len(partsList))
 	}
-	if err := tx.Commit(); err != nil {
-		log.Fatal(err)
+
+	// 8. upload csv files to object storage if a bucket is configured
+	if cfg := objectstore.ConfigFromEnv(); cfg.Bucket != "" {
+		sink, err := objectstore.NewS3Sink(ctx, cfg)
+		if err != nil {
+			fatal(logger, "failed to connect to object storage", err)
+		}
+		now := time.Now()
+		files := map[string]string{
+			"suppliers": "data/suppliers.csv",
+			"parts":     "data/parts.csv",
+		}
+		for entity, filename := range files {
+			key := objectstore.PartitionKey(entity, tenant, now, filename)
+			if err := sink.UploadFile(ctx, filename, key); err != nil {
+				fatal(logger, "failed to upload csv", err)
+			}
+			logger.Info("uploaded csv", "file", filename, "key", key)
+		}
 	}
-	fmt.Println("Inserted parts:", len(partsList))
+
+	// 9. push the run's metrics if a Pushgateway is configured; the
+	// generator exits before Prometheus could scrape it
+	if url := os.Getenv("PUSHGATEWAY_URL"); url != "" {
+		if err := m.Push(ctx, url, "generator"); err != nil {
+			fatal(logger, "failed to push metrics", err)
+		}
+		logger.Info("pushed metrics", "url", url)
+	}
+}
+
+// observeLoad records a load's rows and throughput.
+func observeLoad(m *metrics.Metrics, entity string, stats bulkload.Stats) {
+	m.AddRows(entity, "generator", stats.Rows)
+	m.ObserveGenerator(entity, "load", int(stats.Rows), stats.Duration)
 }
@@ -3,9 +3,9 @@ package main
 import (
 	"context"
 	"database/sql"
-	"os"
 	"testing"
 
+	"github.com/bitterfq/data-ingestion-go/internal/database"
 	"github.com/bitterfq/data-ingestion-go/internal/database/db"
 	"github.com/bitterfq/data-ingestion-go/internal/parts"
 	"github.com/bitterfq/data-ingestion-go/internal/suppliers"
@@ -15,17 +15,13 @@ import (
 func setupTestDB(t *testing.T) (*sql.DB, *db.Queries) {
 	t.Helper()
 
-	conn, err := sql.Open("sqlite3", ":memory:")
+	conn, err := database.Open(":memory:")
 	if err != nil {
 		t.Fatalf("open sqlite: %v", err)
 	}
 
-	schema, err := os.ReadFile("../../internal/database/schema.sql")
-	if err != nil {
-		t.Fatalf("read schema.sql: %v", err)
-	}
-	if _, err := conn.Exec(string(schema)); err != nil {
-		t.Fatalf("apply schema: %v", err)
+	if err := database.Migrate(context.Background(), conn); err != nil {
+		t.Fatalf("apply migrations: %v", err)
 	}
 
 	return conn, db.New(conn)
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: migrate [-db path] <command>
+
+commands:
+  status       list migrations and whether they are applied
+  up           apply all pending migrations
+  down [n]     revert the last n migrations (default 1)
+  to <version> migrate up or down to version (0 reverts everything)`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	dbPath := flag.String("db", "data/data.db", "path to the sqlite database")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	conn, err := database.Open(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	m, err := database.NewMigrator(conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
+
+	before, err := m.Version(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var ran []database.Migration
+	switch cmd := flag.Arg(0); cmd {
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range status {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				state += " (modified since applied)"
+			}
+			fmt.Printf("%04d %-40s %s\n", s.Version, s.Name, state)
+		}
+		return
+	case "up":
+		ran, err = m.Up(ctx)
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			if steps, err = strconv.Atoi(flag.Arg(1)); err != nil || steps < 1 {
+				log.Fatalf("invalid step count %q", flag.Arg(1))
+			}
+		}
+		ran, err = m.Down(ctx, steps)
+	case "to":
+		if flag.NArg() < 2 {
+			log.Fatal("to requires a version")
+		}
+		version, convErr := strconv.Atoi(flag.Arg(1))
+		if convErr != nil {
+			log.Fatalf("invalid version %q", flag.Arg(1))
+		}
+		ran, err = m.To(ctx, version)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", cmd)
+		usage()
+		os.Exit(2)
+	}
+
+	for _, mig := range ran {
+		verb := "applied"
+		if mig.Version <= before {
+			verb = "reverted"
+		}
+		fmt.Printf("%s %04d_%s\n", verb, mig.Version, mig.Name)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(ran) == 0 {
+		fmt.Println("no migrations to run")
+	}
+}
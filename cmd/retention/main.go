@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/retention"
+)
+
+func main() {
+	dbPath := flag.String("db", "data/data.db", "path to the sqlite database")
+	rulesPath := flag.String("rules", "retention.json", "JSON file with the retention rules")
+	dryRun := flag.Bool("dry-run", false, "report what would be removed without deleting")
+	batch := flag.Int("batch", retention.DefaultBatchSize, "rows deleted per transaction")
+	pause := flag.Duration("pause", 0, "pause between batches, to leave room for other writers")
+	flag.Parse()
+
+	rules, err := retention.LoadFile(*rulesPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(rules) == 0 {
+		log.Fatalf("%s has no rules", *rulesPath)
+	}
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		log.Fatal(err)
+	}
+	conn, err := database.Open(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	results, err := retention.Run(context.Background(), conn, rules, retention.Options{
+		BatchSize: *batch,
+		Pause:     *pause,
+		DryRun:    *dryRun,
+	})
+	for _, r := range results {
+		if *dryRun {
+			fmt.Printf("%s: would remove %d rows\n", r.Rule, r.Matched)
+		} else {
+			fmt.Printf("%s: removed %d rows in %d batches (%s)\n", r.Rule, r.Deleted, r.Batches, r.Duration.Round(time.Millisecond))
+		}
+		if len(r.Sample) > 0 {
+			more := ""
+			if r.Matched > int64(len(r.Sample)) {
+				more = ", ..."
+			}
+			fmt.Printf("  %s%s\n", strings.Join(r.Sample, ", "), more)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -2,235 +2,203 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"math/rand"
+	"log/slog"
 	"net/http"
 	"os"
-	"strings"
-	"time"
-
-	"github.com/bitterfq/data-ingestion-go/internal/database/db"
-	_ "github.com/mattn/go-sqlite3"
-	"github.com/oklog/ulid/v2"
-
-	"github.com/bitterfq/data-ingestion-go/internal/snowflake"
+	"os/signal"
+	"syscall"
+
+	"github.com/bitterfq/data-ingestion-go/internal/api"
+	"github.com/bitterfq/data-ingestion-go/internal/auth"
+	"github.com/bitterfq/data-ingestion-go/internal/config"
+	"github.com/bitterfq/data-ingestion-go/internal/logging"
+	"github.com/bitterfq/data-ingestion-go/internal/metrics"
+	"github.com/bitterfq/data-ingestion-go/internal/ratelimit"
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
+	"github.com/bitterfq/data-ingestion-go/internal/tracing"
 )
 
 func main() {
-
-	// 1. connect to db
-	conn, err := sql.Open("sqlite3", "data/data.db")
+	// flags, the environment and an optional file configure the server
+	cfg, err := config.Load(os.Args[1:], os.Getenv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer conn.Close()
 
-	// create schema if it doesn't exist
-	schema, err := os.ReadFile("internal/database/schema.sql")
+	// LOG_LEVEL and LOG_FORMAT configure the logger
+	logger, err := logging.FromEnv()
 	if err != nil {
 		log.Fatal(err)
 	}
+	slog.SetDefault(logger)
 
-	if _, err := conn.Exec(string(schema)); err != nil && !strings.Contains(err.Error(), "already exists") {
-		log.Fatal(err)
+	if err := run(cfg, logger); err != nil {
+		logger.Error("server failed", "err", err)
+		os.Exit(1)
 	}
+}
 
-	q := db.New(conn)
-	ctx := context.Background() //look into this
-
-	log.Printf("Starting server on :8080")
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		fmt.Fprintln(w, "OK")
-	})
-
-	// POST /suppliers
-	mux.HandleFunc("/suppliers", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		// Handle supplier creation
-
-		var body struct {
-			TenantID  string `json:"tenant_id"`
-			LegalName string `json:"legal_name"`
-		}
-
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
-
-		sup, err := q.CreateSupplier(ctx, db.CreateSupplierParams{
-			SupplierID:   ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String(), // Generate a unique ID
-			TenantID:     body.TenantID,
-			SupplierCode: sql.NullString{String: "code", Valid: true},
-			LegalName:    body.LegalName,
-		})
-
-		if err != nil {
-			http.Error(w, "Failed to insert supplier", http.StatusInternalServerError)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(sup)
-	})
-
-	// POST /parts
-	mux.HandleFunc("/parts", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		// Handle part creation
-
-		var body struct {
-			TenantID    string `json:"tenant_id"`
-			PartNumber  string `json:"part_number"`
-			Description string `json:"description"`
-		}
-
-		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
-		part, err := q.CreatePart(ctx, db.CreatePartParams{
-			PartID:      ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String(), // Generate a unique ID
-			TenantID:    body.TenantID,
-			PartNumber:  body.PartNumber,
-			Description: body.Description,
-		})
-
+// authenticator accepts the API keys in a SQLite store and, when keys to
+// verify them are configured, JWT bearer tokens.
+func authenticator(cfg config.Config, store storage.Store) (auth.Authenticator, error) {
+	var chain auth.Chain
+	if backend, _ := storage.ParseDSN(cfg.DSN); backend == "sqlite" {
+		chain = append(chain, auth.APIKeys{Store: store})
+	}
+	jwt := auth.JWT{Secret: []byte(cfg.JWTSecret), Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience}
+	if cfg.JWTPublicKeys != "" {
+		data, err := os.ReadFile(cfg.JWTPublicKeys)
 		if err != nil {
-			http.Error(w, "Failed to insert part", http.StatusInternalServerError)
-			return
+			return nil, err
 		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(part)
-	})
-
-	// DELETE /suppliers/{id}
-	mux.HandleFunc("/suppliers/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodDelete {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		// grab everything after /suppliers/
-		id := r.URL.Path[len("/suppliers/"):]
-		if id == "" {
-			http.Error(w, "Supplier ID is required", http.StatusBadRequest)
-			return
-		}
-
-		if err := q.DeleteSupplier(r.Context(), id); err != nil {
-			log.Printf("failed to delete supplier: %v", err)
-			http.Error(w, "Failed to delete supplier", http.StatusInternalServerError)
-			return
+		if jwt.PublicKeys, err = auth.ParsePublicKeys(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.JWTPublicKeys, err)
 		}
+	}
+	if len(jwt.Secret) > 0 || len(jwt.PublicKeys) > 0 {
+		chain = append(chain, jwt)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("no way to authenticate: DuckDB stores hold no API keys, so configure JWT_SECRET, JWT_SECRET_FILE or JWT_PUBLIC_KEYS, or set AUTH=false")
+	}
+	return chain, nil
+}
 
-		w.WriteHeader(http.StatusNoContent)
-	})
-
-	// delete /parts
-	mux.HandleFunc("/parts/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodDelete {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		id := r.URL.Path[len("/parts/"):]
-		if id == "" {
-			http.Error(w, "Part ID is required", http.StatusBadRequest)
-			return
-		}
-		if err := q.DeletePart(r.Context(), id); err != nil {
-			log.Printf("failed to delete part %s: %v", id, err)
-			http.Error(w, "Failed to delete part", http.StatusInternalServerError)
-			return
-		}
-		log.Printf("deleted part id=%s", id)
-		w.WriteHeader(http.StatusNoContent)
-	})
-
-	// fetch from snowflake and insert into db
-	mux.HandleFunc("/fetch-and-insert", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		// Connect to Snowflake
-		sfDB, err := snowflake.NewClient("")
-		if err != nil {
-			http.Error(w, "Failed to connect to Snowflake: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		defer sfDB.Close()
-
-		rows, err := sfDB.Query("SELECT SUPPLIER_ID, TENANT_ID, SUPPLIER_CODE, LEGAL_NAME, DBA_NAME,COUNTRY, REGION, ADDRESS_LINE1, ADDRESS_LINE2, CITY, STATE, POSTAL_CODE FROM SUPPLY_CHAIN.PUBLIC.SUPPLIERS")
-		if err != nil {
-			http.Error(w, "Failed to query Snowflake: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-
-		//var insertedSuppliers []db.Supplier
-		for rows.Next() {
-			var id, tenantID, legalName string
-			var supplierCode, dbaName, country, region, addressLine1,
-				addressLine2, city, state, postalCode sql.NullString
-
-			if err := rows.Scan(
-				&id, &tenantID, &supplierCode, &legalName,
-				&dbaName, &country, &region, &addressLine1,
-				&addressLine2, &city, &state, &postalCode,
-			); err != nil {
-				http.Error(w, "Failed to scan row: "+err.Error(), http.StatusInternalServerError)
+// limiter enforces the rules in path, or the default rules when path is
+// empty, and rereads path on SIGHUP until ctx is done. A file that fails to
+// load on SIGHUP leaves the rules in force.
+func limiter(ctx context.Context, path string, logger *slog.Logger) (*ratelimit.Limiter, error) {
+	if path == "" {
+		return ratelimit.New(ratelimit.DefaultRules), nil
+	}
+	rules, err := ratelimit.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l := ratelimit.New(rules)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
 				return
+			case <-hup:
 			}
-
-			log.Printf("inserting supplier %s (%s)", id, legalName)
-
-			_, err := q.CreateSupplier(ctx, db.CreateSupplierParams{
-				SupplierID:   id,
-				TenantID:     tenantID,
-				SupplierCode: supplierCode,
-				LegalName:    legalName,
-				DbaName:      dbaName,
-				Country:      country,
-				Region:       region,
-				AddressLine1: addressLine1,
-				AddressLine2: addressLine2,
-				City:         city,
-				State:        state,
-				PostalCode:   postalCode,
-			})
+			rules, err := ratelimit.LoadFile(path)
 			if err != nil {
-				http.Error(w, "Failed to insert supplier into local DB: "+err.Error(), http.StatusInternalServerError)
-				return
+				logger.Error("reload rate limits failed", "file", path, "err", err)
+				continue
 			}
+			l.SetRules(rules)
+			logger.Info("reloaded rate limits", "file", path, "rules", len(rules))
+		}
+	}()
+	return l, nil
+}
 
-			//insertedSuppliers = append(insertedSuppliers, sup)
+// run serves until SIGINT or SIGTERM, then stops accepting connections and
+// waits for in-flight requests, ingests included, to finish.
+func run(cfg config.Config, logger *slog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		}
+	// OTEL_TRACES_EXPORTER picks where spans go
+	shutdownTracing, err := tracing.Setup(ctx, tracing.ConfigFromEnv("server"), os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	// connect to db and apply pending migrations
+	m := metrics.New()
+	store, err := storage.Open(ctx, cfg.DSN, storage.Options{Logger: logger, Metrics: m})
+	if err != nil {
+		logger.Error("failed to open database", "dsn", cfg.DSN, "err", err)
+		return err
+	}
+	defer store.Close()
 
-	})
+	apiCfg := api.Config{Logger: logger, Metrics: m, MaxBodyBytes: cfg.MaxBodyBytes, ValidateRequests: cfg.ValidateRequests}
+	if cfg.Auth {
+		if apiCfg.Authenticator, err = authenticator(cfg, store); err != nil {
+			return err
+		}
+		apiCfg.FailureLimiter = ratelimit.New(ratelimit.DefaultFailureRules)
+	} else {
+		logger.Warn("authentication is off; every caller may act for any tenant")
+	}
+	if apiCfg.Limiter, err = limiter(ctx, cfg.RateLimits, logger); err != nil {
+		return err
+	}
+	if cfg.CheckSnowflake {
+		apiCfg.Checks = map[string]api.Check{"snowflake": api.SnowflakeCheck(cfg.SnowflakeEnv)}
+	}
 
-	if err := http.ListenAndServe(":8080", mux); err != nil {
-		log.Fatalf("Error starting server: %v", err)
+	// the API and the Prometheus metrics, which are unauthenticated, so
+	// beside it only when no separate address keeps them private
+	handler := http.NewServeMux()
+	handler.Handle("/", api.NewServer(apiCfg, store, api.SnowflakeSource(cfg.SnowflakeEnv, m)))
+	metricsHandler := handler
+	if cfg.MetricsAddr != "" {
+		metricsHandler = http.NewServeMux()
+	}
+	metricsHandler.Handle("GET /metrics", m.Handler())
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	errc := make(chan error, 2)
+	go func() {
+		logger.Info("starting server", "addr", cfg.Addr)
+		errc <- srv.ListenAndServe()
+	}()
+	if cfg.MetricsAddr != "" {
+		metricsSrv := &http.Server{
+			Addr:              cfg.MetricsAddr,
+			Handler:           metricsHandler,
+			ReadHeaderTimeout: cfg.ReadTimeout,
+			ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+		}
+		go func() {
+			logger.Info("serving metrics", "addr", cfg.MetricsAddr)
+			errc <- metricsSrv.ListenAndServe()
+		}()
+		// scrapes go on while the API drains
+		defer metricsSrv.Close()
 	}
 
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	// a load is one transaction, so one cut off when the timeout expires
+	// rolls back and can be fetched again
+	logger.Info("shutting down", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return err
+	}
+	logger.Info("server stopped")
+	return nil
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/snapshot"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: snapshot [-db path] [-dir path] [-gzip] <command>
+
+commands:
+  create <label>     snapshot the database, safe while the server is running
+  list               list snapshots, oldest first
+  restore <ref>      restore a snapshot by name, or the newest with that label;
+                     the current database is snapshotted as "pre-restore" first
+  delete <name>      delete a snapshot`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	dbPath := flag.String("db", "data/data.db", "path to the sqlite database")
+	dir := flag.String("dir", snapshot.DefaultDir, "snapshot directory")
+	compress := flag.Bool("gzip", false, "compress new snapshots")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	arg := func() string {
+		if flag.NArg() < 2 {
+			log.Fatalf("%s requires an argument", flag.Arg(0))
+		}
+		return flag.Arg(1)
+	}
+	ctx := context.Background()
+
+	switch cmd := flag.Arg(0); cmd {
+	case "create":
+		conn := open(*dbPath)
+		defer conn.Close()
+		s, err := snapshot.Create(ctx, conn, *dir, arg(), snapshot.Options{Compress: *compress})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("created %s (%d bytes)\n", s.Name, s.Size)
+	case "list":
+		snaps, err := snapshot.List(*dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range snaps {
+			fmt.Printf("%-50s %-20s %s %12d\n", s.Name, s.Label, s.CreatedAt.Format("2006-01-02 15:04:05"), s.Size)
+		}
+		if len(snaps) == 0 {
+			fmt.Println("no snapshots")
+		}
+	case "restore":
+		s, err := snapshot.Find(*dir, arg())
+		if err != nil {
+			log.Fatal(err)
+		}
+		conn := open(*dbPath)
+		defer conn.Close()
+		// keep a way back in case the snapshot is not the one that was wanted
+		pre, err := snapshot.Create(ctx, conn, *dir, "pre-restore", snapshot.Options{Compress: *compress})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("created %s\n", pre.Name)
+		if err := snapshot.Restore(ctx, conn, s); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("restored %s; run migrate up if it predates the current schema\n", s.Name)
+	case "delete":
+		s, err := snapshot.Find(*dir, arg())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if s.Name != flag.Arg(1) {
+			log.Fatalf("delete takes a snapshot name, not a label; did you mean %s?", s.Name)
+		}
+		if err := snapshot.Delete(s); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("deleted %s\n", s.Name)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", cmd)
+		usage()
+		os.Exit(2)
+	}
+}
+
+// open opens an existing database; opening a missing path would create an
+// empty one and snapshot or restore into that instead.
+func open(path string) *sql.DB {
+	if _, err := os.Stat(path); err != nil {
+		log.Fatal(err)
+	}
+	conn, err := database.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return conn
+}
@@ -0,0 +1,340 @@
+// Package api serves suppliers and parts over HTTP. NewServer returns a plain
+// http.Handler, so cmd/server, tests and other services can all mount the
+// same routes on a store of their choosing.
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bitterfq/data-ingestion-go/internal/auth"
+	"github.com/bitterfq/data-ingestion-go/internal/logging"
+	"github.com/bitterfq/data-ingestion-go/internal/metrics"
+	"github.com/bitterfq/data-ingestion-go/internal/ratelimit"
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
+	"github.com/bitterfq/data-ingestion-go/internal/tracing"
+)
+
+// Config tunes a server. The zero value is usable.
+type Config struct {
+	// PageSize is the number of rows a collection or search returns when the
+	// request has no limit. Zero means 20.
+	PageSize int64
+	// MaxPageSize caps the limit parameter. Zero means 100.
+	MaxPageSize int64
+	// MaxBodyBytes caps request bodies; a larger one is answered with 413.
+	// Zero means 1 MiB.
+	MaxBodyBytes int64
+	// Checks are run by GET /readyz, by name, in addition to pinging the
+	// store.
+	Checks map[string]Check
+	// Authenticator identifies callers. Routes other than the health checks
+	// and the docs then need a caller of their tenant whose role allows
+	// them. Nil serves every request unauthenticated.
+	Authenticator auth.Authenticator
+	// Limiter caps how often and how many at once each tenant calls each
+	// route; see ratelimit.Rule. Nil admits every request.
+	Limiter *ratelimit.Limiter
+	// FailureLimiter caps the requests each client address may have
+	// answered 401, with the address as the tenant and every route counting
+	// as one; see ratelimit.DefaultFailureRules. It runs before
+	// authentication, where Limiter cannot. Nil leaves failures unlimited.
+	FailureLimiter *ratelimit.Limiter
+	// ValidateRequests rejects requests that do not match the OpenAPI
+	// document with 400 before they reach a handler.
+	ValidateRequests bool
+	// ValidateResponses checks every response against the OpenAPI document
+	// and replaces one that does not match with a 500. It buffers responses,
+	// so it is meant for tests and staging.
+	ValidateResponses bool
+	// Logger gets a record per request and the handlers' errors. Nil logs
+	// nothing.
+	Logger *slog.Logger
+	// TracerProvider gets a server span per request, continuing a trace the
+	// caller propagated. Nil means the global provider.
+	TracerProvider trace.TracerProvider
+	// Metrics gets the count and latency of requests by route and status,
+	// and the rows written by entity and source. Nil records nothing.
+	Metrics *metrics.Metrics
+}
+
+// server holds the dependencies the handlers share.
+type server struct {
+	cfg     Config
+	store   storage.Store
+	sources SourceFactory
+	spec    *spec
+	log     *slog.Logger
+	tracer  trace.Tracer
+	metrics *metrics.Metrics
+	mux     *http.ServeMux
+}
+
+// NewServer returns a handler serving the API from store. sources opens the
+// upstream system GET /fetch-and-insert reads from; when it is nil that route
+// answers 501. The caller keeps ownership of store.
+func NewServer(cfg Config, store storage.Store, sources SourceFactory) http.Handler {
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = 20
+	}
+	if cfg.MaxPageSize <= 0 {
+		cfg.MaxPageSize = 100
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 1 << 20
+	}
+	sp, err := loadSpec()
+	if err != nil {
+		// the document is embedded, so this is a bug in openapi.yaml
+		panic("api: invalid openapi.yaml: " + err.Error())
+	}
+	s := &server{
+		cfg:     cfg,
+		store:   store,
+		sources: sources,
+		spec:    sp,
+		log:     logging.OrDiscard(cfg.Logger),
+		tracer:  tracing.Tracer(cfg.TracerProvider, "internal/api"),
+		metrics: cfg.Metrics,
+	}
+
+	s.mux = http.NewServeMux()
+	for _, rt := range s.routes() {
+		s.mux.HandleFunc(rt.pattern, rt.handler)
+	}
+	var h http.Handler = s.mux
+	if cfg.ValidateRequests || cfg.ValidateResponses {
+		h = s.validate(h)
+	}
+	if cfg.Limiter != nil {
+		h = s.limitRequests(h)
+	}
+	if cfg.Authenticator != nil {
+		h = s.authenticate(h)
+	}
+	h = s.limitBodies(h)
+	if cfg.Metrics != nil {
+		h = s.measureRequests(h)
+	}
+	return s.logRequests(s.traceRequests(h))
+}
+
+// route returns the pattern r matches, or "" when it matches none. The
+// middleware look it up rather than read r.Pattern, which the mux only sets
+// on the request it is handed, and which requests rejected before reaching
+// it never get.
+func (s *server) route(r *http.Request) string {
+	_, pattern := s.mux.Handler(r)
+	return pattern
+}
+
+type route struct {
+	pattern string
+	// role is the least a caller needs when authentication is configured;
+	// empty means the route is public.
+	role    auth.Role
+	handler http.HandlerFunc
+}
+
+// routes lists every route; each must be described in openapi.yaml.
+func (s *server) routes() []route {
+	return []route{
+		{"GET /health", "", health},
+		{"GET /livez", "", health},
+		{"GET /readyz", "", s.readyz},
+		{"GET /openapi.json", "", s.openapi},
+		{"GET /docs", "", s.docs},
+		{"GET /docs/{file}", "", s.docsAsset},
+
+		{"GET /suppliers", auth.Reader, s.listSuppliers},
+		{"POST /suppliers", auth.Writer, s.createSupplier},
+		{"GET /suppliers/{id}", auth.Reader, s.getSupplier},
+		{"PUT /suppliers/{id}", auth.Writer, s.putSupplier},
+		{"PATCH /suppliers/{id}", auth.Writer, s.patchSupplier},
+		{"DELETE /suppliers/{id}", auth.Writer, s.deleteSupplier},
+		{"POST /suppliers/{id}/restore", auth.Writer, s.restoreSupplier},
+
+		{"GET /parts", auth.Reader, s.listParts},
+		{"POST /parts", auth.Writer, s.createPart},
+		{"GET /parts/{id}", auth.Reader, s.getPart},
+		{"PUT /parts/{id}", auth.Writer, s.putPart},
+		{"PATCH /parts/{id}", auth.Writer, s.patchPart},
+		{"DELETE /parts/{id}", auth.Writer, s.deletePart},
+		{"POST /parts/{id}/restore", auth.Writer, s.restorePart},
+
+		{"GET /search/{kind}", auth.Reader, s.search},
+		{"GET /summary/parts", auth.Reader, s.summarizeParts},
+		{"GET /fetch-and-insert", auth.Admin, s.fetchAndInsert},
+	}
+}
+
+// GET /health and GET /livez report that the process serves requests, without
+// checking its dependencies; GET /readyz does.
+func health(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "OK")
+}
+
+// actor names who made a change for the audit log: the authenticated caller,
+// else the X-Actor header, or "api" when the caller did not send one.
+func actor(r *http.Request) sql.NullString {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return sql.NullString{String: p.Subject, Valid: true}
+	}
+	if a := r.Header.Get("X-Actor"); a != "" {
+		return sql.NullString{String: a, Valid: true}
+	}
+	return sql.NullString{String: "api", Valid: true}
+}
+
+// tenantParam reads the required tenant_id query parameter; rows are only
+// visible to their own tenant.
+func tenantParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tenantID := r.URL.Query().Get("tenant_id")
+	if tenantID == "" {
+		invalid(w, r, fieldError{"tenant_id", "is required"})
+		return "", false
+	}
+	annotate(r, "tenant_id", tenantID)
+	if !ownTenant(w, r, tenantID) {
+		return "", false
+	}
+	return tenantID, true
+}
+
+// sameKey fills in a body's ID and tenant from the URL, rejecting a body that
+// tries to change either.
+func sameKey(w http.ResponseWriter, r *http.Request, idField string, bodyID **string, id string, bodyTenant **string, tenantID string) bool {
+	var f fields
+	if *bodyID != nil && **bodyID != id {
+		f.add(idField, "cannot be changed")
+	}
+	if *bodyTenant != nil && **bodyTenant != tenantID {
+		f.add("tenant_id", "cannot be changed")
+	}
+	if len(f) > 0 {
+		invalid(w, r, f...)
+		return false
+	}
+	*bodyID, *bodyTenant = &id, &tenantID
+	return true
+}
+
+// newID returns a ULID for a created resource.
+func newID() string {
+	entropy := ulid.Monotonic(rand.New(rand.NewSource(time.Now().UnixNano())), 0)
+	return ulid.MustNew(ulid.Timestamp(time.Now()), entropy).String()
+}
+
+// location is the URL of a created resource.
+func location(collection, tenantID, id string) string {
+	return "/" + collection + "/" + url.PathEscape(id) + "?tenant_id=" + url.QueryEscape(tenantID)
+}
+
+// noContent responds to a delete or restore that changed n rows.
+func noContent(w http.ResponseWriter, r *http.Request, kind, id string, n int64, err error) {
+	if err != nil {
+		logger(r).Error("failed to update "+kind, "id", id, "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to update "+kind)
+		return
+	}
+	if n == 0 {
+		writeProblem(w, r, http.StatusNotFound, strings.ToUpper(kind[:1])+kind[1:]+" not found")
+		return
+	}
+	annotate(r, "rows", n)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// taken responds 409 to a create that conflicted with the row of the same
+// kind and id, which exists in the caller's tenant when exists is 1. IDs are
+// unique across tenants, so one the tenant does not have is only said to be
+// unavailable, without a hint of who has it.
+func taken(w http.ResponseWriter, r *http.Request, kind, id string, exists int64, err error) {
+	if err != nil {
+		logger(r).Error("failed to look up "+kind, "id", id, "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to insert "+kind)
+		return
+	}
+	name := strings.ToUpper(kind[:1]) + kind[1:]
+	if exists == 0 {
+		writeProblem(w, r, http.StatusConflict, name+" id "+id+" is not available; choose another or leave it out to have one generated")
+		return
+	}
+	writeProblem(w, r, http.StatusConflict, name+" "+id+" already exists")
+}
+
+// decode reads a JSON body into v, rejecting unknown fields so typos are not
+// silently stored as NULL.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if tooLarge(w, r, err) {
+			return false
+		}
+		writeProblem(w, r, http.StatusBadRequest, "Invalid request body: "+err.Error(), bodyProblem(err)...)
+		return false
+	}
+	return true
+}
+
+// tooLarge answers 413 when err is from reading past the body size limit.
+func tooLarge(w http.ResponseWriter, r *http.Request, err error) bool {
+	var mbe *http.MaxBytesError
+	if !errors.As(err, &mbe) {
+		return false
+	}
+	writeProblem(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("The request body is larger than %d bytes", mbe.Limit))
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// limit reads the limit query parameter, the configured page size when absent.
+func (s *server) limit(r *http.Request) (int64, *fieldError) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return s.cfg.PageSize, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 1 || n > s.cfg.MaxPageSize {
+		return 0, &fieldError{"limit", fmt.Sprintf("must be between 1 and %d", s.cfg.MaxPageSize)}
+	}
+	return n, nil
+}
+
+// queryString returns a query parameter as a filter; absent means no filter.
+func queryString(r *http.Request, name string) sql.NullString {
+	v := r.URL.Query().Get(name)
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// queryFloat is queryString for numbers.
+func queryFloat(r *http.Request, name string) (sql.NullFloat64, *fieldError) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return sql.NullFloat64{}, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return sql.NullFloat64{}, &fieldError{name, "must be a number"}
+	}
+	return sql.NullFloat64{Float64: f, Valid: true}, nil
+}
@@ -0,0 +1,518 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/auth"
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
+)
+
+// testConfig checks every request and response against the OpenAPI
+// document, so a handler drifting from it fails the tests.
+var testConfig = Config{ValidateRequests: true, ValidateResponses: true}
+
+// newTestServer serves the API from a fresh in-memory SQLite store.
+func newTestServer(t *testing.T, sources SourceFactory) (http.Handler, storage.Store) {
+	t.Helper()
+	store := openStore(t)
+	return NewServer(testConfig, store, sources), store
+}
+
+// do sends a request with an optional JSON body through h.
+func do(t *testing.T, h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// expect fails the test unless w has the given status, and decodes its JSON
+// body into v when v is not nil.
+func expect(t *testing.T, w *httptest.ResponseRecorder, status int, v any) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("expected status %d, got %d: %s", status, w.Code, w.Body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("decode %s: %v", w.Body, err)
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	h, _ := newTestServer(t, nil)
+	expect(t, do(t, h, http.MethodGet, "/health", ""), http.StatusOK, nil)
+}
+
+func TestSuppliers(t *testing.T) {
+	h, _ := newTestServer(t, nil)
+
+	w := do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Acme", "country": "US", "certifications": ["ISO9001"]}`)
+	var created supplier
+	expect(t, w, http.StatusCreated, &created)
+	if created.SupplierID == nil || *created.ModifiedBy != "api" || created.Certifications[0] != "ISO9001" {
+		t.Fatalf("unexpected created supplier %+v", created)
+	}
+	if loc := w.Header().Get("Location"); loc != "/suppliers/"+*created.SupplierID+"?tenant_id=tenant_acme" {
+		t.Errorf("unexpected Location %q", loc)
+	}
+	for _, id := range []string{"SUP-1", "SUP-2"} {
+		expect(t, do(t, h, http.MethodPost, "/suppliers", `{"supplier_id": "`+id+`", "tenant_id": "tenant_acme", "legal_name": "Bolt", "country": "DE"}`), http.StatusCreated, nil)
+	}
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"supplier_id": "SUP-1", "tenant_id": "tenant_acme", "legal_name": "Again"}`), http.StatusConflict, nil)
+	// another tenant's ID is taken, but the answer does not say by whom
+	var conflict problem
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"supplier_id": "SUP-1", "tenant_id": "tenant_globex", "legal_name": "Again"}`), http.StatusConflict, &conflict)
+	if strings.Contains(conflict.Detail, "tenant") || strings.Contains(conflict.Detail, "exists") {
+		t.Errorf("expected a neutral conflict, got %q", conflict.Detail)
+	}
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme"}`), http.StatusBadRequest, nil)
+
+	// pages of one, filtered by country
+	var p page[supplier]
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme&country=DE&limit=1", ""), http.StatusOK, &p)
+	if len(p.Items) != 1 || *p.Items[0].SupplierID != "SUP-1" || p.Next != "SUP-1" {
+		t.Fatalf("unexpected first page %+v", p)
+	}
+	p = page[supplier]{}
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme&country=DE&limit=1&after=SUP-1", ""), http.StatusOK, &p)
+	if len(p.Items) != 1 || *p.Items[0].SupplierID != "SUP-2" || p.Next != "" {
+		t.Fatalf("unexpected last page %+v", p)
+	}
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme&limit=500", ""), http.StatusBadRequest, nil)
+	expect(t, do(t, h, http.MethodGet, "/suppliers", ""), http.StatusBadRequest, nil)
+
+	var got supplier
+	expect(t, do(t, h, http.MethodPatch, "/suppliers/SUP-1?tenant_id=tenant_acme", `{"city": "Berlin", "country": null}`), http.StatusOK, &got)
+	if *got.City != "Berlin" || got.Country != nil || *got.LegalName != "Bolt" {
+		t.Errorf("unexpected patched supplier %+v", got)
+	}
+	got = supplier{}
+	expect(t, do(t, h, http.MethodPut, "/suppliers/SUP-1?tenant_id=tenant_acme", `{"legal_name": "Bolt GmbH"}`), http.StatusOK, &got)
+	if *got.LegalName != "Bolt GmbH" || got.City != nil {
+		t.Errorf("unexpected replaced supplier %+v", got)
+	}
+	expect(t, do(t, h, http.MethodPut, "/suppliers/SUP-1?tenant_id=tenant_acme", `{"supplier_id": "SUP-9", "legal_name": "x"}`), http.StatusBadRequest, nil)
+	expect(t, do(t, h, http.MethodPut, "/suppliers/SUP-9?tenant_id=tenant_acme", `{"legal_name": "x"}`), http.StatusNotFound, nil)
+	expect(t, do(t, h, http.MethodPatch, "/suppliers/SUP-1?tenant_id=tenant_globex", `{"city": "x"}`), http.StatusNotFound, nil)
+
+	// another tenant sees nothing
+	expect(t, do(t, h, http.MethodGet, "/suppliers/SUP-1?tenant_id=tenant_globex", ""), http.StatusNotFound, nil)
+	expect(t, do(t, h, http.MethodDelete, "/suppliers/SUP-1?tenant_id=tenant_globex", ""), http.StatusNotFound, nil)
+
+	expect(t, do(t, h, http.MethodDelete, "/suppliers/SUP-1?tenant_id=tenant_acme", ""), http.StatusNoContent, nil)
+	expect(t, do(t, h, http.MethodGet, "/suppliers/SUP-1?tenant_id=tenant_acme", ""), http.StatusNotFound, nil)
+	expect(t, do(t, h, http.MethodPost, "/suppliers/SUP-1/restore?tenant_id=tenant_acme", ""), http.StatusNoContent, nil)
+	expect(t, do(t, h, http.MethodGet, "/suppliers/SUP-1?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+
+	expect(t, do(t, h, http.MethodGet, "/suppliers/SUP-1/restore?tenant_id=tenant_acme", ""), http.StatusMethodNotAllowed, nil)
+}
+
+func TestParts(t *testing.T) {
+	h, store := newTestServer(t, nil)
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"supplier_id": "SUP-1", "tenant_id": "tenant_acme", "legal_name": "Acme"}`), http.StatusCreated, nil)
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"supplier_id": "SUP-2", "tenant_id": "tenant_acme", "legal_name": "Bolt"}`), http.StatusCreated, nil)
+	// the qualified suppliers are linked in part_supplier
+	linked := func() []string {
+		rows, err := store.(interface {
+			ListPartSuppliers(context.Context, db.ListPartSuppliersParams) ([]db.DimSupplierV1, error)
+		}).ListPartSuppliers(context.Background(), db.ListPartSuppliersParams{TenantID: "tenant_acme", PartID: "PART-1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, row := range rows {
+			ids = append(ids, row.SupplierID)
+		}
+		return ids
+	}
+
+	var created part
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"part_id": "PART-1", "tenant_id": "tenant_acme", "part_number": "P-1", "description": "bolt",
+		"category": "MECHANICAL", "default_supplier_id": "SUP-1", "qualified_supplier_ids": ["SUP-1"], "unit_cost": 0.25}`), http.StatusCreated, &created)
+	if *created.UnitCost != 0.25 || created.QualifiedSupplierIDs[0] != "SUP-1" {
+		t.Errorf("unexpected created part %+v", created)
+	}
+	if got := linked(); !slices.Equal(got, []string{"SUP-1"}) {
+		t.Errorf("create: unexpected linked suppliers %v", got)
+	}
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"tenant_id": "tenant_acme", "part_number": "P-2", "description": "nut", "default_supplier_id": "missing"}`), http.StatusBadRequest, nil)
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"tenant_id": "tenant_acme", "part_numbr": "P-2"}`), http.StatusBadRequest, nil)
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"part_id": "PART-1", "tenant_id": "tenant_acme", "part_number": "P-1", "description": "bolt"}`), http.StatusConflict, nil)
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"part_id": "PART-1", "tenant_id": "tenant_globex", "part_number": "P-1", "description": "bolt"}`), http.StatusConflict, nil)
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"tenant_id": "tenant_acme", "part_number": "P-3", "description": "washer", "category": "RAW_MATERIAL"}`), http.StatusCreated, nil)
+
+	var p page[part]
+	expect(t, do(t, h, http.MethodGet, "/parts?tenant_id=tenant_acme&category=MECHANICAL", ""), http.StatusOK, &p)
+	if len(p.Items) != 1 || *p.Items[0].PartID != "PART-1" {
+		t.Errorf("unexpected parts %+v", p)
+	}
+
+	var got part
+	expect(t, do(t, h, http.MethodPatch, "/parts/PART-1?tenant_id=tenant_acme", `{"description": "hex bolt"}`), http.StatusOK, &got)
+	if *got.Description != "hex bolt" || *got.DefaultSupplierID != "SUP-1" {
+		t.Errorf("unexpected patched part %+v", got)
+	}
+	expect(t, do(t, h, http.MethodPatch, "/parts/PART-1?tenant_id=tenant_acme", `{"default_supplier_id": "missing"}`), http.StatusBadRequest, nil)
+	expect(t, do(t, h, http.MethodPatch, "/parts/PART-1?tenant_id=tenant_acme", `{"qualified_supplier_ids": ["SUP-2", "missing"]}`), http.StatusBadRequest, nil)
+	expect(t, do(t, h, http.MethodPatch, "/parts/PART-1?tenant_id=tenant_acme", `{"qualified_supplier_ids": ["SUP-2"]}`), http.StatusOK, nil)
+	if got := linked(); !slices.Equal(got, []string{"SUP-2"}) {
+		t.Errorf("patch: unexpected linked suppliers %v", got)
+	}
+	expect(t, do(t, h, http.MethodDelete, "/parts/PART-1?tenant_id=tenant_acme", ""), http.StatusNoContent, nil)
+	expect(t, do(t, h, http.MethodDelete, "/parts/PART-1?tenant_id=tenant_acme", ""), http.StatusNotFound, nil)
+	expect(t, do(t, h, http.MethodPost, "/parts/PART-1/restore?tenant_id=tenant_acme", ""), http.StatusNoContent, nil)
+}
+
+func TestListRoundTrip(t *testing.T) {
+	h, _ := newTestServer(t, nil)
+	var sup supplier
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"supplier_id": "SUP-1", "tenant_id": "tenant_acme", "legal_name": "Acme",
+		"contracts": ["CONTRACT_0001", "MSA-2024/07", "Ünïcode"]}`), http.StatusCreated, nil)
+	expect(t, do(t, h, http.MethodGet, "/suppliers/SUP-1?tenant_id=tenant_acme", ""), http.StatusOK, &sup)
+	if want := []string{"CONTRACT_0001", "MSA-2024/07", "Ünïcode"}; !slices.Equal(sup.Contracts, want) {
+		t.Errorf("contracts %q, want %q", sup.Contracts, want)
+	}
+	var p part
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"part_id": "PART-1", "tenant_id": "tenant_acme", "part_number": "P-1", "description": "bolt",
+		"bom_compatibility": ["ABC", "x-1.2"], "qualified_supplier_ids": ["SUP-1"]}`), http.StatusCreated, nil)
+	expect(t, do(t, h, http.MethodGet, "/parts/PART-1?tenant_id=tenant_acme", ""), http.StatusOK, &p)
+	if !slices.Equal(p.BomCompatibility, []string{"ABC", "x-1.2"}) || !slices.Equal(p.QualifiedSupplierIDs, []string{"SUP-1"}) {
+		t.Errorf("unexpected lists %q %q", p.BomCompatibility, p.QualifiedSupplierIDs)
+	}
+
+	// items that would not read back the same are rejected, by the spec and
+	// by the handlers without it
+	for _, h := range []http.Handler{h, NewServer(Config{}, openStore(t), nil)} {
+		expect(t, do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Acme", "contracts": ["Master agreement"]}`), http.StatusBadRequest, nil)
+		expect(t, do(t, h, http.MethodPost, "/parts", `{"tenant_id": "tenant_acme", "part_number": "P-2", "description": "nut", "bom_compatibility": ["[ABC]"]}`), http.StatusBadRequest, nil)
+	}
+}
+
+func TestSearchAndSummary(t *testing.T) {
+	h, _ := newTestServer(t, nil)
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"tenant_id": "tenant_acme", "part_number": "HB-10", "description": "hex bolt", "category": "MECHANICAL", "unit_cost": 2}`), http.StatusCreated, nil)
+
+	var found []db.SearchPartsRow
+	expect(t, do(t, h, http.MethodGet, "/search/parts?tenant_id=tenant_acme&q=hex", ""), http.StatusOK, &found)
+	if len(found) != 1 || found[0].PartNumber != "HB-10" {
+		t.Errorf("unexpected search results %+v", found)
+	}
+	expect(t, do(t, h, http.MethodGet, "/search/parts?tenant_id=tenant_acme", ""), http.StatusBadRequest, nil)
+	expect(t, do(t, h, http.MethodGet, "/search/widgets?tenant_id=tenant_acme&q=hex", ""), http.StatusNotFound, nil)
+
+	var summary []db.SummarizePartsRow
+	expect(t, do(t, h, http.MethodGet, "/summary/parts?tenant_id=tenant_acme", ""), http.StatusOK, &summary)
+	if len(summary) != 1 || summary[0].Parts != 1 || summary[0].AvgUnitCost != 2 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	duck, err := storage.Open(context.Background(), "duckdb:", storage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer duck.Close()
+	expect(t, do(t, NewServer(testConfig, duck, nil), http.MethodGet, "/search/parts?tenant_id=tenant_acme&q=hex", ""), http.StatusNotImplemented, nil)
+}
+
+// stubSource serves a fixed list of suppliers.
+type stubSource struct {
+	sups   []db.CreateSupplierParams
+	err    error
+	closed bool
+}
+
+func (s *stubSource) FetchSuppliers(ctx context.Context, tenantID string) ([]db.CreateSupplierParams, error) {
+	return s.sups, s.err
+}
+
+func (s *stubSource) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestFetchAndInsert(t *testing.T) {
+	ts := time.Date(2025, 9, 24, 10, 0, 0, 0, time.UTC)
+	src := &stubSource{sups: []db.CreateSupplierParams{
+		{SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Acme", SourceTimestamp: sql.NullTime{Time: ts, Valid: true}},
+		{SupplierID: "SUP-2", TenantID: "tenant_acme", LegalName: "Bolt", SourceTimestamp: sql.NullTime{Time: ts, Valid: true}},
+	}}
+	h, store := newTestServer(t, func(ctx context.Context) (Source, error) { return src, nil })
+
+	var summary loadSummary
+	expect(t, do(t, h, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusOK, &summary)
+	if summary != (loadSummary{Inserted: 2}) || !src.closed {
+		t.Errorf("unexpected summary %+v, closed %v", summary, src.closed)
+	}
+	if _, err := store.GetSupplier(context.Background(), db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-2"}); err != nil {
+		t.Errorf("expected SUP-2 to be loaded: %v", err)
+	}
+	summary = loadSummary{}
+	expect(t, do(t, h, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusOK, &summary)
+	if summary != (loadSummary{Unchanged: 2}) {
+		t.Errorf("rerun: unexpected summary %+v", summary)
+	}
+
+	src.err = errors.New("warehouse suspended")
+	expect(t, do(t, h, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusInternalServerError, nil)
+	expect(t, do(t, h, http.MethodPost, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusMethodNotAllowed, nil)
+
+	unconfigured, _ := newTestServer(t, nil)
+	expect(t, do(t, unconfigured, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusNotImplemented, nil)
+}
+
+func TestFetchAndInsertScopesToTenant(t *testing.T) {
+	src, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	if _, err := src.Exec(`CREATE TABLE suppliers (SUPPLIER_ID TEXT, TENANT_ID TEXT, SUPPLIER_CODE TEXT, LEGAL_NAME TEXT,
+		DBA_NAME TEXT, COUNTRY TEXT, REGION TEXT, ADDRESS_LINE1 TEXT, ADDRESS_LINE2 TEXT, CITY TEXT, STATE TEXT,
+		POSTAL_CODE TEXT, SOURCE_TIMESTAMP TIMESTAMP);
+		INSERT INTO suppliers (SUPPLIER_ID, TENANT_ID, LEGAL_NAME, SOURCE_TIMESTAMP) VALUES
+			('SUP-1', 'tenant_acme', 'Acme', '2025-01-02 03:04:05'),
+			('SUP-2', 'tenant_bolt', 'Bolt', '2025-01-02 03:04:05')`); err != nil {
+		t.Fatal(err)
+	}
+	h, store := newTestServer(t, func(ctx context.Context) (Source, error) {
+		return sharedSource{&SQLSource{DB: src, Table: "suppliers"}}, nil
+	})
+
+	var summary loadSummary
+	expect(t, do(t, h, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusOK, &summary)
+	if summary != (loadSummary{Inserted: 1}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if _, err := store.GetSupplier(context.Background(), db.GetSupplierParams{TenantID: "tenant_bolt", SupplierID: "SUP-2"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected another tenant's row to be skipped, got %v", err)
+	}
+	expect(t, do(t, h, http.MethodGet, "/fetch-and-insert", ""), http.StatusBadRequest, nil)
+
+	// a source that ignores the tenant still only writes the caller's rows
+	stub := &stubSource{sups: []db.CreateSupplierParams{
+		{SupplierID: "SUP-3", TenantID: "tenant_acme", LegalName: "Acme"},
+		{SupplierID: "SUP-4", TenantID: "tenant_bolt", LegalName: "Bolt"},
+	}}
+	h, _ = newTestServer(t, func(ctx context.Context) (Source, error) { return stub, nil })
+	summary = loadSummary{}
+	expect(t, do(t, h, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusOK, &summary)
+	if summary != (loadSummary{Inserted: 1}) {
+		t.Errorf("ignoring source: unexpected summary %+v", summary)
+	}
+}
+
+// racingStore runs race once, right after the first supplier or part a
+// handler reads, as a concurrent request would.
+type racingStore struct {
+	storage.Store
+	race func()
+}
+
+func (s *racingStore) GetSupplier(ctx context.Context, arg db.GetSupplierParams) (db.DimSupplierV1, error) {
+	row, err := s.Store.GetSupplier(ctx, arg)
+	s.run()
+	return row, err
+}
+
+func (s *racingStore) GetPart(ctx context.Context, arg db.GetPartParams) (db.DimPartV1, error) {
+	row, err := s.Store.GetPart(ctx, arg)
+	s.run()
+	return row, err
+}
+
+func (s *racingStore) run() {
+	if race := s.race; race != nil {
+		s.race = nil
+		race()
+	}
+}
+
+func TestPatchLostUpdate(t *testing.T) {
+	store := &racingStore{Store: openStore(t)}
+	h := NewServer(testConfig, store, nil)
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"supplier_id": "SUP-1", "tenant_id": "tenant_acme", "legal_name": "Acme"}`), http.StatusCreated, nil)
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"part_id": "PART-1", "tenant_id": "tenant_acme", "part_number": "P-1", "description": "bolt"}`), http.StatusCreated, nil)
+
+	// a PATCH that lands between another's read and write makes the later one fail
+	store.race = func() {
+		expect(t, do(t, h, http.MethodPatch, "/suppliers/SUP-1?tenant_id=tenant_acme", `{"city": "Berlin"}`), http.StatusOK, nil)
+	}
+	expect(t, do(t, h, http.MethodPatch, "/suppliers/SUP-1?tenant_id=tenant_acme", `{"region": "EMEA"}`), http.StatusConflict, nil)
+	var sup supplier
+	expect(t, do(t, h, http.MethodGet, "/suppliers/SUP-1?tenant_id=tenant_acme", ""), http.StatusOK, &sup)
+	if sup.City == nil || *sup.City != "Berlin" || sup.Region != nil {
+		t.Errorf("expected the first PATCH to stand alone, got city %v region %v", sup.City, sup.Region)
+	}
+	// retried, it applies on top of the other
+	expect(t, do(t, h, http.MethodPatch, "/suppliers/SUP-1?tenant_id=tenant_acme", `{"region": "EMEA"}`), http.StatusOK, &sup)
+	if sup.City == nil || *sup.City != "Berlin" || sup.Region == nil || *sup.Region != "EMEA" {
+		t.Errorf("expected both changes, got city %v region %v", sup.City, sup.Region)
+	}
+
+	store.race = func() {
+		expect(t, do(t, h, http.MethodPatch, "/parts/PART-1?tenant_id=tenant_acme", `{"uom": "EA"}`), http.StatusOK, nil)
+	}
+	expect(t, do(t, h, http.MethodPatch, "/parts/PART-1?tenant_id=tenant_acme", `{"description": "hex bolt"}`), http.StatusConflict, nil)
+	// a part deleted meanwhile is still not found
+	store.race = func() {
+		expect(t, do(t, h, http.MethodDelete, "/parts/PART-1?tenant_id=tenant_acme", ""), http.StatusNoContent, nil)
+	}
+	expect(t, do(t, h, http.MethodPatch, "/parts/PART-1?tenant_id=tenant_acme", `{"description": "hex bolt"}`), http.StatusNotFound, nil)
+}
+
+func TestUpsertSupplierSummary(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.Open(ctx, "sqlite::memory:", storage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ts := time.Date(2025, 9, 24, 10, 0, 0, 0, time.UTC)
+	upsert := func(summary *loadSummary, name string, at time.Time) {
+		t.Helper()
+		stats, err := store.LoadSuppliers(ctx, []db.CreateSupplierParams{{
+			SupplierID:      "SUP-1",
+			TenantID:        "tenant_acme",
+			LegalName:       name,
+			SourceTimestamp: sql.NullTime{Time: at, Valid: true},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := summaryOf(stats)
+		summary.Inserted += s.Inserted
+		summary.Updated += s.Updated
+		summary.Unchanged += s.Unchanged
+	}
+
+	var summary loadSummary
+	upsert(&summary, "Acme", ts)
+	upsert(&summary, "Acme", ts)                                                   // rerun
+	upsert(&summary, "Acme Old", ts.Add(-time.Hour))                               // stale
+	upsert(&summary, "Acme New", ts.In(time.FixedZone("", 3600)).Add(time.Minute)) // newer, other zone
+	if summary != (loadSummary{Inserted: 1, Updated: 1, Unchanged: 2}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	rows, err := store.SearchSuppliers(ctx, db.SearchSuppliersParams{Query: database.SearchQuery("acme"), TenantID: "tenant_acme", PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Snippet != "[Acme] New" {
+		t.Errorf("expected newest version to win, got %+v", rows)
+	}
+}
+
+func TestActor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/suppliers/SUP-1", nil)
+	if got := actor(req); got.String != "api" {
+		t.Errorf("expected default actor api, got %q", got.String)
+	}
+	req.Header.Set("X-Actor", "alice")
+	if got := actor(req); got.String != "alice" {
+		t.Errorf("expected actor alice, got %q", got.String)
+	}
+	// an authenticated caller cannot claim to be someone else
+	req = req.WithContext(auth.NewContext(req.Context(), auth.Principal{Subject: "key:k1"}))
+	if got := actor(req); got.String != "key:k1" {
+		t.Errorf("expected the principal as actor, got %q", got.String)
+	}
+}
+
+func TestSupplierRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.Open(ctx, "sqlite::memory:", storage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	var in supplier
+	body := `{"supplier_id": "SUP-1", "tenant_id": "tenant_acme", "legal_name": "Acme",
+		"certifications": ["ISO9001", "AS9100"], "risk_score": 12.5, "lat": 52.5, "lon": 13.4,
+		"source_timestamp": "2025-09-24T10:00:00Z"}`
+	if w := httptest.NewRecorder(); !decode(w, httptest.NewRequest(http.MethodPost, "/suppliers", strings.NewReader(body)), &in) {
+		t.Fatal(w.Body)
+	}
+	if _, err := store.CreateSupplier(ctx, in.createParams()); err != nil {
+		t.Fatal(err)
+	}
+	row, err := store.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := supplierOf(row)
+	if *out.LegalName != "Acme" || strings.Join(out.Certifications, ",") != "ISO9001,AS9100" ||
+		*out.RiskScore != 12.5 || *out.Lon != 13.4 || !out.SourceTimestamp.Equal(*in.SourceTimestamp) || out.Country != nil {
+		t.Errorf("unexpected supplier %+v", out)
+	}
+
+	// a merge patch sets, clears and keeps fields
+	patch := `{"city": "Berlin", "risk_score": null}`
+	if w := httptest.NewRecorder(); !decode(w, httptest.NewRequest(http.MethodPatch, "/suppliers/SUP-1", strings.NewReader(patch)), &out) {
+		t.Fatal(w.Body)
+	}
+	if *out.City != "Berlin" || out.RiskScore != nil || *out.LegalName != "Acme" {
+		t.Errorf("unexpected patched supplier %+v", out)
+	}
+
+	w := httptest.NewRecorder()
+	if decode(w, httptest.NewRequest(http.MethodPost, "/suppliers", strings.NewReader(`{"legal_nam": "x"}`)), &in) {
+		t.Error("expected unknown fields to be rejected")
+	}
+	var p problem
+	expect(t, w, http.StatusBadRequest, &p)
+	if len(p.Errors) != 1 || p.Errors[0].Field != "legal_nam" {
+		t.Errorf("expected the unknown field to be named, got %+v", p)
+	}
+}
+
+func TestSameKey(t *testing.T) {
+	str := func(s string) *string { return &s }
+	for _, tt := range []struct {
+		id, tenant *string
+		want       bool
+	}{
+		{nil, nil, true},
+		{str("SUP-1"), str("tenant_acme"), true},
+		{str("SUP-2"), nil, false},
+		{nil, str("tenant_globex"), false},
+	} {
+		w := httptest.NewRecorder()
+		id, tenant := tt.id, tt.tenant
+		r := httptest.NewRequest(http.MethodPut, "/suppliers/SUP-1", nil)
+		ok := sameKey(w, r, "supplier_id", &id, "SUP-1", &tenant, "tenant_acme")
+		if ok != tt.want {
+			t.Errorf("sameKey(%v, %v) = %v, want %v", tt.id, tt.tenant, ok, tt.want)
+		}
+		if ok && (*id != "SUP-1" || *tenant != "tenant_acme") {
+			t.Errorf("expected the key to be filled in, got %s %s", *id, *tenant)
+		}
+		if !ok && w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", w.Code)
+		}
+	}
+}
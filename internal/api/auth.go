@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/bitterfq/data-ingestion-go/internal/auth"
+	"github.com/bitterfq/data-ingestion-go/internal/ratelimit"
+)
+
+// authenticate identifies the caller of every route that needs a role and
+// checks the role allows the route, answering 401 for missing or bad
+// credentials and 403 for a role that falls short. Handlers then check the
+// caller's tenant with ownTenant. With a FailureLimiter, each 401 counts
+// against the client's address, and an address out of allowance gets 429
+// before its credentials are checked, so keys and secrets cannot be guessed
+// at the rate the server answers.
+func (s *server) authenticate(next http.Handler) http.Handler {
+	roles := map[string]auth.Role{}
+	for _, rt := range s.routes() {
+		roles[rt.pattern] = rt.role
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		need := roles[s.route(r)]
+		if need == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		failures, addr := s.cfg.FailureLimiter, clientAddr(r)
+		if failures != nil {
+			var limited *ratelimit.Error
+			if errors.As(failures.Check(addr, ""), &limited) {
+				tooMany(w, r, limited)
+				return
+			}
+		}
+		p, err := s.cfg.Authenticator.Authenticate(r)
+		if errors.Is(err, auth.ErrNoCredentials) || errors.Is(err, auth.ErrInvalidCredentials) {
+			if failures != nil {
+				if release, err := failures.Acquire(addr, ""); err == nil {
+					release()
+				}
+			}
+			detail := "The request has no credentials; send an X-API-Key header or a bearer token"
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				detail = err.Error()
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="data-ingestion-go"`)
+			writeProblem(w, r, http.StatusUnauthorized, detail)
+			return
+		}
+		if err != nil {
+			logger(r).Error("authenticate failed", "err", err)
+			writeProblem(w, r, http.StatusInternalServerError, "Failed to authenticate")
+			return
+		}
+		annotate(r, "principal", p.Subject)
+		if !p.Role.Allows(need) {
+			writeProblem(w, r, http.StatusForbidden, "The "+string(p.Role)+" role cannot do this; it needs "+string(need))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), p)))
+	})
+}
+
+// clientAddr returns the host of the address r came from. Behind a proxy
+// that is the proxy's, since forwarding headers are the client's to forge.
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ownTenant answers 403 unless the caller, when authenticated, acts for
+// tenantID.
+func ownTenant(w http.ResponseWriter, r *http.Request, tenantID string) bool {
+	if p, ok := auth.FromContext(r.Context()); ok && p.TenantID != tenantID {
+		writeProblem(w, r, http.StatusForbidden, "The caller cannot access tenant "+tenantID)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/bitterfq/data-ingestion-go/internal/auth"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/ratelimit"
+)
+
+// doAs sends a request carrying the API key or, when it has no key prefix,
+// the bearer token cred.
+func doAs(t *testing.T, h http.Handler, cred, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	if strings.HasPrefix(cred, auth.KeyPrefix) {
+		req.Header.Set(auth.APIKeyHeader, cred)
+	} else if cred != "" {
+		req.Header.Set("Authorization", "Bearer "+cred)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthentication(t *testing.T) {
+	store := openStore(t)
+	keys := store.(interface {
+		CreateAPIKey(context.Context, db.CreateAPIKeyParams) error
+	})
+	newKey := func(tenantID string, role auth.Role) string {
+		t.Helper()
+		id, key, err := auth.NewKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = keys.CreateAPIKey(context.Background(), db.CreateAPIKeyParams{
+			KeyID: id, KeyHash: auth.HashKey(key), Name: "test", TenantID: tenantID, Role: string(role),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	reader, writer := newKey("tenant_acme", auth.Reader), newKey("tenant_acme", auth.Writer)
+	secret := []byte("s3cret")
+	admin, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "alice", "tenant_id": "tenant_acme", "role": "admin", "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig
+	cfg.Authenticator = auth.Chain{auth.APIKeys{Store: store}, auth.JWT{Secret: secret}}
+	h := NewServer(cfg, store, func(context.Context) (Source, error) { return &stubSource{}, nil })
+
+	// probes and docs are public
+	for _, path := range []string{"/health", "/livez", "/readyz", "/openapi.json"} {
+		expect(t, do(t, h, http.MethodGet, path, ""), http.StatusOK, nil)
+	}
+
+	// everything else needs valid credentials
+	w := do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme", "")
+	expect(t, w, http.StatusUnauthorized, nil)
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("expected a WWW-Authenticate challenge")
+	}
+	expect(t, doAs(t, h, auth.KeyPrefix+"guess", http.MethodGet, "/suppliers?tenant_id=tenant_acme", ""), http.StatusUnauthorized, nil)
+	expect(t, doAs(t, h, "not.a.token", http.MethodGet, "/suppliers?tenant_id=tenant_acme", ""), http.StatusUnauthorized, nil)
+
+	// a writer writes its own tenant, and is recorded as the actor
+	var created supplier
+	expect(t, doAs(t, h, writer, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Acme"}`), http.StatusCreated, &created)
+	if created.ModifiedBy == nil || !strings.HasPrefix(*created.ModifiedBy, "key:") {
+		t.Errorf("expected the key to be the actor, got %v", created.ModifiedBy)
+	}
+	expect(t, doAs(t, h, reader, http.MethodGet, "/suppliers/"+*created.SupplierID+"?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+
+	// but no other tenant's
+	expect(t, doAs(t, h, writer, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_bolt", "legal_name": "Bolt"}`), http.StatusForbidden, nil)
+	expect(t, doAs(t, h, writer, http.MethodPost, "/parts", `{"tenant_id": "tenant_bolt", "part_number": "P-1", "description": "Hex bolt"}`), http.StatusForbidden, nil)
+	expect(t, doAs(t, h, reader, http.MethodGet, "/suppliers?tenant_id=tenant_bolt", ""), http.StatusForbidden, nil)
+	expect(t, doAs(t, h, writer, http.MethodDelete, "/suppliers/"+*created.SupplierID+"?tenant_id=tenant_bolt", ""), http.StatusForbidden, nil)
+
+	// a reader cannot write, and only an admin syncs from Snowflake
+	expect(t, doAs(t, h, reader, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Bolt"}`), http.StatusForbidden, nil)
+	expect(t, doAs(t, h, writer, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusForbidden, nil)
+	expect(t, doAs(t, h, admin, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+}
+
+func TestFailureLimit(t *testing.T) {
+	secret := []byte("s3cret")
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "alice", "tenant_id": "tenant_acme", "role": "reader", "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig
+	cfg.Authenticator = auth.JWT{Secret: secret}
+	cfg.FailureLimiter = ratelimit.New([]ratelimit.Rule{{Rate: 0.1, Burst: 2}})
+	h := NewServer(cfg, openStore(t), nil)
+	from := func(addr, cred, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = addr
+		if cred != "" {
+			req.Header.Set("Authorization", "Bearer "+cred)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// successes do not count, failures do, on any route
+	for range 3 {
+		expect(t, from("192.0.2.1:1000", token, "/suppliers?tenant_id=tenant_acme"), http.StatusOK, nil)
+	}
+	expect(t, from("192.0.2.1:1000", "", "/suppliers?tenant_id=tenant_acme"), http.StatusUnauthorized, nil)
+	expect(t, from("192.0.2.1:1001", "guess", "/parts?tenant_id=tenant_acme"), http.StatusUnauthorized, nil)
+
+	// then the address is refused before its credentials are checked, good or bad
+	w := from("192.0.2.1:1002", "guess", "/suppliers?tenant_id=tenant_acme")
+	expect(t, w, http.StatusTooManyRequests, nil)
+	if got := w.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("expected Retry-After 10, got %q", got)
+	}
+	expect(t, from("192.0.2.1:1003", token, "/suppliers?tenant_id=tenant_acme"), http.StatusTooManyRequests, nil)
+
+	// other addresses and the probes are unaffected
+	expect(t, from("192.0.2.2:1000", token, "/suppliers?tenant_id=tenant_acme"), http.StatusOK, nil)
+	expect(t, from("192.0.2.1:1004", "", "/readyz"), http.StatusOK, nil)
+}
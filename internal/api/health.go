@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Check reports whether a dependency the server needs is usable.
+type Check func(ctx context.Context) error
+
+// checkTimeout bounds each readiness check, so a hung dependency fails the
+// probe instead of stalling it.
+const checkTimeout = 5 * time.Second
+
+// cacheCheck returns check with its result reused for ttl, so a probe does
+// not reach a dependency that is slow or costly to reach every time. The check
+// runs on its own, bounded by checkTimeout rather than by the probe that
+// started it, and every probe that comes while it runs shares its result. A
+// probe that gives up first fails, but the run goes on and its result, failed
+// or timed out too, is kept, so a hung dependency is not retried by each probe.
+func cacheCheck(check Check, ttl time.Duration) Check {
+	var (
+		mu      sync.Mutex
+		last    error
+		at      time.Time
+		running chan struct{} // closed when the run in flight finishes
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		if !at.IsZero() && time.Since(at) < ttl {
+			defer mu.Unlock()
+			return last
+		}
+		if running == nil {
+			done := make(chan struct{})
+			running = done
+			go func() {
+				rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), checkTimeout)
+				err := check(rctx)
+				cancel()
+				mu.Lock()
+				last, at, running = err, time.Now(), nil
+				mu.Unlock()
+				close(done)
+			}()
+		}
+		done := running
+		mu.Unlock()
+
+		select {
+		case <-done:
+			mu.Lock()
+			defer mu.Unlock()
+			return last
+		case <-ctx.Done():
+			return fmt.Errorf("check still running: %w", ctx.Err())
+		}
+	}
+}
+
+// readiness is the body of GET /readyz.
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// GET /readyz checks the store and every configured check, answering 503
+// when any fails so a load balancer stops sending traffic.
+func (s *server) readyz(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]Check{"storage": s.store.Ping}
+	for name, check := range s.cfg.Checks {
+		checks[name] = check
+	}
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	body := readiness{Status: "ok", Checks: map[string]string{}}
+	status := http.StatusOK
+	for _, name := range names {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		err := checks[name](ctx)
+		cancel()
+		if err != nil {
+			logger(r).Warn("readiness check failed", "check", name, "err", err)
+			body.Checks[name] = err.Error()
+			body.Status, status = "unavailable", http.StatusServiceUnavailable
+			continue
+		}
+		body.Checks[name] = "ok"
+	}
+	writeJSON(w, status, body)
+}
+
+// limitBodies caps request bodies at the configured size; reading past it
+// fails, and decode answers 413.
+func (s *server) limitBodies(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadyz(t *testing.T) {
+	store := openStore(t)
+	var snowflakeErr error
+	cfg := testConfig
+	cfg.Checks = map[string]Check{"snowflake": func(ctx context.Context) error { return snowflakeErr }}
+	h := NewServer(cfg, store, nil)
+
+	expect(t, do(t, h, http.MethodGet, "/livez", ""), http.StatusOK, nil)
+
+	var got readiness
+	expect(t, do(t, h, http.MethodGet, "/readyz", ""), http.StatusOK, &got)
+	if got.Status != "ok" || got.Checks["storage"] != "ok" || got.Checks["snowflake"] != "ok" {
+		t.Errorf("unexpected readiness %+v", got)
+	}
+
+	snowflakeErr = errors.New("login failed")
+	got = readiness{}
+	expect(t, do(t, h, http.MethodGet, "/readyz", ""), http.StatusServiceUnavailable, &got)
+	if got.Status != "unavailable" || got.Checks["storage"] != "ok" || got.Checks["snowflake"] != "login failed" {
+		t.Errorf("unexpected readiness %+v", got)
+	}
+
+	// the store is always checked; liveness does not depend on it
+	snowflakeErr = nil
+	store.Close()
+	got = readiness{}
+	expect(t, do(t, h, http.MethodGet, "/readyz", ""), http.StatusServiceUnavailable, &got)
+	if got.Checks["storage"] == "ok" {
+		t.Errorf("expected the closed store to fail, got %+v", got)
+	}
+	expect(t, do(t, h, http.MethodGet, "/livez", ""), http.StatusOK, nil)
+}
+
+func TestCacheCheck(t *testing.T) {
+	var runs atomic.Int32
+	var result error
+	check := func(ctx context.Context) error { runs.Add(1); return result }
+
+	cached := cacheCheck(check, time.Hour)
+	result = errors.New("login failed")
+	for range 3 {
+		if err := cached(context.Background()); err == nil || err.Error() != "login failed" {
+			t.Errorf("expected the first result, got %v", err)
+		}
+	}
+	result = nil
+	if err := cached(context.Background()); err == nil || runs.Load() != 1 {
+		t.Errorf("expected the result to be reused, got %v after %d runs", err, runs.Load())
+	}
+
+	// a canceled probe does not cancel the run, whose result is kept
+	runs.Store(0)
+	cached = cacheCheck(func(ctx context.Context) error { runs.Add(1); return ctx.Err() }, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cached(ctx)
+	if err := cached(context.Background()); err != nil || runs.Load() != 1 {
+		t.Errorf("expected one uncanceled run, got %v after %d runs", err, runs.Load())
+	}
+
+	runs.Store(0)
+	cached = cacheCheck(check, 0)
+	cached(context.Background())
+	cached(context.Background())
+	if runs.Load() != 2 {
+		t.Errorf("expected an expired result to be checked again, got %d runs", runs.Load())
+	}
+}
+
+func TestCacheCheckSlow(t *testing.T) {
+	// a login that ignores its context and outlasts every probe
+	var runs atomic.Int32
+	release := make(chan struct{})
+	cached := cacheCheck(func(context.Context) error {
+		runs.Add(1)
+		<-release
+		return errors.New("login timed out")
+	}, time.Hour)
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			if err := cached(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected the probe to give up, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := runs.Load(); n != 1 {
+		t.Errorf("expected the probes to share one run, got %d", n)
+	}
+
+	// the late result is kept like any other
+	close(release)
+	if err := cached(context.Background()); err == nil || err.Error() != "login timed out" {
+		t.Errorf("expected the run's result, got %v", err)
+	}
+	if err := cached(context.Background()); err == nil || runs.Load() != 1 {
+		t.Errorf("expected the failure to be reused, got %v after %d runs", err, runs.Load())
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	body := `{"tenant_id": "tenant_acme", "legal_name": "` + strings.Repeat("x", 200) + `"}`
+	// with and without the spec reading the body first
+	for _, cfg := range []Config{testConfig, {}} {
+		cfg.MaxBodyBytes = 100
+		h := NewServer(cfg, openStore(t), nil)
+		var p problem
+		expect(t, do(t, h, http.MethodPost, "/suppliers", body), http.StatusRequestEntityTooLarge, &p)
+		if p.Detail != "The request body is larger than 100 bytes" {
+			t.Errorf("unexpected problem %+v", p)
+		}
+		expect(t, do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Acme"}`), http.StatusCreated, nil)
+	}
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bitterfq/data-ingestion-go/internal/bulkload"
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/metrics"
+	"github.com/bitterfq/data-ingestion-go/internal/snowflake"
+	"github.com/bitterfq/data-ingestion-go/internal/tracing"
+)
+
+// Source is an upstream system suppliers are fetched from.
+type Source interface {
+	// FetchSuppliers reads every supplier the source holds for a tenant.
+	FetchSuppliers(ctx context.Context, tenantID string) ([]db.CreateSupplierParams, error)
+	Close() error
+}
+
+// SourceFactory opens a Source for one fetch. The server closes it when the
+// fetch is done.
+type SourceFactory func(ctx context.Context) (Source, error)
+
+// SnowflakeSource returns a factory connecting to Snowflake with the
+// credentials in the env file at envPath; empty means the default .env. m
+// gets the durations of its queries and may be nil.
+func SnowflakeSource(envPath string, m *metrics.Metrics) SourceFactory {
+	return func(ctx context.Context) (Source, error) {
+		conn, err := snowflake.NewClient(envPath)
+		if err != nil {
+			return nil, err
+		}
+		return &SQLSource{DB: conn, Metrics: m}, nil
+	}
+}
+
+// snowflakeCheckTTL is how long SnowflakeCheck reuses a result. A login is
+// slow and counted by Snowflake, and probes come every few seconds.
+const snowflakeCheckTTL = time.Minute
+
+// SnowflakeCheck returns a readiness check that logs in to Snowflake with the
+// credentials in the env file at envPath, at most once a minute.
+func SnowflakeCheck(envPath string) Check {
+	return cacheCheck(func(ctx context.Context) error {
+		conn, err := snowflake.NewClient(envPath)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		return conn.PingContext(ctx)
+	}, snowflakeCheckTTL)
+}
+
+// SQLSource reads suppliers from the SUPPLY_CHAIN.PUBLIC.SUPPLIERS table of a
+// database such as Snowflake.
+type SQLSource struct {
+	DB *sql.DB
+	// Table overrides the table read.
+	Table string
+	// TracerProvider gets a span for the query and one for fetching its
+	// rows. Nil means the global provider.
+	TracerProvider trace.TracerProvider
+	// Metrics gets the duration of the query and of fetching its rows. Nil
+	// records nothing.
+	Metrics *metrics.Metrics
+}
+
+const fetchSuppliers = `SELECT SUPPLIER_ID, TENANT_ID, SUPPLIER_CODE, LEGAL_NAME, DBA_NAME, COUNTRY, REGION, ADDRESS_LINE1, ADDRESS_LINE2, CITY, STATE, POSTAL_CODE, SOURCE_TIMESTAMP
+FROM `
+
+func (s *SQLSource) FetchSuppliers(ctx context.Context, tenantID string) (sups []db.CreateSupplierParams, err error) {
+	table := s.Table
+	if table == "" {
+		table = "SUPPLY_CHAIN.PUBLIC.SUPPLIERS"
+	}
+	query := fetchSuppliers + table + " WHERE TENANT_ID = ?"
+	attrs := trace.WithAttributes(
+		attribute.String("db.system.name", "snowflake"),
+		attribute.String("db.collection.name", table),
+	)
+	tracer := tracing.Tracer(s.TracerProvider, "internal/api")
+
+	// the query span covers executing the query; the fetch span covers
+	// paging its results in, which for Snowflake is most of the time
+	qctx, span := tracer.Start(ctx, "source.query", attrs, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.query.text", query)))
+	start := time.Now()
+	rows, err := s.DB.QueryContext(qctx, query, tenantID)
+	s.Metrics.ObserveQuery("query", time.Since(start))
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	_, span = tracer.Start(ctx, "source.fetch", attrs)
+	start = time.Now()
+	defer func() {
+		s.Metrics.ObserveQuery("fetch", time.Since(start))
+		span.SetAttributes(attribute.Int("rows", len(sups)))
+		tracing.End(span, err)
+	}()
+
+	now := time.Now()
+	for rows.Next() {
+		var p db.CreateSupplierParams
+		if err := rows.Scan(
+			&p.SupplierID, &p.TenantID, &p.SupplierCode, &p.LegalName,
+			&p.DbaName, &p.Country, &p.Region, &p.AddressLine1,
+			&p.AddressLine2, &p.City, &p.State, &p.PostalCode,
+			&p.SourceTimestamp,
+		); err != nil {
+			return nil, err
+		}
+		p.DataSource = sql.NullString{String: "snowflake", Valid: true}
+		p.IngestionTimestamp = sql.NullTime{Time: now, Valid: true}
+		p.ModifiedBy = sql.NullString{String: "fetch-and-insert", Valid: true}
+		sups = append(sups, p)
+	}
+	return sups, rows.Err()
+}
+
+func (s *SQLSource) Close() error {
+	return s.DB.Close()
+}
+
+// loadSummary counts what a re-runnable load did to each incoming row.
+type loadSummary struct {
+	Inserted  int64 `json:"inserted"`
+	Updated   int64 `json:"updated"`
+	Unchanged int64 `json:"unchanged"`
+}
+
+// summaryOf reports a load's counts.
+func summaryOf(stats bulkload.Stats) loadSummary {
+	return loadSummary{Inserted: stats.Inserted, Updated: stats.Updated, Unchanged: stats.Unchanged}
+}
+
+// GET /fetch-and-insert?tenant_id=... copies every supplier of the tenant
+// from the source into the store.
+func (s *server) fetchAndInsert(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	if s.sources == nil {
+		writeProblem(w, r, http.StatusNotImplemented, "No source is configured")
+		return
+	}
+	ctx, span := s.tracer.Start(r.Context(), "source.connect")
+	src, err := s.sources(ctx)
+	tracing.End(span, err)
+	if err != nil {
+		logger(r).Error("connect to source failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to connect to source: "+err.Error())
+		return
+	}
+	defer src.Close()
+
+	sups, err := src.FetchSuppliers(r.Context(), tenantID)
+	if err != nil {
+		logger(r).Error("fetch suppliers failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to fetch suppliers: "+err.Error())
+		return
+	}
+	// a source that ignored the tenant must not write another tenant's rows
+	sups = slices.DeleteFunc(sups, func(p db.CreateSupplierParams) bool { return p.TenantID != tenantID })
+
+	// the load is one transaction, so a failure part way through leaves
+	// the local DB untouched and the fetch can simply be retried
+	stats, err := s.store.LoadSuppliers(r.Context(), sups)
+	if err != nil {
+		logger(r).Error("load suppliers failed", "rows", len(sups), "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to upsert suppliers into local DB: "+err.Error())
+		return
+	}
+	s.metrics.AddRows("suppliers", "snowflake", stats.Rows)
+	annotate(r, "load", stats)
+	writeJSON(w, http.StatusOK, summaryOf(stats))
+}
+
+// GET /search/suppliers?tenant_id=...&q=... and GET /search/parts?tenant_id=...&q=...
+func (s *server) search(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	query := database.SearchQuery(r.URL.Query().Get("q"))
+	if query == "" {
+		invalid(w, r, fieldError{"q", "must contain a word to search for"})
+		return
+	}
+	limit, bad := s.limit(r)
+	if bad != nil {
+		invalid(w, r, *bad)
+		return
+	}
+
+	// empty results are [] rather than null
+	var results any
+	var n int
+	var err error
+	switch r.PathValue("kind") {
+	case "suppliers":
+		var rows []db.SearchSuppliersRow
+		rows, err = s.store.SearchSuppliers(r.Context(), db.SearchSuppliersParams{Query: query, TenantID: tenantID, PageSize: limit})
+		results, n = nonNil(rows), len(rows)
+	case "parts":
+		var rows []db.SearchPartsRow
+		rows, err = s.store.SearchParts(r.Context(), db.SearchPartsParams{Query: query, TenantID: tenantID, PageSize: limit})
+		results, n = nonNil(rows), len(rows)
+	default:
+		writeProblem(w, r, http.StatusNotFound, "No such search")
+		return
+	}
+	if errors.Is(err, errors.ErrUnsupported) {
+		writeProblem(w, r, http.StatusNotImplemented, "Search is not supported by this storage backend")
+		return
+	}
+	if err != nil {
+		logger(r).Error("search failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Search failed")
+		return
+	}
+	annotate(r, "rows", n)
+	writeJSON(w, http.StatusOK, results)
+}
+
+// GET /summary/parts?tenant_id=... reports per category and lifecycle status
+// part counts and averages for a tenant.
+func (s *server) summarizeParts(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	summary, err := s.store.SummarizeParts(r.Context(), tenantID)
+	if err != nil {
+		logger(r).Error("summarize parts failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Summary failed")
+		return
+	}
+	annotate(r, "rows", len(summary))
+	writeJSON(w, http.StatusOK, nonNil(summary))
+}
+
+// nonNil returns an empty slice for nil, so it encodes as [].
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/bitterfq/data-ingestion-go/internal/auth"
+	"github.com/bitterfq/data-ingestion-go/internal/ratelimit"
+)
+
+// limitRequests admits requests to every route that needs a role by the
+// limiter's rules, answering 429 with Retry-After for one over its limit.
+// The probes and docs are never limited.
+func (s *server) limitRequests(next http.Handler) http.Handler {
+	public := map[string]bool{}
+	for _, rt := range s.routes() {
+		public[rt.pattern] = rt.role == ""
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := s.route(r)
+		if route == "" || public[route] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tenantID, ok := limitTenant(w, r, route)
+		if !ok {
+			return
+		}
+		release, err := s.cfg.Limiter.Acquire(tenantID, route)
+		var limited *ratelimit.Error
+		if errors.As(err, &limited) {
+			tooMany(w, r, limited)
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tooMany answers 429 with Retry-After for a request over limited's rule.
+func tooMany(w http.ResponseWriter, r *http.Request, limited *ratelimit.Error) {
+	wait := max(1, int(math.Ceil(limited.RetryAfter.Seconds())))
+	w.Header().Set("Retry-After", strconv.Itoa(wait))
+	annotate(r, "limited", limited.Rule.String())
+	writeProblem(w, r, http.StatusTooManyRequests, fmt.Sprintf("Too many requests: %s; retry in %ds", limited, wait))
+}
+
+// bodyTenant lists the routes that name their tenant in the body rather than
+// the tenant_id parameter.
+var bodyTenant = map[string]bool{
+	"POST /suppliers": true,
+	"POST /parts":     true,
+}
+
+// limitTenant returns the tenant a request counts against: the caller's, or
+// without authentication the tenant the handler would read, from the
+// tenant_id parameter or the body. A request without one is answered 400
+// rather than sharing an empty tenant's limits.
+func limitTenant(w http.ResponseWriter, r *http.Request, route string) (string, bool) {
+	var tenantID string
+	if p, ok := auth.FromContext(r.Context()); ok {
+		tenantID = p.TenantID
+	} else if bodyTenant[route] {
+		var ok bool
+		if tenantID, ok = peekTenant(w, r); !ok {
+			return "", false
+		}
+	} else {
+		tenantID = r.URL.Query().Get("tenant_id")
+	}
+	if tenantID == "" {
+		invalid(w, r, fieldError{"tenant_id", "is required"})
+		return "", false
+	}
+	return tenantID, true
+}
+
+// peekTenant reads the tenant_id of a JSON body, leaving the body for the
+// handler to decode.
+func peekTenant(w http.ResponseWriter, r *http.Request) (string, bool) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		if !tooLarge(w, r, err) {
+			writeProblem(w, r, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		}
+		return "", false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	var body struct {
+		TenantID string `json:"tenant_id"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid request body: "+err.Error(), bodyProblem(err)...)
+		return "", false
+	}
+	return body.TenantID, true
+}
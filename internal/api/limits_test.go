@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/bitterfq/data-ingestion-go/internal/auth"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/ratelimit"
+)
+
+func TestRateLimits(t *testing.T) {
+	cfg := testConfig
+	cfg.Limiter = ratelimit.New([]ratelimit.Rule{{Route: "GET /suppliers", Rate: 0.1, Burst: 2}})
+	h := NewServer(cfg, openStore(t), nil)
+
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+	w := do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme", "")
+	expect(t, w, http.StatusTooManyRequests, nil)
+	if got := w.Header().Get("Retry-After"); got != "10" {
+		t.Errorf("expected Retry-After 10, got %q", got)
+	}
+
+	// other tenants, other routes and the probes are unaffected
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_bolt", ""), http.StatusOK, nil)
+	expect(t, do(t, h, http.MethodGet, "/parts?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+	cfg.Limiter.SetRules([]ratelimit.Rule{{Rate: 0.1, Burst: 1}})
+	for range 3 {
+		expect(t, do(t, h, http.MethodGet, "/readyz", ""), http.StatusOK, nil)
+	}
+
+	// the rules change at runtime
+	cfg.Limiter.SetRules(nil)
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+}
+
+func TestLimitTenant(t *testing.T) {
+	cfg := testConfig
+	cfg.Limiter = ratelimit.New([]ratelimit.Rule{{Rate: 0.1, Burst: 1}})
+	h := NewServer(cfg, openStore(t), nil)
+
+	// a request naming no tenant is rejected, not counted against an empty one
+	for range 2 {
+		var p problem
+		expect(t, do(t, h, http.MethodGet, "/suppliers", ""), http.StatusBadRequest, &p)
+		if !slices.Equal(fieldsOf(p), []string{"tenant_id"}) {
+			t.Errorf("expected a tenant_id error, got %+v", p)
+		}
+	}
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"part_number": "P-1"}`), http.StatusBadRequest, nil)
+	expect(t, do(t, h, http.MethodPost, "/parts", `{"tenant_id": 1}`), http.StatusBadRequest, nil)
+
+	// creates count against the tenant in their body, and leave it for the handler
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Acme"}`), http.StatusCreated, nil)
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_bolt", "legal_name": "Bolt"}`), http.StatusCreated, nil)
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Acme"}`), http.StatusTooManyRequests, nil)
+
+	// an authenticated caller counts against its own tenant whatever it names
+	secret := []byte("s3cret")
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "alice", "tenant_id": "tenant_cove", "role": "reader", "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Authenticator = auth.JWT{Secret: secret}
+	h = NewServer(cfg, openStore(t), nil)
+	expect(t, doAs(t, h, token, http.MethodGet, "/suppliers?tenant_id=tenant_dune", ""), http.StatusForbidden, nil)
+	expect(t, doAs(t, h, token, http.MethodGet, "/suppliers?tenant_id=tenant_cove", ""), http.StatusTooManyRequests, nil)
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	src := &blockingSource{started: make(chan struct{}), release: make(chan struct{})}
+	cfg := testConfig
+	cfg.Limiter = ratelimit.New([]ratelimit.Rule{{Route: "GET /fetch-and-insert", Concurrency: 1}})
+	h := NewServer(cfg, openStore(t), func(context.Context) (Source, error) { return src, nil })
+
+	done := make(chan int)
+	go func() { done <- do(t, h, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", "").Code }()
+	select {
+	case <-src.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first fetch did not start")
+	}
+	w := do(t, h, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", "")
+	expect(t, w, http.StatusTooManyRequests, nil)
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+	close(src.release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expected the first fetch to succeed, got %d", code)
+	}
+}
+
+// blockingSource blocks each fetch until release is closed.
+type blockingSource struct {
+	started, release chan struct{}
+	once             sync.Once
+}
+
+func (b *blockingSource) FetchSuppliers(ctx context.Context, tenantID string) ([]db.CreateSupplierParams, error) {
+	b.once.Do(func() { close(b.started) })
+	<-b.release
+	return nil, nil
+}
+
+func (*blockingSource) Close() error { return nil }
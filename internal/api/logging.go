@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/logging"
+)
+
+// requestIDHeader carries a request's correlation ID. A caller's ID is kept
+// so one ID can follow a request across services; otherwise the server makes
+// one. Either way it is echoed in the response and logged with every record
+// of the request.
+const requestIDHeader = "X-Request-ID"
+
+// requestInfo collects what the handlers add to a request's log record.
+type requestInfo struct {
+	attrs []any
+}
+
+type requestInfoKey struct{}
+
+// annotate adds attributes, such as the tenant or a row count, to the record
+// logged when the request finishes.
+func annotate(r *http.Request, args ...any) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		info.attrs = append(info.attrs, args...)
+	}
+}
+
+// logger returns the request's logger, which carries its ID.
+func logger(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context())
+}
+
+// logRequests logs one record per request with its ID, route, status and
+// latency, plus what the handler annotated. Server errors are logged at
+// error level, everything else at info.
+func (s *server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		log := s.log.With("request_id", id)
+		info := &requestInfo{}
+		ctx := context.WithValue(logging.NewContext(r.Context(), log), requestInfoKey{}, info)
+		r = r.WithContext(ctx)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		args := []any{
+			"method", r.Method,
+			"route", s.route(r),
+			"path", r.URL.Path,
+			"status", rec.status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"bytes", rec.bytes,
+		}
+		log.Log(ctx, level, "request", append(args, info.attrs...)...)
+	})
+}
+
+// validRequestID accepts a caller's ID of up to 128 letters, digits and
+// -._: so it is safe to echo and log.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '.', c == '_', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// statusRecorder records the status and size of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	wrote  bool
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if !s.wrote {
+		s.status, s.wrote = status, true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	s.wrote = true
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += n
+	return n, err
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// measureRequests counts requests and records their latency by route and
+// status.
+func (s *server) measureRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		s.metrics.ObserveRequest(s.route(r), rec.status, time.Since(start))
+	})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/metrics"
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
+)
+
+// scrape returns the exposition of m.
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return w.Body.String()
+}
+
+func TestRequestMetrics(t *testing.T) {
+	m := metrics.New()
+	store, err := storage.Open(context.Background(), "sqlite::memory:", storage.Options{Metrics: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ts := time.Date(2025, 9, 24, 10, 0, 0, 0, time.UTC)
+	src := &stubSource{sups: []db.CreateSupplierParams{
+		{SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Acme", SourceTimestamp: sql.NullTime{Time: ts, Valid: true}},
+		{SupplierID: "SUP-2", TenantID: "tenant_acme", LegalName: "Bolt", SourceTimestamp: sql.NullTime{Time: ts, Valid: true}},
+	}}
+	cfg := testConfig
+	cfg.Metrics = m
+	h := NewServer(cfg, store, func(ctx context.Context) (Source, error) { return src, nil })
+
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Acme"}`), http.StatusCreated, nil)
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+	expect(t, do(t, h, http.MethodGet, "/suppliers", ""), http.StatusBadRequest, nil)
+	expect(t, do(t, h, http.MethodGet, "/nowhere", ""), http.StatusNotFound, nil)
+	expect(t, do(t, h, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusOK, nil)
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`http_requests_total{route="POST /suppliers",status="201"} 1`,
+		`http_requests_total{route="GET /suppliers",status="200"} 2`,
+		`http_requests_total{route="GET /suppliers",status="400"} 1`,
+		`http_requests_total{route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{route="GET /fetch-and-insert",status="200"} 1`,
+		`ingest_rows_total{entity="suppliers",source="api"} 1`,
+		`ingest_rows_total{entity="suppliers",source="snowflake"} 2`,
+		`db_transaction_duration_seconds_count{outcome="commit",system="sqlite",table="dim_supplier_v1"} 1`,
+		`go_sql_max_open_connections{db_name="sqlite"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the exposition", want)
+		}
+	}
+}
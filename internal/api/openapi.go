@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// openapiYAML is the hand-maintained description of every route. Change it
+// with the handlers; the tests validate every request and response they make
+// against it.
+//
+//go:embed openapi.yaml
+var openapiYAML []byte
+
+//go:embed docs.html
+var docsHTML []byte
+
+// spec holds the parsed document, the JSON served for it and a router
+// matching requests to its operations.
+type spec struct {
+	doc    *openapi3.T
+	json   []byte
+	router routers.Router
+}
+
+// loadSpec parses openapi.yaml once.
+var loadSpec = sync.OnceValues(func() (*spec, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openapiYAML)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	js, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &spec{doc: doc, json: js, router: router}, nil
+})
+
+// OpenAPI returns the API's OpenAPI 3 document.
+func OpenAPI() (*openapi3.T, error) {
+	s, err := loadSpec()
+	if err != nil {
+		return nil, err
+	}
+	return s.doc, nil
+}
+
+// GET /openapi.json
+func (s *server) openapi(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.spec.json)
+}
+
+// GET /docs serves Swagger UI for /openapi.json.
+func (s *server) docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsHTML)
+}
+
+// docsAssets are the Swagger UI files docs.html loads. They are embedded in
+// the binary by swaggo/files, pinned in go.sum, so the page runs no script
+// from a CDN.
+var docsAssets = []string{"swagger-ui.css", "swagger-ui-bundle.js"}
+
+// GET /docs/{file} serves a Swagger UI asset.
+func (s *server) docsAsset(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("file")
+	if !slices.Contains(docsAssets, name) {
+		writeProblem(w, r, http.StatusNotFound, "File not found")
+		return
+	}
+	http.ServeFileFS(w, r, swaggerFiles.FS, name)
+}
+
+// validate checks requests and responses of operations in the spec, as
+// configured. An invalid request is answered with 400 without reaching next.
+// An invalid response is logged and replaced with a 500, so drift between the
+// handlers and the spec fails loudly instead of reaching clients. Requests
+// that match no operation pass through unchecked and get the mux's 404 or
+// 405.
+func (s *server) validate(next http.Handler) http.Handler {
+	opts := &openapi3filter.Options{
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, params, err := s.spec.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		in := &openapi3filter.RequestValidationInput{Request: r, PathParams: params, Route: route, Options: opts}
+		if s.cfg.ValidateRequests {
+			if err := openapi3filter.ValidateRequest(r.Context(), in); err != nil {
+				if tooLarge(w, r, err) {
+					return
+				}
+				writeProblem(w, r, http.StatusBadRequest, err.Error(), specProblem(err)...)
+				return
+			}
+		}
+		if !s.cfg.ValidateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: in,
+			Status:                 rec.status,
+			Header:                 rec.header,
+			Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+			Options:                opts,
+		})
+		if err != nil {
+			logger(r).Error("response does not match the spec", "status", rec.status, "err", err)
+			writeProblem(w, r, http.StatusInternalServerError, "Response does not match the API specification")
+			return
+		}
+		for k, v := range rec.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.status)
+		w.Write(rec.body.Bytes())
+	})
+}
+
+// bufferedResponse holds a response until it has been validated.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if !b.wrote {
+		b.status, b.wrote = status, true
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.wrote = true
+	return b.body.Write(p)
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRoutesMatchSpec checks that every route is documented and every
+// documented operation is routed.
+func TestRoutesMatchSpec(t *testing.T) {
+	sp, err := loadSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{spec: sp}
+	mux := http.NewServeMux()
+	documented := map[string]bool{}
+	for _, rt := range s.routes() {
+		mux.HandleFunc(rt.pattern, rt.handler)
+		method, path, _ := strings.Cut(rt.pattern, " ")
+		kinds := []string{""}
+		if strings.Contains(path, "{kind}") {
+			kinds = []string{"suppliers", "parts"}
+		}
+		for _, kind := range kinds {
+			p := strings.NewReplacer("{id}", "x", "{kind}", kind).Replace(path)
+			if _, _, err := sp.router.FindRoute(httptest.NewRequest(method, p, nil)); err != nil {
+				t.Errorf("%s %s is not in openapi.yaml: %v", method, p, err)
+			}
+			documented[method+" "+p] = true
+		}
+	}
+
+	for path, item := range sp.doc.Paths.Map() {
+		for method := range item.Operations() {
+			p := strings.ReplaceAll(path, "{id}", "x")
+			if !documented[method+" "+p] {
+				t.Errorf("%s %s is documented but not routed", method, path)
+			}
+		}
+	}
+}
+
+func TestServeSpec(t *testing.T) {
+	h, _ := newTestServer(t, nil)
+	var doc struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	expect(t, do(t, h, http.MethodGet, "/openapi.json", ""), http.StatusOK, &doc)
+	if !strings.HasPrefix(doc.OpenAPI, "3.") || doc.Paths["/suppliers/{id}"] == nil {
+		t.Errorf("unexpected document %+v", doc)
+	}
+	w := do(t, h, http.MethodGet, "/docs", "")
+	expect(t, w, http.StatusOK, nil)
+	if !strings.Contains(w.Body.String(), "openapi.json") {
+		t.Error("expected the docs page to load openapi.json")
+	}
+	if strings.Contains(w.Body.String(), "https://") {
+		t.Error("expected the docs page to load nothing from another origin")
+	}
+	for _, name := range docsAssets {
+		w := do(t, h, http.MethodGet, "/docs/"+name, "")
+		expect(t, w, http.StatusOK, nil)
+		if !strings.Contains(w.Body.String(), "swagger") {
+			t.Errorf("%s: unexpected body", name)
+		}
+	}
+	expect(t, do(t, h, http.MethodGet, "/docs/index.html", ""), http.StatusNotFound, nil)
+}
+
+func TestValidateRequests(t *testing.T) {
+	h, _ := newTestServer(t, nil)
+	for _, tt := range []struct {
+		name, method, target, contentType, body string
+	}{
+		{"limit not a number", http.MethodGet, "/suppliers?tenant_id=tenant_acme&limit=ten", "", ""},
+		{"missing query", http.MethodGet, "/search/parts?tenant_id=tenant_acme", "", ""},
+		{"wrong field type", http.MethodPost, "/suppliers", "application/json", `{"tenant_id": "tenant_acme", "legal_name": "Acme", "risk_score": "high"}`},
+		{"form body", http.MethodPost, "/suppliers", "application/x-www-form-urlencoded", "tenant_id=tenant_acme&legal_name=Acme"},
+		{"null required field", http.MethodPatch, "/suppliers/SUP-1?tenant_id=tenant_acme", "application/merge-patch+json", `{"legal_name": null}`},
+	} {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", tt.name, w.Code, w.Body)
+		}
+	}
+
+	// a merge patch may use its own media type
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"supplier_id": "SUP-1", "tenant_id": "tenant_acme", "legal_name": "Acme"}`), http.StatusCreated, nil)
+	req := httptest.NewRequest(http.MethodPatch, "/suppliers/SUP-1?tenant_id=tenant_acme", strings.NewReader(`{"city": "Berlin"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var got supplier
+	expect(t, w, http.StatusOK, &got)
+	if *got.City != "Berlin" {
+		t.Errorf("unexpected supplier %+v", got)
+	}
+}
+
+func TestValidateResponses(t *testing.T) {
+	sp, err := loadSpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{cfg: Config{ValidateResponses: true}, spec: sp}
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+		want    int
+	}{
+		{"matches", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, loadSummary{Inserted: 1})
+		}, http.StatusOK},
+		{"undocumented status", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}, http.StatusInternalServerError},
+		{"missing field", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, map[string]int{"inserted": 1})
+		}, http.StatusInternalServerError},
+	} {
+		w := httptest.NewRecorder()
+		s.validate(tt.handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, w.Code, w.Body)
+		}
+		if tt.want == http.StatusOK {
+			var summary loadSummary
+			if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil || summary.Inserted != 1 {
+				t.Errorf("%s: body not passed through: %s", tt.name, w.Body)
+			}
+		}
+	}
+}
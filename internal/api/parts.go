@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
+)
+
+// GET /parts?tenant_id=...&category=...&lifecycle_status=...&after=...&limit=...
+func (s *server) listParts(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	limit, bad := s.limit(r)
+	if bad != nil {
+		invalid(w, r, *bad)
+		return
+	}
+	// one extra row tells whether there is another page
+	rows, err := s.store.ListParts(r.Context(), db.ListPartsParams{
+		TenantID:        tenantID,
+		Category:        queryString(r, "category"),
+		LifecycleStatus: queryString(r, "lifecycle_status"),
+		After:           r.URL.Query().Get("after"),
+		PageSize:        limit + 1,
+	})
+	if err != nil {
+		logger(r).Error("list parts failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to list parts")
+		return
+	}
+	res := page[part]{Items: []part{}}
+	for i, row := range rows {
+		if int64(i) == limit {
+			res.Next = rows[i-1].PartID
+			break
+		}
+		res.Items = append(res.Items, partOf(row))
+	}
+	annotate(r, "rows", len(res.Items))
+	writeJSON(w, http.StatusOK, res)
+}
+
+// GET /parts/{id}?tenant_id=...
+func (s *server) getPart(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	s.writePart(w, r, http.StatusOK, tenantID, r.PathValue("id"))
+}
+
+// POST /parts creates a part from any of its fields; part_id is generated
+// when absent.
+func (s *server) createPart(w http.ResponseWriter, r *http.Request) {
+	var body part
+	if !decode(w, r, &body) {
+		return
+	}
+	// before validating, which looks up the suppliers in the tenant
+	if body.TenantID != nil && !ownTenant(w, r, *body.TenantID) {
+		return
+	}
+	errs, err := s.validatePart(r.Context(), &body)
+	if err != nil {
+		logger(r).Error("validate part failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to validate part")
+		return
+	}
+	if len(errs) > 0 {
+		invalid(w, r, errs...)
+		return
+	}
+	if body.PartID == nil || *body.PartID == "" {
+		id := newID()
+		body.PartID = &id
+	}
+	annotate(r, "tenant_id", *body.TenantID)
+	by := actor(r).String
+	body.ModifiedBy = &by
+
+	_, err = s.store.CreatePart(r.Context(), body.createParams())
+	if errors.Is(err, storage.ErrConflict) {
+		// the suppliers were validated, so the conflict is the part's ID
+		n, err := s.store.PartExists(r.Context(), db.PartExistsParams{TenantID: *body.TenantID, PartID: *body.PartID})
+		taken(w, r, "part", *body.PartID, n, err)
+		return
+	}
+	if err != nil {
+		logger(r).Error("create part failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to insert part")
+		return
+	}
+	s.metrics.AddRows("parts", "api", 1)
+	w.Header().Set("Location", location("parts", *body.TenantID, *body.PartID))
+	s.writePart(w, r, http.StatusCreated, *body.TenantID, *body.PartID)
+}
+
+// PUT /parts/{id}?tenant_id=... replaces every field.
+func (s *server) putPart(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	var body part
+	if !decode(w, r, &body) {
+		return
+	}
+	if !sameKey(w, r, "part_id", &body.PartID, r.PathValue("id"), &body.TenantID, tenantID) {
+		return
+	}
+	s.replacePart(w, r, body, sql.NullInt64{})
+}
+
+// PATCH /parts/{id}?tenant_id=... merges the fields sent, like suppliers.
+func (s *server) patchPart(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	row, err := s.store.GetPart(r.Context(), db.GetPartParams{TenantID: tenantID, PartID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		writeProblem(w, r, http.StatusNotFound, "Part not found")
+		return
+	}
+	if err != nil {
+		logger(r).Error("get part failed", "id", id, "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to update part")
+		return
+	}
+	body := partOf(row)
+	if !decode(w, r, &body) {
+		return
+	}
+	if !sameKey(w, r, "part_id", &body.PartID, id, &body.TenantID, tenantID) {
+		return
+	}
+	s.replacePart(w, r, body, sql.NullInt64{Int64: row.Version, Valid: true})
+}
+
+// replacePart writes every field of body. With a valid version it only
+// replaces the part if no other write has changed it since that version was
+// read, so concurrent PATCHes cannot silently undo each other.
+func (s *server) replacePart(w http.ResponseWriter, r *http.Request, body part, version sql.NullInt64) {
+	errs, err := s.validatePart(r.Context(), &body)
+	if err != nil {
+		logger(r).Error("validate part failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to validate part")
+		return
+	}
+	if len(errs) > 0 {
+		invalid(w, r, errs...)
+		return
+	}
+	by := actor(r).String
+	body.ModifiedBy = &by
+	arg := body.updateParams()
+	arg.Version = version
+	n, err := s.store.UpdatePart(r.Context(), arg)
+	if errors.Is(err, storage.ErrConflict) {
+		writeProblem(w, r, http.StatusConflict, "A supplier of the part does not exist")
+		return
+	}
+	if err != nil {
+		logger(r).Error("update part failed", "id", *body.PartID, "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to update part")
+		return
+	}
+	if n == 0 && version.Valid {
+		_, err := s.store.GetPart(r.Context(), db.GetPartParams{TenantID: *body.TenantID, PartID: *body.PartID})
+		if err == nil {
+			writeProblem(w, r, http.StatusConflict, "Part was changed by another request while this one was applied; retry it")
+			return
+		}
+	}
+	if n == 0 {
+		writeProblem(w, r, http.StatusNotFound, "Part not found")
+		return
+	}
+	s.metrics.AddRows("parts", "api", n)
+	s.writePart(w, r, http.StatusOK, *body.TenantID, *body.PartID)
+}
+
+// validatePart checks a part's fields and that its default and qualified
+// suppliers are live suppliers of the same tenant. The foreign keys alone
+// would accept a deleted supplier or one of another tenant.
+func (s *server) validatePart(ctx context.Context, p *part) (fields, error) {
+	f := p.validate()
+	if p.TenantID == nil {
+		return f, nil
+	}
+	known := func(id string) (bool, error) {
+		_, err := s.store.GetSupplier(ctx, db.GetSupplierParams{TenantID: *p.TenantID, SupplierID: id})
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if p.DefaultSupplierID != nil {
+		ok, err := known(*p.DefaultSupplierID)
+		if err != nil {
+			return f, err
+		}
+		if !ok {
+			f.add("default_supplier_id", "is not a supplier of this tenant")
+		}
+	}
+	for _, id := range p.QualifiedSupplierIDs {
+		ok, err := known(id)
+		if err != nil {
+			return f, err
+		}
+		if !ok {
+			f.add("qualified_supplier_ids", "includes %s, which is not a supplier of this tenant", id)
+		}
+	}
+	return f, nil
+}
+
+// DELETE /parts/{id}?tenant_id=... soft-deletes a part.
+func (s *server) deletePart(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	n, err := s.store.DeletePart(r.Context(), db.DeletePartParams{DeletedBy: actor(r), TenantID: tenantID, PartID: r.PathValue("id")})
+	noContent(w, r, "part", r.PathValue("id"), n, err)
+}
+
+// POST /parts/{id}/restore?tenant_id=... undoes a soft delete.
+func (s *server) restorePart(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	n, err := s.store.RestorePart(r.Context(), db.RestorePartParams{ModifiedBy: actor(r), TenantID: tenantID, PartID: r.PathValue("id")})
+	noContent(w, r, "part", r.PathValue("id"), n, err)
+}
+
+// writePart responds with a part as stored.
+func (s *server) writePart(w http.ResponseWriter, r *http.Request, status int, tenantID, id string) {
+	row, err := s.store.GetPart(r.Context(), db.GetPartParams{TenantID: tenantID, PartID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		writeProblem(w, r, http.StatusNotFound, "Part not found")
+		return
+	}
+	if err != nil {
+		logger(r).Error("get part failed", "id", id, "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to get part")
+		return
+	}
+	writeJSON(w, status, partOf(row))
+}
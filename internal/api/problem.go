@@ -0,0 +1,179 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// problem is an RFC 7807 problem details object, the body of every error
+// response. Errors lists what is wrong with each field of an invalid request.
+type problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []fieldError `json:"errors,omitempty"`
+}
+
+// fieldError is one invalid field: its JSON name, or the name of the query
+// parameter, and what is wrong with it.
+type fieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// writeProblem responds with a problem of type about:blank, whose title is
+// the status text.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string, errs ...fieldError) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Errors:   errs,
+	})
+}
+
+// invalid responds 400 to a request with invalid fields.
+func invalid(w http.ResponseWriter, r *http.Request, errs ...fieldError) {
+	detail := "The request has an invalid field"
+	if len(errs) > 1 {
+		detail = fmt.Sprintf("The request has %d invalid fields", len(errs))
+	}
+	writeProblem(w, r, http.StatusBadRequest, detail, errs...)
+}
+
+// bodyProblem turns a decoding error into a field error where the decoder
+// names the field.
+func bodyProblem(err error) []fieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []fieldError{{typeErr.Field, "must be " + article(typeErr.Type.String())}}
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return []fieldError{{strings.Trim(name, `"`), "is not a field of this resource"}}
+	}
+	return nil
+}
+
+// article names a Go type the way a JSON client would read it.
+func article(goType string) string {
+	goType = strings.TrimPrefix(goType, "*")
+	switch {
+	case goType == "string":
+		return "a string"
+	case strings.HasPrefix(goType, "int"):
+		return "an integer"
+	case strings.HasPrefix(goType, "float"):
+		return "a number"
+	case strings.HasPrefix(goType, "[]"):
+		return "an array"
+	case goType == "time.Time":
+		return "an RFC 3339 timestamp"
+	}
+	return "a " + goType
+}
+
+// specProblem turns a request the OpenAPI document rejects into field errors.
+func specProblem(err error) []fieldError {
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return nil
+	}
+	detail := reqErr.Reason
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		detail = schemaErr.Reason
+	}
+	if detail == "" && reqErr.Err != nil {
+		detail = reqErr.Err.Error()
+	}
+	switch {
+	case reqErr.Parameter != nil:
+		return []fieldError{{reqErr.Parameter.Name, detail}}
+	case schemaErr != nil && len(schemaErr.JSONPointer()) > 0:
+		return []fieldError{{strings.Join(schemaErr.JSONPointer(), "."), detail}}
+	}
+	return nil
+}
+
+// fields collects the field errors of a request body, so a client learns
+// about every invalid field at once.
+type fields []fieldError
+
+func (f *fields) add(field, format string, args ...any) {
+	*f = append(*f, fieldError{field, fmt.Sprintf(format, args...)})
+}
+
+func (f *fields) required(field string, v *string) {
+	if v == nil || *v == "" {
+		f.add(field, "is required")
+	}
+}
+
+// oneOf checks a coded field against its vocabulary.
+func (f *fields) oneOf(field string, v *string, vocabulary []string) {
+	if v != nil && !slices.Contains(vocabulary, *v) {
+		f.add(field, "must be one of %s", strings.Join(vocabulary, ", "))
+	}
+}
+
+// allOf checks every element of a coded list against its vocabulary.
+func (f *fields) allOf(field string, l []string, vocabulary []string) {
+	for _, v := range l {
+		if !slices.Contains(vocabulary, v) {
+			f.add(field, "%q is not one of %s", v, strings.Join(vocabulary, ", "))
+		}
+	}
+}
+
+// words checks a free-form list. Lists are stored as "[a b]", so only items
+// without spaces or brackets read back as they were written.
+func (f *fields) words(field string, l []string) {
+	for _, v := range l {
+		if v == "" || strings.ContainsFunc(v, unicode.IsSpace) || strings.ContainsAny(v, "[]") {
+			f.add(field, "%q must be a single word without brackets", v)
+		}
+	}
+}
+
+func (f *fields) between(field string, v *float64, lo, hi float64) {
+	if v != nil && (*v < lo || *v > hi) {
+		f.add(field, "must be between %g and %g", lo, hi)
+	}
+}
+
+func (f *fields) atLeast(field string, v *int64, lo int64) {
+	if v != nil && *v < lo {
+		f.add(field, "must be at least %d", lo)
+	}
+}
+
+func (f *fields) nonNegative(field string, v *float64) {
+	if v != nil && *v < 0 {
+		f.add(field, "must not be negative")
+	}
+}
+
+// email accepts a bare address, without a display name.
+func (f *fields) email(field string, v *string) {
+	if v == nil {
+		return
+	}
+	if a, err := mail.ParseAddress(*v); err != nil || a.Address != *v {
+		f.add(field, "must be an email address")
+	}
+}
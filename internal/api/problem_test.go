@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/bitterfq/data-ingestion-go/internal/bulkload"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/parts"
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
+	"github.com/bitterfq/data-ingestion-go/internal/suppliers"
+)
+
+// openStore opens a fresh in-memory SQLite store.
+func openStore(t *testing.T) storage.Store {
+	t.Helper()
+	store, err := storage.Open(context.Background(), "sqlite::memory:", storage.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// fieldsOf returns the names of the invalid fields of a problem.
+func fieldsOf(p problem) []string {
+	var names []string
+	for _, e := range p.Errors {
+		names = append(names, e.Field)
+	}
+	return names
+}
+
+func TestValidateSupplier(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+	for _, tt := range []struct {
+		name string
+		s    supplier
+		want []string
+	}{
+		{"valid", supplier{TenantID: str("tenant_acme"), LegalName: str("Acme"), Country: str("DE"), ContactEmail: str("buyer@acme.example"), OnTimeDeliveryRate: num(100)}, nil},
+		{"missing", supplier{LegalName: str("")}, []string{"tenant_id", "legal_name"}},
+		{"vocabularies", supplier{TenantID: str("t"), LegalName: str("x"), Country: str("GB"), PreferredCurrency: str("GBP"), Incoterms: str("DAP"), ComplianceFlags: []string{"REACH", "WEEE"}},
+			[]string{"country", "preferred_currency", "incoterms", "compliance_flags"}},
+		{"ranges", supplier{TenantID: str("t"), LegalName: str("x"), OnTimeDeliveryRate: num(101), RiskScore: num(-1), Lat: num(91)},
+			[]string{"on_time_delivery_rate", "risk_score", "lat"}},
+		{"email", supplier{TenantID: str("t"), LegalName: str("x"), ContactEmail: str("Buyer <buyer@acme.example>")}, []string{"contact_email"}},
+		{"lists", supplier{TenantID: str("t"), LegalName: str("x"), Contracts: []string{"CT-1", "CT 2", "[CT-3]", ""}},
+			[]string{"contracts", "contracts", "contracts"}},
+	} {
+		if got := fieldsOf(problem{Errors: tt.s.validate()}); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: invalid fields %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// every generated supplier is valid once stored
+	ctx := context.Background()
+	store := openStore(t)
+	for _, sup := range suppliers.GenerateSuppliers("tenant_acme", 100) {
+		if _, err := store.CreateSupplier(ctx, bulkload.SupplierParams(sup)); err != nil {
+			t.Fatal(err)
+		}
+		row, err := store.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: sup.SupplierID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := supplierOf(row); len(s.validate()) > 0 {
+			t.Fatalf("generated supplier %+v is invalid: %v", s, s.validate())
+		}
+	}
+}
+
+func TestValidatePart(t *testing.T) {
+	str := func(s string) *string { return &s }
+	n := func(i int64) *int64 { return &i }
+	p := part{TenantID: str("t"), PartNumber: str("P-1"), Description: str("bolt"), Category: str("FASTENER"), Moq: n(0), HazardClass: str(""),
+		BomCompatibility: []string{"ABC", "A B"}, QualifiedSupplierIDs: []string{"SUP-1", "SUP-2\t"}}
+	if got, want := fieldsOf(problem{Errors: p.validate()}), []string{"category", "bom_compatibility", "qualified_supplier_ids", "moq", "hazard_class"}; !slices.Equal(got, want) {
+		t.Errorf("invalid fields %v, want %v", got, want)
+	}
+
+	ctx := context.Background()
+	store := openStore(t)
+	sup := suppliers.GenerateSupplier("tenant_acme")
+	if _, err := store.CreateSupplier(ctx, bulkload.SupplierParams(sup)); err != nil {
+		t.Fatal(err)
+	}
+	for _, gen := range parts.GenerateParts(100, "tenant_acme", []string{sup.SupplierID}) {
+		if _, err := store.CreatePart(ctx, bulkload.PartParams(gen)); err != nil {
+			t.Fatal(err)
+		}
+		row, err := store.GetPart(ctx, db.GetPartParams{TenantID: "tenant_acme", PartID: gen.PartID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p := partOf(row); len(p.validate()) > 0 {
+			t.Fatalf("generated part %+v is invalid: %v", p, p.validate())
+		}
+	}
+}
+
+func TestProblems(t *testing.T) {
+	// without the spec the handlers' own validation answers
+	h := NewServer(Config{}, openStore(t), nil)
+
+	w := do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "country": "GB", "contact_email": "nobody", "on_time_delivery_rate": 250}`)
+	var p problem
+	expect(t, w, http.StatusBadRequest, &p)
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("unexpected Content-Type %q", ct)
+	}
+	if want := []string{"legal_name", "country", "contact_email", "on_time_delivery_rate"}; !slices.Equal(fieldsOf(p), want) ||
+		p.Status != http.StatusBadRequest || p.Title != "Bad Request" || p.Instance != "/suppliers" {
+		t.Errorf("unexpected problem %+v", p)
+	}
+
+	p = problem{}
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Acme", "lead_time_days_avg": "soon"}`), http.StatusBadRequest, &p)
+	if !slices.Equal(fieldsOf(p), []string{"lead_time_days_avg"}) || p.Errors[0].Detail != "must be an integer" {
+		t.Errorf("unexpected problem %+v", p)
+	}
+
+	// the default and qualified suppliers must be live suppliers of the
+	// same tenant
+	for _, body := range []string{
+		`{"supplier_id": "SUP-1", "tenant_id": "tenant_acme", "legal_name": "Acme"}`,
+		`{"supplier_id": "SUP-2", "tenant_id": "tenant_globex", "legal_name": "Globex"}`,
+	} {
+		expect(t, do(t, h, http.MethodPost, "/suppliers", body), http.StatusCreated, nil)
+	}
+	expect(t, do(t, h, http.MethodDelete, "/suppliers/SUP-1?tenant_id=tenant_acme", ""), http.StatusNoContent, nil)
+	for _, id := range []string{"SUP-1", "SUP-2", "SUP-3"} {
+		p = problem{}
+		expect(t, do(t, h, http.MethodPost, "/parts", `{"tenant_id": "tenant_acme", "part_number": "P-1", "description": "bolt", "default_supplier_id": "`+id+`"}`), http.StatusBadRequest, &p)
+		if !slices.Equal(fieldsOf(p), []string{"default_supplier_id"}) {
+			t.Errorf("%s: unexpected problem %+v", id, p)
+		}
+		p = problem{}
+		expect(t, do(t, h, http.MethodPost, "/parts", `{"tenant_id": "tenant_acme", "part_number": "P-1", "description": "bolt", "qualified_supplier_ids": ["`+id+`"]}`), http.StatusBadRequest, &p)
+		if !slices.Equal(fieldsOf(p), []string{"qualified_supplier_ids"}) {
+			t.Errorf("%s: unexpected problem %+v", id, p)
+		}
+	}
+
+	p = problem{}
+	expect(t, do(t, h, http.MethodGet, "/parts/PART-1?tenant_id=tenant_acme", ""), http.StatusNotFound, &p)
+	if p.Detail != "Part not found" || p.Errors != nil {
+		t.Errorf("unexpected problem %+v", p)
+	}
+
+	// with it, the spec names the field it rejects
+	h, _ = newTestServer(t, nil)
+	p = problem{}
+	expect(t, do(t, h, http.MethodPost, "/suppliers", `{"tenant_id": "tenant_acme", "legal_name": "Acme", "incoterms": "DAP"}`), http.StatusBadRequest, &p)
+	if !slices.Equal(fieldsOf(p), []string{"incoterms"}) {
+		t.Errorf("unexpected problem %+v", p)
+	}
+	p = problem{}
+	expect(t, do(t, h, http.MethodGet, "/suppliers?tenant_id=tenant_acme&limit=0", ""), http.StatusBadRequest, &p)
+	if !slices.Equal(fieldsOf(p), []string{"limit"}) {
+		t.Errorf("unexpected problem %+v", p)
+	}
+}
+
+// TestVocabulariesMatchSpec checks that openapi.yaml lists the same codes the
+// generators draw from.
+func TestVocabulariesMatchSpec(t *testing.T) {
+	doc, err := OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for schema, fields := range map[string]map[string][]string{
+		"Supplier": {
+			"country":             suppliers.Countries,
+			"region":              suppliers.Regions,
+			"preferred_currency":  suppliers.Currencies,
+			"incoterms":           suppliers.Incoterms,
+			"financial_risk_tier": suppliers.RiskTiers,
+			"certifications":      suppliers.Certifications,
+			"compliance_flags":    suppliers.ComplianceFlags,
+			"approved_status":     suppliers.ApprovedStatuses,
+		},
+		"Part": {
+			"category":         parts.Categories,
+			"lifecycle_status": parts.LifecycleStatuses,
+			"uom":              parts.Uoms,
+			"quality_grade":    parts.QualityGrades,
+			"compliance_flags": parts.ComplianceFlags,
+			"hazard_class":     parts.HazardClasses,
+		},
+	} {
+		for field, vocabulary := range fields {
+			prop := doc.Components.Schemas[schema].Value.Properties[field].Value
+			if prop.Items != nil {
+				prop = prop.Items.Value
+			}
+			var enum []string
+			for _, v := range prop.Enum {
+				enum = append(enum, v.(string))
+			}
+			if !slices.Equal(enum, vocabulary) {
+				t.Errorf("%s.%s: openapi.yaml lists %v, the generator %v", schema, field, enum, vocabulary)
+			}
+		}
+	}
+}
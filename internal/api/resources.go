@@ -0,0 +1,422 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/parts"
+	"github.com/bitterfq/data-ingestion-go/internal/suppliers"
+)
+
+// supplier is the JSON form of a supplier. Every field is optional so the
+// same type serves create, replace and merge patch bodies; absent and null
+// fields are stored as NULL. modified_by is set from the request.
+type supplier struct {
+	SupplierID           *string    `json:"supplier_id,omitempty"`
+	TenantID             *string    `json:"tenant_id,omitempty"`
+	SupplierCode         *string    `json:"supplier_code,omitempty"`
+	LegalName            *string    `json:"legal_name,omitempty"`
+	DBAName              *string    `json:"dba_name,omitempty"`
+	Country              *string    `json:"country,omitempty"`
+	Region               *string    `json:"region,omitempty"`
+	AddressLine1         *string    `json:"address_line1,omitempty"`
+	AddressLine2         *string    `json:"address_line2,omitempty"`
+	City                 *string    `json:"city,omitempty"`
+	State                *string    `json:"state,omitempty"`
+	PostalCode           *string    `json:"postal_code,omitempty"`
+	ContactEmail         *string    `json:"contact_email,omitempty"`
+	ContactPhone         *string    `json:"contact_phone,omitempty"`
+	PreferredCurrency    *string    `json:"preferred_currency,omitempty"`
+	Incoterms            *string    `json:"incoterms,omitempty"`
+	LeadTimeDaysAvg      *int64     `json:"lead_time_days_avg,omitempty"`
+	LeadTimeDaysP95      *int64     `json:"lead_time_days_p95,omitempty"`
+	OnTimeDeliveryRate   *float64   `json:"on_time_delivery_rate,omitempty"`
+	DefectRatePPM        *int64     `json:"defect_rate_ppm,omitempty"`
+	CapacityUnitsPerWeek *int64     `json:"capacity_units_per_week,omitempty"`
+	RiskScore            *float64   `json:"risk_score,omitempty"`
+	FinancialRiskTier    *string    `json:"financial_risk_tier,omitempty"`
+	Certifications       []string   `json:"certifications,omitempty"`
+	ComplianceFlags      []string   `json:"compliance_flags,omitempty"`
+	ApprovedStatus       *string    `json:"approved_status,omitempty"`
+	Contracts            []string   `json:"contracts,omitempty"`
+	TermsVersion         *string    `json:"terms_version,omitempty"`
+	Lat                  *float64   `json:"lat,omitempty"`
+	Lon                  *float64   `json:"lon,omitempty"`
+	DataSource           *string    `json:"data_source,omitempty"`
+	SourceTimestamp      *time.Time `json:"source_timestamp,omitempty"`
+	IngestionTimestamp   *time.Time `json:"ingestion_timestamp,omitempty"`
+	SchemaVersion        *string    `json:"schema_version,omitempty"`
+	ModifiedBy           *string    `json:"modified_by,omitempty"`
+}
+
+// part is the JSON form of a part, like supplier.
+type part struct {
+	PartID               *string    `json:"part_id,omitempty"`
+	TenantID             *string    `json:"tenant_id,omitempty"`
+	PartNumber           *string    `json:"part_number,omitempty"`
+	Description          *string    `json:"description,omitempty"`
+	Category             *string    `json:"category,omitempty"`
+	LifecycleStatus      *string    `json:"lifecycle_status,omitempty"`
+	Uom                  *string    `json:"uom,omitempty"`
+	SpecHash             *string    `json:"spec_hash,omitempty"`
+	BomCompatibility     []string   `json:"bom_compatibility,omitempty"`
+	DefaultSupplierID    *string    `json:"default_supplier_id,omitempty"`
+	QualifiedSupplierIDs []string   `json:"qualified_supplier_ids,omitempty"`
+	UnitCost             *float64   `json:"unit_cost,omitempty"`
+	Moq                  *int64     `json:"moq,omitempty"`
+	LeadTimeDaysAvg      *int64     `json:"lead_time_days_avg,omitempty"`
+	LeadTimeDaysP95      *int64     `json:"lead_time_days_p95,omitempty"`
+	QualityGrade         *string    `json:"quality_grade,omitempty"`
+	ComplianceFlags      []string   `json:"compliance_flags,omitempty"`
+	HazardClass          *string    `json:"hazard_class,omitempty"`
+	LastPriceChange      *time.Time `json:"last_price_change,omitempty"`
+	DataSource           *string    `json:"data_source,omitempty"`
+	SourceTimestamp      *time.Time `json:"source_timestamp,omitempty"`
+	IngestionTimestamp   *time.Time `json:"ingestion_timestamp,omitempty"`
+	SchemaVersion        *string    `json:"schema_version,omitempty"`
+	ModifiedBy           *string    `json:"modified_by,omitempty"`
+}
+
+// page is a page of a collection. Next is the after parameter for the
+// following page, empty on the last one.
+type page[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next,omitempty"`
+}
+
+func supplierOf(s db.DimSupplierV1) supplier {
+	return supplier{
+		SupplierID:           &s.SupplierID,
+		TenantID:             &s.TenantID,
+		SupplierCode:         stringOf(s.SupplierCode),
+		LegalName:            &s.LegalName,
+		DBAName:              stringOf(s.DbaName),
+		Country:              stringOf(s.Country),
+		Region:               stringOf(s.Region),
+		AddressLine1:         stringOf(s.AddressLine1),
+		AddressLine2:         stringOf(s.AddressLine2),
+		City:                 stringOf(s.City),
+		State:                stringOf(s.State),
+		PostalCode:           stringOf(s.PostalCode),
+		ContactEmail:         stringOf(s.ContactEmail),
+		ContactPhone:         stringOf(s.ContactPhone),
+		PreferredCurrency:    stringOf(s.PreferredCurrency),
+		Incoterms:            stringOf(s.Incoterms),
+		LeadTimeDaysAvg:      intOf(s.LeadTimeDaysAvg),
+		LeadTimeDaysP95:      intOf(s.LeadTimeDaysP95),
+		OnTimeDeliveryRate:   floatOf(s.OnTimeDeliveryRate),
+		DefectRatePPM:        intOf(s.DefectRatePpm),
+		CapacityUnitsPerWeek: intOf(s.CapacityUnitsPerWeek),
+		RiskScore:            floatOf(s.RiskScore),
+		FinancialRiskTier:    stringOf(s.FinancialRiskTier),
+		Certifications:       listOf(s.Certifications),
+		ComplianceFlags:      listOf(s.ComplianceFlags),
+		ApprovedStatus:       stringOf(s.ApprovedStatus),
+		Contracts:            listOf(s.Contracts),
+		TermsVersion:         stringOf(s.TermsVersion),
+		Lat:                  floatOf(s.Lat),
+		Lon:                  floatOf(s.Lon),
+		DataSource:           stringOf(s.DataSource),
+		SourceTimestamp:      timeOf(s.SourceTimestamp),
+		IngestionTimestamp:   timeOf(s.IngestionTimestamp),
+		SchemaVersion:        stringOf(s.SchemaVersion),
+		ModifiedBy:           stringOf(s.ModifiedBy),
+	}
+}
+
+// validate checks the fields of a supplier against the generator's
+// vocabularies and the ranges the columns hold.
+func (s *supplier) validate() fields {
+	var f fields
+	f.required("tenant_id", s.TenantID)
+	f.required("legal_name", s.LegalName)
+	f.oneOf("country", s.Country, suppliers.Countries)
+	f.oneOf("region", s.Region, suppliers.Regions)
+	f.email("contact_email", s.ContactEmail)
+	f.oneOf("preferred_currency", s.PreferredCurrency, suppliers.Currencies)
+	f.oneOf("incoterms", s.Incoterms, suppliers.Incoterms)
+	f.atLeast("lead_time_days_avg", s.LeadTimeDaysAvg, 0)
+	f.atLeast("lead_time_days_p95", s.LeadTimeDaysP95, 0)
+	f.between("on_time_delivery_rate", s.OnTimeDeliveryRate, 0, 100)
+	f.atLeast("defect_rate_ppm", s.DefectRatePPM, 0)
+	f.atLeast("capacity_units_per_week", s.CapacityUnitsPerWeek, 0)
+	f.between("risk_score", s.RiskScore, 0, 100)
+	f.oneOf("financial_risk_tier", s.FinancialRiskTier, suppliers.RiskTiers)
+	f.allOf("certifications", s.Certifications, suppliers.Certifications)
+	f.allOf("compliance_flags", s.ComplianceFlags, suppliers.ComplianceFlags)
+	f.oneOf("approved_status", s.ApprovedStatus, suppliers.ApprovedStatuses)
+	f.words("contracts", s.Contracts)
+	f.between("lat", s.Lat, -90, 90)
+	f.between("lon", s.Lon, -180, 180)
+	return f
+}
+
+func (s *supplier) createParams() db.CreateSupplierParams {
+	return db.CreateSupplierParams{
+		SupplierID:           deref(s.SupplierID),
+		SupplierCode:         nullString(s.SupplierCode),
+		TenantID:             deref(s.TenantID),
+		LegalName:            deref(s.LegalName),
+		DbaName:              nullString(s.DBAName),
+		Country:              nullString(s.Country),
+		Region:               nullString(s.Region),
+		AddressLine1:         nullString(s.AddressLine1),
+		AddressLine2:         nullString(s.AddressLine2),
+		City:                 nullString(s.City),
+		State:                nullString(s.State),
+		PostalCode:           nullString(s.PostalCode),
+		ContactEmail:         nullString(s.ContactEmail),
+		ContactPhone:         nullString(s.ContactPhone),
+		PreferredCurrency:    nullString(s.PreferredCurrency),
+		Incoterms:            nullString(s.Incoterms),
+		LeadTimeDaysAvg:      nullInt(s.LeadTimeDaysAvg),
+		LeadTimeDaysP95:      nullInt(s.LeadTimeDaysP95),
+		OnTimeDeliveryRate:   nullFloat(s.OnTimeDeliveryRate),
+		DefectRatePpm:        nullInt(s.DefectRatePPM),
+		CapacityUnitsPerWeek: nullInt(s.CapacityUnitsPerWeek),
+		RiskScore:            nullFloat(s.RiskScore),
+		FinancialRiskTier:    nullString(s.FinancialRiskTier),
+		Certifications:       nullList(s.Certifications),
+		ComplianceFlags:      nullList(s.ComplianceFlags),
+		ApprovedStatus:       nullString(s.ApprovedStatus),
+		Contracts:            nullList(s.Contracts),
+		TermsVersion:         nullString(s.TermsVersion),
+		Lat:                  nullFloat(s.Lat),
+		Lon:                  nullFloat(s.Lon),
+		DataSource:           nullString(s.DataSource),
+		SourceTimestamp:      nullTime(s.SourceTimestamp),
+		IngestionTimestamp:   nullTime(s.IngestionTimestamp),
+		SchemaVersion:        nullString(s.SchemaVersion),
+		ModifiedBy:           nullString(s.ModifiedBy),
+	}
+}
+
+func (s *supplier) updateParams() db.UpdateSupplierParams {
+	p := s.createParams()
+	return db.UpdateSupplierParams{
+		SupplierCode:         p.SupplierCode,
+		LegalName:            p.LegalName,
+		DbaName:              p.DbaName,
+		Country:              p.Country,
+		Region:               p.Region,
+		AddressLine1:         p.AddressLine1,
+		AddressLine2:         p.AddressLine2,
+		City:                 p.City,
+		State:                p.State,
+		PostalCode:           p.PostalCode,
+		ContactEmail:         p.ContactEmail,
+		ContactPhone:         p.ContactPhone,
+		PreferredCurrency:    p.PreferredCurrency,
+		Incoterms:            p.Incoterms,
+		LeadTimeDaysAvg:      p.LeadTimeDaysAvg,
+		LeadTimeDaysP95:      p.LeadTimeDaysP95,
+		OnTimeDeliveryRate:   p.OnTimeDeliveryRate,
+		DefectRatePpm:        p.DefectRatePpm,
+		CapacityUnitsPerWeek: p.CapacityUnitsPerWeek,
+		RiskScore:            p.RiskScore,
+		FinancialRiskTier:    p.FinancialRiskTier,
+		Certifications:       p.Certifications,
+		ComplianceFlags:      p.ComplianceFlags,
+		ApprovedStatus:       p.ApprovedStatus,
+		Contracts:            p.Contracts,
+		TermsVersion:         p.TermsVersion,
+		Lat:                  p.Lat,
+		Lon:                  p.Lon,
+		DataSource:           p.DataSource,
+		SourceTimestamp:      p.SourceTimestamp,
+		IngestionTimestamp:   p.IngestionTimestamp,
+		SchemaVersion:        p.SchemaVersion,
+		ModifiedBy:           p.ModifiedBy,
+		TenantID:             p.TenantID,
+		SupplierID:           p.SupplierID,
+	}
+}
+
+func partOf(p db.DimPartV1) part {
+	return part{
+		PartID:               &p.PartID,
+		TenantID:             &p.TenantID,
+		PartNumber:           &p.PartNumber,
+		Description:          &p.Description,
+		Category:             stringOf(p.Category),
+		LifecycleStatus:      stringOf(p.LifecycleStatus),
+		Uom:                  stringOf(p.Uom),
+		SpecHash:             stringOf(p.SpecHash),
+		BomCompatibility:     listOf(p.BomCompatibility),
+		DefaultSupplierID:    stringOf(p.DefaultSupplierID),
+		QualifiedSupplierIDs: listOf(p.QualifiedSupplierIds),
+		UnitCost:             floatOf(p.UnitCost),
+		Moq:                  intOf(p.Moq),
+		LeadTimeDaysAvg:      intOf(p.LeadTimeDaysAvg),
+		LeadTimeDaysP95:      intOf(p.LeadTimeDaysP95),
+		QualityGrade:         stringOf(p.QualityGrade),
+		ComplianceFlags:      listOf(p.ComplianceFlags),
+		HazardClass:          stringOf(p.HazardClass),
+		LastPriceChange:      timeOf(p.LastPriceChange),
+		DataSource:           stringOf(p.DataSource),
+		SourceTimestamp:      timeOf(p.SourceTimestamp),
+		IngestionTimestamp:   timeOf(p.IngestionTimestamp),
+		SchemaVersion:        stringOf(p.SchemaVersion),
+		ModifiedBy:           stringOf(p.ModifiedBy),
+	}
+}
+
+// validate checks the fields of a part like supplier.validate. Whether
+// default_supplier_id exists is up to the caller, which has the store.
+func (p *part) validate() fields {
+	var f fields
+	f.required("tenant_id", p.TenantID)
+	f.required("part_number", p.PartNumber)
+	f.required("description", p.Description)
+	f.oneOf("category", p.Category, parts.Categories)
+	f.oneOf("lifecycle_status", p.LifecycleStatus, parts.LifecycleStatuses)
+	f.oneOf("uom", p.Uom, parts.Uoms)
+	f.words("bom_compatibility", p.BomCompatibility)
+	f.words("qualified_supplier_ids", p.QualifiedSupplierIDs)
+	f.nonNegative("unit_cost", p.UnitCost)
+	f.atLeast("moq", p.Moq, 1)
+	f.atLeast("lead_time_days_avg", p.LeadTimeDaysAvg, 0)
+	f.atLeast("lead_time_days_p95", p.LeadTimeDaysP95, 0)
+	f.oneOf("quality_grade", p.QualityGrade, parts.QualityGrades)
+	f.allOf("compliance_flags", p.ComplianceFlags, parts.ComplianceFlags)
+	f.oneOf("hazard_class", p.HazardClass, parts.HazardClasses)
+	return f
+}
+
+func (p *part) createParams() db.CreatePartParams {
+	return db.CreatePartParams{
+		PartID:               deref(p.PartID),
+		TenantID:             deref(p.TenantID),
+		PartNumber:           deref(p.PartNumber),
+		Description:          deref(p.Description),
+		Category:             nullString(p.Category),
+		LifecycleStatus:      nullString(p.LifecycleStatus),
+		Uom:                  nullString(p.Uom),
+		SpecHash:             nullString(p.SpecHash),
+		BomCompatibility:     nullList(p.BomCompatibility),
+		DefaultSupplierID:    nullString(p.DefaultSupplierID),
+		QualifiedSupplierIds: nullList(p.QualifiedSupplierIDs),
+		UnitCost:             nullFloat(p.UnitCost),
+		Moq:                  nullInt(p.Moq),
+		LeadTimeDaysAvg:      nullInt(p.LeadTimeDaysAvg),
+		LeadTimeDaysP95:      nullInt(p.LeadTimeDaysP95),
+		QualityGrade:         nullString(p.QualityGrade),
+		ComplianceFlags:      nullList(p.ComplianceFlags),
+		HazardClass:          nullString(p.HazardClass),
+		LastPriceChange:      nullTime(p.LastPriceChange),
+		DataSource:           nullString(p.DataSource),
+		SourceTimestamp:      nullTime(p.SourceTimestamp),
+		IngestionTimestamp:   nullTime(p.IngestionTimestamp),
+		SchemaVersion:        nullString(p.SchemaVersion),
+		ModifiedBy:           nullString(p.ModifiedBy),
+	}
+}
+
+func (p *part) updateParams() db.UpdatePartParams {
+	c := p.createParams()
+	return db.UpdatePartParams{
+		PartNumber:           c.PartNumber,
+		Description:          c.Description,
+		Category:             c.Category,
+		LifecycleStatus:      c.LifecycleStatus,
+		Uom:                  c.Uom,
+		SpecHash:             c.SpecHash,
+		BomCompatibility:     c.BomCompatibility,
+		DefaultSupplierID:    c.DefaultSupplierID,
+		QualifiedSupplierIds: c.QualifiedSupplierIds,
+		UnitCost:             c.UnitCost,
+		Moq:                  c.Moq,
+		LeadTimeDaysAvg:      c.LeadTimeDaysAvg,
+		LeadTimeDaysP95:      c.LeadTimeDaysP95,
+		QualityGrade:         c.QualityGrade,
+		ComplianceFlags:      c.ComplianceFlags,
+		HazardClass:          c.HazardClass,
+		LastPriceChange:      c.LastPriceChange,
+		DataSource:           c.DataSource,
+		SourceTimestamp:      c.SourceTimestamp,
+		IngestionTimestamp:   c.IngestionTimestamp,
+		SchemaVersion:        c.SchemaVersion,
+		ModifiedBy:           c.ModifiedBy,
+		TenantID:             c.TenantID,
+		PartID:               c.PartID,
+	}
+}
+
+func deref(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+func nullString(p *string) sql.NullString {
+	if p == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *p, Valid: true}
+}
+
+func nullInt(p *int64) sql.NullInt64 {
+	if p == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *p, Valid: true}
+}
+
+func nullFloat(p *float64) sql.NullFloat64 {
+	if p == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *p, Valid: true}
+}
+
+func nullTime(p *time.Time) sql.NullTime {
+	if p == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *p, Valid: true}
+}
+
+// nullList stores a list the way the loaders do, as its "%v" text.
+func nullList(l []string) sql.NullString {
+	return sql.NullString{String: fmt.Sprintf("%v", l), Valid: len(l) > 0}
+}
+
+func stringOf(n sql.NullString) *string {
+	if !n.Valid {
+		return nil
+	}
+	return &n.String
+}
+
+func intOf(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+func floatOf(n sql.NullFloat64) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Float64
+}
+
+func timeOf(n sql.NullTime) *time.Time {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Time
+}
+
+// listOf parses a list stored by nullList.
+func listOf(n sql.NullString) []string {
+	if !n.Valid {
+		return nil
+	}
+	return strings.Fields(strings.TrimSuffix(strings.TrimPrefix(n.String, "["), "]"))
+}
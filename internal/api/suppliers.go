@@ -0,0 +1,222 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
+)
+
+// GET /suppliers?tenant_id=...&country=...&region=...&approved_status=...&min_risk_score=...&max_risk_score=...&after=...&limit=...
+func (s *server) listSuppliers(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	limit, bad := s.limit(r)
+	if bad != nil {
+		invalid(w, r, *bad)
+		return
+	}
+	minRisk, bad := queryFloat(r, "min_risk_score")
+	if bad != nil {
+		invalid(w, r, *bad)
+		return
+	}
+	maxRisk, bad := queryFloat(r, "max_risk_score")
+	if bad != nil {
+		invalid(w, r, *bad)
+		return
+	}
+	// one extra row tells whether there is another page
+	rows, err := s.store.ListSuppliers(r.Context(), db.ListSuppliersParams{
+		TenantID:       tenantID,
+		Country:        queryString(r, "country"),
+		Region:         queryString(r, "region"),
+		ApprovedStatus: queryString(r, "approved_status"),
+		MinRiskScore:   minRisk,
+		MaxRiskScore:   maxRisk,
+		After:          r.URL.Query().Get("after"),
+		PageSize:       limit + 1,
+	})
+	if err != nil {
+		logger(r).Error("list suppliers failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to list suppliers")
+		return
+	}
+	res := page[supplier]{Items: []supplier{}}
+	for i, row := range rows {
+		if int64(i) == limit {
+			res.Next = rows[i-1].SupplierID
+			break
+		}
+		res.Items = append(res.Items, supplierOf(row))
+	}
+	annotate(r, "rows", len(res.Items))
+	writeJSON(w, http.StatusOK, res)
+}
+
+// GET /suppliers/{id}?tenant_id=...
+func (s *server) getSupplier(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	s.writeSupplier(w, r, http.StatusOK, tenantID, r.PathValue("id"))
+}
+
+// POST /suppliers creates a supplier from any of its fields; supplier_id is
+// generated when absent.
+func (s *server) createSupplier(w http.ResponseWriter, r *http.Request) {
+	var body supplier
+	if !decode(w, r, &body) {
+		return
+	}
+	if body.TenantID != nil && !ownTenant(w, r, *body.TenantID) {
+		return
+	}
+	if errs := body.validate(); len(errs) > 0 {
+		invalid(w, r, errs...)
+		return
+	}
+	if body.SupplierID == nil || *body.SupplierID == "" {
+		id := newID()
+		body.SupplierID = &id
+	}
+	annotate(r, "tenant_id", *body.TenantID)
+	by := actor(r).String
+	body.ModifiedBy = &by
+
+	_, err := s.store.CreateSupplier(r.Context(), body.createParams())
+	if errors.Is(err, storage.ErrConflict) {
+		n, err := s.store.SupplierExists(r.Context(), db.SupplierExistsParams{TenantID: *body.TenantID, SupplierID: *body.SupplierID})
+		taken(w, r, "supplier", *body.SupplierID, n, err)
+		return
+	}
+	if err != nil {
+		logger(r).Error("create supplier failed", "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to insert supplier")
+		return
+	}
+	s.metrics.AddRows("suppliers", "api", 1)
+	w.Header().Set("Location", location("suppliers", *body.TenantID, *body.SupplierID))
+	s.writeSupplier(w, r, http.StatusCreated, *body.TenantID, *body.SupplierID)
+}
+
+// PUT /suppliers/{id}?tenant_id=... replaces every field.
+func (s *server) putSupplier(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	var body supplier
+	if !decode(w, r, &body) {
+		return
+	}
+	if !sameKey(w, r, "supplier_id", &body.SupplierID, r.PathValue("id"), &body.TenantID, tenantID) {
+		return
+	}
+	s.replaceSupplier(w, r, body, sql.NullInt64{})
+}
+
+// PATCH /suppliers/{id}?tenant_id=... merges the fields sent (RFC 7396);
+// null clears one.
+func (s *server) patchSupplier(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	row, err := s.store.GetSupplier(r.Context(), db.GetSupplierParams{TenantID: tenantID, SupplierID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		writeProblem(w, r, http.StatusNotFound, "Supplier not found")
+		return
+	}
+	if err != nil {
+		logger(r).Error("get supplier failed", "id", id, "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to update supplier")
+		return
+	}
+	body := supplierOf(row)
+	if !decode(w, r, &body) {
+		return
+	}
+	if !sameKey(w, r, "supplier_id", &body.SupplierID, id, &body.TenantID, tenantID) {
+		return
+	}
+	s.replaceSupplier(w, r, body, sql.NullInt64{Int64: row.Version, Valid: true})
+}
+
+// replaceSupplier writes every field of body. With a valid version it only
+// replaces the supplier if no other write has changed it since that version was
+// read, so concurrent PATCHes cannot silently undo each other.
+func (s *server) replaceSupplier(w http.ResponseWriter, r *http.Request, body supplier, version sql.NullInt64) {
+	if errs := body.validate(); len(errs) > 0 {
+		invalid(w, r, errs...)
+		return
+	}
+	by := actor(r).String
+	body.ModifiedBy = &by
+	arg := body.updateParams()
+	arg.Version = version
+	n, err := s.store.UpdateSupplier(r.Context(), arg)
+	if errors.Is(err, storage.ErrConflict) {
+		writeProblem(w, r, http.StatusConflict, "Supplier conflicts with existing data")
+		return
+	}
+	if err != nil {
+		logger(r).Error("update supplier failed", "id", *body.SupplierID, "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to update supplier")
+		return
+	}
+	if n == 0 && version.Valid {
+		_, err := s.store.GetSupplier(r.Context(), db.GetSupplierParams{TenantID: *body.TenantID, SupplierID: *body.SupplierID})
+		if err == nil {
+			writeProblem(w, r, http.StatusConflict, "Supplier was changed by another request while this one was applied; retry it")
+			return
+		}
+	}
+	if n == 0 {
+		writeProblem(w, r, http.StatusNotFound, "Supplier not found")
+		return
+	}
+	s.metrics.AddRows("suppliers", "api", n)
+	s.writeSupplier(w, r, http.StatusOK, *body.TenantID, *body.SupplierID)
+}
+
+// DELETE /suppliers/{id}?tenant_id=... soft-deletes a supplier.
+func (s *server) deleteSupplier(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	n, err := s.store.DeleteSupplier(r.Context(), db.DeleteSupplierParams{DeletedBy: actor(r), TenantID: tenantID, SupplierID: r.PathValue("id")})
+	noContent(w, r, "supplier", r.PathValue("id"), n, err)
+}
+
+// POST /suppliers/{id}/restore?tenant_id=... undoes a soft delete.
+func (s *server) restoreSupplier(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := tenantParam(w, r)
+	if !ok {
+		return
+	}
+	n, err := s.store.RestoreSupplier(r.Context(), db.RestoreSupplierParams{ModifiedBy: actor(r), TenantID: tenantID, SupplierID: r.PathValue("id")})
+	noContent(w, r, "supplier", r.PathValue("id"), n, err)
+}
+
+// writeSupplier responds with a supplier as stored.
+func (s *server) writeSupplier(w http.ResponseWriter, r *http.Request, status int, tenantID, id string) {
+	row, err := s.store.GetSupplier(r.Context(), db.GetSupplierParams{TenantID: tenantID, SupplierID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		writeProblem(w, r, http.StatusNotFound, "Supplier not found")
+		return
+	}
+	if err != nil {
+		logger(r).Error("get supplier failed", "id", id, "err", err)
+		writeProblem(w, r, http.StatusInternalServerError, "Failed to get supplier")
+		return
+	}
+	writeJSON(w, status, supplierOf(row))
+}
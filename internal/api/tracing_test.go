@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
+	"github.com/bitterfq/data-ingestion-go/internal/tracing"
+)
+
+// spanTree indexes finished spans by name and checks their parents.
+type spanTree map[string]tracetest.SpanStub
+
+func treeOf(t *testing.T, exp *tracetest.InMemoryExporter) spanTree {
+	t.Helper()
+	tree := spanTree{}
+	for _, s := range exp.GetSpans() {
+		if _, dup := tree[s.Name]; dup {
+			t.Fatalf("span %q finished twice", s.Name)
+		}
+		tree[s.Name] = s
+	}
+	return tree
+}
+
+// parent asserts that the span child has the span parent as its parent.
+func (tree spanTree) parent(t *testing.T, child, parent string) {
+	t.Helper()
+	c, ok := tree[child]
+	if !ok {
+		t.Fatalf("no span %q in %v", child, tree.names())
+	}
+	p, ok := tree[parent]
+	if !ok {
+		t.Fatalf("no span %q in %v", parent, tree.names())
+	}
+	if c.Parent.SpanID() != p.SpanContext.SpanID() {
+		t.Errorf("expected %q to be a child of %q", child, parent)
+	}
+}
+
+func (tree spanTree) names() []string {
+	var names []string
+	for name := range tree {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestTracing(t *testing.T) {
+	// install the W3C propagators the server reads traceparent with
+	if _, err := tracing.Setup(context.Background(), tracing.Config{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	// the source is a SQLite table shaped like the Snowflake one
+	src, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	if _, err := src.Exec(`CREATE TABLE suppliers (SUPPLIER_ID TEXT, TENANT_ID TEXT, SUPPLIER_CODE TEXT, LEGAL_NAME TEXT,
+		DBA_NAME TEXT, COUNTRY TEXT, REGION TEXT, ADDRESS_LINE1 TEXT, ADDRESS_LINE2 TEXT, CITY TEXT, STATE TEXT,
+		POSTAL_CODE TEXT, SOURCE_TIMESTAMP TIMESTAMP);
+		INSERT INTO suppliers (SUPPLIER_ID, TENANT_ID, LEGAL_NAME, SOURCE_TIMESTAMP) VALUES
+			('SUP-1', 'tenant_acme', 'Acme', '2025-01-02 03:04:05'),
+			('SUP-2', 'tenant_acme', 'Bolt', '2025-01-02 03:04:05')`); err != nil {
+		t.Fatal(err)
+	}
+	sources := func(ctx context.Context) (Source, error) {
+		// the server closes the source, which must not close the shared DB
+		return sharedSource{&SQLSource{DB: src, Table: "suppliers", TracerProvider: tp}}, nil
+	}
+
+	store, err := storage.Open(context.Background(), "sqlite::memory:", storage.Options{TracerProvider: tp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	var buf bytes.Buffer
+	cfg := testConfig
+	cfg.TracerProvider = tp
+	cfg.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	h := NewServer(cfg, store, sources)
+
+	// the caller's trace is continued
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	expect(t, w, http.StatusOK, nil)
+
+	tree := treeOf(t, exp)
+	root, ok := tree["GET /fetch-and-insert"]
+	if !ok {
+		t.Fatalf("expected a span named after the route, got %v", tree.names())
+	}
+	if root.SpanKind != trace.SpanKindServer || root.SpanContext.TraceID().String() != traceID ||
+		root.Parent.SpanID().String() != "00f067aa0ba902b7" || !root.Parent.IsRemote() {
+		t.Errorf("expected a server span continuing the caller's trace, got %+v", root)
+	}
+	for child, parent := range map[string]string{
+		"source.connect": "GET /fetch-and-insert",
+		"source.query":   "GET /fetch-and-insert",
+		"source.fetch":   "GET /fetch-and-insert",
+		"sqlite.load":    "GET /fetch-and-insert",
+		"sqlite.batch":   "sqlite.load",
+	} {
+		tree.parent(t, child, parent)
+	}
+	if attrs := attrsOf(tree["source.fetch"]); attrs["rows"] != "2" || attrs["db.system.name"] != "snowflake" {
+		t.Errorf("unexpected fetch attributes %v", attrs)
+	}
+	if attrs := attrsOf(root); attrs["http.route"] != "GET /fetch-and-insert" || attrs["http.response.status_code"] != "200" {
+		t.Errorf("unexpected request attributes %v", attrs)
+	}
+
+	// the request's log record carries the trace ID
+	if rec := records(t, &buf)[0]; rec["trace_id"] != traceID {
+		t.Errorf("expected the trace ID in %v", rec)
+	}
+
+	// a failing query fails its span, and the request's
+	exp.Reset()
+	if _, err := src.Exec(`DROP TABLE suppliers`); err != nil {
+		t.Fatal(err)
+	}
+	expect(t, do(t, h, http.MethodGet, "/fetch-and-insert?tenant_id=tenant_acme", ""), http.StatusInternalServerError, nil)
+	tree = treeOf(t, exp)
+	if tree["source.query"].Status.Code.String() != "Error" || tree["GET /fetch-and-insert"].Status.Code.String() != "Error" {
+		t.Errorf("expected the query and request spans to fail, got %v and %v", tree["source.query"].Status, tree["GET /fetch-and-insert"].Status)
+	}
+	if _, ok := tree["source.fetch"]; ok {
+		t.Error("expected no fetch after a failed query")
+	}
+}
+
+// sharedSource reads a DB the test still needs, so it does not close it.
+type sharedSource struct {
+	*SQLSource
+}
+
+func (sharedSource) Close() error { return nil }
+
+// attrsOf returns a span's attributes as strings.
+func attrsOf(s tracetest.SpanStub) map[string]string {
+	attrs := map[string]string{}
+	for _, kv := range s.Attributes {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	return attrs
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+)
+
+// APIKeyHeader carries a static API key.
+const APIKeyHeader = "X-API-Key"
+
+// KeyPrefix starts every API key, so a leaked one is easy to recognise.
+const KeyPrefix = "dik_"
+
+var keyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewKey returns a new random API key and its ID, which names it in logs and
+// when revoking it. Only HashKey(key) should be stored.
+func NewKey() (id, key string, err error) {
+	b := make([]byte, 8+32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(b[:8]), KeyPrefix + strings.ToLower(keyEncoding.EncodeToString(b[8:])), nil
+}
+
+// HashKey returns the hex SHA-256 of key. Keys are random and long, so a
+// fast unsalted hash is enough to make a leaked table useless.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyStore finds a live API key by its hash, returning sql.ErrNoRows when
+// there is none. *db.Queries and storage.Store implement it.
+type KeyStore interface {
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (db.ApiKey, error)
+}
+
+// APIKeys authenticates the static API key in the X-API-Key header against
+// the keys in Store.
+type APIKeys struct {
+	Store KeyStore
+}
+
+func (a APIKeys) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	k, err := a.Store.GetAPIKeyByHash(r.Context(), HashKey(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Principal{}, fmt.Errorf("%w: unknown or revoked API key", ErrInvalidCredentials)
+	}
+	if err != nil {
+		return Principal{}, fmt.Errorf("look up API key: %w", err)
+	}
+	role, err := ParseRole(k.Role)
+	if err != nil {
+		return Principal{}, fmt.Errorf("API key %s: %w", k.KeyID, err)
+	}
+	return Principal{Subject: "key:" + k.KeyID, TenantID: k.TenantID, Role: role}, nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+)
+
+// keyStore holds keys by hash.
+type keyStore map[string]db.ApiKey
+
+func (s keyStore) GetAPIKeyByHash(_ context.Context, hash string) (db.ApiKey, error) {
+	k, ok := s[hash]
+	if !ok {
+		return db.ApiKey{}, sql.ErrNoRows
+	}
+	return k, nil
+}
+
+func TestNewKey(t *testing.T) {
+	id, key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != 16 || !strings.HasPrefix(key, KeyPrefix) || len(key) != len(KeyPrefix)+52 {
+		t.Errorf("unexpected id %q and key %q", id, key)
+	}
+	_, other, _ := NewKey()
+	if other == key || HashKey(other) == HashKey(key) {
+		t.Error("expected keys to differ")
+	}
+	if HashKey(key) != HashKey(key) || len(HashKey(key)) != 64 {
+		t.Errorf("unexpected hash %q", HashKey(key))
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	_, key, _ := NewKey()
+	store := keyStore{HashKey(key): {KeyID: "k1", TenantID: "tenant_acme", Role: "writer"}}
+	a := APIKeys{Store: store}
+	request := func(key string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			r.Header.Set(APIKeyHeader, key)
+		}
+		return r
+	}
+
+	p, err := a.Authenticate(request(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != (Principal{Subject: "key:k1", TenantID: "tenant_acme", Role: Writer}) {
+		t.Errorf("unexpected principal %+v", p)
+	}
+	if _, err := a.Authenticate(request("")); err != ErrNoCredentials {
+		t.Errorf("expected no credentials, got %v", err)
+	}
+	if _, err := a.Authenticate(request(KeyPrefix + "guess")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected an unknown key to be invalid, got %v", err)
+	}
+
+	// a broken row is the server's fault, not the caller's
+	store[HashKey(key)] = db.ApiKey{KeyID: "k1", TenantID: "tenant_acme", Role: "owner"}
+	if _, err := a.Authenticate(request(key)); err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected an unknown role to fail, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+// Package auth identifies API callers and what they may do. A caller is a
+// Principal acting for one tenant in one role; an Authenticator finds it from
+// the request's credentials, a static API key or a JWT bearer token.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Role is what a principal may do within its tenant. Each role may do
+// everything the one before it may.
+type Role string
+
+const (
+	// Reader may read the tenant's suppliers and parts.
+	Reader Role = "reader"
+	// Writer may also create, change and delete them.
+	Writer Role = "writer"
+	// Admin may also sync from Snowflake.
+	Admin Role = "admin"
+)
+
+var ranks = map[Role]int{Reader: 1, Writer: 2, Admin: 3}
+
+// ParseRole returns the role named s.
+func ParseRole(s string) (Role, error) {
+	if _, ok := ranks[Role(s)]; !ok {
+		return "", fmt.Errorf("unknown role %q; want reader, writer or admin", s)
+	}
+	return Role(s), nil
+}
+
+// Allows reports whether r may do what min may.
+func (r Role) Allows(min Role) bool {
+	return ranks[r] > 0 && ranks[r] >= ranks[min]
+}
+
+// Principal is an authenticated caller.
+type Principal struct {
+	// Subject names the caller in logs and the audit log: "key:<id>" for an
+	// API key, the token's sub claim for a JWT.
+	Subject  string
+	TenantID string
+	Role     Role
+}
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request
+	// carries none of the credentials it handles.
+	ErrNoCredentials = errors.New("auth: no credentials")
+	// ErrInvalidCredentials is wrapped by errors for credentials that are
+	// unknown, revoked, expired or malformed.
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+)
+
+// Authenticator identifies the caller of a request.
+type Authenticator interface {
+	// Authenticate returns the caller of r, ErrNoCredentials when r carries
+	// no credentials it handles, or an error wrapping ErrInvalidCredentials
+	// when they do not identify a caller. Other errors mean it could not
+	// tell, such as when its store fails.
+	Authenticate(r *http.Request) (Principal, error)
+}
+
+// Chain tries each Authenticator in turn; the first to find credentials it
+// handles decides.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return p, err
+		}
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying p.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
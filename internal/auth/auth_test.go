@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRoles(t *testing.T) {
+	for _, c := range []struct {
+		role, min Role
+		want      bool
+	}{
+		{Reader, Reader, true},
+		{Reader, Writer, false},
+		{Writer, Reader, true},
+		{Writer, Admin, false},
+		{Admin, Writer, true},
+		{"", Reader, false},
+		{"root", Reader, false},
+	} {
+		if got := c.role.Allows(c.min); got != c.want {
+			t.Errorf("%q.Allows(%q) = %v, want %v", c.role, c.min, got, c.want)
+		}
+	}
+	if r, err := ParseRole("writer"); err != nil || r != Writer {
+		t.Errorf("ParseRole(writer) = %q, %v", r, err)
+	}
+	if _, err := ParseRole("Admin"); err == nil {
+		t.Error("expected roles to be case-sensitive")
+	}
+}
+
+// fixed returns p or err for every request.
+type fixed struct {
+	p   Principal
+	err error
+}
+
+func (f fixed) Authenticate(*http.Request) (Principal, error) { return f.p, f.err }
+
+func TestChain(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	alice := Principal{Subject: "alice", TenantID: "tenant_acme", Role: Reader}
+
+	p, err := Chain{fixed{err: ErrNoCredentials}, fixed{p: alice}}.Authenticate(r)
+	if err != nil || p != alice {
+		t.Errorf("expected the second authenticator to decide, got %+v, %v", p, err)
+	}
+	// the first with credentials decides, even when it rejects them
+	_, err = Chain{fixed{err: ErrInvalidCredentials}, fixed{p: alice}}.Authenticate(r)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected invalid credentials, got %v", err)
+	}
+	if _, err := (Chain{fixed{err: ErrNoCredentials}}).Authenticate(r); err != ErrNoCredentials {
+		t.Errorf("expected no credentials, got %v", err)
+	}
+	if _, ok := FromContext(NewContext(r.Context(), alice)); !ok {
+		t.Error("expected the principal carried by the context")
+	}
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// clockSkew is how far the clocks of the token issuer and the server may
+// disagree when checking exp and nbf.
+const clockSkew = 30 * time.Second
+
+// JWT authenticates a bearer token in the Authorization header. The token
+// must be signed by one of the configured keys, unexpired, and carry sub,
+// tenant_id and role claims.
+type JWT struct {
+	// Secret verifies HS256, HS384 and HS512 tokens.
+	Secret []byte
+	// PublicKeys verify RS*, PS*, ES* and EdDSA tokens; see ParsePublicKeys.
+	PublicKeys []crypto.PublicKey
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+}
+
+// claims are the claims a token must carry besides the registered ones.
+type claims struct {
+	TenantID string `json:"tenant_id"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+func (a JWT) Authenticate(r *http.Request) (Principal, error) {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	// with no keys the parser would accept any algorithm, and an empty
+	// secret would verify tokens signed with one
+	methods := a.methods()
+	if len(methods) == 0 {
+		return Principal{}, fmt.Errorf("%w: no keys to verify tokens with", ErrInvalidCredentials)
+	}
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired(), jwt.WithLeeway(clockSkew)}
+	if a.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.Issuer))
+	}
+	if a.Audience != "" {
+		opts = append(opts, jwt.WithAudience(a.Audience))
+	}
+	var c claims
+	if _, err := jwt.ParseWithClaims(token, &c, a.keys, opts...); err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if c.Subject == "" || c.TenantID == "" {
+		return Principal{}, fmt.Errorf("%w: token has no sub or tenant_id", ErrInvalidCredentials)
+	}
+	role, err := ParseRole(c.Role)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return Principal{Subject: c.Subject, TenantID: c.TenantID, Role: role}, nil
+}
+
+// methods lists the signing algorithms the configured keys can verify, so a
+// token cannot pick one they were not meant for.
+func (a JWT) methods() []string {
+	var methods []string
+	if len(a.Secret) > 0 {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	seen := map[string]bool{}
+	for _, k := range a.PublicKeys {
+		var algs []string
+		switch k.(type) {
+		case *rsa.PublicKey:
+			algs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+		case *ecdsa.PublicKey:
+			algs = []string{"ES256", "ES384", "ES512"}
+		case ed25519.PublicKey:
+			algs = []string{"EdDSA"}
+		}
+		for _, alg := range algs {
+			if !seen[alg] {
+				seen[alg] = true
+				methods = append(methods, alg)
+			}
+		}
+	}
+	return methods
+}
+
+// keys returns the keys that may have signed t, for its algorithm.
+func (a JWT) keys(t *jwt.Token) (any, error) {
+	var set jwt.VerificationKeySet
+	switch t.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		set.Keys = append(set.Keys, a.Secret)
+	default:
+		for _, k := range a.PublicKeys {
+			set.Keys = append(set.Keys, k)
+		}
+	}
+	return set, nil
+}
+
+// ParsePublicKeys reads the PEM encoded RSA, ECDSA and Ed25519 public keys in
+// data, such as an identity provider's signing keys.
+func ParsePublicKeys(data []byte) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		var k any
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			k, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			k, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		default:
+			return nil, fmt.Errorf("unexpected PEM block %q", block.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no PEM public keys found")
+	}
+	return keys, nil
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// token signs claims with method and key.
+func token(t *testing.T, method jwt.SigningMethod, key any, c jwt.MapClaims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(method, c).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// bearer returns a request carrying token.
+func bearer(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+// valid returns claims that pass, changed by edits.
+func valid(edits jwt.MapClaims) jwt.MapClaims {
+	c := jwt.MapClaims{
+		"sub":       "alice",
+		"tenant_id": "tenant_acme",
+		"role":      "admin",
+		"iss":       "idp",
+		"aud":       "ingest",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range edits {
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+func TestJWT(t *testing.T) {
+	secret := []byte("correct horse battery staple")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := JWT{Secret: secret, PublicKeys: []crypto.PublicKey{&rsaKey.PublicKey, &ecKey.PublicKey}, Issuer: "idp", Audience: "ingest"}
+	want := Principal{Subject: "alice", TenantID: "tenant_acme", Role: Admin}
+
+	for name, tok := range map[string]string{
+		"HS256": token(t, jwt.SigningMethodHS256, secret, valid(nil)),
+		"RS256": token(t, jwt.SigningMethodRS256, rsaKey, valid(nil)),
+		"ES256": token(t, jwt.SigningMethodES256, ecKey, valid(nil)),
+	} {
+		if p, err := a.Authenticate(bearer(tok)); err != nil || p != want {
+			t.Errorf("%s: got %+v, %v", name, p, err)
+		}
+	}
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	for name, tok := range map[string]string{
+		"wrong secret":   token(t, jwt.SigningMethodHS256, []byte("guess"), valid(nil)),
+		"unknown key":    token(t, jwt.SigningMethodES256, otherKey, valid(nil)),
+		"expired":        token(t, jwt.SigningMethodHS256, secret, valid(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"no expiry":      token(t, jwt.SigningMethodHS256, secret, valid(jwt.MapClaims{"exp": nil})),
+		"wrong issuer":   token(t, jwt.SigningMethodHS256, secret, valid(jwt.MapClaims{"iss": "evil"})),
+		"wrong audience": token(t, jwt.SigningMethodHS256, secret, valid(jwt.MapClaims{"aud": "other"})),
+		"no tenant":      token(t, jwt.SigningMethodHS256, secret, valid(jwt.MapClaims{"tenant_id": nil})),
+		"no subject":     token(t, jwt.SigningMethodHS256, secret, valid(jwt.MapClaims{"sub": nil})),
+		"unknown role":   token(t, jwt.SigningMethodHS256, secret, valid(jwt.MapClaims{"role": "root"})),
+		"none":           token(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid(nil)),
+		"malformed":      "not.a.token",
+	} {
+		if _, err := a.Authenticate(bearer(tok)); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: expected invalid credentials, got %v", name, err)
+		}
+	}
+
+	// an RSA public key must not double as an HMAC secret
+	pub, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub})
+	onlyRSA := JWT{PublicKeys: []crypto.PublicKey{&rsaKey.PublicKey}}
+	if _, err := onlyRSA.Authenticate(bearer(token(t, jwt.SigningMethodHS256, pemKey, valid(nil)))); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected a token signed with the public key to be rejected, got %v", err)
+	}
+	// without keys nothing verifies
+	if _, err := (JWT{}).Authenticate(bearer(token(t, jwt.SigningMethodHS256, []byte{}, valid(nil)))); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected no keys to reject every token, got %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Basic YWxpY2U6cHc=")
+	if _, err := a.Authenticate(r); err != ErrNoCredentials {
+		t.Errorf("expected other schemes to be ignored, got %v", err)
+	}
+}
+
+func TestParsePublicKeys(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	pkix, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	data := append(
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix})...)
+	keys, err := ParsePublicKeys(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected two keys, got %d", len(keys))
+	}
+	if _, ok := keys[1].(*ecdsa.PublicKey); !ok {
+		t.Errorf("expected an ECDSA key, got %T", keys[1])
+	}
+
+	priv := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	for name, data := range map[string][]byte{"empty": nil, "private key": priv} {
+		if _, err := ParsePublicKeys(data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
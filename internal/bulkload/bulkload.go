@@ -0,0 +1,342 @@
+// Package bulkload loads large batches of suppliers and parts into SQLite using
+// multi-row upserts on a single tuned connection. Loads are idempotent: a row
+// whose key already exists is only overwritten when the incoming
+// source_timestamp is newer, so the same data can be loaded again safely.
+package bulkload
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bitterfq/data-ingestion-go/internal/logging"
+	"github.com/bitterfq/data-ingestion-go/internal/metrics"
+	"github.com/bitterfq/data-ingestion-go/internal/tracing"
+)
+
+// maxVariables is SQLite's default SQLITE_MAX_VARIABLE_NUMBER (3.32+).
+const maxVariables = 32766
+
+// DefaultBatchSize is the number of rows per INSERT when Options.BatchSize is zero.
+const DefaultBatchSize = 500
+
+// Options tunes a load.
+type Options struct {
+	// BatchSize is the number of rows per multi-row upsert. It is capped so a
+	// statement never exceeds SQLite's bound-variable limit.
+	BatchSize int
+	// DeferIndexes drops the table's secondary indexes before loading and
+	// recreates them afterwards, which is much faster for large loads.
+	DeferIndexes bool
+	// CacheSizeKB is the page cache used during the load. Zero means 256 MiB.
+	CacheSizeKB int
+	// Logger gets a debug record per batch and an info record per load. Nil
+	// logs nothing.
+	Logger *slog.Logger
+	// TracerProvider gets a span per load, the transaction, with a child per
+	// batch. Nil means the global provider.
+	TracerProvider trace.TracerProvider
+	// Metrics gets the duration of every load's transaction. Nil records
+	// nothing.
+	Metrics *metrics.Metrics
+}
+
+// Stats summarises a finished load. Rows is the number of input rows, split
+// into rows that were new, rows that replaced an older version and rows that
+// were skipped because the stored version was as new or newer.
+type Stats struct {
+	Table     string
+	Rows      int64
+	Inserted  int64
+	Updated   int64
+	Unchanged int64
+	Duration  time.Duration
+}
+
+// RowsPerSecond returns the load throughput.
+func (s Stats) RowsPerSecond() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Rows) / s.Duration.Seconds()
+}
+
+// LogValue logs a Stats as a group of its counts.
+func (s Stats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("table", s.Table),
+		slog.Int64("rows", s.Rows),
+		slog.Int64("inserted", s.Inserted),
+		slog.Int64("updated", s.Updated),
+		slog.Int64("unchanged", s.Unchanged),
+		slog.Duration("duration", s.Duration),
+		slog.Float64("rows_per_sec", s.RowsPerSecond()),
+	)
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%s: %d rows (%d inserted, %d updated, %d unchanged) in %s (%.0f rows/sec)",
+		s.Table, s.Rows, s.Inserted, s.Updated, s.Unchanged, s.Duration.Round(time.Millisecond), s.RowsPerSecond())
+}
+
+// Loader writes rows to SQLite in large batches.
+type Loader struct {
+	conn   *sql.DB
+	opts   Options
+	log    *slog.Logger
+	tracer trace.Tracer
+}
+
+// New returns a Loader for conn.
+func New(conn *sql.DB, opts Options) *Loader {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.CacheSizeKB <= 0 {
+		opts.CacheSizeKB = 256 * 1024
+	}
+	return &Loader{conn: conn, opts: opts, log: logging.OrDiscard(opts.Logger), tracer: tracing.Tracer(opts.TracerProvider, "internal/bulkload")}
+}
+
+// load inserts n rows into table, resolving key conflicts with onConflict.
+// args fills dst with the values of row i in column order. The whole load runs
+// in one transaction on one connection so the pragmas and prepared statements
+// apply to every batch.
+func (l *Loader) load(ctx context.Context, table string, columns []string, onConflict string, n int, args func(i int, dst []any)) (stats Stats, err error) {
+	start := time.Now()
+	stats = Stats{Table: table}
+	if n == 0 {
+		return stats, nil
+	}
+	ctx, span := l.tracer.Start(ctx, "sqlite.load", trace.WithAttributes(
+		attribute.String("db.system.name", "sqlite"),
+		attribute.String("db.collection.name", table),
+		attribute.Int("rows", n),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Int64("inserted", stats.Inserted), attribute.Int64("updated", stats.Updated), attribute.Int64("unchanged", stats.Unchanged))
+		tracing.End(span, err)
+		l.opts.Metrics.ObserveTransaction("sqlite", table, time.Since(start), err)
+	}()
+
+	batch := l.opts.BatchSize
+	if limit := maxVariables / len(columns); batch > limit {
+		batch = limit
+	}
+
+	c, err := l.conn.Conn(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer c.Close()
+
+	restore, err := tunePragmas(ctx, c, l.opts.CacheSizeKB)
+	if err != nil {
+		return stats, err
+	}
+	defer restore()
+
+	tx, err := c.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, err
+	}
+	defer tx.Rollback()
+
+	var indexes []string
+	if l.opts.DeferIndexes {
+		if indexes, err = dropIndexes(ctx, tx, table); err != nil {
+			return stats, err
+		}
+	}
+
+	before, err := countRows(ctx, tx, table)
+	if err != nil {
+		return stats, err
+	}
+
+	full, err := tx.PrepareContext(ctx, insertSQL(table, columns, batch, onConflict))
+	if err != nil {
+		return stats, fmt.Errorf("prepare %s upsert: %w", table, err)
+	}
+	defer full.Close()
+
+	// changes counts both inserts and updates; updates skipped by the
+	// source_timestamp guard are not counted.
+	var changes int64
+
+	vals := make([]any, batch*len(columns))
+	for offset := 0; offset < n; offset += batch {
+		size := min(batch, n-offset)
+		for i := 0; i < size; i++ {
+			args(offset+i, vals[i*len(columns):(i+1)*len(columns)])
+		}
+
+		stmt := full
+		if size < batch {
+			if stmt, err = tx.PrepareContext(ctx, insertSQL(table, columns, size, onConflict)); err != nil {
+				return stats, fmt.Errorf("prepare %s upsert: %w", table, err)
+			}
+			defer stmt.Close()
+		}
+		affected, err := l.batch(ctx, stmt, table, offset, size, vals[:size*len(columns)])
+		if err != nil {
+			return stats, err
+		}
+		changes += affected
+	}
+
+	after, err := countRows(ctx, tx, table)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, ddl := range indexes {
+		if _, err := tx.ExecContext(ctx, ddl); err != nil {
+			return stats, fmt.Errorf("recreate index: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, err
+	}
+
+	stats.Rows = int64(n)
+	stats.Inserted = after - before
+	stats.Updated = changes - stats.Inserted
+	stats.Unchanged = stats.Rows - stats.Inserted - stats.Updated
+	stats.Duration = time.Since(start)
+	l.log.InfoContext(ctx, "bulk load finished", "load", stats)
+	return stats, nil
+}
+
+// batch runs one multi-row upsert of size rows starting at offset and returns
+// the rows it changed.
+func (l *Loader) batch(ctx context.Context, stmt *sql.Stmt, table string, offset, size int, vals []any) (affected int64, err error) {
+	ctx, span := l.tracer.Start(ctx, "sqlite.batch", trace.WithAttributes(attribute.Int("offset", offset), attribute.Int("rows", size)))
+	defer func() {
+		span.SetAttributes(attribute.Int64("changes", affected))
+		tracing.End(span, err)
+	}()
+	res, err := stmt.ExecContext(ctx, vals...)
+	if err != nil {
+		return 0, fmt.Errorf("upsert %s rows %d-%d: %w", table, offset, offset+size-1, err)
+	}
+	if affected, err = res.RowsAffected(); err != nil {
+		return 0, err
+	}
+	l.log.DebugContext(ctx, "upserted batch", "table", table, "offset", offset, "rows", size, "changes", affected)
+	return affected, nil
+}
+
+func insertSQL(table string, columns []string, rows int, onConflict string) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+	var b strings.Builder
+	b.WriteString("INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES ")
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(row)
+	}
+	b.WriteString(" " + onConflict)
+	return b.String()
+}
+
+// newerWins is the conflict clause for the dimension tables: an existing row
+// with the same key (columns[0]) is only updated by a row of the same tenant
+// with a newer source_timestamp, matching the UpsertSupplier and UpsertPart
+// queries.
+func newerWins(table string, columns []string) string {
+	var b strings.Builder
+	b.WriteString("ON CONFLICT (" + columns[0] + ") DO UPDATE SET ")
+	for i, col := range columns[1:] {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(col + " = excluded." + col)
+	}
+	b.WriteString(" WHERE " + table + ".tenant_id = excluded.tenant_id AND (" + table + ".source_timestamp IS NULL" +
+		" OR julianday(excluded.source_timestamp) > julianday(" + table + ".source_timestamp))")
+	return b.String()
+}
+
+func countRows(ctx context.Context, tx *sql.Tx, table string) (int64, error) {
+	var n int64
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count %s: %w", table, err)
+	}
+	return n, nil
+}
+
+// tunePragmas switches the connection to WAL with relaxed syncing and a large
+// page cache, and returns a func that restores the per-connection settings.
+// WAL is persistent and left on, since it also suits the server's reads.
+// synchronous = NORMAL skips the fsync per commit but, unlike OFF, cannot
+// corrupt the database if the power fails mid-load; at worst the last
+// commits are lost.
+func tunePragmas(ctx context.Context, c *sql.Conn, cacheSizeKB int) (func(), error) {
+	var synchronous, cacheSize int
+	if err := c.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&synchronous); err != nil {
+		return nil, err
+	}
+	if err := c.QueryRowContext(ctx, "PRAGMA cache_size").Scan(&cacheSize); err != nil {
+		return nil, err
+	}
+
+	var mode string
+	if err := c.QueryRowContext(ctx, "PRAGMA journal_mode = WAL").Scan(&mode); err != nil {
+		return nil, fmt.Errorf("enable wal: %w", err)
+	}
+	for _, p := range []string{
+		"PRAGMA synchronous = NORMAL",
+		fmt.Sprintf("PRAGMA cache_size = -%d", cacheSizeKB),
+		"PRAGMA temp_store = MEMORY",
+	} {
+		if _, err := c.ExecContext(ctx, p); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	return func() {
+		c.ExecContext(context.Background(), fmt.Sprintf("PRAGMA synchronous = %d", synchronous))
+		c.ExecContext(context.Background(), fmt.Sprintf("PRAGMA cache_size = %d", cacheSize))
+		c.ExecContext(context.Background(), "PRAGMA temp_store = DEFAULT")
+	}, nil
+}
+
+// dropIndexes drops the explicit indexes on table and returns their CREATE
+// statements. Automatic indexes backing PRIMARY KEY/UNIQUE have no sql and stay.
+func dropIndexes(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT name, sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", table)
+	if err != nil {
+		return nil, err
+	}
+	var names, ddls []string
+	for rows.Next() {
+		var name, ddl string
+		if err := rows.Scan(&name, &ddl); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+		ddls = append(ddls, ddl)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		if _, err := tx.ExecContext(ctx, `DROP INDEX "`+name+`"`); err != nil {
+			return nil, fmt.Errorf("drop index %s: %w", name, err)
+		}
+	}
+	return ddls, nil
+}
@@ -0,0 +1,384 @@
+package bulkload
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/parts"
+	"github.com/bitterfq/data-ingestion-go/internal/suppliers"
+	_ "github.com/mattn/go-sqlite3"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupTestDB(tb testing.TB) *sql.DB {
+	tb.Helper()
+	conn, err := database.Open(filepath.Join(tb.TempDir(), "bulk.db"))
+	if err != nil {
+		tb.Fatalf("open sqlite: %v", err)
+	}
+	tb.Cleanup(func() { conn.Close() })
+	if err := database.Migrate(context.Background(), conn); err != nil {
+		tb.Fatalf("apply migrations: %v", err)
+	}
+	return conn
+}
+
+func generate(nSuppliers, nParts int) ([]db.CreateSupplierParams, []db.CreatePartParams) {
+	sups := suppliers.GenerateSuppliers("tenant_acme", nSuppliers)
+	supRows := make([]db.CreateSupplierParams, len(sups))
+	ids := make([]string, len(sups))
+	for i, sup := range sups {
+		supRows[i] = SupplierParams(sup)
+		ids[i] = sup.SupplierID
+	}
+	ps := parts.GenerateParts(nParts, "tenant_acme", ids)
+	partRows := make([]db.CreatePartParams, len(ps))
+	for i, p := range ps {
+		partRows[i] = PartParams(p)
+	}
+	return supRows, partRows
+}
+
+func TestLoad(t *testing.T) {
+	ctx := context.Background()
+	conn := setupTestDB(t)
+	sups, ps := generate(25, 1234)
+
+	// a small batch size exercises the full and partial batch statements
+	l := New(conn, Options{BatchSize: 100, DeferIndexes: true})
+	if _, err := conn.Exec("CREATE INDEX idx_test_part_number ON dim_part_v1 (part_number)"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := l.LoadSuppliers(ctx, sups)
+	if err != nil {
+		t.Fatalf("load suppliers: %v", err)
+	}
+	if stats.Rows != 25 {
+		t.Errorf("expected 25 supplier rows, got %d", stats.Rows)
+	}
+
+	stats, err = l.LoadParts(ctx, ps)
+	if err != nil {
+		t.Fatalf("load parts: %v", err)
+	}
+	if stats.Rows != 1234 || stats.RowsPerSecond() <= 0 {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+
+	var count int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM dim_part_v1").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1234 {
+		t.Errorf("expected 1234 parts, got %d", count)
+	}
+
+	var legalName string
+	var risk float64
+	if err := conn.QueryRow("SELECT legal_name, risk_score FROM dim_supplier_v1 WHERE supplier_id = ?", sups[7].SupplierID).Scan(&legalName, &risk); err != nil {
+		t.Fatal(err)
+	}
+	if legalName != sups[7].LegalName || risk != sups[7].RiskScore.Float64 {
+		t.Errorf("supplier row did not round-trip: %q %v", legalName, risk)
+	}
+
+	// deferred index is recreated
+	var indexes int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_test_part_number'").Scan(&indexes); err != nil {
+		t.Fatal(err)
+	}
+	if indexes != 1 {
+		t.Error("expected deferred index to be recreated")
+	}
+
+	var mode string
+	if err := conn.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+	if mode != "wal" {
+		t.Errorf("expected wal journal mode, got %s", mode)
+	}
+}
+
+func TestTunePragmas(t *testing.T) {
+	ctx := context.Background()
+	conn, err := database.Open(filepath.Join(t.TempDir(), "tune.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	synchronous := func() int {
+		t.Helper()
+		var n int
+		if err := c.QueryRowContext(ctx, "PRAGMA synchronous").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	before := synchronous()
+	restore, err := tunePragmas(ctx, c, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// NORMAL, which is crash-safe under WAL; OFF is not
+	if got := synchronous(); got != 1 {
+		t.Errorf("expected synchronous NORMAL (1) during a load, got %d", got)
+	}
+	restore()
+	if got := synchronous(); got != before {
+		t.Errorf("expected synchronous %d restored, got %d", before, got)
+	}
+}
+
+func TestLoadLogs(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	sups, _ := generate(10, 0)
+	if _, err := New(setupTestDB(t), Options{BatchSize: 4, Logger: logger}).LoadSuppliers(context.Background(), sups); err != nil {
+		t.Fatal(err)
+	}
+
+	var batches int
+	var load map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec struct {
+			Msg  string         `json:"msg"`
+			Load map[string]any `json:"load"`
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		switch rec.Msg {
+		case "upserted batch":
+			batches++
+		case "bulk load finished":
+			load = rec.Load
+		}
+	}
+	if batches != 3 || load["table"] != "dim_supplier_v1" || load["rows"] != 10.0 || load["inserted"] != 10.0 {
+		t.Errorf("expected 3 batches and the load's counts, got %d and %v", batches, load)
+	}
+}
+
+func TestLoadSpans(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	sups, _ := generate(10, 0)
+	if _, err := New(setupTestDB(t), Options{BatchSize: 4, TracerProvider: tp}).LoadSuppliers(ctx, sups); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	var load tracetest.SpanStub
+	var batches []tracetest.SpanStub
+	for _, s := range exp.GetSpans() {
+		switch s.Name {
+		case "sqlite.load":
+			load = s
+		case "sqlite.batch":
+			batches = append(batches, s)
+		}
+	}
+	if load.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("expected the load to be a child of the caller's span")
+	}
+	if len(batches) != 3 {
+		t.Fatalf("expected 3 batch spans, got %d", len(batches))
+	}
+	for _, b := range batches {
+		if b.Parent.SpanID() != load.SpanContext.SpanID() {
+			t.Errorf("expected batch %v to be a child of the load", b.Attributes)
+		}
+	}
+}
+
+func TestLoadRollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	conn := setupTestDB(t)
+	sups, _ := generate(10, 0)
+	sups[9].SupplierID = "boom" // fails in the last batch
+	if _, err := conn.Exec(`CREATE TRIGGER fail_boom BEFORE INSERT ON dim_supplier_v1
+		WHEN NEW.supplier_id = 'boom' BEGIN SELECT RAISE(ABORT, 'boom'); END`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := New(conn, Options{BatchSize: 4}).LoadSuppliers(ctx, sups); err == nil {
+		t.Fatal("expected trigger error")
+	}
+	var count int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM dim_supplier_v1").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected failed load to be rolled back, found %d rows", count)
+	}
+}
+
+func TestLoadIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	conn := setupTestDB(t)
+	sups, _ := generate(10, 0)
+	l := New(conn, Options{BatchSize: 4})
+
+	stats, err := l.LoadSuppliers(ctx, sups)
+	if err != nil {
+		t.Fatalf("first load: %v", err)
+	}
+	if stats.Inserted != 10 || stats.Updated != 0 || stats.Unchanged != 0 {
+		t.Errorf("first load: unexpected stats %v", stats)
+	}
+
+	// reloading the same rows changes nothing
+	stats, err = l.LoadSuppliers(ctx, sups)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if stats.Inserted != 0 || stats.Updated != 0 || stats.Unchanged != 10 {
+		t.Errorf("reload: unexpected stats %v", stats)
+	}
+
+	// a newer version replaces the row, an older one is skipped, a new key is inserted
+	newer, older, added := sups[0], sups[1], sups[2]
+	newer.LegalName = "Newer Name"
+	newer.SourceTimestamp.Time = newer.SourceTimestamp.Time.Add(time.Hour)
+	older.LegalName = "Older Name"
+	older.SourceTimestamp.Time = older.SourceTimestamp.Time.Add(-time.Hour)
+	added.SupplierID = "SUP-NEW"
+	stats, err = l.LoadSuppliers(ctx, []db.CreateSupplierParams{newer, older, added})
+	if err != nil {
+		t.Fatalf("mixed load: %v", err)
+	}
+	if stats.Inserted != 1 || stats.Updated != 1 || stats.Unchanged != 1 {
+		t.Errorf("mixed load: unexpected stats %v", stats)
+	}
+
+	for id, want := range map[string]string{sups[0].SupplierID: "Newer Name", sups[1].SupplierID: sups[1].LegalName} {
+		var name string
+		if err := conn.QueryRow("SELECT legal_name FROM dim_supplier_v1 WHERE supplier_id = ?", id).Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		if name != want {
+			t.Errorf("%s: expected legal_name %q, got %q", id, want, name)
+		}
+	}
+}
+
+func TestLoadPartSuppliers(t *testing.T) {
+	ctx := context.Background()
+	conn := setupTestDB(t)
+	l := New(conn, Options{})
+
+	sups := suppliers.GenerateSuppliers("tenant_acme", 5)
+	supRows := make([]db.CreateSupplierParams, len(sups))
+	ids := make([]string, len(sups))
+	for i, sup := range sups {
+		supRows[i] = SupplierParams(sup)
+		ids[i] = sup.SupplierID
+	}
+	ps := parts.GenerateParts(20, "tenant_acme", ids)
+	partRows := make([]db.CreatePartParams, len(ps))
+	var links []db.AddPartSupplierParams
+	for i, p := range ps {
+		partRows[i] = PartParams(p)
+		links = append(links, PartSupplierParams(p)...)
+	}
+	if _, err := l.LoadSuppliers(ctx, supRows); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.LoadParts(ctx, partRows); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := l.LoadPartSuppliers(ctx, links)
+	if err != nil {
+		t.Fatalf("load part suppliers: %v", err)
+	}
+	if stats.Inserted != int64(len(links)) {
+		t.Errorf("expected %d links inserted, got %v", len(links), stats)
+	}
+	stats, err = l.LoadPartSuppliers(ctx, links)
+	if err != nil || stats.Unchanged != int64(len(links)) {
+		t.Errorf("expected reload to leave links unchanged, got %v %v", stats, err)
+	}
+
+	// links must point at loaded parts and suppliers
+	bad := []db.AddPartSupplierParams{{PartID: ps[0].PartID, SupplierID: "missing", TenantID: "tenant_acme"}}
+	if _, err := l.LoadPartSuppliers(ctx, bad); err == nil {
+		t.Error("expected foreign key error")
+	}
+}
+
+const benchParts = 20000
+
+// setupBenchDB returns a database holding sups, so the parts' default
+// suppliers satisfy the foreign key.
+func setupBenchDB(b *testing.B, sups []db.CreateSupplierParams) *sql.DB {
+	conn := setupTestDB(b)
+	if _, err := New(conn, Options{}).LoadSuppliers(context.Background(), sups); err != nil {
+		b.Fatal(err)
+	}
+	return conn
+}
+
+// BenchmarkLoadPartsRowByRow is the original generator path: one sqlc
+// CreatePart call per row inside a transaction.
+func BenchmarkLoadPartsRowByRow(b *testing.B) {
+	ctx := context.Background()
+	sups, ps := generate(10, benchParts)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		conn := setupBenchDB(b, sups)
+		b.StartTimer()
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		qtx := db.New(conn).WithTx(tx)
+		for _, p := range ps {
+			if _, err := qtx.CreatePart(ctx, p); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(benchParts*b.N)/b.Elapsed().Seconds(), "rows/sec")
+}
+
+func BenchmarkLoadPartsBulk(b *testing.B) {
+	ctx := context.Background()
+	sups, ps := generate(10, benchParts)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		conn := setupBenchDB(b, sups)
+		l := New(conn, Options{DeferIndexes: true})
+		b.StartTimer()
+
+		if _, err := l.LoadParts(ctx, ps); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(benchParts*b.N)/b.Elapsed().Seconds(), "rows/sec")
+}
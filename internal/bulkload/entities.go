@@ -0,0 +1,189 @@
+package bulkload
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/parts"
+	"github.com/bitterfq/data-ingestion-go/internal/suppliers"
+)
+
+// SupplierColumns are the dim_supplier_v1 columns a load writes, in the order
+// SupplierValues fills them.
+var SupplierColumns = []string{
+	"supplier_id", "supplier_code", "tenant_id", "legal_name", "dba_name",
+	"country", "region", "address_line1", "address_line2", "city", "state", "postal_code",
+	"contact_email", "contact_phone", "preferred_currency", "incoterms",
+	"lead_time_days_avg", "lead_time_days_p95", "on_time_delivery_rate", "defect_rate_ppm",
+	"capacity_units_per_week", "risk_score", "financial_risk_tier",
+	"certifications", "compliance_flags", "approved_status", "contracts", "terms_version",
+	"lat", "lon", "data_source", "source_timestamp", "ingestion_timestamp", "schema_version",
+	"modified_by",
+}
+
+// PartColumns are the dim_part_v1 columns a load writes, in the order
+// PartValues fills them.
+var PartColumns = []string{
+	"part_id", "tenant_id", "part_number", "description", "category", "lifecycle_status",
+	"uom", "spec_hash", "bom_compatibility", "default_supplier_id", "qualified_supplier_ids",
+	"unit_cost", "moq", "lead_time_days_avg", "lead_time_days_p95", "quality_grade",
+	"compliance_flags", "hazard_class", "last_price_change",
+	"data_source", "source_timestamp", "ingestion_timestamp", "schema_version",
+	"modified_by",
+}
+
+// PartSupplierColumns are the part_supplier columns a load writes, in the
+// order PartSupplierValues fills them.
+var PartSupplierColumns = []string{"part_id", "supplier_id", "tenant_id"}
+
+// LoadSuppliers upserts rows into dim_supplier_v1.
+func (l *Loader) LoadSuppliers(ctx context.Context, rows []db.CreateSupplierParams) (Stats, error) {
+	return l.load(ctx, "dim_supplier_v1", SupplierColumns, newerWins("dim_supplier_v1", SupplierColumns), len(rows), func(i int, dst []any) {
+		SupplierValues(&rows[i], dst)
+	})
+}
+
+// LoadParts upserts rows into dim_part_v1.
+func (l *Loader) LoadParts(ctx context.Context, rows []db.CreatePartParams) (Stats, error) {
+	return l.load(ctx, "dim_part_v1", PartColumns, newerWins("dim_part_v1", PartColumns), len(rows), func(i int, dst []any) {
+		PartValues(&rows[i], dst)
+	})
+}
+
+// LoadPartSuppliers inserts part_supplier links. Links that already exist are
+// counted as unchanged. The parts and suppliers must be loaded first.
+func (l *Loader) LoadPartSuppliers(ctx context.Context, rows []db.AddPartSupplierParams) (Stats, error) {
+	return l.load(ctx, "part_supplier", PartSupplierColumns, "ON CONFLICT (part_id, supplier_id) DO NOTHING", len(rows), func(i int, dst []any) {
+		PartSupplierValues(&rows[i], dst)
+	})
+}
+
+// SupplierValues writes the values of p to dst in SupplierColumns order, with
+// NULLs as nil.
+func SupplierValues(p *db.CreateSupplierParams, dst []any) {
+	dst[0], dst[1], dst[2], dst[3], dst[4] = p.SupplierID, value(p.SupplierCode), p.TenantID, p.LegalName, value(p.DbaName)
+	dst[5], dst[6], dst[7], dst[8], dst[9], dst[10], dst[11] = value(p.Country), value(p.Region), value(p.AddressLine1), value(p.AddressLine2), value(p.City), value(p.State), value(p.PostalCode)
+	dst[12], dst[13], dst[14], dst[15] = value(p.ContactEmail), value(p.ContactPhone), value(p.PreferredCurrency), value(p.Incoterms)
+	dst[16], dst[17], dst[18], dst[19] = value(p.LeadTimeDaysAvg), value(p.LeadTimeDaysP95), value(p.OnTimeDeliveryRate), value(p.DefectRatePpm)
+	dst[20], dst[21], dst[22] = value(p.CapacityUnitsPerWeek), value(p.RiskScore), value(p.FinancialRiskTier)
+	dst[23], dst[24], dst[25], dst[26], dst[27] = value(p.Certifications), value(p.ComplianceFlags), value(p.ApprovedStatus), value(p.Contracts), value(p.TermsVersion)
+	dst[28], dst[29], dst[30], dst[31], dst[32], dst[33] = value(p.Lat), value(p.Lon), value(p.DataSource), value(p.SourceTimestamp), value(p.IngestionTimestamp), value(p.SchemaVersion)
+	dst[34] = value(p.ModifiedBy)
+}
+
+// PartValues writes the values of p to dst in PartColumns order, with NULLs as
+// nil.
+func PartValues(p *db.CreatePartParams, dst []any) {
+	dst[0], dst[1], dst[2], dst[3], dst[4], dst[5] = p.PartID, p.TenantID, p.PartNumber, p.Description, value(p.Category), value(p.LifecycleStatus)
+	dst[6], dst[7], dst[8], dst[9], dst[10] = value(p.Uom), value(p.SpecHash), value(p.BomCompatibility), value(p.DefaultSupplierID), value(p.QualifiedSupplierIds)
+	dst[11], dst[12], dst[13], dst[14], dst[15] = value(p.UnitCost), value(p.Moq), value(p.LeadTimeDaysAvg), value(p.LeadTimeDaysP95), value(p.QualityGrade)
+	dst[16], dst[17], dst[18] = value(p.ComplianceFlags), value(p.HazardClass), value(p.LastPriceChange)
+	dst[19], dst[20], dst[21], dst[22] = value(p.DataSource), value(p.SourceTimestamp), value(p.IngestionTimestamp), value(p.SchemaVersion)
+	dst[23] = value(p.ModifiedBy)
+}
+
+// PartSupplierValues writes the values of p to dst in PartSupplierColumns
+// order.
+func PartSupplierValues(p *db.AddPartSupplierParams, dst []any) {
+	dst[0], dst[1], dst[2] = p.PartID, p.SupplierID, p.TenantID
+}
+
+// value unwraps sql.Null* parameters so database/sql can bind them without
+// going through driver.Valuer, which dominates the cost of wide batches.
+func value(v driver.Valuer) any {
+	val, _ := v.Value()
+	return val
+}
+
+// SupplierParams converts a generated supplier to insert parameters, storing
+// empty strings and lists as NULL.
+func SupplierParams(sup suppliers.Supplier) db.CreateSupplierParams {
+	p := db.CreateSupplierParams{
+		SupplierID:           sup.SupplierID,
+		SupplierCode:         sql.NullString{String: sup.SupplierCode, Valid: sup.SupplierCode != ""},
+		TenantID:             sup.TenantID,
+		LegalName:            sup.LegalName,
+		DbaName:              sql.NullString{String: sup.DBAName, Valid: sup.DBAName != ""},
+		Country:              sql.NullString{String: sup.Country, Valid: sup.Country != ""},
+		Region:               sql.NullString{String: sup.Region, Valid: sup.Region != ""},
+		AddressLine1:         sql.NullString{String: sup.AddressLine1, Valid: sup.AddressLine1 != ""},
+		AddressLine2:         sql.NullString{String: sup.AddressLine2, Valid: sup.AddressLine2 != ""},
+		City:                 sql.NullString{String: sup.City, Valid: sup.City != ""},
+		State:                sql.NullString{String: sup.State, Valid: sup.State != ""},
+		PostalCode:           sql.NullString{String: sup.PostalCode, Valid: sup.PostalCode != ""},
+		ContactEmail:         sql.NullString{String: sup.ContactEmail, Valid: sup.ContactEmail != ""},
+		ContactPhone:         sql.NullString{String: sup.ContactPhone, Valid: sup.ContactPhone != ""},
+		PreferredCurrency:    sql.NullString{String: sup.PreferredCurrency, Valid: sup.PreferredCurrency != ""},
+		Incoterms:            sql.NullString{String: sup.Incoterms, Valid: sup.Incoterms != ""},
+		LeadTimeDaysAvg:      sql.NullInt64{Int64: int64(sup.LeadTimeDaysAvg), Valid: true},
+		LeadTimeDaysP95:      sql.NullInt64{Int64: int64(sup.LeadTimeDaysP95), Valid: true},
+		OnTimeDeliveryRate:   sql.NullFloat64{Float64: sup.OnTimeDeliveryRate, Valid: true},
+		DefectRatePpm:        sql.NullInt64{Int64: int64(sup.DefectRatePPM), Valid: true},
+		CapacityUnitsPerWeek: sql.NullInt64{Int64: int64(sup.CapacityUnitsPerWeek), Valid: true},
+		RiskScore:            sql.NullFloat64{Float64: sup.RiskScore, Valid: true},
+		FinancialRiskTier:    sql.NullString{String: sup.FinancialRiskTier, Valid: sup.FinancialRiskTier != ""},
+		Certifications:       sql.NullString{String: fmt.Sprintf("%v", sup.Certifications), Valid: len(sup.Certifications) > 0},
+		ComplianceFlags:      sql.NullString{String: fmt.Sprintf("%v", sup.ComplianceFlags), Valid: len(sup.ComplianceFlags) > 0},
+		ApprovedStatus:       sql.NullString{String: sup.ApprovedStatus, Valid: sup.ApprovedStatus != ""},
+		Contracts:            sql.NullString{String: fmt.Sprintf("%v", sup.Contracts), Valid: len(sup.Contracts) > 0},
+		TermsVersion:         sql.NullString{String: sup.TermsVersion, Valid: sup.TermsVersion != ""},
+		DataSource:           sql.NullString{String: sup.DataSource, Valid: sup.DataSource != ""},
+		SourceTimestamp:      sql.NullTime{Time: sup.SourceTimestamp, Valid: true},
+		IngestionTimestamp:   sql.NullTime{Time: sup.IngestionTimestamp, Valid: true},
+		SchemaVersion:        sql.NullString{String: sup.SchemaVersion, Valid: sup.SchemaVersion != ""},
+	}
+	if sup.GeoCoords != nil {
+		p.Lat = sql.NullFloat64{Float64: sup.GeoCoords.Lat, Valid: true}
+		p.Lon = sql.NullFloat64{Float64: sup.GeoCoords.Lon, Valid: true}
+	}
+	return p
+}
+
+// PartParams converts a generated part to insert parameters, storing empty
+// strings and lists as NULL.
+func PartParams(part parts.Part) db.CreatePartParams {
+	return db.CreatePartParams{
+		PartID:               part.PartID,
+		TenantID:             part.TenantID,
+		PartNumber:           part.PartNumber,
+		Description:          part.Description,
+		Category:             sql.NullString{String: part.Category, Valid: part.Category != ""},
+		LifecycleStatus:      sql.NullString{String: part.LifecycleStatus, Valid: part.LifecycleStatus != ""},
+		Uom:                  sql.NullString{String: part.Uom, Valid: part.Uom != ""},
+		SpecHash:             sql.NullString{String: part.SpecHash, Valid: part.SpecHash != ""},
+		BomCompatibility:     sql.NullString{String: fmt.Sprintf("%v", part.BomCompatibility), Valid: len(part.BomCompatibility) > 0},
+		DefaultSupplierID:    sql.NullString{String: part.DefaultSupplierID, Valid: part.DefaultSupplierID != ""},
+		QualifiedSupplierIds: sql.NullString{String: fmt.Sprintf("%v", part.QualifiedSupplierIDs), Valid: len(part.QualifiedSupplierIDs) > 0},
+		UnitCost:             sql.NullFloat64{Float64: part.UnitCost, Valid: true},
+		Moq:                  sql.NullInt64{Int64: int64(part.Moq), Valid: true},
+		LeadTimeDaysAvg:      sql.NullInt64{Int64: int64(part.LeadTimeDaysAvg), Valid: true},
+		LeadTimeDaysP95:      sql.NullInt64{Int64: int64(part.LeadTimeDaysP95), Valid: true},
+		QualityGrade:         sql.NullString{String: part.QualityGrade, Valid: part.QualityGrade != ""},
+		ComplianceFlags:      sql.NullString{String: fmt.Sprintf("%v", part.ComplianceFlags), Valid: len(part.ComplianceFlags) > 0},
+		HazardClass:          sql.NullString{String: part.HazardClass, Valid: part.HazardClass != ""},
+		LastPriceChange:      sql.NullTime{Time: part.LastPriceChange, Valid: true},
+		DataSource:           sql.NullString{String: part.DataSource, Valid: part.DataSource != ""},
+		SourceTimestamp:      sql.NullTime{Time: part.SourceTimestamp, Valid: true},
+		IngestionTimestamp:   sql.NullTime{Time: part.IngestionTimestamp, Valid: true},
+		SchemaVersion:        sql.NullString{String: part.SchemaVersion, Valid: part.SchemaVersion != ""},
+	}
+}
+
+// PartSupplierParams returns the part_supplier links for a generated part's
+// qualified suppliers. The generator may pick a supplier twice; each link is
+// returned once.
+func PartSupplierParams(part parts.Part) []db.AddPartSupplierParams {
+	links := make([]db.AddPartSupplierParams, 0, len(part.QualifiedSupplierIDs))
+	seen := make(map[string]bool, len(part.QualifiedSupplierIDs))
+	for _, id := range part.QualifiedSupplierIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		links = append(links, db.AddPartSupplierParams{PartID: part.PartID, SupplierID: id, TenantID: part.TenantID})
+	}
+	return links
+}
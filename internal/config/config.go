@@ -0,0 +1,218 @@
+// Package config reads the server's settings from flags, the environment and
+// an optional file, in that order of precedence, over built-in defaults.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/bitterfq/data-ingestion-go/internal/storage"
+)
+
+// Config holds the server's settings.
+type Config struct {
+	// Addr is the address to listen on.
+	Addr string
+	// MetricsAddr, when set, is a separate address serving GET /metrics,
+	// which is then not served on Addr. The metrics are unauthenticated, so
+	// without it anyone who can reach the API can read them.
+	MetricsAddr string
+	// DSN names the store, as accepted by storage.Open.
+	DSN string
+	// SnowflakeEnv is the env file with the Snowflake credentials.
+	SnowflakeEnv string
+	// CheckSnowflake adds a Snowflake login to the readiness check.
+	CheckSnowflake bool
+
+	// ReadTimeout bounds reading a request, headers and body.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds handling a request and writing its response, so it
+	// must allow for the slowest ingest.
+	WriteTimeout time.Duration
+	// IdleTimeout bounds how long a keep-alive connection waits for its next
+	// request.
+	IdleTimeout time.Duration
+	// ShutdownTimeout bounds how long a shutdown waits for in-flight requests
+	// to finish before closing their connections.
+	ShutdownTimeout time.Duration
+	// MaxBodyBytes caps request bodies.
+	MaxBodyBytes int64
+	// ValidateRequests rejects requests that do not match the OpenAPI
+	// document with 400 before they reach a handler.
+	ValidateRequests bool
+
+	// Auth requires callers to present an API key or a bearer token. Turn it
+	// off only behind something else that authenticates.
+	Auth bool
+	// JWTSecret verifies HMAC-signed bearer tokens. It comes from the
+	// environment, the config file or JWT_SECRET_FILE, never from a flag,
+	// so it does not show up in ps or shell history.
+	JWTSecret string
+	// JWTPublicKeys is a PEM file of public keys that verify bearer tokens.
+	JWTPublicKeys string
+	// JWTIssuer and JWTAudience, when set, must match a token's iss and aud.
+	JWTIssuer   string
+	JWTAudience string
+
+	// RateLimits is a JSON file of ratelimit rules, reread on SIGHUP. Empty
+	// means ratelimit.DefaultRules.
+	RateLimits string
+}
+
+// setting is one field of Config with its variable, flag and default. A
+// setting without a flag is read from the environment and the config file
+// only.
+type setting struct {
+	env, flag, def, usage string
+	set                   func(c *Config, v string) error
+}
+
+var settings = []setting{
+	{"LISTEN_ADDR", "addr", ":8080", "address to listen on", func(c *Config, v string) error {
+		c.Addr = v
+		return nil
+	}},
+	{"METRICS_ADDR", "metrics-addr", "", "separate address for the unauthenticated /metrics; empty serves it on -addr", func(c *Config, v string) error {
+		c.MetricsAddr = v
+		return nil
+	}},
+	{"DATABASE_DSN", "dsn", storage.DefaultDSN, "store to serve, sqlite:<path> or duckdb:<path>", func(c *Config, v string) error {
+		c.DSN = v
+		return nil
+	}},
+	{"SNOWFLAKE_ENV", "snowflake-env", ".env", "env file with the Snowflake credentials", func(c *Config, v string) error {
+		c.SnowflakeEnv = v
+		return nil
+	}},
+	{"READYZ_SNOWFLAKE", "readyz-snowflake", "false", "log in to Snowflake in the readiness check", func(c *Config, v string) (err error) {
+		c.CheckSnowflake, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"READ_TIMEOUT", "read-timeout", "15s", "time to read a request", func(c *Config, v string) (err error) {
+		c.ReadTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"WRITE_TIMEOUT", "write-timeout", "5m", "time to handle a request and write its response", func(c *Config, v string) (err error) {
+		c.WriteTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"IDLE_TIMEOUT", "idle-timeout", "2m", "time a keep-alive connection waits for its next request", func(c *Config, v string) (err error) {
+		c.IdleTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "5m", "time a shutdown waits for in-flight requests", func(c *Config, v string) (err error) {
+		c.ShutdownTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"MAX_BODY_BYTES", "max-body-bytes", "1048576", "largest request body accepted", func(c *Config, v string) (err error) {
+		c.MaxBodyBytes, err = strconv.ParseInt(v, 10, 64)
+		if err == nil && c.MaxBodyBytes <= 0 {
+			err = fmt.Errorf("must be positive")
+		}
+		return err
+	}},
+	{"VALIDATE_REQUESTS", "validate-requests", "true", "reject requests that do not match the OpenAPI document", func(c *Config, v string) (err error) {
+		c.ValidateRequests, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"AUTH", "auth", "true", "require an API key or bearer token", func(c *Config, v string) (err error) {
+		c.Auth, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"JWT_SECRET", "", "", "secret verifying HMAC-signed bearer tokens", func(c *Config, v string) error {
+		c.JWTSecret = v
+		return nil
+	}},
+	{"JWT_SECRET_FILE", "jwt-secret-file", "", "file holding the secret verifying HMAC-signed bearer tokens", func(c *Config, v string) error {
+		if v == "" {
+			return nil
+		}
+		if c.JWTSecret != "" {
+			return errors.New("JWT_SECRET is set too")
+		}
+		data, err := os.ReadFile(v)
+		if err != nil {
+			return err
+		}
+		if c.JWTSecret = strings.TrimRight(string(data), "\r\n"); c.JWTSecret == "" {
+			return errors.New("file is empty")
+		}
+		return nil
+	}},
+	{"JWT_PUBLIC_KEYS", "jwt-public-keys", "", "PEM file of public keys verifying bearer tokens", func(c *Config, v string) error {
+		c.JWTPublicKeys = v
+		return nil
+	}},
+	{"JWT_ISSUER", "jwt-issuer", "", "iss claim bearer tokens must carry", func(c *Config, v string) error {
+		c.JWTIssuer = v
+		return nil
+	}},
+	{"JWT_AUDIENCE", "jwt-audience", "", "aud claim bearer tokens must carry", func(c *Config, v string) error {
+		c.JWTAudience = v
+		return nil
+	}},
+	{"RATE_LIMITS", "rate-limits", "", "JSON file of rate limit rules, reread on SIGHUP", func(c *Config, v string) error {
+		c.RateLimits = v
+		return nil
+	}},
+}
+
+// Load reads the settings. A flag overrides its environment variable, which
+// overrides the file named by -config or CONFIG_FILE, which overrides the
+// default. The file holds KEY=value lines with the variables' names, like a
+// .env file. getenv is os.Getenv outside tests. Usage and errors go to out.
+func Load(args []string, getenv func(string) string, out io.Writer) (Config, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(out)
+	file := fs.String("config", getenv("CONFIG_FILE"), "file of KEY=value settings, overridden by the environment and flags")
+	flags := map[string]*string{}
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		usage := fmt.Sprintf("%s (%s; default %s)", s.usage, s.env, s.def)
+		if s.def == "" {
+			usage = fmt.Sprintf("%s (%s)", s.usage, s.env)
+		}
+		flags[s.flag] = fs.String(s.flag, "", usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	fromFile := map[string]string{}
+	if *file != "" {
+		var err error
+		if fromFile, err = godotenv.Read(*file); err != nil {
+			return Config{}, fmt.Errorf("read config file: %w", err)
+		}
+	}
+
+	var c Config
+	for _, s := range settings {
+		v, from := s.def, "default"
+		if fv, ok := fromFile[s.env]; ok {
+			v, from = fv, *file
+		}
+		if ev := getenv(s.env); ev != "" {
+			v, from = ev, s.env
+		}
+		if s.flag != "" && set[s.flag] {
+			v, from = *flags[s.flag], "-"+s.flag
+		}
+		if err := s.set(&c, v); err != nil {
+			return Config{}, fmt.Errorf("%s %q from %s: %w", s.env, v, from, err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a getenv reading vars.
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestDefaults(t *testing.T) {
+	c, err := Load(nil, env(nil), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Addr != ":8080" || c.DSN != "sqlite:data/data.db" || c.SnowflakeEnv != ".env" || c.CheckSnowflake ||
+		c.ReadTimeout != 15*time.Second || c.WriteTimeout != 5*time.Minute || c.IdleTimeout != 2*time.Minute ||
+		c.ShutdownTimeout != 5*time.Minute || c.MaxBodyBytes != 1<<20 || !c.ValidateRequests || !c.Auth || c.JWTSecret != "" || c.JWTPublicKeys != "" || c.RateLimits != "" || c.MetricsAddr != "" {
+		t.Errorf("unexpected defaults %+v", c)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "server.env")
+	if err := os.WriteFile(file, []byte("# settings\nLISTEN_ADDR=:9000\nDATABASE_DSN=duckdb:file.duckdb\nREAD_TIMEOUT=1s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	vars := map[string]string{"CONFIG_FILE": file, "DATABASE_DSN": "sqlite:env.db", "READ_TIMEOUT": "2s", "READYZ_SNOWFLAKE": "true", "JWT_ISSUER": "idp", "VALIDATE_REQUESTS": "false"}
+	c, err := Load([]string{"-read-timeout", "3s", "-auth=false"}, env(vars), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the file beats the default, the environment the file, a flag the environment
+	if c.Addr != ":9000" || c.DSN != "sqlite:env.db" || c.ReadTimeout != 3*time.Second || !c.CheckSnowflake ||
+		c.Auth || c.JWTIssuer != "idp" || c.ValidateRequests {
+		t.Errorf("unexpected config %+v", c)
+	}
+
+	// -config beats CONFIG_FILE
+	if _, err := Load([]string{"-config", filepath.Join(t.TempDir(), "missing.env")}, env(vars), io.Discard); err == nil {
+		t.Error("expected a missing config file to fail")
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{"-write-timeout", "soon"},
+		{"-max-body-bytes", "0"},
+		{"-readyz-snowflake", "maybe"},
+		{"-auth", "sometimes"},
+		{"-validate-requests", "yes please"},
+		{"-port", "80"},
+	} {
+		if _, err := Load(args, env(nil), io.Discard); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+
+	_, err := Load([]string{"-h"}, env(nil), io.Discard)
+	if err != flag.ErrHelp {
+		t.Errorf("expected flag.ErrHelp, got %v", err)
+	}
+	_, err = Load(nil, env(map[string]string{"IDLE_TIMEOUT": "x"}), io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "IDLE_TIMEOUT") {
+		t.Errorf("expected the error to name the variable, got %v", err)
+	}
+}
+
+func TestJWTSecret(t *testing.T) {
+	if _, err := Load([]string{"-jwt-secret", "s3cret"}, env(nil), io.Discard); err == nil {
+		t.Error("expected the secret to have no flag")
+	}
+	c, err := Load(nil, env(map[string]string{"JWT_SECRET": "from-env"}), io.Discard)
+	if err != nil || c.JWTSecret != "from-env" {
+		t.Errorf("got %q, %v from the environment", c.JWTSecret, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "jwt-secret")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err = Load([]string{"-jwt-secret-file", file}, env(nil), io.Discard)
+	if err != nil || c.JWTSecret != "from-file" {
+		t.Errorf("got %q, %v from -jwt-secret-file", c.JWTSecret, err)
+	}
+	if _, err := Load([]string{"-jwt-secret-file", file}, env(map[string]string{"JWT_SECRET": "from-env"}), io.Discard); err == nil {
+		t.Error("expected JWT_SECRET and -jwt-secret-file together to fail")
+	}
+	if _, err := Load([]string{"-jwt-secret-file", filepath.Join(t.TempDir(), "missing")}, env(nil), io.Discard); err == nil {
+		t.Error("expected a missing secret file to fail")
+	}
+}
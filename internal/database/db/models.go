@@ -6,8 +6,31 @@ package db
 
 import (
 	"database/sql"
+	"time"
 )
 
+type ApiKey struct {
+	KeyID     string
+	KeyHash   string
+	Name      string
+	TenantID  string
+	Role      string
+	CreatedAt time.Time
+	RevokedAt sql.NullTime
+}
+
+type AuditLog struct {
+	AuditID    int64
+	OccurredAt time.Time
+	Actor      string
+	Action     string
+	Entity     string
+	EntityID   string
+	TenantID   string
+	BeforeJson sql.NullString
+	AfterJson  sql.NullString
+}
+
 type DimPartV1 struct {
 	PartID               string
 	TenantID             string
@@ -32,6 +55,40 @@ type DimPartV1 struct {
 	SourceTimestamp      sql.NullTime
 	IngestionTimestamp   sql.NullTime
 	SchemaVersion        sql.NullString
+	ModifiedBy           sql.NullString
+	DeletedAt            sql.NullTime
+	DeletedBy            sql.NullString
+	Version              int64
+}
+
+type DimPartV1History struct {
+	HistoryID            int64
+	PartID               string
+	TenantID             string
+	PartNumber           string
+	Description          string
+	Category             sql.NullString
+	LifecycleStatus      sql.NullString
+	Uom                  sql.NullString
+	SpecHash             sql.NullString
+	BomCompatibility     sql.NullString
+	DefaultSupplierID    sql.NullString
+	QualifiedSupplierIds sql.NullString
+	UnitCost             sql.NullFloat64
+	Moq                  sql.NullInt64
+	LeadTimeDaysAvg      sql.NullInt64
+	LeadTimeDaysP95      sql.NullInt64
+	QualityGrade         sql.NullString
+	ComplianceFlags      sql.NullString
+	HazardClass          sql.NullString
+	LastPriceChange      sql.NullTime
+	DataSource           sql.NullString
+	SourceTimestamp      sql.NullTime
+	IngestionTimestamp   sql.NullTime
+	SchemaVersion        sql.NullString
+	ValidFrom            time.Time
+	ValidTo              sql.NullTime
+	IsCurrent            bool
 }
 
 type DimSupplierV1 struct {
@@ -69,4 +126,68 @@ type DimSupplierV1 struct {
 	SourceTimestamp      sql.NullTime
 	IngestionTimestamp   sql.NullTime
 	SchemaVersion        sql.NullString
+	ModifiedBy           sql.NullString
+	DeletedAt            sql.NullTime
+	DeletedBy            sql.NullString
+	Version              int64
+}
+
+type DimSupplierV1History struct {
+	HistoryID            int64
+	SupplierID           string
+	SupplierCode         sql.NullString
+	TenantID             string
+	LegalName            string
+	DbaName              sql.NullString
+	Country              sql.NullString
+	Region               sql.NullString
+	AddressLine1         sql.NullString
+	AddressLine2         sql.NullString
+	City                 sql.NullString
+	State                sql.NullString
+	PostalCode           sql.NullString
+	ContactEmail         sql.NullString
+	ContactPhone         sql.NullString
+	PreferredCurrency    sql.NullString
+	Incoterms            sql.NullString
+	LeadTimeDaysAvg      sql.NullInt64
+	LeadTimeDaysP95      sql.NullInt64
+	OnTimeDeliveryRate   sql.NullFloat64
+	DefectRatePpm        sql.NullInt64
+	CapacityUnitsPerWeek sql.NullInt64
+	RiskScore            sql.NullFloat64
+	FinancialRiskTier    sql.NullString
+	Certifications       sql.NullString
+	ComplianceFlags      sql.NullString
+	ApprovedStatus       sql.NullString
+	Contracts            sql.NullString
+	TermsVersion         sql.NullString
+	Lat                  sql.NullFloat64
+	Lon                  sql.NullFloat64
+	DataSource           sql.NullString
+	SourceTimestamp      sql.NullTime
+	IngestionTimestamp   sql.NullTime
+	SchemaVersion        sql.NullString
+	ValidFrom            time.Time
+	ValidTo              sql.NullTime
+	IsCurrent            bool
+}
+
+type PartSearch struct {
+	PartID      sql.NullString
+	PartNumber  sql.NullString
+	Description sql.NullString
+}
+
+type PartSupplier struct {
+	PartID     string
+	SupplierID string
+	TenantID   string
+}
+
+type SupplierSearch struct {
+	SupplierID sql.NullString
+	LegalName  sql.NullString
+	DbaName    sql.NullString
+	City       sql.NullString
 }
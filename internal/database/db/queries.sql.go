@@ -10,6 +10,117 @@ import (
 	"database/sql"
 )
 
+const addPartSupplier = `-- name: AddPartSupplier :exec
+INSERT INTO part_supplier (part_id, supplier_id, tenant_id)
+VALUES (?, ?, ?)
+ON CONFLICT (part_id, supplier_id) DO NOTHING
+`
+
+type AddPartSupplierParams struct {
+	PartID     string
+	SupplierID string
+	TenantID   string
+}
+
+// AddPartSupplier fails unless the part and the supplier belong to the tenant.
+func (q *Queries) AddPartSupplier(ctx context.Context, arg AddPartSupplierParams) error {
+	_, err := q.db.ExecContext(ctx, addPartSupplier, arg.PartID, arg.SupplierID, arg.TenantID)
+	return err
+}
+
+const clearPartSuppliers = `-- name: ClearPartSuppliers :exec
+DELETE FROM part_supplier WHERE tenant_id = ? AND part_id = ?
+`
+
+type ClearPartSuppliersParams struct {
+	TenantID string
+	PartID   string
+}
+
+// ClearPartSuppliers removes every supplier link of a part.
+func (q *Queries) ClearPartSuppliers(ctx context.Context, arg ClearPartSuppliersParams) error {
+	_, err := q.db.ExecContext(ctx, clearPartSuppliers, arg.TenantID, arg.PartID)
+	return err
+}
+
+const countParts = `-- name: CountParts :one
+SELECT COUNT(*) FROM dim_part_v1
+WHERE tenant_id = ?1
+    AND deleted_at IS NULL
+    AND (?2 IS NULL OR category = ?2)
+    AND (?3 IS NULL OR lifecycle_status = ?3)
+`
+
+type CountPartsParams struct {
+	TenantID        string
+	Category        sql.NullString
+	LifecycleStatus sql.NullString
+}
+
+func (q *Queries) CountParts(ctx context.Context, arg CountPartsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countParts, arg.TenantID, arg.Category, arg.LifecycleStatus)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSuppliers = `-- name: CountSuppliers :one
+SELECT COUNT(*) FROM dim_supplier_v1
+WHERE tenant_id = ?1
+    AND deleted_at IS NULL
+    AND (?2 IS NULL OR country = ?2)
+    AND (?3 IS NULL OR region = ?3)
+    AND (?4 IS NULL OR approved_status = ?4)
+    AND (?5 IS NULL OR risk_score >= ?5)
+    AND (?6 IS NULL OR risk_score <= ?6)
+`
+
+type CountSuppliersParams struct {
+	TenantID       string
+	Country        sql.NullString
+	Region         sql.NullString
+	ApprovedStatus sql.NullString
+	MinRiskScore   sql.NullFloat64
+	MaxRiskScore   sql.NullFloat64
+}
+
+func (q *Queries) CountSuppliers(ctx context.Context, arg CountSuppliersParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSuppliers,
+		arg.TenantID,
+		arg.Country,
+		arg.Region,
+		arg.ApprovedStatus,
+		arg.MinRiskScore,
+		arg.MaxRiskScore,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPIKey = `-- name: CreateAPIKey :exec
+INSERT INTO api_key (key_id, key_hash, name, tenant_id, role) VALUES (?, ?, ?, ?, ?)
+`
+
+type CreateAPIKeyParams struct {
+	KeyID    string
+	KeyHash  string
+	Name     string
+	TenantID string
+	Role     string
+}
+
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) error {
+	_, err := q.db.ExecContext(ctx, createAPIKey,
+		arg.KeyID,
+		arg.KeyHash,
+		arg.Name,
+		arg.TenantID,
+		arg.Role,
+	)
+	return err
+}
+
 const createPart = `-- name: CreatePart :execrows
 INSERT INTO dim_part_v1
     (
@@ -35,13 +146,14 @@ INSERT INTO dim_part_v1
     data_source,
     source_timestamp,
     ingestion_timestamp,
-    schema_version
+    schema_version,
+    modified_by
     )
 VALUES
     (
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
-        ?, ?, ?
+        ?, ?, ?, ?
 )
 `
 
@@ -69,6 +181,7 @@ type CreatePartParams struct {
 	SourceTimestamp      sql.NullTime
 	IngestionTimestamp   sql.NullTime
 	SchemaVersion        sql.NullString
+	ModifiedBy           sql.NullString
 }
 
 func (q *Queries) CreatePart(ctx context.Context, arg CreatePartParams) (int64, error) {
@@ -96,6 +209,7 @@ func (q *Queries) CreatePart(ctx context.Context, arg CreatePartParams) (int64,
 		arg.SourceTimestamp,
 		arg.IngestionTimestamp,
 		arg.SchemaVersion,
+		arg.ModifiedBy,
 	)
 	if err != nil {
 		return 0, err
@@ -139,14 +253,15 @@ INSERT INTO dim_supplier_v1
     data_source,
     source_timestamp,
     ingestion_timestamp,
-    schema_version
+    schema_version,
+    modified_by
     )
 VALUES
     (
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
-        ?, ?, ?, ?
+        ?, ?, ?, ?, ?
 )
 `
 
@@ -185,6 +300,7 @@ type CreateSupplierParams struct {
 	SourceTimestamp      sql.NullTime
 	IngestionTimestamp   sql.NullTime
 	SchemaVersion        sql.NullString
+	ModifiedBy           sql.NullString
 }
 
 func (q *Queries) CreateSupplier(ctx context.Context, arg CreateSupplierParams) (int64, error) {
@@ -223,6 +339,7 @@ func (q *Queries) CreateSupplier(ctx context.Context, arg CreateSupplierParams)
 		arg.SourceTimestamp,
 		arg.IngestionTimestamp,
 		arg.SchemaVersion,
+		arg.ModifiedBy,
 	)
 	if err != nil {
 		return 0, err
@@ -230,20 +347,1799 @@ func (q *Queries) CreateSupplier(ctx context.Context, arg CreateSupplierParams)
 	return result.RowsAffected()
 }
 
-const deletePart = `-- name: DeletePart :exec
-DELETE FROM dim_part_v1 WHERE part_id = ?
+const deletePart = `-- name: DeletePart :execrows
+UPDATE dim_part_v1
+SET deleted_at = strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    deleted_by = ?1
+WHERE tenant_id = ?2 AND part_id = ?3 AND deleted_at IS NULL
 `
 
-func (q *Queries) DeletePart(ctx context.Context, partID string) error {
-	_, err := q.db.ExecContext(ctx, deletePart, partID)
-	return err
+type DeletePartParams struct {
+	DeletedBy sql.NullString
+	TenantID  string
+	PartID    string
+}
+
+// DeletePart soft-deletes a part. It returns 0 if the part does not exist or
+// is already deleted.
+func (q *Queries) DeletePart(ctx context.Context, arg DeletePartParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePart, arg.DeletedBy, arg.TenantID, arg.PartID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSupplier = `-- name: DeleteSupplier :execrows
+UPDATE dim_supplier_v1
+SET deleted_at = strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    deleted_by = ?1
+WHERE tenant_id = ?2 AND supplier_id = ?3 AND deleted_at IS NULL
+`
+
+type DeleteSupplierParams struct {
+	DeletedBy  sql.NullString
+	TenantID   string
+	SupplierID string
+}
+
+// DeleteSupplier soft-deletes a supplier. It returns 0 if the supplier does
+// not exist or is already deleted.
+func (q *Queries) DeleteSupplier(ctx context.Context, arg DeleteSupplierParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSupplier, arg.DeletedBy, arg.TenantID, arg.SupplierID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAPIKeyByHash = `-- name: GetAPIKeyByHash :one
+SELECT key_id, key_hash, name, tenant_id, role, created_at, revoked_at FROM api_key WHERE key_hash = ? AND revoked_at IS NULL
+`
+
+// GetAPIKeyByHash finds the live key with the SHA-256 hash key_hash.
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, getAPIKeyByHash, keyHash)
+	var i ApiKey
+	err := row.Scan(
+		&i.KeyID,
+		&i.KeyHash,
+		&i.Name,
+		&i.TenantID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getPart = `-- name: GetPart :one
+SELECT part_id, tenant_id, part_number, description, category, lifecycle_status, uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost, moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class, last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version, modified_by, deleted_at, deleted_by, version FROM dim_part_v1 WHERE tenant_id = ? AND part_id = ? AND deleted_at IS NULL
+`
+
+type GetPartParams struct {
+	TenantID string
+	PartID   string
+}
+
+func (q *Queries) GetPart(ctx context.Context, arg GetPartParams) (DimPartV1, error) {
+	row := q.db.QueryRowContext(ctx, getPart, arg.TenantID, arg.PartID)
+	var i DimPartV1
+	err := row.Scan(
+		&i.PartID,
+		&i.TenantID,
+		&i.PartNumber,
+		&i.Description,
+		&i.Category,
+		&i.LifecycleStatus,
+		&i.Uom,
+		&i.SpecHash,
+		&i.BomCompatibility,
+		&i.DefaultSupplierID,
+		&i.QualifiedSupplierIds,
+		&i.UnitCost,
+		&i.Moq,
+		&i.LeadTimeDaysAvg,
+		&i.LeadTimeDaysP95,
+		&i.QualityGrade,
+		&i.ComplianceFlags,
+		&i.HazardClass,
+		&i.LastPriceChange,
+		&i.DataSource,
+		&i.SourceTimestamp,
+		&i.IngestionTimestamp,
+		&i.SchemaVersion,
+		&i.ModifiedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getPartAsOf = `-- name: GetPartAsOf :one
+SELECT history_id, part_id, tenant_id, part_number, description, category, lifecycle_status, uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost, moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class, last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version, valid_from, valid_to, is_current FROM dim_part_v1_history
+WHERE tenant_id = ?1
+    AND part_id = ?2
+    AND valid_from <= strftime('%Y-%m-%d %H:%M:%f', ?3)
+    AND (valid_to IS NULL OR valid_to > strftime('%Y-%m-%d %H:%M:%f', ?3))
+`
+
+type GetPartAsOfParams struct {
+	TenantID string
+	PartID   string
+	AsOf     interface{}
+}
+
+// GetPartAsOf returns the version of a part that was current at as_of.
+func (q *Queries) GetPartAsOf(ctx context.Context, arg GetPartAsOfParams) (DimPartV1History, error) {
+	row := q.db.QueryRowContext(ctx, getPartAsOf, arg.TenantID, arg.PartID, arg.AsOf)
+	var i DimPartV1History
+	err := row.Scan(
+		&i.HistoryID,
+		&i.PartID,
+		&i.TenantID,
+		&i.PartNumber,
+		&i.Description,
+		&i.Category,
+		&i.LifecycleStatus,
+		&i.Uom,
+		&i.SpecHash,
+		&i.BomCompatibility,
+		&i.DefaultSupplierID,
+		&i.QualifiedSupplierIds,
+		&i.UnitCost,
+		&i.Moq,
+		&i.LeadTimeDaysAvg,
+		&i.LeadTimeDaysP95,
+		&i.QualityGrade,
+		&i.ComplianceFlags,
+		&i.HazardClass,
+		&i.LastPriceChange,
+		&i.DataSource,
+		&i.SourceTimestamp,
+		&i.IngestionTimestamp,
+		&i.SchemaVersion,
+		&i.ValidFrom,
+		&i.ValidTo,
+		&i.IsCurrent,
+	)
+	return i, err
+}
+
+const getPartByNumber = `-- name: GetPartByNumber :one
+SELECT part_id, tenant_id, part_number, description, category, lifecycle_status, uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost, moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class, last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version, modified_by, deleted_at, deleted_by, version FROM dim_part_v1
+WHERE tenant_id = ? AND part_number = ? AND deleted_at IS NULL
+ORDER BY part_id
+LIMIT 1
+`
+
+type GetPartByNumberParams struct {
+	TenantID   string
+	PartNumber string
+}
+
+func (q *Queries) GetPartByNumber(ctx context.Context, arg GetPartByNumberParams) (DimPartV1, error) {
+	row := q.db.QueryRowContext(ctx, getPartByNumber, arg.TenantID, arg.PartNumber)
+	var i DimPartV1
+	err := row.Scan(
+		&i.PartID,
+		&i.TenantID,
+		&i.PartNumber,
+		&i.Description,
+		&i.Category,
+		&i.LifecycleStatus,
+		&i.Uom,
+		&i.SpecHash,
+		&i.BomCompatibility,
+		&i.DefaultSupplierID,
+		&i.QualifiedSupplierIds,
+		&i.UnitCost,
+		&i.Moq,
+		&i.LeadTimeDaysAvg,
+		&i.LeadTimeDaysP95,
+		&i.QualityGrade,
+		&i.ComplianceFlags,
+		&i.HazardClass,
+		&i.LastPriceChange,
+		&i.DataSource,
+		&i.SourceTimestamp,
+		&i.IngestionTimestamp,
+		&i.SchemaVersion,
+		&i.ModifiedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getSupplier = `-- name: GetSupplier :one
+SELECT supplier_id, supplier_code, tenant_id, legal_name, dba_name, country, region, address_line1, address_line2, city, state, postal_code, contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95, on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications, compliance_flags, approved_status, contracts, terms_version, lat, lon, data_source, source_timestamp, ingestion_timestamp, schema_version, modified_by, deleted_at, deleted_by, version FROM dim_supplier_v1 WHERE tenant_id = ? AND supplier_id = ? AND deleted_at IS NULL
+`
+
+type GetSupplierParams struct {
+	TenantID   string
+	SupplierID string
+}
+
+func (q *Queries) GetSupplier(ctx context.Context, arg GetSupplierParams) (DimSupplierV1, error) {
+	row := q.db.QueryRowContext(ctx, getSupplier, arg.TenantID, arg.SupplierID)
+	var i DimSupplierV1
+	err := row.Scan(
+		&i.SupplierID,
+		&i.SupplierCode,
+		&i.TenantID,
+		&i.LegalName,
+		&i.DbaName,
+		&i.Country,
+		&i.Region,
+		&i.AddressLine1,
+		&i.AddressLine2,
+		&i.City,
+		&i.State,
+		&i.PostalCode,
+		&i.ContactEmail,
+		&i.ContactPhone,
+		&i.PreferredCurrency,
+		&i.Incoterms,
+		&i.LeadTimeDaysAvg,
+		&i.LeadTimeDaysP95,
+		&i.OnTimeDeliveryRate,
+		&i.DefectRatePpm,
+		&i.CapacityUnitsPerWeek,
+		&i.RiskScore,
+		&i.FinancialRiskTier,
+		&i.Certifications,
+		&i.ComplianceFlags,
+		&i.ApprovedStatus,
+		&i.Contracts,
+		&i.TermsVersion,
+		&i.Lat,
+		&i.Lon,
+		&i.DataSource,
+		&i.SourceTimestamp,
+		&i.IngestionTimestamp,
+		&i.SchemaVersion,
+		&i.ModifiedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getSupplierAsOf = `-- name: GetSupplierAsOf :one
+SELECT history_id, supplier_id, supplier_code, tenant_id, legal_name, dba_name, country, region, address_line1, address_line2, city, state, postal_code, contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95, on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications, compliance_flags, approved_status, contracts, terms_version, lat, lon, data_source, source_timestamp, ingestion_timestamp, schema_version, valid_from, valid_to, is_current FROM dim_supplier_v1_history
+WHERE tenant_id = ?1
+    AND supplier_id = ?2
+    AND valid_from <= strftime('%Y-%m-%d %H:%M:%f', ?3)
+    AND (valid_to IS NULL OR valid_to > strftime('%Y-%m-%d %H:%M:%f', ?3))
+`
+
+type GetSupplierAsOfParams struct {
+	TenantID   string
+	SupplierID string
+	AsOf       interface{}
+}
+
+// GetSupplierAsOf returns the version of a supplier that was current at as_of.
+func (q *Queries) GetSupplierAsOf(ctx context.Context, arg GetSupplierAsOfParams) (DimSupplierV1History, error) {
+	row := q.db.QueryRowContext(ctx, getSupplierAsOf, arg.TenantID, arg.SupplierID, arg.AsOf)
+	var i DimSupplierV1History
+	err := row.Scan(
+		&i.HistoryID,
+		&i.SupplierID,
+		&i.SupplierCode,
+		&i.TenantID,
+		&i.LegalName,
+		&i.DbaName,
+		&i.Country,
+		&i.Region,
+		&i.AddressLine1,
+		&i.AddressLine2,
+		&i.City,
+		&i.State,
+		&i.PostalCode,
+		&i.ContactEmail,
+		&i.ContactPhone,
+		&i.PreferredCurrency,
+		&i.Incoterms,
+		&i.LeadTimeDaysAvg,
+		&i.LeadTimeDaysP95,
+		&i.OnTimeDeliveryRate,
+		&i.DefectRatePpm,
+		&i.CapacityUnitsPerWeek,
+		&i.RiskScore,
+		&i.FinancialRiskTier,
+		&i.Certifications,
+		&i.ComplianceFlags,
+		&i.ApprovedStatus,
+		&i.Contracts,
+		&i.TermsVersion,
+		&i.Lat,
+		&i.Lon,
+		&i.DataSource,
+		&i.SourceTimestamp,
+		&i.IngestionTimestamp,
+		&i.SchemaVersion,
+		&i.ValidFrom,
+		&i.ValidTo,
+		&i.IsCurrent,
+	)
+	return i, err
+}
+
+const getSupplierByCode = `-- name: GetSupplierByCode :one
+SELECT supplier_id, supplier_code, tenant_id, legal_name, dba_name, country, region, address_line1, address_line2, city, state, postal_code, contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95, on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications, compliance_flags, approved_status, contracts, terms_version, lat, lon, data_source, source_timestamp, ingestion_timestamp, schema_version, modified_by, deleted_at, deleted_by, version FROM dim_supplier_v1
+WHERE tenant_id = ? AND supplier_code = ? AND deleted_at IS NULL
+ORDER BY supplier_id
+LIMIT 1
+`
+
+type GetSupplierByCodeParams struct {
+	TenantID     string
+	SupplierCode sql.NullString
+}
+
+func (q *Queries) GetSupplierByCode(ctx context.Context, arg GetSupplierByCodeParams) (DimSupplierV1, error) {
+	row := q.db.QueryRowContext(ctx, getSupplierByCode, arg.TenantID, arg.SupplierCode)
+	var i DimSupplierV1
+	err := row.Scan(
+		&i.SupplierID,
+		&i.SupplierCode,
+		&i.TenantID,
+		&i.LegalName,
+		&i.DbaName,
+		&i.Country,
+		&i.Region,
+		&i.AddressLine1,
+		&i.AddressLine2,
+		&i.City,
+		&i.State,
+		&i.PostalCode,
+		&i.ContactEmail,
+		&i.ContactPhone,
+		&i.PreferredCurrency,
+		&i.Incoterms,
+		&i.LeadTimeDaysAvg,
+		&i.LeadTimeDaysP95,
+		&i.OnTimeDeliveryRate,
+		&i.DefectRatePpm,
+		&i.CapacityUnitsPerWeek,
+		&i.RiskScore,
+		&i.FinancialRiskTier,
+		&i.Certifications,
+		&i.ComplianceFlags,
+		&i.ApprovedStatus,
+		&i.Contracts,
+		&i.TermsVersion,
+		&i.Lat,
+		&i.Lon,
+		&i.DataSource,
+		&i.SourceTimestamp,
+		&i.IngestionTimestamp,
+		&i.SchemaVersion,
+		&i.ModifiedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+		&i.Version,
+	)
+	return i, err
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT key_id, key_hash, name, tenant_id, role, created_at, revoked_at FROM api_key WHERE tenant_id = ? ORDER BY created_at, key_id
+`
+
+// ListAPIKeys returns a tenant's keys, revoked ones included, oldest first.
+func (q *Queries) ListAPIKeys(ctx context.Context, tenantID string) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listAPIKeys, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.KeyID,
+			&i.KeyHash,
+			&i.Name,
+			&i.TenantID,
+			&i.Role,
+			&i.CreatedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditLog = `-- name: ListAuditLog :many
+SELECT audit_id, occurred_at, actor, action, entity, entity_id, tenant_id, before_json, after_json FROM audit_log
+WHERE tenant_id = ? AND entity = ? AND entity_id = ?
+ORDER BY audit_id
+`
+
+type ListAuditLogParams struct {
+	TenantID string
+	Entity   string
+	EntityID string
+}
+
+// ListAuditLog returns every change to one entity, oldest first.
+func (q *Queries) ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditLog, arg.TenantID, arg.Entity, arg.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.AuditID,
+			&i.OccurredAt,
+			&i.Actor,
+			&i.Action,
+			&i.Entity,
+			&i.EntityID,
+			&i.TenantID,
+			&i.BeforeJson,
+			&i.AfterJson,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPartHistory = `-- name: ListPartHistory :many
+SELECT history_id, part_id, tenant_id, part_number, description, category, lifecycle_status, uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost, moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class, last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version, valid_from, valid_to, is_current FROM dim_part_v1_history
+WHERE tenant_id = ? AND part_id = ?
+ORDER BY valid_from, history_id
+`
+
+type ListPartHistoryParams struct {
+	TenantID string
+	PartID   string
+}
+
+func (q *Queries) ListPartHistory(ctx context.Context, arg ListPartHistoryParams) ([]DimPartV1History, error) {
+	rows, err := q.db.QueryContext(ctx, listPartHistory, arg.TenantID, arg.PartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DimPartV1History
+	for rows.Next() {
+		var i DimPartV1History
+		if err := rows.Scan(
+			&i.HistoryID,
+			&i.PartID,
+			&i.TenantID,
+			&i.PartNumber,
+			&i.Description,
+			&i.Category,
+			&i.LifecycleStatus,
+			&i.Uom,
+			&i.SpecHash,
+			&i.BomCompatibility,
+			&i.DefaultSupplierID,
+			&i.QualifiedSupplierIds,
+			&i.UnitCost,
+			&i.Moq,
+			&i.LeadTimeDaysAvg,
+			&i.LeadTimeDaysP95,
+			&i.QualityGrade,
+			&i.ComplianceFlags,
+			&i.HazardClass,
+			&i.LastPriceChange,
+			&i.DataSource,
+			&i.SourceTimestamp,
+			&i.IngestionTimestamp,
+			&i.SchemaVersion,
+			&i.ValidFrom,
+			&i.ValidTo,
+			&i.IsCurrent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPartSuppliers = `-- name: ListPartSuppliers :many
+SELECT dim_supplier_v1.supplier_id, dim_supplier_v1.supplier_code, dim_supplier_v1.tenant_id, dim_supplier_v1.legal_name, dim_supplier_v1.dba_name, dim_supplier_v1.country, dim_supplier_v1.region, dim_supplier_v1.address_line1, dim_supplier_v1.address_line2, dim_supplier_v1.city, dim_supplier_v1.state, dim_supplier_v1.postal_code, dim_supplier_v1.contact_email, dim_supplier_v1.contact_phone, dim_supplier_v1.preferred_currency, dim_supplier_v1.incoterms, dim_supplier_v1.lead_time_days_avg, dim_supplier_v1.lead_time_days_p95, dim_supplier_v1.on_time_delivery_rate, dim_supplier_v1.defect_rate_ppm, dim_supplier_v1.capacity_units_per_week, dim_supplier_v1.risk_score, dim_supplier_v1.financial_risk_tier, dim_supplier_v1.certifications, dim_supplier_v1.compliance_flags, dim_supplier_v1.approved_status, dim_supplier_v1.contracts, dim_supplier_v1.terms_version, dim_supplier_v1.lat, dim_supplier_v1.lon, dim_supplier_v1.data_source, dim_supplier_v1.source_timestamp, dim_supplier_v1.ingestion_timestamp, dim_supplier_v1.schema_version, dim_supplier_v1.modified_by, dim_supplier_v1.deleted_at, dim_supplier_v1.deleted_by, dim_supplier_v1.version FROM dim_supplier_v1
+JOIN part_supplier ON part_supplier.supplier_id = dim_supplier_v1.supplier_id AND part_supplier.tenant_id = dim_supplier_v1.tenant_id
+WHERE part_supplier.tenant_id = ? AND part_supplier.part_id = ? AND dim_supplier_v1.deleted_at IS NULL
+ORDER BY dim_supplier_v1.supplier_id
+`
+
+type ListPartSuppliersParams struct {
+	TenantID string
+	PartID   string
+}
+
+// ListPartSuppliers returns the suppliers qualified for a part.
+func (q *Queries) ListPartSuppliers(ctx context.Context, arg ListPartSuppliersParams) ([]DimSupplierV1, error) {
+	rows, err := q.db.QueryContext(ctx, listPartSuppliers, arg.TenantID, arg.PartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DimSupplierV1
+	for rows.Next() {
+		var i DimSupplierV1
+		if err := rows.Scan(
+			&i.SupplierID,
+			&i.SupplierCode,
+			&i.TenantID,
+			&i.LegalName,
+			&i.DbaName,
+			&i.Country,
+			&i.Region,
+			&i.AddressLine1,
+			&i.AddressLine2,
+			&i.City,
+			&i.State,
+			&i.PostalCode,
+			&i.ContactEmail,
+			&i.ContactPhone,
+			&i.PreferredCurrency,
+			&i.Incoterms,
+			&i.LeadTimeDaysAvg,
+			&i.LeadTimeDaysP95,
+			&i.OnTimeDeliveryRate,
+			&i.DefectRatePpm,
+			&i.CapacityUnitsPerWeek,
+			&i.RiskScore,
+			&i.FinancialRiskTier,
+			&i.Certifications,
+			&i.ComplianceFlags,
+			&i.ApprovedStatus,
+			&i.Contracts,
+			&i.TermsVersion,
+			&i.Lat,
+			&i.Lon,
+			&i.DataSource,
+			&i.SourceTimestamp,
+			&i.IngestionTimestamp,
+			&i.SchemaVersion,
+			&i.ModifiedBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listParts = `-- name: ListParts :many
+SELECT part_id, tenant_id, part_number, description, category, lifecycle_status, uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost, moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class, last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version, modified_by, deleted_at, deleted_by, version FROM dim_part_v1
+WHERE tenant_id = ?1
+    AND deleted_at IS NULL
+    AND (?2 IS NULL OR category = ?2)
+    AND (?3 IS NULL OR lifecycle_status = ?3)
+    AND part_id > ?4
+ORDER BY part_id
+LIMIT ?5
+`
+
+type ListPartsParams struct {
+	TenantID        string
+	Category        sql.NullString
+	LifecycleStatus sql.NullString
+	After           string
+	PageSize        int64
+}
+
+// ListParts pages through a tenant's parts in part_id order, like ListSuppliers.
+func (q *Queries) ListParts(ctx context.Context, arg ListPartsParams) ([]DimPartV1, error) {
+	rows, err := q.db.QueryContext(ctx, listParts,
+		arg.TenantID,
+		arg.Category,
+		arg.LifecycleStatus,
+		arg.After,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DimPartV1
+	for rows.Next() {
+		var i DimPartV1
+		if err := rows.Scan(
+			&i.PartID,
+			&i.TenantID,
+			&i.PartNumber,
+			&i.Description,
+			&i.Category,
+			&i.LifecycleStatus,
+			&i.Uom,
+			&i.SpecHash,
+			&i.BomCompatibility,
+			&i.DefaultSupplierID,
+			&i.QualifiedSupplierIds,
+			&i.UnitCost,
+			&i.Moq,
+			&i.LeadTimeDaysAvg,
+			&i.LeadTimeDaysP95,
+			&i.QualityGrade,
+			&i.ComplianceFlags,
+			&i.HazardClass,
+			&i.LastPriceChange,
+			&i.DataSource,
+			&i.SourceTimestamp,
+			&i.IngestionTimestamp,
+			&i.SchemaVersion,
+			&i.ModifiedBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSupplierHistory = `-- name: ListSupplierHistory :many
+SELECT history_id, supplier_id, supplier_code, tenant_id, legal_name, dba_name, country, region, address_line1, address_line2, city, state, postal_code, contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95, on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications, compliance_flags, approved_status, contracts, terms_version, lat, lon, data_source, source_timestamp, ingestion_timestamp, schema_version, valid_from, valid_to, is_current FROM dim_supplier_v1_history
+WHERE tenant_id = ? AND supplier_id = ?
+ORDER BY valid_from, history_id
+`
+
+type ListSupplierHistoryParams struct {
+	TenantID   string
+	SupplierID string
+}
+
+func (q *Queries) ListSupplierHistory(ctx context.Context, arg ListSupplierHistoryParams) ([]DimSupplierV1History, error) {
+	rows, err := q.db.QueryContext(ctx, listSupplierHistory, arg.TenantID, arg.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DimSupplierV1History
+	for rows.Next() {
+		var i DimSupplierV1History
+		if err := rows.Scan(
+			&i.HistoryID,
+			&i.SupplierID,
+			&i.SupplierCode,
+			&i.TenantID,
+			&i.LegalName,
+			&i.DbaName,
+			&i.Country,
+			&i.Region,
+			&i.AddressLine1,
+			&i.AddressLine2,
+			&i.City,
+			&i.State,
+			&i.PostalCode,
+			&i.ContactEmail,
+			&i.ContactPhone,
+			&i.PreferredCurrency,
+			&i.Incoterms,
+			&i.LeadTimeDaysAvg,
+			&i.LeadTimeDaysP95,
+			&i.OnTimeDeliveryRate,
+			&i.DefectRatePpm,
+			&i.CapacityUnitsPerWeek,
+			&i.RiskScore,
+			&i.FinancialRiskTier,
+			&i.Certifications,
+			&i.ComplianceFlags,
+			&i.ApprovedStatus,
+			&i.Contracts,
+			&i.TermsVersion,
+			&i.Lat,
+			&i.Lon,
+			&i.DataSource,
+			&i.SourceTimestamp,
+			&i.IngestionTimestamp,
+			&i.SchemaVersion,
+			&i.ValidFrom,
+			&i.ValidTo,
+			&i.IsCurrent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSupplierParts = `-- name: ListSupplierParts :many
+SELECT dim_part_v1.part_id, dim_part_v1.tenant_id, dim_part_v1.part_number, dim_part_v1.description, dim_part_v1.category, dim_part_v1.lifecycle_status, dim_part_v1.uom, dim_part_v1.spec_hash, dim_part_v1.bom_compatibility, dim_part_v1.default_supplier_id, dim_part_v1.qualified_supplier_ids, dim_part_v1.unit_cost, dim_part_v1.moq, dim_part_v1.lead_time_days_avg, dim_part_v1.lead_time_days_p95, dim_part_v1.quality_grade, dim_part_v1.compliance_flags, dim_part_v1.hazard_class, dim_part_v1.last_price_change, dim_part_v1.data_source, dim_part_v1.source_timestamp, dim_part_v1.ingestion_timestamp, dim_part_v1.schema_version, dim_part_v1.modified_by, dim_part_v1.deleted_at, dim_part_v1.deleted_by, dim_part_v1.version FROM dim_part_v1
+JOIN part_supplier ON part_supplier.part_id = dim_part_v1.part_id AND part_supplier.tenant_id = dim_part_v1.tenant_id
+WHERE part_supplier.tenant_id = ? AND part_supplier.supplier_id = ? AND dim_part_v1.deleted_at IS NULL
+ORDER BY dim_part_v1.part_id
+`
+
+type ListSupplierPartsParams struct {
+	TenantID   string
+	SupplierID string
+}
+
+// ListSupplierParts returns the parts a supplier is qualified for.
+func (q *Queries) ListSupplierParts(ctx context.Context, arg ListSupplierPartsParams) ([]DimPartV1, error) {
+	rows, err := q.db.QueryContext(ctx, listSupplierParts, arg.TenantID, arg.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DimPartV1
+	for rows.Next() {
+		var i DimPartV1
+		if err := rows.Scan(
+			&i.PartID,
+			&i.TenantID,
+			&i.PartNumber,
+			&i.Description,
+			&i.Category,
+			&i.LifecycleStatus,
+			&i.Uom,
+			&i.SpecHash,
+			&i.BomCompatibility,
+			&i.DefaultSupplierID,
+			&i.QualifiedSupplierIds,
+			&i.UnitCost,
+			&i.Moq,
+			&i.LeadTimeDaysAvg,
+			&i.LeadTimeDaysP95,
+			&i.QualityGrade,
+			&i.ComplianceFlags,
+			&i.HazardClass,
+			&i.LastPriceChange,
+			&i.DataSource,
+			&i.SourceTimestamp,
+			&i.IngestionTimestamp,
+			&i.SchemaVersion,
+			&i.ModifiedBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSuppliers = `-- name: ListSuppliers :many
+SELECT supplier_id, supplier_code, tenant_id, legal_name, dba_name, country, region, address_line1, address_line2, city, state, postal_code, contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95, on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications, compliance_flags, approved_status, contracts, terms_version, lat, lon, data_source, source_timestamp, ingestion_timestamp, schema_version, modified_by, deleted_at, deleted_by, version FROM dim_supplier_v1
+WHERE tenant_id = ?1
+    AND deleted_at IS NULL
+    AND (?2 IS NULL OR country = ?2)
+    AND (?3 IS NULL OR region = ?3)
+    AND (?4 IS NULL OR approved_status = ?4)
+    AND (?5 IS NULL OR risk_score >= ?5)
+    AND (?6 IS NULL OR risk_score <= ?6)
+    AND supplier_id > ?7
+ORDER BY supplier_id
+LIMIT ?8
+`
+
+type ListSuppliersParams struct {
+	TenantID       string
+	Country        sql.NullString
+	Region         sql.NullString
+	ApprovedStatus sql.NullString
+	MinRiskScore   sql.NullFloat64
+	MaxRiskScore   sql.NullFloat64
+	After          string
+	PageSize       int64
+}
+
+// ListSuppliers pages through a tenant's suppliers in supplier_id order.
+// Pass the last supplier_id of the previous page as after (empty for the first
+// page); NULL filters are ignored. Soft-deleted suppliers are skipped.
+func (q *Queries) ListSuppliers(ctx context.Context, arg ListSuppliersParams) ([]DimSupplierV1, error) {
+	rows, err := q.db.QueryContext(ctx, listSuppliers,
+		arg.TenantID,
+		arg.Country,
+		arg.Region,
+		arg.ApprovedStatus,
+		arg.MinRiskScore,
+		arg.MaxRiskScore,
+		arg.After,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DimSupplierV1
+	for rows.Next() {
+		var i DimSupplierV1
+		if err := rows.Scan(
+			&i.SupplierID,
+			&i.SupplierCode,
+			&i.TenantID,
+			&i.LegalName,
+			&i.DbaName,
+			&i.Country,
+			&i.Region,
+			&i.AddressLine1,
+			&i.AddressLine2,
+			&i.City,
+			&i.State,
+			&i.PostalCode,
+			&i.ContactEmail,
+			&i.ContactPhone,
+			&i.PreferredCurrency,
+			&i.Incoterms,
+			&i.LeadTimeDaysAvg,
+			&i.LeadTimeDaysP95,
+			&i.OnTimeDeliveryRate,
+			&i.DefectRatePpm,
+			&i.CapacityUnitsPerWeek,
+			&i.RiskScore,
+			&i.FinancialRiskTier,
+			&i.Certifications,
+			&i.ComplianceFlags,
+			&i.ApprovedStatus,
+			&i.Contracts,
+			&i.TermsVersion,
+			&i.Lat,
+			&i.Lon,
+			&i.DataSource,
+			&i.SourceTimestamp,
+			&i.IngestionTimestamp,
+			&i.SchemaVersion,
+			&i.ModifiedBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTenantAuditLog = `-- name: ListTenantAuditLog :many
+SELECT audit_id, occurred_at, actor, action, entity, entity_id, tenant_id, before_json, after_json FROM audit_log
+WHERE tenant_id = ?1 AND audit_id > ?2
+ORDER BY audit_id
+LIMIT ?3
+`
+
+type ListTenantAuditLogParams struct {
+	TenantID string
+	After    int64
+	PageSize int64
+}
+
+// ListTenantAuditLog pages through a tenant's changes in the order they
+// happened. Pass the last audit_id of the previous page as after.
+func (q *Queries) ListTenantAuditLog(ctx context.Context, arg ListTenantAuditLogParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listTenantAuditLog, arg.TenantID, arg.After, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.AuditID,
+			&i.OccurredAt,
+			&i.Actor,
+			&i.Action,
+			&i.Entity,
+			&i.EntityID,
+			&i.TenantID,
+			&i.BeforeJson,
+			&i.AfterJson,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const partExists = `-- name: PartExists :one
+SELECT EXISTS (SELECT 1 FROM dim_part_v1 WHERE tenant_id = ? AND part_id = ?)
+`
+
+type PartExistsParams struct {
+	TenantID string
+	PartID   string
+}
+
+func (q *Queries) PartExists(ctx context.Context, arg PartExistsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, partExists, arg.TenantID, arg.PartID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const purgePart = `-- name: PurgePart :execrows
+DELETE FROM dim_part_v1 WHERE tenant_id = ? AND part_id = ?
+`
+
+type PurgePartParams struct {
+	TenantID string
+	PartID   string
+}
+
+// PurgePart removes a part and its links for good.
+func (q *Queries) PurgePart(ctx context.Context, arg PurgePartParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgePart, arg.TenantID, arg.PartID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeSupplier = `-- name: PurgeSupplier :execrows
+DELETE FROM dim_supplier_v1 WHERE tenant_id = ? AND supplier_id = ?
+`
+
+type PurgeSupplierParams struct {
+	TenantID   string
+	SupplierID string
 }
 
-const deleteSupplier = `-- name: DeleteSupplier :exec
-DELETE FROM dim_supplier_v1 WHERE supplier_id = ?
+// PurgeSupplier removes a supplier for good, applying the foreign key delete
+// policy to its parts and links.
+func (q *Queries) PurgeSupplier(ctx context.Context, arg PurgeSupplierParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeSupplier, arg.TenantID, arg.SupplierID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removePartSupplier = `-- name: RemovePartSupplier :exec
+DELETE FROM part_supplier WHERE tenant_id = ? AND part_id = ? AND supplier_id = ?
 `
 
-func (q *Queries) DeleteSupplier(ctx context.Context, supplierID string) error {
-	_, err := q.db.ExecContext(ctx, deleteSupplier, supplierID)
+type RemovePartSupplierParams struct {
+	TenantID   string
+	PartID     string
+	SupplierID string
+}
+
+func (q *Queries) RemovePartSupplier(ctx context.Context, arg RemovePartSupplierParams) error {
+	_, err := q.db.ExecContext(ctx, removePartSupplier, arg.TenantID, arg.PartID, arg.SupplierID)
 	return err
 }
+
+const restorePart = `-- name: RestorePart :execrows
+UPDATE dim_part_v1
+SET deleted_at = NULL,
+    deleted_by = NULL,
+    modified_by = ?1
+WHERE tenant_id = ?2 AND part_id = ?3 AND deleted_at IS NOT NULL
+`
+
+type RestorePartParams struct {
+	ModifiedBy sql.NullString
+	TenantID   string
+	PartID     string
+}
+
+// RestorePart undoes a soft delete. It returns 0 if the part is not deleted.
+func (q *Queries) RestorePart(ctx context.Context, arg RestorePartParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restorePart, arg.ModifiedBy, arg.TenantID, arg.PartID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreSupplier = `-- name: RestoreSupplier :execrows
+UPDATE dim_supplier_v1
+SET deleted_at = NULL,
+    deleted_by = NULL,
+    modified_by = ?1
+WHERE tenant_id = ?2 AND supplier_id = ?3 AND deleted_at IS NOT NULL
+`
+
+type RestoreSupplierParams struct {
+	ModifiedBy sql.NullString
+	TenantID   string
+	SupplierID string
+}
+
+// RestoreSupplier undoes a soft delete. It returns 0 if the supplier is not
+// deleted.
+func (q *Queries) RestoreSupplier(ctx context.Context, arg RestoreSupplierParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreSupplier, arg.ModifiedBy, arg.TenantID, arg.SupplierID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :execrows
+UPDATE api_key
+SET revoked_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+WHERE key_id = ? AND revoked_at IS NULL
+`
+
+// RevokeAPIKey stops a key from authenticating. It returns 0 if the key does
+// not exist or is already revoked.
+func (q *Queries) RevokeAPIKey(ctx context.Context, keyID string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeAPIKey, keyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchParts = `-- name: SearchParts :many
+SELECT
+    dim_part_v1.part_id,
+    dim_part_v1.part_number,
+    dim_part_v1.description,
+    snippet(part_search, -1, '[', ']', '...', 12) AS snippet,
+    bm25(part_search, 0.0, 5.0, 1.0) AS rank
+FROM part_search
+JOIN dim_part_v1 ON dim_part_v1.part_id = part_search.part_id
+WHERE part_search MATCH ?1
+    AND dim_part_v1.tenant_id = ?2
+    AND dim_part_v1.deleted_at IS NULL
+ORDER BY rank, dim_part_v1.part_id
+LIMIT ?3
+`
+
+type SearchPartsParams struct {
+	Query    string
+	TenantID string
+	PageSize int64
+}
+
+// SearchParts runs an FTS5 query over part number and description within a
+// tenant, best match first. Matches in the part number weigh the most.
+// snippet marks the matched terms with [ and ].
+type SearchPartsRow struct {
+	PartID      string
+	PartNumber  string
+	Description string
+	Snippet     string
+	Rank        float64
+}
+
+func (q *Queries) SearchParts(ctx context.Context, arg SearchPartsParams) ([]SearchPartsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchParts, arg.Query, arg.TenantID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPartsRow
+	for rows.Next() {
+		var i SearchPartsRow
+		if err := rows.Scan(
+			&i.PartID,
+			&i.PartNumber,
+			&i.Description,
+			&i.Snippet,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchSuppliers = `-- name: SearchSuppliers :many
+SELECT
+    dim_supplier_v1.supplier_id,
+    dim_supplier_v1.legal_name,
+    dim_supplier_v1.dba_name,
+    dim_supplier_v1.city,
+    snippet(supplier_search, -1, '[', ']', '...', 12) AS snippet,
+    bm25(supplier_search, 0.0, 10.0, 5.0, 1.0) AS rank
+FROM supplier_search
+JOIN dim_supplier_v1 ON dim_supplier_v1.supplier_id = supplier_search.supplier_id
+WHERE supplier_search MATCH ?1
+    AND dim_supplier_v1.tenant_id = ?2
+    AND dim_supplier_v1.deleted_at IS NULL
+ORDER BY rank, dim_supplier_v1.supplier_id
+LIMIT ?3
+`
+
+type SearchSuppliersParams struct {
+	Query    string
+	TenantID string
+	PageSize int64
+}
+
+// SearchSuppliers runs an FTS5 query over supplier legal name, DBA name and
+// city within a tenant, best match first. Matches in the legal name weigh the
+// most. snippet marks the matched terms with [ and ].
+type SearchSuppliersRow struct {
+	SupplierID string
+	LegalName  string
+	DbaName    sql.NullString
+	City       sql.NullString
+	Snippet    string
+	Rank       float64
+}
+
+func (q *Queries) SearchSuppliers(ctx context.Context, arg SearchSuppliersParams) ([]SearchSuppliersRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchSuppliers, arg.Query, arg.TenantID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchSuppliersRow
+	for rows.Next() {
+		var i SearchSuppliersRow
+		if err := rows.Scan(
+			&i.SupplierID,
+			&i.LegalName,
+			&i.DbaName,
+			&i.City,
+			&i.Snippet,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const summarizeParts = `-- name: SummarizeParts :many
+SELECT
+    category,
+    lifecycle_status,
+    COUNT(*) AS parts,
+    CAST(COALESCE(AVG(unit_cost), 0) AS REAL) AS avg_unit_cost,
+    CAST(COALESCE(AVG(lead_time_days_avg), 0) AS REAL) AS avg_lead_time_days,
+    COUNT(DISTINCT default_supplier_id) AS suppliers
+FROM dim_part_v1
+WHERE tenant_id = ? AND deleted_at IS NULL
+GROUP BY category, lifecycle_status
+ORDER BY category, lifecycle_status
+`
+
+// SummarizeParts aggregates a tenant's parts by category and lifecycle
+// status: how many there are, their average unit cost and lead time, and how
+// many distinct default suppliers they use.
+type SummarizePartsRow struct {
+	Category        sql.NullString
+	LifecycleStatus sql.NullString
+	Parts           int64
+	AvgUnitCost     float64
+	AvgLeadTimeDays float64
+	Suppliers       int64
+}
+
+func (q *Queries) SummarizeParts(ctx context.Context, tenantID string) ([]SummarizePartsRow, error) {
+	rows, err := q.db.QueryContext(ctx, summarizeParts, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SummarizePartsRow
+	for rows.Next() {
+		var i SummarizePartsRow
+		if err := rows.Scan(
+			&i.Category,
+			&i.LifecycleStatus,
+			&i.Parts,
+			&i.AvgUnitCost,
+			&i.AvgLeadTimeDays,
+			&i.Suppliers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const supplierExists = `-- name: SupplierExists :one
+SELECT EXISTS (SELECT 1 FROM dim_supplier_v1 WHERE tenant_id = ? AND supplier_id = ?)
+`
+
+type SupplierExistsParams struct {
+	TenantID   string
+	SupplierID string
+}
+
+func (q *Queries) SupplierExists(ctx context.Context, arg SupplierExistsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, supplierExists, arg.TenantID, arg.SupplierID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const updatePart = `-- name: UpdatePart :execrows
+UPDATE dim_part_v1
+SET part_number = ?1,
+    description = ?2,
+    category = ?3,
+    lifecycle_status = ?4,
+    uom = ?5,
+    spec_hash = ?6,
+    bom_compatibility = ?7,
+    default_supplier_id = ?8,
+    qualified_supplier_ids = ?9,
+    unit_cost = ?10,
+    moq = ?11,
+    lead_time_days_avg = ?12,
+    lead_time_days_p95 = ?13,
+    quality_grade = ?14,
+    compliance_flags = ?15,
+    hazard_class = ?16,
+    last_price_change = ?17,
+    data_source = ?18,
+    source_timestamp = ?19,
+    ingestion_timestamp = ?20,
+    schema_version = ?21,
+    modified_by = ?22
+WHERE tenant_id = ?23 AND part_id = ?24 AND deleted_at IS NULL
+    AND (?25 IS NULL OR version = ?25)
+`
+
+type UpdatePartParams struct {
+	PartNumber           string
+	Description          string
+	Category             sql.NullString
+	LifecycleStatus      sql.NullString
+	Uom                  sql.NullString
+	SpecHash             sql.NullString
+	BomCompatibility     sql.NullString
+	DefaultSupplierID    sql.NullString
+	QualifiedSupplierIds sql.NullString
+	UnitCost             sql.NullFloat64
+	Moq                  sql.NullInt64
+	LeadTimeDaysAvg      sql.NullInt64
+	LeadTimeDaysP95      sql.NullInt64
+	QualityGrade         sql.NullString
+	ComplianceFlags      sql.NullString
+	HazardClass          sql.NullString
+	LastPriceChange      sql.NullTime
+	DataSource           sql.NullString
+	SourceTimestamp      sql.NullTime
+	IngestionTimestamp   sql.NullTime
+	SchemaVersion        sql.NullString
+	ModifiedBy           sql.NullString
+	TenantID             string
+	PartID               string
+	Version              sql.NullInt64
+}
+
+// UpdatePart replaces every field of a live part, like UpdateSupplier.
+func (q *Queries) UpdatePart(ctx context.Context, arg UpdatePartParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updatePart,
+		arg.PartNumber,
+		arg.Description,
+		arg.Category,
+		arg.LifecycleStatus,
+		arg.Uom,
+		arg.SpecHash,
+		arg.BomCompatibility,
+		arg.DefaultSupplierID,
+		arg.QualifiedSupplierIds,
+		arg.UnitCost,
+		arg.Moq,
+		arg.LeadTimeDaysAvg,
+		arg.LeadTimeDaysP95,
+		arg.QualityGrade,
+		arg.ComplianceFlags,
+		arg.HazardClass,
+		arg.LastPriceChange,
+		arg.DataSource,
+		arg.SourceTimestamp,
+		arg.IngestionTimestamp,
+		arg.SchemaVersion,
+		arg.ModifiedBy,
+		arg.TenantID,
+		arg.PartID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateSupplier = `-- name: UpdateSupplier :execrows
+UPDATE dim_supplier_v1
+SET supplier_code = ?1,
+    legal_name = ?2,
+    dba_name = ?3,
+    country = ?4,
+    region = ?5,
+    address_line1 = ?6,
+    address_line2 = ?7,
+    city = ?8,
+    state = ?9,
+    postal_code = ?10,
+    contact_email = ?11,
+    contact_phone = ?12,
+    preferred_currency = ?13,
+    incoterms = ?14,
+    lead_time_days_avg = ?15,
+    lead_time_days_p95 = ?16,
+    on_time_delivery_rate = ?17,
+    defect_rate_ppm = ?18,
+    capacity_units_per_week = ?19,
+    risk_score = ?20,
+    financial_risk_tier = ?21,
+    certifications = ?22,
+    compliance_flags = ?23,
+    approved_status = ?24,
+    contracts = ?25,
+    terms_version = ?26,
+    lat = ?27,
+    lon = ?28,
+    data_source = ?29,
+    source_timestamp = ?30,
+    ingestion_timestamp = ?31,
+    schema_version = ?32,
+    modified_by = ?33
+WHERE tenant_id = ?34 AND supplier_id = ?35 AND deleted_at IS NULL
+    AND (?36 IS NULL OR version = ?36)
+`
+
+type UpdateSupplierParams struct {
+	SupplierCode         sql.NullString
+	LegalName            string
+	DbaName              sql.NullString
+	Country              sql.NullString
+	Region               sql.NullString
+	AddressLine1         sql.NullString
+	AddressLine2         sql.NullString
+	City                 sql.NullString
+	State                sql.NullString
+	PostalCode           sql.NullString
+	ContactEmail         sql.NullString
+	ContactPhone         sql.NullString
+	PreferredCurrency    sql.NullString
+	Incoterms            sql.NullString
+	LeadTimeDaysAvg      sql.NullInt64
+	LeadTimeDaysP95      sql.NullInt64
+	OnTimeDeliveryRate   sql.NullFloat64
+	DefectRatePpm        sql.NullInt64
+	CapacityUnitsPerWeek sql.NullInt64
+	RiskScore            sql.NullFloat64
+	FinancialRiskTier    sql.NullString
+	Certifications       sql.NullString
+	ComplianceFlags      sql.NullString
+	ApprovedStatus       sql.NullString
+	Contracts            sql.NullString
+	TermsVersion         sql.NullString
+	Lat                  sql.NullFloat64
+	Lon                  sql.NullFloat64
+	DataSource           sql.NullString
+	SourceTimestamp      sql.NullTime
+	IngestionTimestamp   sql.NullTime
+	SchemaVersion        sql.NullString
+	ModifiedBy           sql.NullString
+	TenantID             string
+	SupplierID           string
+	Version              sql.NullInt64
+}
+
+// UpdateSupplier replaces every field of a live supplier. It returns 0 if the
+// supplier does not exist, is deleted or belongs to another tenant, or when
+// version is set and the supplier is no longer at that version.
+func (q *Queries) UpdateSupplier(ctx context.Context, arg UpdateSupplierParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateSupplier,
+		arg.SupplierCode,
+		arg.LegalName,
+		arg.DbaName,
+		arg.Country,
+		arg.Region,
+		arg.AddressLine1,
+		arg.AddressLine2,
+		arg.City,
+		arg.State,
+		arg.PostalCode,
+		arg.ContactEmail,
+		arg.ContactPhone,
+		arg.PreferredCurrency,
+		arg.Incoterms,
+		arg.LeadTimeDaysAvg,
+		arg.LeadTimeDaysP95,
+		arg.OnTimeDeliveryRate,
+		arg.DefectRatePpm,
+		arg.CapacityUnitsPerWeek,
+		arg.RiskScore,
+		arg.FinancialRiskTier,
+		arg.Certifications,
+		arg.ComplianceFlags,
+		arg.ApprovedStatus,
+		arg.Contracts,
+		arg.TermsVersion,
+		arg.Lat,
+		arg.Lon,
+		arg.DataSource,
+		arg.SourceTimestamp,
+		arg.IngestionTimestamp,
+		arg.SchemaVersion,
+		arg.ModifiedBy,
+		arg.TenantID,
+		arg.SupplierID,
+		arg.Version,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertPart = `-- name: UpsertPart :execrows
+INSERT INTO dim_part_v1
+    (
+    part_id,
+    tenant_id,
+    part_number,
+    description,
+    category,
+    lifecycle_status,
+    uom,
+    spec_hash,
+    bom_compatibility,
+    default_supplier_id,
+    qualified_supplier_ids,
+    unit_cost,
+    moq,
+    lead_time_days_avg,
+    lead_time_days_p95,
+    quality_grade,
+    compliance_flags,
+    hazard_class,
+    last_price_change,
+    data_source,
+    source_timestamp,
+    ingestion_timestamp,
+    schema_version,
+    modified_by
+    )
+VALUES
+    (
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?
+)
+ON CONFLICT (part_id) DO UPDATE SET
+    tenant_id = excluded.tenant_id,
+    part_number = excluded.part_number,
+    description = excluded.description,
+    category = excluded.category,
+    lifecycle_status = excluded.lifecycle_status,
+    uom = excluded.uom,
+    spec_hash = excluded.spec_hash,
+    bom_compatibility = excluded.bom_compatibility,
+    default_supplier_id = excluded.default_supplier_id,
+    qualified_supplier_ids = excluded.qualified_supplier_ids,
+    unit_cost = excluded.unit_cost,
+    moq = excluded.moq,
+    lead_time_days_avg = excluded.lead_time_days_avg,
+    lead_time_days_p95 = excluded.lead_time_days_p95,
+    quality_grade = excluded.quality_grade,
+    compliance_flags = excluded.compliance_flags,
+    hazard_class = excluded.hazard_class,
+    last_price_change = excluded.last_price_change,
+    data_source = excluded.data_source,
+    source_timestamp = excluded.source_timestamp,
+    ingestion_timestamp = excluded.ingestion_timestamp,
+    schema_version = excluded.schema_version,
+    modified_by = excluded.modified_by
+WHERE dim_part_v1.tenant_id = excluded.tenant_id AND (dim_part_v1.source_timestamp IS NULL
+    OR julianday(excluded.source_timestamp) > julianday(dim_part_v1.source_timestamp))
+`
+
+type UpsertPartParams struct {
+	PartID               string
+	TenantID             string
+	PartNumber           string
+	Description          string
+	Category             sql.NullString
+	LifecycleStatus      sql.NullString
+	Uom                  sql.NullString
+	SpecHash             sql.NullString
+	BomCompatibility     sql.NullString
+	DefaultSupplierID    sql.NullString
+	QualifiedSupplierIds sql.NullString
+	UnitCost             sql.NullFloat64
+	Moq                  sql.NullInt64
+	LeadTimeDaysAvg      sql.NullInt64
+	LeadTimeDaysP95      sql.NullInt64
+	QualityGrade         sql.NullString
+	ComplianceFlags      sql.NullString
+	HazardClass          sql.NullString
+	LastPriceChange      sql.NullTime
+	DataSource           sql.NullString
+	SourceTimestamp      sql.NullTime
+	IngestionTimestamp   sql.NullTime
+	SchemaVersion        sql.NullString
+	ModifiedBy           sql.NullString
+}
+
+func (q *Queries) UpsertPart(ctx context.Context, arg UpsertPartParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertPart,
+		arg.PartID,
+		arg.TenantID,
+		arg.PartNumber,
+		arg.Description,
+		arg.Category,
+		arg.LifecycleStatus,
+		arg.Uom,
+		arg.SpecHash,
+		arg.BomCompatibility,
+		arg.DefaultSupplierID,
+		arg.QualifiedSupplierIds,
+		arg.UnitCost,
+		arg.Moq,
+		arg.LeadTimeDaysAvg,
+		arg.LeadTimeDaysP95,
+		arg.QualityGrade,
+		arg.ComplianceFlags,
+		arg.HazardClass,
+		arg.LastPriceChange,
+		arg.DataSource,
+		arg.SourceTimestamp,
+		arg.IngestionTimestamp,
+		arg.SchemaVersion,
+		arg.ModifiedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upsertSupplier = `-- name: UpsertSupplier :execrows
+INSERT INTO dim_supplier_v1
+    (
+    supplier_id,
+    supplier_code,
+    tenant_id,
+    legal_name,
+    dba_name,
+    country,
+    region,
+    address_line1,
+    address_line2,
+    city,
+    state,
+    postal_code,
+    contact_email,
+    contact_phone,
+    preferred_currency,
+    incoterms,
+    lead_time_days_avg,
+    lead_time_days_p95,
+    on_time_delivery_rate,
+    defect_rate_ppm,
+    capacity_units_per_week,
+    risk_score,
+    financial_risk_tier,
+    certifications,
+    compliance_flags,
+    approved_status,
+    contracts,
+    terms_version,
+    lat,
+    lon,
+    data_source,
+    source_timestamp,
+    ingestion_timestamp,
+    schema_version,
+    modified_by
+    )
+VALUES
+    (
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?, ?
+)
+ON CONFLICT (supplier_id) DO UPDATE SET
+    supplier_code = excluded.supplier_code,
+    tenant_id = excluded.tenant_id,
+    legal_name = excluded.legal_name,
+    dba_name = excluded.dba_name,
+    country = excluded.country,
+    region = excluded.region,
+    address_line1 = excluded.address_line1,
+    address_line2 = excluded.address_line2,
+    city = excluded.city,
+    state = excluded.state,
+    postal_code = excluded.postal_code,
+    contact_email = excluded.contact_email,
+    contact_phone = excluded.contact_phone,
+    preferred_currency = excluded.preferred_currency,
+    incoterms = excluded.incoterms,
+    lead_time_days_avg = excluded.lead_time_days_avg,
+    lead_time_days_p95 = excluded.lead_time_days_p95,
+    on_time_delivery_rate = excluded.on_time_delivery_rate,
+    defect_rate_ppm = excluded.defect_rate_ppm,
+    capacity_units_per_week = excluded.capacity_units_per_week,
+    risk_score = excluded.risk_score,
+    financial_risk_tier = excluded.financial_risk_tier,
+    certifications = excluded.certifications,
+    compliance_flags = excluded.compliance_flags,
+    approved_status = excluded.approved_status,
+    contracts = excluded.contracts,
+    terms_version = excluded.terms_version,
+    lat = excluded.lat,
+    lon = excluded.lon,
+    data_source = excluded.data_source,
+    source_timestamp = excluded.source_timestamp,
+    ingestion_timestamp = excluded.ingestion_timestamp,
+    schema_version = excluded.schema_version,
+    modified_by = excluded.modified_by
+WHERE dim_supplier_v1.tenant_id = excluded.tenant_id AND (dim_supplier_v1.source_timestamp IS NULL
+    OR julianday(excluded.source_timestamp) > julianday(dim_supplier_v1.source_timestamp))
+`
+
+type UpsertSupplierParams struct {
+	SupplierID           string
+	SupplierCode         sql.NullString
+	TenantID             string
+	LegalName            string
+	DbaName              sql.NullString
+	Country              sql.NullString
+	Region               sql.NullString
+	AddressLine1         sql.NullString
+	AddressLine2         sql.NullString
+	City                 sql.NullString
+	State                sql.NullString
+	PostalCode           sql.NullString
+	ContactEmail         sql.NullString
+	ContactPhone         sql.NullString
+	PreferredCurrency    sql.NullString
+	Incoterms            sql.NullString
+	LeadTimeDaysAvg      sql.NullInt64
+	LeadTimeDaysP95      sql.NullInt64
+	OnTimeDeliveryRate   sql.NullFloat64
+	DefectRatePpm        sql.NullInt64
+	CapacityUnitsPerWeek sql.NullInt64
+	RiskScore            sql.NullFloat64
+	FinancialRiskTier    sql.NullString
+	Certifications       sql.NullString
+	ComplianceFlags      sql.NullString
+	ApprovedStatus       sql.NullString
+	Contracts            sql.NullString
+	TermsVersion         sql.NullString
+	Lat                  sql.NullFloat64
+	Lon                  sql.NullFloat64
+	DataSource           sql.NullString
+	SourceTimestamp      sql.NullTime
+	IngestionTimestamp   sql.NullTime
+	SchemaVersion        sql.NullString
+	ModifiedBy           sql.NullString
+}
+
+func (q *Queries) UpsertSupplier(ctx context.Context, arg UpsertSupplierParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, upsertSupplier,
+		arg.SupplierID,
+		arg.SupplierCode,
+		arg.TenantID,
+		arg.LegalName,
+		arg.DbaName,
+		arg.Country,
+		arg.Region,
+		arg.AddressLine1,
+		arg.AddressLine2,
+		arg.City,
+		arg.State,
+		arg.PostalCode,
+		arg.ContactEmail,
+		arg.ContactPhone,
+		arg.PreferredCurrency,
+		arg.Incoterms,
+		arg.LeadTimeDaysAvg,
+		arg.LeadTimeDaysP95,
+		arg.OnTimeDeliveryRate,
+		arg.DefectRatePpm,
+		arg.CapacityUnitsPerWeek,
+		arg.RiskScore,
+		arg.FinancialRiskTier,
+		arg.Certifications,
+		arg.ComplianceFlags,
+		arg.ApprovedStatus,
+		arg.Contracts,
+		arg.TermsVersion,
+		arg.Lat,
+		arg.Lon,
+		arg.DataSource,
+		arg.SourceTimestamp,
+		arg.IngestionTimestamp,
+		arg.SchemaVersion,
+		arg.ModifiedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
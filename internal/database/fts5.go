@@ -0,0 +1,10 @@
+//go:build !sqlite_fts5
+
+package database
+
+// The search migration creates FTS5 tables, which the sqlite3 driver only
+// compiles in with the sqlite_fts5 build tag. Without it every database would
+// fail to migrate at run time, so the build fails here instead with
+// "undefined: sqlite_fts5_build_tag_required". Build, run and test with
+// -tags sqlite_fts5, or set GOFLAGS=-tags=sqlite_fts5.
+var _ = sqlite_fts5_build_tag_required
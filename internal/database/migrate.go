@@ -0,0 +1,393 @@
+// Package database owns the SQLite schema: embedded, versioned migrations and
+// the sqlc-generated queries in the db subpackage.
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Migration is one numbered schema change with its up and down scripts.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Modified is set when the applied checksum differs from the embedded script.
+	Modified bool
+}
+
+// ErrChecksumMismatch is returned when an applied migration was edited after it ran.
+var ErrChecksumMismatch = errors.New("applied migration has been modified")
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migrator applies and reverts migrations against a database. Processes
+// sharing a database, such as the server and the generator, may migrate it
+// at the same time: each migration takes the write lock before checking
+// whether it is still pending.
+type Migrator struct {
+	conn       *sql.DB
+	migrations []Migration
+	// begin starts a migration's transaction. SQLite's takes the write lock
+	// at once, so two processes cannot both find a version pending.
+	begin string
+}
+
+// NewMigrator returns a Migrator for the migrations embedded in this package.
+func NewMigrator(conn *sql.DB) (*Migrator, error) {
+	return newMigrator(conn, migrationsFS, "migrations")
+}
+
+// NewMigratorFS returns a Migrator for the migrations in dir of fsys. Storage
+// backends with their own schema use it.
+func NewMigratorFS(conn *sql.DB, fsys fs.FS, dir string) (*Migrator, error) {
+	return newMigrator(conn, fsys, dir)
+}
+
+func newMigrator(conn *sql.DB, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	begin := "BEGIN TRANSACTION"
+	if _, ok := conn.Driver().(*sqlite3.SQLiteDriver); ok {
+		begin = "BEGIN IMMEDIATE"
+	}
+	return &Migrator{conn: conn, migrations: migrations, begin: begin}, nil
+}
+
+// Migrate applies every pending migration. Binaries call it on startup.
+func Migrate(ctx context.Context, conn *sql.DB) error {
+	m, err := NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(ctx)
+	return err
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		match := migrationFile.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrations returns the known migrations in version order.
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	var exists bool
+	err := m.conn.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')").Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// another process may create it first
+	_, err = m.conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    applied_at DATETIME NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	// databases created from the old schema.sql already have the initial
+	// tables; record them as migrated instead of failing on "already exists"
+	var legacy bool
+	err = m.conn.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'dim_supplier_v1')").Scan(&legacy)
+	if err != nil {
+		return err
+	}
+	if legacy && len(m.migrations) > 0 && m.migrations[0].Version == 1 {
+		first := m.migrations[0]
+		_, err = m.conn.ExecContext(ctx,
+			"INSERT OR IGNORE INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+			first.Version, first.Name, first.Checksum, time.Now().UTC())
+		if err != nil {
+			return fmt.Errorf("baseline legacy schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]appliedMigration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := m.conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		out[version] = a
+	}
+	return out, rows.Err()
+}
+
+// Status lists every migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]MigrationStatus, len(m.migrations))
+	for i, mig := range m.migrations {
+		out[i] = MigrationStatus{Migration: mig}
+		if a, ok := applied[mig.Version]; ok {
+			out[i].Applied = true
+			out[i].AppliedAt = a.appliedAt
+			out[i].Modified = a.checksum != mig.Checksum
+		}
+	}
+	return out, nil
+}
+
+// Version returns the highest applied migration version, or 0 for an empty database.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Up applies all pending migrations and returns the ones it ran.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if len(m.migrations) == 0 {
+		return nil, nil
+	}
+	return m.To(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down reverts the most recent steps applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("invalid step count %d; want at least 1", steps)
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var versions []int
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	target := 0
+	if steps < len(versions) {
+		target = versions[steps]
+	}
+	return m.To(ctx, target)
+}
+
+// To migrates up or down until version is the latest applied migration.
+// Each migration runs in its own transaction. One another process applied or
+// reverted in the meantime is skipped, and not returned.
+func (m *Migrator) To(ctx context.Context, version int) ([]Migration, error) {
+	if version != 0 && m.find(version) == nil {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, mig := range m.migrations {
+		if a, ok := applied[mig.Version]; ok && a.checksum != mig.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+
+	var ran []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok || mig.Version > version {
+			continue
+		}
+		done, err := m.run(ctx, mig, true)
+		if err != nil {
+			return ran, err
+		}
+		if done {
+			ran = append(ran, mig)
+		}
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok || mig.Version <= version {
+			continue
+		}
+		done, err := m.run(ctx, mig, false)
+		if err != nil {
+			return ran, err
+		}
+		if done {
+			ran = append(ran, mig)
+		}
+	}
+	return ran, nil
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// run applies or reverts mig, reporting false when another process already
+// had. It takes the write lock before checking, and holds it to the commit.
+func (m *Migrator) run(ctx context.Context, mig Migration, up bool) (bool, error) {
+	script, direction := mig.Up, "up"
+	if !up {
+		script, direction = mig.Down, "down"
+		if script == "" {
+			return false, fmt.Errorf("migration %d_%s has no down script", mig.Version, mig.Name)
+		}
+	}
+
+	// database/sql's transactions cannot say BEGIN IMMEDIATE, so the
+	// transaction is run by hand on one connection
+	conn, err := m.conn.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, m.begin); err != nil {
+		return false, fmt.Errorf("migration %d_%s %s: lock: %w", mig.Version, mig.Name, direction, err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			conn.ExecContext(context.Background(), "ROLLBACK")
+		}
+	}()
+
+	var applied bool
+	err = conn.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?)", mig.Version).Scan(&applied)
+	if err != nil {
+		return false, err
+	}
+	if applied == up {
+		return false, nil
+	}
+
+	if _, err := conn.ExecContext(ctx, script); err != nil {
+		// the sqlite3 driver only compiles in FTS5 with the sqlite_fts5 tag
+		if strings.Contains(err.Error(), "no such module: fts5") {
+			return false, fmt.Errorf("migration %d_%s %s: %w (build with -tags sqlite_fts5)", mig.Version, mig.Name, direction, err)
+		}
+		return false, fmt.Errorf("migration %d_%s %s: %w", mig.Version, mig.Name, direction, err)
+	}
+	if up {
+		_, err = conn.ExecContext(ctx,
+			"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+			mig.Version, mig.Name, mig.Checksum, time.Now().UTC())
+	} else {
+		_, err = conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", mig.Version)
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return false, err
+	}
+	committed = true
+	return true, nil
+}
@@ -0,0 +1,380 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := Open(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func tableExists(t *testing.T, conn *sql.DB, name string) bool {
+	t.Helper()
+	var exists bool
+	err := conn.QueryRow("SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)", name).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exists
+}
+
+var testMigrations = fstest.MapFS{
+	"m/0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER PRIMARY KEY);")},
+	"m/0001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	"m/0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER PRIMARY KEY);")},
+	"m/0002_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+	"m/0003_create_c.up.sql":   {Data: []byte("CREATE TABLE c (id INTEGER PRIMARY KEY);")},
+	"m/0003_create_c.down.sql": {Data: []byte("DROP TABLE c;")},
+	"m/README.md":              {Data: []byte("ignored")},
+}
+
+func TestMigrateEmbedded(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+
+	if err := Migrate(ctx, conn); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if !tableExists(t, conn, "dim_supplier_v1") || !tableExists(t, conn, "dim_part_v1") {
+		t.Fatal("expected dim tables to exist after migrating")
+	}
+
+	// running again is a no-op
+	m, err := NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ran, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("expected no migrations on second run, got %d", len(ran))
+	}
+
+	// every embedded migration must be reversible
+	if _, err := m.To(ctx, 0); err != nil {
+		t.Fatalf("migrate down to 0: %v", err)
+	}
+	if tableExists(t, conn, "dim_supplier_v1") {
+		t.Error("expected dim_supplier_v1 to be dropped")
+	}
+}
+
+func TestMigrateUpDownTo(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	m, err := newMigrator(conn, testMigrations, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.To(ctx, 2); err != nil {
+		t.Fatalf("to 2: %v", err)
+	}
+	if !tableExists(t, conn, "b") || tableExists(t, conn, "c") {
+		t.Error("expected tables a and b only")
+	}
+
+	ran, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(ran) != 1 || ran[0].Version != 3 {
+		t.Errorf("expected only migration 3 to run, got %+v", ran)
+	}
+
+	if _, err := m.Down(ctx, 2); err != nil {
+		t.Fatalf("down 2: %v", err)
+	}
+	version, err := m.Version(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Errorf("expected version 1, got %d", version)
+	}
+	if tableExists(t, conn, "b") || !tableExists(t, conn, "a") {
+		t.Error("expected only table a after down 2")
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 3 || !status[0].Applied || status[1].Applied || status[2].Applied {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if _, err := m.To(ctx, 9); err == nil {
+		t.Error("expected error for unknown version")
+	}
+	for _, steps := range []int{0, -1} {
+		if _, err := m.Down(ctx, steps); err == nil {
+			t.Errorf("down %d: expected an error", steps)
+		}
+	}
+}
+
+func TestMigrateConcurrently(t *testing.T) {
+	// the server and the generator each open the file and migrate it
+	path := filepath.Join(t.TempDir(), "data.db")
+	errs := make(chan error, 4)
+	ran := make(chan int, 4)
+	for range 4 {
+		go func() {
+			conn, err := Open(path)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer conn.Close()
+			m, err := newMigrator(conn, testMigrations, "m")
+			if err != nil {
+				errs <- err
+				return
+			}
+			migrations, err := m.Up(context.Background())
+			ran <- len(migrations)
+			errs <- err
+		}()
+	}
+	total := 0
+	for range 4 {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	close(ran)
+	for n := range ran {
+		total += n
+	}
+	if total != 3 {
+		t.Errorf("expected each migration to run once, ran %d", total)
+	}
+}
+
+func TestMigrateChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	m, err := newMigrator(conn, testMigrations, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.To(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := fstest.MapFS{}
+	for k, v := range testMigrations {
+		edited[k] = v
+	}
+	edited["m/0001_create_a.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE a (id INTEGER PRIMARY KEY, x TEXT);")}
+
+	m, err = newMigrator(conn, edited, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[0].Modified {
+		t.Error("expected migration 1 to be reported as modified")
+	}
+}
+
+func TestMigrateFailureRollsBack(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	m, err := newMigrator(conn, fstest.MapFS{
+		"m/0001_broken.up.sql": {Data: []byte("CREATE TABLE ok (id INTEGER); CREATE TABLE nope (;")},
+	}, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err == nil {
+		t.Fatal("expected broken migration to fail")
+	}
+	if tableExists(t, conn, "ok") {
+		t.Error("expected failed migration to be rolled back")
+	}
+}
+
+func TestMigrateBaselinesLegacySchema(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+
+	// simulate a database created by the old schema.sql exec
+	legacy, err := os.ReadFile("migrations/0001_initial_schema.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(string(legacy)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(ctx, conn); err != nil {
+		t.Fatalf("migrate legacy database: %v", err)
+	}
+}
+
+func TestMigratePartSupplierBackfill(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	m, err := NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.To(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// databases written before foreign keys were enabled can hold dangling references
+	_, err = conn.Exec(`
+		PRAGMA foreign_keys = OFF;
+		INSERT INTO dim_supplier_v1 (supplier_id, tenant_id, legal_name) VALUES ('S1', 't', 'one'), ('S2', 't', 'two');
+		INSERT INTO dim_part_v1 (part_id, tenant_id, part_number, description, default_supplier_id, qualified_supplier_ids)
+		VALUES ('P1', 't', 'P-1', 'bolt', 'S1', '[S1 S2 S9]'), ('P2', 't', 'P-2', 'nut', 'S9', NULL);
+		PRAGMA foreign_keys = ON;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+
+	rows, err := conn.Query("SELECT part_id || ':' || supplier_id FROM part_supplier ORDER BY 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var links []string
+	for rows.Next() {
+		var link string
+		if err := rows.Scan(&link); err != nil {
+			t.Fatal(err)
+		}
+		links = append(links, link)
+	}
+	rows.Close()
+	if got := strings.Join(links, ","); got != "P1:S1,P1:S2" {
+		t.Errorf("unexpected backfilled links %q", got)
+	}
+
+	var orphan sql.NullString
+	if err := conn.QueryRow("SELECT default_supplier_id FROM dim_part_v1 WHERE part_id = 'P2'").Scan(&orphan); err != nil {
+		t.Fatal(err)
+	}
+	if orphan.Valid {
+		t.Errorf("expected dangling default supplier to be cleared, got %q", orphan.String)
+	}
+
+	// reverting writes the links, as they are now, back into the list
+	_, err = conn.Exec(`
+		DELETE FROM part_supplier WHERE part_id = 'P1' AND supplier_id = 'S1';
+		INSERT INTO part_supplier (part_id, supplier_id, tenant_id) VALUES ('P2', 'S2', 't'), ('P2', 'S1', 't');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.To(ctx, 2); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	for part, want := range map[string]string{"P1": "[S2]", "P2": "[S1 S2]"} {
+		var got sql.NullString
+		if err := conn.QueryRow("SELECT qualified_supplier_ids FROM dim_part_v1 WHERE part_id = ?", part).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.String != want {
+			t.Errorf("%s: expected qualified_supplier_ids %q after down, got %q", part, want, got.String)
+		}
+	}
+}
+
+func TestMigratePartSupplierTenant(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	m, err := NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.To(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`
+		INSERT INTO dim_supplier_v1 (supplier_id, tenant_id, legal_name) VALUES ('S1', 'a', 'one'), ('S2', 'b', 'two');
+		INSERT INTO dim_part_v1 (part_id, tenant_id, part_number, description) VALUES ('P1', 'a', 'P-1', 'bolt'), ('P2', 'b', 'P-2', 'nut');
+		INSERT INTO part_supplier (part_id, supplier_id, tenant_id) VALUES ('P1', 'S1', 'a'), ('P1', 'S2', 'a'), ('P2', 'S1', 'b');`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	var links string
+	if err := conn.QueryRow("SELECT group_concat(part_id || ':' || supplier_id || ':' || tenant_id) FROM part_supplier").Scan(&links); err != nil {
+		t.Fatal(err)
+	}
+	if links != "P1:S1:a" {
+		t.Errorf("expected only the link within tenant a to stay, got %q", links)
+	}
+	if _, err := conn.Exec("INSERT INTO part_supplier (part_id, supplier_id, tenant_id) VALUES ('P1', 'S2', 'a')"); err == nil {
+		t.Error("expected a link to another tenant's supplier to be refused")
+	}
+	if _, err := conn.Exec("UPDATE part_supplier SET supplier_id = 'S2'"); err == nil {
+		t.Error("expected moving a link to another tenant's supplier to be refused")
+	}
+
+	if _, err := m.To(ctx, 7); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if _, err := conn.Exec("INSERT INTO part_supplier (part_id, supplier_id, tenant_id) VALUES ('P1', 'S2', 'a')"); err != nil {
+		t.Errorf("expected the triggers to be dropped on down: %v", err)
+	}
+}
+
+func TestMigrateSearchBackfill(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	m, err := NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.To(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	q := db.New(conn)
+	if _, err := q.CreateSupplier(ctx, db.CreateSupplierParams{SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Acme"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// rows loaded before the search index existed are indexed by the migration
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := q.SearchSuppliers(ctx, db.SearchSuppliersParams{Query: SearchQuery("acme"), TenantID: "tenant_acme", PageSize: 10})
+	if err != nil || len(rows) != 1 {
+		t.Errorf("expected existing supplier to be indexed, got %+v %v", rows, err)
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE dim_part_v1;
+
+DROP TABLE dim_supplier_v1;
@@ -0,0 +1,22 @@
+DROP INDEX idx_part_default_supplier;
+
+DROP INDEX idx_part_tenant_lifecycle_status;
+
+DROP INDEX idx_part_tenant_category;
+
+DROP INDEX idx_part_tenant_part_number;
+
+DROP INDEX idx_part_tenant;
+
+
+DROP INDEX idx_supplier_tenant_risk_score;
+
+DROP INDEX idx_supplier_tenant_approved_status;
+
+DROP INDEX idx_supplier_tenant_region;
+
+DROP INDEX idx_supplier_tenant_country;
+
+DROP INDEX idx_supplier_code;
+
+DROP INDEX idx_supplier_tenant;
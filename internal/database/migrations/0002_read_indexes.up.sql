@@ -0,0 +1,22 @@
+CREATE INDEX idx_supplier_tenant ON dim_supplier_v1 (tenant_id, supplier_id);
+
+CREATE INDEX idx_supplier_code ON dim_supplier_v1 (supplier_code);
+
+CREATE INDEX idx_supplier_tenant_country ON dim_supplier_v1 (tenant_id, country);
+
+CREATE INDEX idx_supplier_tenant_region ON dim_supplier_v1 (tenant_id, region);
+
+CREATE INDEX idx_supplier_tenant_approved_status ON dim_supplier_v1 (tenant_id, approved_status);
+
+CREATE INDEX idx_supplier_tenant_risk_score ON dim_supplier_v1 (tenant_id, risk_score);
+
+
+CREATE INDEX idx_part_tenant ON dim_part_v1 (tenant_id, part_id);
+
+CREATE INDEX idx_part_tenant_part_number ON dim_part_v1 (tenant_id, part_number);
+
+CREATE INDEX idx_part_tenant_category ON dim_part_v1 (tenant_id, category);
+
+CREATE INDEX idx_part_tenant_lifecycle_status ON dim_part_v1 (tenant_id, lifecycle_status);
+
+CREATE INDEX idx_part_default_supplier ON dim_part_v1 (default_supplier_id);
@@ -0,0 +1,55 @@
+-- Write the links back into the stringified "[id1 id2 ...]" list. Parts
+-- without links keep the list they have, which may name suppliers the
+-- backfill skipped.
+UPDATE dim_part_v1
+SET qualified_supplier_ids = (
+    SELECT '[' || group_concat(supplier_id, ' ') || ']'
+    FROM (SELECT supplier_id FROM part_supplier WHERE part_supplier.part_id = dim_part_v1.part_id ORDER BY supplier_id)
+)
+WHERE part_id IN (SELECT part_id FROM part_supplier);
+
+DROP TABLE part_supplier;
+
+CREATE TABLE dim_part_v1_old
+(
+    part_id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    part_number TEXT NOT NULL,
+    description TEXT NOT NULL,
+    category TEXT,
+    lifecycle_status TEXT,
+    uom TEXT,
+    spec_hash TEXT,
+    bom_compatibility TEXT,
+    default_supplier_id TEXT,
+    qualified_supplier_ids TEXT,
+    unit_cost REAL,
+    moq INTEGER,
+    lead_time_days_avg INTEGER,
+    lead_time_days_p95 INTEGER,
+    quality_grade TEXT,
+    compliance_flags TEXT,
+    hazard_class TEXT,
+    last_price_change DATE,
+    data_source TEXT,
+    source_timestamp DATETIME,
+    ingestion_timestamp DATETIME,
+    schema_version TEXT,
+    FOREIGN KEY(default_supplier_id) REFERENCES dim_supplier_v1(supplier_id)
+);
+
+INSERT INTO dim_part_v1_old SELECT * FROM dim_part_v1;
+
+DROP TABLE dim_part_v1;
+
+ALTER TABLE dim_part_v1_old RENAME TO dim_part_v1;
+
+CREATE INDEX idx_part_tenant ON dim_part_v1 (tenant_id, part_id);
+
+CREATE INDEX idx_part_tenant_part_number ON dim_part_v1 (tenant_id, part_number);
+
+CREATE INDEX idx_part_tenant_category ON dim_part_v1 (tenant_id, category);
+
+CREATE INDEX idx_part_tenant_lifecycle_status ON dim_part_v1 (tenant_id, lifecycle_status);
+
+CREATE INDEX idx_part_default_supplier ON dim_part_v1 (default_supplier_id);
@@ -0,0 +1,84 @@
+-- Rebuild dim_part_v1 so deleting a supplier clears default_supplier_id
+-- instead of leaving the part pointing at a missing row. Parts that already
+-- point at a missing supplier are cleared on the way.
+CREATE TABLE dim_part_v1_new
+(
+    part_id TEXT PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    part_number TEXT NOT NULL,
+    description TEXT NOT NULL,
+    category TEXT,
+    lifecycle_status TEXT,
+    uom TEXT,
+    spec_hash TEXT,
+    bom_compatibility TEXT,
+    default_supplier_id TEXT,
+    qualified_supplier_ids TEXT,
+    unit_cost REAL,
+    moq INTEGER,
+    lead_time_days_avg INTEGER,
+    lead_time_days_p95 INTEGER,
+    quality_grade TEXT,
+    compliance_flags TEXT,
+    hazard_class TEXT,
+    last_price_change DATE,
+    data_source TEXT,
+    source_timestamp DATETIME,
+    ingestion_timestamp DATETIME,
+    schema_version TEXT,
+    FOREIGN KEY(default_supplier_id) REFERENCES dim_supplier_v1(supplier_id) ON DELETE SET NULL
+);
+
+INSERT INTO dim_part_v1_new
+SELECT
+    part_id, tenant_id, part_number, description, category, lifecycle_status, uom, spec_hash,
+    bom_compatibility,
+    CASE WHEN default_supplier_id IN (SELECT supplier_id FROM dim_supplier_v1) THEN default_supplier_id END,
+    qualified_supplier_ids, unit_cost, moq, lead_time_days_avg, lead_time_days_p95, quality_grade,
+    compliance_flags, hazard_class, last_price_change, data_source, source_timestamp,
+    ingestion_timestamp, schema_version
+FROM dim_part_v1;
+
+DROP TABLE dim_part_v1;
+
+ALTER TABLE dim_part_v1_new RENAME TO dim_part_v1;
+
+CREATE INDEX idx_part_tenant ON dim_part_v1 (tenant_id, part_id);
+
+CREATE INDEX idx_part_tenant_part_number ON dim_part_v1 (tenant_id, part_number);
+
+CREATE INDEX idx_part_tenant_category ON dim_part_v1 (tenant_id, category);
+
+CREATE INDEX idx_part_tenant_lifecycle_status ON dim_part_v1 (tenant_id, lifecycle_status);
+
+CREATE INDEX idx_part_default_supplier ON dim_part_v1 (default_supplier_id);
+
+
+-- part_supplier lists the suppliers qualified for each part. Rows go away
+-- with either side.
+CREATE TABLE part_supplier
+(
+    part_id TEXT NOT NULL,
+    supplier_id TEXT NOT NULL,
+    tenant_id TEXT NOT NULL,
+    PRIMARY KEY (part_id, supplier_id),
+    FOREIGN KEY(part_id) REFERENCES dim_part_v1(part_id) ON DELETE CASCADE,
+    FOREIGN KEY(supplier_id) REFERENCES dim_supplier_v1(supplier_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_part_supplier_supplier ON part_supplier (supplier_id, part_id);
+
+-- Backfill from the stringified "[id1 id2 ...]" list, skipping unknown suppliers.
+INSERT INTO part_supplier (part_id, supplier_id, tenant_id)
+WITH RECURSIVE split(part_id, tenant_id, rest, supplier_id) AS (
+    SELECT part_id, tenant_id, trim(qualified_supplier_ids, '[]') || ' ', ''
+    FROM dim_part_v1
+    WHERE qualified_supplier_ids IS NOT NULL
+    UNION ALL
+    SELECT part_id, tenant_id, substr(rest, instr(rest, ' ') + 1), substr(rest, 1, instr(rest, ' ') - 1)
+    FROM split
+    WHERE rest <> ''
+)
+SELECT DISTINCT part_id, supplier_id, tenant_id
+FROM split
+WHERE supplier_id IN (SELECT supplier_id FROM dim_supplier_v1);
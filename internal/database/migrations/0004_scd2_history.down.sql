@@ -0,0 +1,16 @@
+DROP TRIGGER dim_part_v1_history_delete;
+
+DROP TRIGGER dim_part_v1_history_update;
+
+DROP TRIGGER dim_part_v1_history_insert;
+
+DROP TABLE dim_part_v1_history;
+
+
+DROP TRIGGER dim_supplier_v1_history_delete;
+
+DROP TRIGGER dim_supplier_v1_history_update;
+
+DROP TRIGGER dim_supplier_v1_history_insert;
+
+DROP TABLE dim_supplier_v1_history;
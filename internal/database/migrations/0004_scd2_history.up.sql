@@ -0,0 +1,291 @@
+-- Type 2 history: every version of a supplier or part with the interval it
+-- was valid for. Triggers open a version when a row is inserted, close it and
+-- open a new one when a tracked attribute changes, and close it when the row
+-- is deleted. Load metadata (data_source, timestamps, schema_version) is not
+-- tracked. A change takes effect at the new source_timestamp, or now when the
+-- update did not come with one (local edits, ON DELETE SET NULL).
+-- valid_from/valid_to are UTC text so they compare as strings.
+CREATE TABLE dim_supplier_v1_history
+(
+    history_id INTEGER PRIMARY KEY,
+    supplier_id TEXT NOT NULL,
+    supplier_code TEXT,
+    tenant_id TEXT NOT NULL,
+    legal_name TEXT NOT NULL,
+    dba_name TEXT,
+    country TEXT,
+    region TEXT,
+    address_line1 TEXT,
+    address_line2 TEXT,
+    city TEXT,
+    state TEXT,
+    postal_code TEXT,
+    contact_email TEXT,
+    contact_phone TEXT,
+    preferred_currency TEXT,
+    incoterms TEXT,
+    lead_time_days_avg INTEGER,
+    lead_time_days_p95 INTEGER,
+    on_time_delivery_rate REAL,
+    defect_rate_ppm INTEGER,
+    capacity_units_per_week INTEGER,
+    risk_score REAL,
+    financial_risk_tier TEXT,
+    certifications TEXT,
+    compliance_flags TEXT,
+    approved_status TEXT,
+    contracts TEXT,
+    terms_version TEXT,
+    lat REAL,
+    lon REAL,
+    data_source TEXT,
+    source_timestamp DATETIME,
+    ingestion_timestamp DATETIME,
+    schema_version TEXT,
+    valid_from DATETIME NOT NULL,
+    valid_to DATETIME,
+    is_current BOOLEAN NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX idx_dim_supplier_v1_history_current ON dim_supplier_v1_history (supplier_id) WHERE is_current;
+
+CREATE INDEX idx_dim_supplier_v1_history_valid ON dim_supplier_v1_history (supplier_id, valid_from);
+
+INSERT INTO dim_supplier_v1_history
+    (
+        supplier_id, supplier_code, tenant_id, legal_name, dba_name, country,
+        region, address_line1, address_line2, city, state, postal_code,
+        contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95,
+        on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications,
+        compliance_flags, approved_status, contracts, terms_version, lat, lon,
+        data_source, source_timestamp, ingestion_timestamp, schema_version,
+        valid_from
+    )
+SELECT
+        supplier_id, supplier_code, tenant_id, legal_name, dba_name, country,
+        region, address_line1, address_line2, city, state, postal_code,
+        contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95,
+        on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications,
+        compliance_flags, approved_status, contracts, terms_version, lat, lon,
+        data_source, source_timestamp, ingestion_timestamp, schema_version,
+        strftime('%Y-%m-%d %H:%M:%f', COALESCE(source_timestamp, ingestion_timestamp, 'now'))
+FROM dim_supplier_v1;
+
+CREATE TRIGGER dim_supplier_v1_history_insert AFTER INSERT ON dim_supplier_v1
+BEGIN
+    INSERT INTO dim_supplier_v1_history
+    (
+        supplier_id, supplier_code, tenant_id, legal_name, dba_name, country,
+        region, address_line1, address_line2, city, state, postal_code,
+        contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95,
+        on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications,
+        compliance_flags, approved_status, contracts, terms_version, lat, lon,
+        data_source, source_timestamp, ingestion_timestamp, schema_version,
+        valid_from
+    )
+    VALUES
+    (
+        NEW.supplier_id, NEW.supplier_code, NEW.tenant_id, NEW.legal_name, NEW.dba_name, NEW.country,
+        NEW.region, NEW.address_line1, NEW.address_line2, NEW.city, NEW.state, NEW.postal_code,
+        NEW.contact_email, NEW.contact_phone, NEW.preferred_currency, NEW.incoterms, NEW.lead_time_days_avg, NEW.lead_time_days_p95,
+        NEW.on_time_delivery_rate, NEW.defect_rate_ppm, NEW.capacity_units_per_week, NEW.risk_score, NEW.financial_risk_tier, NEW.certifications,
+        NEW.compliance_flags, NEW.approved_status, NEW.contracts, NEW.terms_version, NEW.lat, NEW.lon,
+        NEW.data_source, NEW.source_timestamp, NEW.ingestion_timestamp, NEW.schema_version,
+        strftime('%Y-%m-%d %H:%M:%f', COALESCE(NEW.source_timestamp, 'now'))
+    );
+END;
+
+CREATE TRIGGER dim_supplier_v1_history_update AFTER UPDATE ON dim_supplier_v1
+WHEN OLD.supplier_code IS NOT NEW.supplier_code
+    OR OLD.tenant_id IS NOT NEW.tenant_id
+    OR OLD.legal_name IS NOT NEW.legal_name
+    OR OLD.dba_name IS NOT NEW.dba_name
+    OR OLD.country IS NOT NEW.country
+    OR OLD.region IS NOT NEW.region
+    OR OLD.address_line1 IS NOT NEW.address_line1
+    OR OLD.address_line2 IS NOT NEW.address_line2
+    OR OLD.city IS NOT NEW.city
+    OR OLD.state IS NOT NEW.state
+    OR OLD.postal_code IS NOT NEW.postal_code
+    OR OLD.contact_email IS NOT NEW.contact_email
+    OR OLD.contact_phone IS NOT NEW.contact_phone
+    OR OLD.preferred_currency IS NOT NEW.preferred_currency
+    OR OLD.incoterms IS NOT NEW.incoterms
+    OR OLD.lead_time_days_avg IS NOT NEW.lead_time_days_avg
+    OR OLD.lead_time_days_p95 IS NOT NEW.lead_time_days_p95
+    OR OLD.on_time_delivery_rate IS NOT NEW.on_time_delivery_rate
+    OR OLD.defect_rate_ppm IS NOT NEW.defect_rate_ppm
+    OR OLD.capacity_units_per_week IS NOT NEW.capacity_units_per_week
+    OR OLD.risk_score IS NOT NEW.risk_score
+    OR OLD.financial_risk_tier IS NOT NEW.financial_risk_tier
+    OR OLD.certifications IS NOT NEW.certifications
+    OR OLD.compliance_flags IS NOT NEW.compliance_flags
+    OR OLD.approved_status IS NOT NEW.approved_status
+    OR OLD.contracts IS NOT NEW.contracts
+    OR OLD.terms_version IS NOT NEW.terms_version
+    OR OLD.lat IS NOT NEW.lat
+    OR OLD.lon IS NOT NEW.lon
+BEGIN
+    UPDATE dim_supplier_v1_history
+    SET valid_to = max(strftime('%Y-%m-%d %H:%M:%f', CASE WHEN NEW.source_timestamp IS NOT OLD.source_timestamp THEN COALESCE(NEW.source_timestamp, 'now') ELSE 'now' END), valid_from),
+        is_current = 0
+    WHERE supplier_id = NEW.supplier_id AND is_current;
+
+    INSERT INTO dim_supplier_v1_history
+    (
+        supplier_id, supplier_code, tenant_id, legal_name, dba_name, country,
+        region, address_line1, address_line2, city, state, postal_code,
+        contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95,
+        on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications,
+        compliance_flags, approved_status, contracts, terms_version, lat, lon,
+        data_source, source_timestamp, ingestion_timestamp, schema_version,
+        valid_from
+    )
+    VALUES
+    (
+        NEW.supplier_id, NEW.supplier_code, NEW.tenant_id, NEW.legal_name, NEW.dba_name, NEW.country,
+        NEW.region, NEW.address_line1, NEW.address_line2, NEW.city, NEW.state, NEW.postal_code,
+        NEW.contact_email, NEW.contact_phone, NEW.preferred_currency, NEW.incoterms, NEW.lead_time_days_avg, NEW.lead_time_days_p95,
+        NEW.on_time_delivery_rate, NEW.defect_rate_ppm, NEW.capacity_units_per_week, NEW.risk_score, NEW.financial_risk_tier, NEW.certifications,
+        NEW.compliance_flags, NEW.approved_status, NEW.contracts, NEW.terms_version, NEW.lat, NEW.lon,
+        NEW.data_source, NEW.source_timestamp, NEW.ingestion_timestamp, NEW.schema_version,
+        max(
+            strftime('%Y-%m-%d %H:%M:%f', CASE WHEN NEW.source_timestamp IS NOT OLD.source_timestamp THEN COALESCE(NEW.source_timestamp, 'now') ELSE 'now' END),
+            COALESCE((SELECT max(valid_to) FROM dim_supplier_v1_history WHERE supplier_id = NEW.supplier_id), '')
+        )
+    );
+END;
+
+CREATE TRIGGER dim_supplier_v1_history_delete AFTER DELETE ON dim_supplier_v1
+BEGIN
+    UPDATE dim_supplier_v1_history
+    SET valid_to = max(strftime('%Y-%m-%d %H:%M:%f', 'now'), valid_from),
+        is_current = 0
+    WHERE supplier_id = OLD.supplier_id AND is_current;
+END;
+
+
+CREATE TABLE dim_part_v1_history
+(
+    history_id INTEGER PRIMARY KEY,
+    part_id TEXT NOT NULL,
+    tenant_id TEXT NOT NULL,
+    part_number TEXT NOT NULL,
+    description TEXT NOT NULL,
+    category TEXT,
+    lifecycle_status TEXT,
+    uom TEXT,
+    spec_hash TEXT,
+    bom_compatibility TEXT,
+    default_supplier_id TEXT,
+    qualified_supplier_ids TEXT,
+    unit_cost REAL,
+    moq INTEGER,
+    lead_time_days_avg INTEGER,
+    lead_time_days_p95 INTEGER,
+    quality_grade TEXT,
+    compliance_flags TEXT,
+    hazard_class TEXT,
+    last_price_change DATE,
+    data_source TEXT,
+    source_timestamp DATETIME,
+    ingestion_timestamp DATETIME,
+    schema_version TEXT,
+    valid_from DATETIME NOT NULL,
+    valid_to DATETIME,
+    is_current BOOLEAN NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX idx_dim_part_v1_history_current ON dim_part_v1_history (part_id) WHERE is_current;
+
+CREATE INDEX idx_dim_part_v1_history_valid ON dim_part_v1_history (part_id, valid_from);
+
+INSERT INTO dim_part_v1_history
+    (
+        part_id, tenant_id, part_number, description, category, lifecycle_status,
+        uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost,
+        moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class,
+        last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version,
+        valid_from
+    )
+SELECT
+        part_id, tenant_id, part_number, description, category, lifecycle_status,
+        uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost,
+        moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class,
+        last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version,
+        strftime('%Y-%m-%d %H:%M:%f', COALESCE(source_timestamp, ingestion_timestamp, 'now'))
+FROM dim_part_v1;
+
+CREATE TRIGGER dim_part_v1_history_insert AFTER INSERT ON dim_part_v1
+BEGIN
+    INSERT INTO dim_part_v1_history
+    (
+        part_id, tenant_id, part_number, description, category, lifecycle_status,
+        uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost,
+        moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class,
+        last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version,
+        valid_from
+    )
+    VALUES
+    (
+        NEW.part_id, NEW.tenant_id, NEW.part_number, NEW.description, NEW.category, NEW.lifecycle_status,
+        NEW.uom, NEW.spec_hash, NEW.bom_compatibility, NEW.default_supplier_id, NEW.qualified_supplier_ids, NEW.unit_cost,
+        NEW.moq, NEW.lead_time_days_avg, NEW.lead_time_days_p95, NEW.quality_grade, NEW.compliance_flags, NEW.hazard_class,
+        NEW.last_price_change, NEW.data_source, NEW.source_timestamp, NEW.ingestion_timestamp, NEW.schema_version,
+        strftime('%Y-%m-%d %H:%M:%f', COALESCE(NEW.source_timestamp, 'now'))
+    );
+END;
+
+CREATE TRIGGER dim_part_v1_history_update AFTER UPDATE ON dim_part_v1
+WHEN OLD.tenant_id IS NOT NEW.tenant_id
+    OR OLD.part_number IS NOT NEW.part_number
+    OR OLD.description IS NOT NEW.description
+    OR OLD.category IS NOT NEW.category
+    OR OLD.lifecycle_status IS NOT NEW.lifecycle_status
+    OR OLD.uom IS NOT NEW.uom
+    OR OLD.spec_hash IS NOT NEW.spec_hash
+    OR OLD.bom_compatibility IS NOT NEW.bom_compatibility
+    OR OLD.default_supplier_id IS NOT NEW.default_supplier_id
+    OR OLD.qualified_supplier_ids IS NOT NEW.qualified_supplier_ids
+    OR OLD.unit_cost IS NOT NEW.unit_cost
+    OR OLD.moq IS NOT NEW.moq
+    OR OLD.lead_time_days_avg IS NOT NEW.lead_time_days_avg
+    OR OLD.lead_time_days_p95 IS NOT NEW.lead_time_days_p95
+    OR OLD.quality_grade IS NOT NEW.quality_grade
+    OR OLD.compliance_flags IS NOT NEW.compliance_flags
+    OR OLD.hazard_class IS NOT NEW.hazard_class
+    OR OLD.last_price_change IS NOT NEW.last_price_change
+BEGIN
+    UPDATE dim_part_v1_history
+    SET valid_to = max(strftime('%Y-%m-%d %H:%M:%f', CASE WHEN NEW.source_timestamp IS NOT OLD.source_timestamp THEN COALESCE(NEW.source_timestamp, 'now') ELSE 'now' END), valid_from),
+        is_current = 0
+    WHERE part_id = NEW.part_id AND is_current;
+
+    INSERT INTO dim_part_v1_history
+    (
+        part_id, tenant_id, part_number, description, category, lifecycle_status,
+        uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost,
+        moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class,
+        last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version,
+        valid_from
+    )
+    VALUES
+    (
+        NEW.part_id, NEW.tenant_id, NEW.part_number, NEW.description, NEW.category, NEW.lifecycle_status,
+        NEW.uom, NEW.spec_hash, NEW.bom_compatibility, NEW.default_supplier_id, NEW.qualified_supplier_ids, NEW.unit_cost,
+        NEW.moq, NEW.lead_time_days_avg, NEW.lead_time_days_p95, NEW.quality_grade, NEW.compliance_flags, NEW.hazard_class,
+        NEW.last_price_change, NEW.data_source, NEW.source_timestamp, NEW.ingestion_timestamp, NEW.schema_version,
+        max(
+            strftime('%Y-%m-%d %H:%M:%f', CASE WHEN NEW.source_timestamp IS NOT OLD.source_timestamp THEN COALESCE(NEW.source_timestamp, 'now') ELSE 'now' END),
+            COALESCE((SELECT max(valid_to) FROM dim_part_v1_history WHERE part_id = NEW.part_id), '')
+        )
+    );
+END;
+
+CREATE TRIGGER dim_part_v1_history_delete AFTER DELETE ON dim_part_v1
+BEGIN
+    UPDATE dim_part_v1_history
+    SET valid_to = max(strftime('%Y-%m-%d %H:%M:%f', 'now'), valid_from),
+        is_current = 0
+    WHERE part_id = OLD.part_id AND is_current;
+END;
@@ -0,0 +1,406 @@
+-- Soft delete: deleted_at/deleted_by mark a row as deleted without removing
+-- it. modified_by records who made the last write and is set by every writer.
+ALTER TABLE dim_supplier_v1 ADD COLUMN modified_by TEXT;
+
+ALTER TABLE dim_supplier_v1 ADD COLUMN deleted_at DATETIME;
+
+ALTER TABLE dim_supplier_v1 ADD COLUMN deleted_by TEXT;
+
+ALTER TABLE dim_part_v1 ADD COLUMN modified_by TEXT;
+
+ALTER TABLE dim_part_v1 ADD COLUMN deleted_at DATETIME;
+
+ALTER TABLE dim_part_v1 ADD COLUMN deleted_by TEXT;
+
+
+-- audit_log records every change to suppliers, parts and part_supplier links
+-- with the row before and after as JSON. Soft deletes and restores are logged
+-- as delete and restore; removing a row for good is logged as purge.
+CREATE TABLE audit_log
+(
+    audit_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    occurred_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    actor TEXT NOT NULL,
+    action TEXT NOT NULL,
+    entity TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    tenant_id TEXT NOT NULL,
+    before_json TEXT,
+    after_json TEXT
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log (entity, entity_id, audit_id);
+
+CREATE INDEX idx_audit_log_tenant ON audit_log (tenant_id, audit_id);
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER dim_supplier_v1_audit_insert AFTER INSERT ON dim_supplier_v1
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, tenant_id, before_json, after_json)
+    VALUES (
+        COALESCE(NEW.modified_by, 'system'), 'create', 'supplier', NEW.supplier_id, NEW.tenant_id, NULL,
+        json_object(
+            'supplier_id', NEW.supplier_id, 'supplier_code', NEW.supplier_code, 'tenant_id', NEW.tenant_id,
+            'legal_name', NEW.legal_name, 'dba_name', NEW.dba_name, 'country', NEW.country,
+            'region', NEW.region, 'address_line1', NEW.address_line1, 'address_line2', NEW.address_line2,
+            'city', NEW.city, 'state', NEW.state, 'postal_code', NEW.postal_code,
+            'contact_email', NEW.contact_email, 'contact_phone', NEW.contact_phone, 'preferred_currency', NEW.preferred_currency,
+            'incoterms', NEW.incoterms, 'lead_time_days_avg', NEW.lead_time_days_avg, 'lead_time_days_p95', NEW.lead_time_days_p95,
+            'on_time_delivery_rate', NEW.on_time_delivery_rate, 'defect_rate_ppm', NEW.defect_rate_ppm, 'capacity_units_per_week', NEW.capacity_units_per_week,
+            'risk_score', NEW.risk_score, 'financial_risk_tier', NEW.financial_risk_tier, 'certifications', NEW.certifications,
+            'compliance_flags', NEW.compliance_flags, 'approved_status', NEW.approved_status, 'contracts', NEW.contracts,
+            'terms_version', NEW.terms_version, 'lat', NEW.lat, 'lon', NEW.lon,
+            'data_source', NEW.data_source, 'source_timestamp', NEW.source_timestamp, 'ingestion_timestamp', NEW.ingestion_timestamp,
+            'schema_version', NEW.schema_version, 'modified_by', NEW.modified_by, 'deleted_at', NEW.deleted_at,
+            'deleted_by', NEW.deleted_by
+        )
+    );
+END;
+
+CREATE TRIGGER dim_supplier_v1_audit_update AFTER UPDATE ON dim_supplier_v1
+WHEN OLD.supplier_id IS NOT NEW.supplier_id
+    OR OLD.supplier_code IS NOT NEW.supplier_code
+    OR OLD.tenant_id IS NOT NEW.tenant_id
+    OR OLD.legal_name IS NOT NEW.legal_name
+    OR OLD.dba_name IS NOT NEW.dba_name
+    OR OLD.country IS NOT NEW.country
+    OR OLD.region IS NOT NEW.region
+    OR OLD.address_line1 IS NOT NEW.address_line1
+    OR OLD.address_line2 IS NOT NEW.address_line2
+    OR OLD.city IS NOT NEW.city
+    OR OLD.state IS NOT NEW.state
+    OR OLD.postal_code IS NOT NEW.postal_code
+    OR OLD.contact_email IS NOT NEW.contact_email
+    OR OLD.contact_phone IS NOT NEW.contact_phone
+    OR OLD.preferred_currency IS NOT NEW.preferred_currency
+    OR OLD.incoterms IS NOT NEW.incoterms
+    OR OLD.lead_time_days_avg IS NOT NEW.lead_time_days_avg
+    OR OLD.lead_time_days_p95 IS NOT NEW.lead_time_days_p95
+    OR OLD.on_time_delivery_rate IS NOT NEW.on_time_delivery_rate
+    OR OLD.defect_rate_ppm IS NOT NEW.defect_rate_ppm
+    OR OLD.capacity_units_per_week IS NOT NEW.capacity_units_per_week
+    OR OLD.risk_score IS NOT NEW.risk_score
+    OR OLD.financial_risk_tier IS NOT NEW.financial_risk_tier
+    OR OLD.certifications IS NOT NEW.certifications
+    OR OLD.compliance_flags IS NOT NEW.compliance_flags
+    OR OLD.approved_status IS NOT NEW.approved_status
+    OR OLD.contracts IS NOT NEW.contracts
+    OR OLD.terms_version IS NOT NEW.terms_version
+    OR OLD.lat IS NOT NEW.lat
+    OR OLD.lon IS NOT NEW.lon
+    OR OLD.data_source IS NOT NEW.data_source
+    OR OLD.source_timestamp IS NOT NEW.source_timestamp
+    OR OLD.ingestion_timestamp IS NOT NEW.ingestion_timestamp
+    OR OLD.schema_version IS NOT NEW.schema_version
+    OR OLD.modified_by IS NOT NEW.modified_by
+    OR OLD.deleted_at IS NOT NEW.deleted_at
+    OR OLD.deleted_by IS NOT NEW.deleted_by
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, tenant_id, before_json, after_json)
+    VALUES (
+        CASE
+            WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN COALESCE(NEW.deleted_by, 'system')
+            ELSE COALESCE(NEW.modified_by, 'system')
+        END,
+        CASE
+            WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN 'delete'
+            WHEN OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN 'restore'
+            ELSE 'update'
+        END,
+        'supplier', NEW.supplier_id, NEW.tenant_id,
+        json_object(
+            'supplier_id', OLD.supplier_id, 'supplier_code', OLD.supplier_code, 'tenant_id', OLD.tenant_id,
+            'legal_name', OLD.legal_name, 'dba_name', OLD.dba_name, 'country', OLD.country,
+            'region', OLD.region, 'address_line1', OLD.address_line1, 'address_line2', OLD.address_line2,
+            'city', OLD.city, 'state', OLD.state, 'postal_code', OLD.postal_code,
+            'contact_email', OLD.contact_email, 'contact_phone', OLD.contact_phone, 'preferred_currency', OLD.preferred_currency,
+            'incoterms', OLD.incoterms, 'lead_time_days_avg', OLD.lead_time_days_avg, 'lead_time_days_p95', OLD.lead_time_days_p95,
+            'on_time_delivery_rate', OLD.on_time_delivery_rate, 'defect_rate_ppm', OLD.defect_rate_ppm, 'capacity_units_per_week', OLD.capacity_units_per_week,
+            'risk_score', OLD.risk_score, 'financial_risk_tier', OLD.financial_risk_tier, 'certifications', OLD.certifications,
+            'compliance_flags', OLD.compliance_flags, 'approved_status', OLD.approved_status, 'contracts', OLD.contracts,
+            'terms_version', OLD.terms_version, 'lat', OLD.lat, 'lon', OLD.lon,
+            'data_source', OLD.data_source, 'source_timestamp', OLD.source_timestamp, 'ingestion_timestamp', OLD.ingestion_timestamp,
+            'schema_version', OLD.schema_version, 'modified_by', OLD.modified_by, 'deleted_at', OLD.deleted_at,
+            'deleted_by', OLD.deleted_by
+        ),
+        json_object(
+            'supplier_id', NEW.supplier_id, 'supplier_code', NEW.supplier_code, 'tenant_id', NEW.tenant_id,
+            'legal_name', NEW.legal_name, 'dba_name', NEW.dba_name, 'country', NEW.country,
+            'region', NEW.region, 'address_line1', NEW.address_line1, 'address_line2', NEW.address_line2,
+            'city', NEW.city, 'state', NEW.state, 'postal_code', NEW.postal_code,
+            'contact_email', NEW.contact_email, 'contact_phone', NEW.contact_phone, 'preferred_currency', NEW.preferred_currency,
+            'incoterms', NEW.incoterms, 'lead_time_days_avg', NEW.lead_time_days_avg, 'lead_time_days_p95', NEW.lead_time_days_p95,
+            'on_time_delivery_rate', NEW.on_time_delivery_rate, 'defect_rate_ppm', NEW.defect_rate_ppm, 'capacity_units_per_week', NEW.capacity_units_per_week,
+            'risk_score', NEW.risk_score, 'financial_risk_tier', NEW.financial_risk_tier, 'certifications', NEW.certifications,
+            'compliance_flags', NEW.compliance_flags, 'approved_status', NEW.approved_status, 'contracts', NEW.contracts,
+            'terms_version', NEW.terms_version, 'lat', NEW.lat, 'lon', NEW.lon,
+            'data_source', NEW.data_source, 'source_timestamp', NEW.source_timestamp, 'ingestion_timestamp', NEW.ingestion_timestamp,
+            'schema_version', NEW.schema_version, 'modified_by', NEW.modified_by, 'deleted_at', NEW.deleted_at,
+            'deleted_by', NEW.deleted_by
+        )
+    );
+END;
+
+CREATE TRIGGER dim_supplier_v1_audit_delete AFTER DELETE ON dim_supplier_v1
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, tenant_id, before_json, after_json)
+    VALUES (
+        'system', 'purge', 'supplier', OLD.supplier_id, OLD.tenant_id,
+        json_object(
+            'supplier_id', OLD.supplier_id, 'supplier_code', OLD.supplier_code, 'tenant_id', OLD.tenant_id,
+            'legal_name', OLD.legal_name, 'dba_name', OLD.dba_name, 'country', OLD.country,
+            'region', OLD.region, 'address_line1', OLD.address_line1, 'address_line2', OLD.address_line2,
+            'city', OLD.city, 'state', OLD.state, 'postal_code', OLD.postal_code,
+            'contact_email', OLD.contact_email, 'contact_phone', OLD.contact_phone, 'preferred_currency', OLD.preferred_currency,
+            'incoterms', OLD.incoterms, 'lead_time_days_avg', OLD.lead_time_days_avg, 'lead_time_days_p95', OLD.lead_time_days_p95,
+            'on_time_delivery_rate', OLD.on_time_delivery_rate, 'defect_rate_ppm', OLD.defect_rate_ppm, 'capacity_units_per_week', OLD.capacity_units_per_week,
+            'risk_score', OLD.risk_score, 'financial_risk_tier', OLD.financial_risk_tier, 'certifications', OLD.certifications,
+            'compliance_flags', OLD.compliance_flags, 'approved_status', OLD.approved_status, 'contracts', OLD.contracts,
+            'terms_version', OLD.terms_version, 'lat', OLD.lat, 'lon', OLD.lon,
+            'data_source', OLD.data_source, 'source_timestamp', OLD.source_timestamp, 'ingestion_timestamp', OLD.ingestion_timestamp,
+            'schema_version', OLD.schema_version, 'modified_by', OLD.modified_by, 'deleted_at', OLD.deleted_at,
+            'deleted_by', OLD.deleted_by
+        ),
+        NULL
+    );
+END;
+
+CREATE TRIGGER dim_part_v1_audit_insert AFTER INSERT ON dim_part_v1
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, tenant_id, before_json, after_json)
+    VALUES (
+        COALESCE(NEW.modified_by, 'system'), 'create', 'part', NEW.part_id, NEW.tenant_id, NULL,
+        json_object(
+            'part_id', NEW.part_id, 'tenant_id', NEW.tenant_id, 'part_number', NEW.part_number,
+            'description', NEW.description, 'category', NEW.category, 'lifecycle_status', NEW.lifecycle_status,
+            'uom', NEW.uom, 'spec_hash', NEW.spec_hash, 'bom_compatibility', NEW.bom_compatibility,
+            'default_supplier_id', NEW.default_supplier_id, 'qualified_supplier_ids', NEW.qualified_supplier_ids, 'unit_cost', NEW.unit_cost,
+            'moq', NEW.moq, 'lead_time_days_avg', NEW.lead_time_days_avg, 'lead_time_days_p95', NEW.lead_time_days_p95,
+            'quality_grade', NEW.quality_grade, 'compliance_flags', NEW.compliance_flags, 'hazard_class', NEW.hazard_class,
+            'last_price_change', NEW.last_price_change, 'data_source', NEW.data_source, 'source_timestamp', NEW.source_timestamp,
+            'ingestion_timestamp', NEW.ingestion_timestamp, 'schema_version', NEW.schema_version, 'modified_by', NEW.modified_by,
+            'deleted_at', NEW.deleted_at, 'deleted_by', NEW.deleted_by
+        )
+    );
+END;
+
+CREATE TRIGGER dim_part_v1_audit_update AFTER UPDATE ON dim_part_v1
+WHEN OLD.part_id IS NOT NEW.part_id
+    OR OLD.tenant_id IS NOT NEW.tenant_id
+    OR OLD.part_number IS NOT NEW.part_number
+    OR OLD.description IS NOT NEW.description
+    OR OLD.category IS NOT NEW.category
+    OR OLD.lifecycle_status IS NOT NEW.lifecycle_status
+    OR OLD.uom IS NOT NEW.uom
+    OR OLD.spec_hash IS NOT NEW.spec_hash
+    OR OLD.bom_compatibility IS NOT NEW.bom_compatibility
+    OR OLD.default_supplier_id IS NOT NEW.default_supplier_id
+    OR OLD.qualified_supplier_ids IS NOT NEW.qualified_supplier_ids
+    OR OLD.unit_cost IS NOT NEW.unit_cost
+    OR OLD.moq IS NOT NEW.moq
+    OR OLD.lead_time_days_avg IS NOT NEW.lead_time_days_avg
+    OR OLD.lead_time_days_p95 IS NOT NEW.lead_time_days_p95
+    OR OLD.quality_grade IS NOT NEW.quality_grade
+    OR OLD.compliance_flags IS NOT NEW.compliance_flags
+    OR OLD.hazard_class IS NOT NEW.hazard_class
+    OR OLD.last_price_change IS NOT NEW.last_price_change
+    OR OLD.data_source IS NOT NEW.data_source
+    OR OLD.source_timestamp IS NOT NEW.source_timestamp
+    OR OLD.ingestion_timestamp IS NOT NEW.ingestion_timestamp
+    OR OLD.schema_version IS NOT NEW.schema_version
+    OR OLD.modified_by IS NOT NEW.modified_by
+    OR OLD.deleted_at IS NOT NEW.deleted_at
+    OR OLD.deleted_by IS NOT NEW.deleted_by
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, tenant_id, before_json, after_json)
+    VALUES (
+        CASE
+            WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN COALESCE(NEW.deleted_by, 'system')
+            ELSE COALESCE(NEW.modified_by, 'system')
+        END,
+        CASE
+            WHEN OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN 'delete'
+            WHEN OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN 'restore'
+            ELSE 'update'
+        END,
+        'part', NEW.part_id, NEW.tenant_id,
+        json_object(
+            'part_id', OLD.part_id, 'tenant_id', OLD.tenant_id, 'part_number', OLD.part_number,
+            'description', OLD.description, 'category', OLD.category, 'lifecycle_status', OLD.lifecycle_status,
+            'uom', OLD.uom, 'spec_hash', OLD.spec_hash, 'bom_compatibility', OLD.bom_compatibility,
+            'default_supplier_id', OLD.default_supplier_id, 'qualified_supplier_ids', OLD.qualified_supplier_ids, 'unit_cost', OLD.unit_cost,
+            'moq', OLD.moq, 'lead_time_days_avg', OLD.lead_time_days_avg, 'lead_time_days_p95', OLD.lead_time_days_p95,
+            'quality_grade', OLD.quality_grade, 'compliance_flags', OLD.compliance_flags, 'hazard_class', OLD.hazard_class,
+            'last_price_change', OLD.last_price_change, 'data_source', OLD.data_source, 'source_timestamp', OLD.source_timestamp,
+            'ingestion_timestamp', OLD.ingestion_timestamp, 'schema_version', OLD.schema_version, 'modified_by', OLD.modified_by,
+            'deleted_at', OLD.deleted_at, 'deleted_by', OLD.deleted_by
+        ),
+        json_object(
+            'part_id', NEW.part_id, 'tenant_id', NEW.tenant_id, 'part_number', NEW.part_number,
+            'description', NEW.description, 'category', NEW.category, 'lifecycle_status', NEW.lifecycle_status,
+            'uom', NEW.uom, 'spec_hash', NEW.spec_hash, 'bom_compatibility', NEW.bom_compatibility,
+            'default_supplier_id', NEW.default_supplier_id, 'qualified_supplier_ids', NEW.qualified_supplier_ids, 'unit_cost', NEW.unit_cost,
+            'moq', NEW.moq, 'lead_time_days_avg', NEW.lead_time_days_avg, 'lead_time_days_p95', NEW.lead_time_days_p95,
+            'quality_grade', NEW.quality_grade, 'compliance_flags', NEW.compliance_flags, 'hazard_class', NEW.hazard_class,
+            'last_price_change', NEW.last_price_change, 'data_source', NEW.data_source, 'source_timestamp', NEW.source_timestamp,
+            'ingestion_timestamp', NEW.ingestion_timestamp, 'schema_version', NEW.schema_version, 'modified_by', NEW.modified_by,
+            'deleted_at', NEW.deleted_at, 'deleted_by', NEW.deleted_by
+        )
+    );
+END;
+
+CREATE TRIGGER dim_part_v1_audit_delete AFTER DELETE ON dim_part_v1
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, tenant_id, before_json, after_json)
+    VALUES (
+        'system', 'purge', 'part', OLD.part_id, OLD.tenant_id,
+        json_object(
+            'part_id', OLD.part_id, 'tenant_id', OLD.tenant_id, 'part_number', OLD.part_number,
+            'description', OLD.description, 'category', OLD.category, 'lifecycle_status', OLD.lifecycle_status,
+            'uom', OLD.uom, 'spec_hash', OLD.spec_hash, 'bom_compatibility', OLD.bom_compatibility,
+            'default_supplier_id', OLD.default_supplier_id, 'qualified_supplier_ids', OLD.qualified_supplier_ids, 'unit_cost', OLD.unit_cost,
+            'moq', OLD.moq, 'lead_time_days_avg', OLD.lead_time_days_avg, 'lead_time_days_p95', OLD.lead_time_days_p95,
+            'quality_grade', OLD.quality_grade, 'compliance_flags', OLD.compliance_flags, 'hazard_class', OLD.hazard_class,
+            'last_price_change', OLD.last_price_change, 'data_source', OLD.data_source, 'source_timestamp', OLD.source_timestamp,
+            'ingestion_timestamp', OLD.ingestion_timestamp, 'schema_version', OLD.schema_version, 'modified_by', OLD.modified_by,
+            'deleted_at', OLD.deleted_at, 'deleted_by', OLD.deleted_by
+        ),
+        NULL
+    );
+END;
+
+CREATE TRIGGER part_supplier_audit_insert AFTER INSERT ON part_supplier
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, tenant_id, before_json, after_json)
+    VALUES (
+        'system', 'create', 'part_supplier', NEW.part_id || ':' || NEW.supplier_id, NEW.tenant_id, NULL,
+        json_object('part_id', NEW.part_id, 'supplier_id', NEW.supplier_id, 'tenant_id', NEW.tenant_id)
+    );
+END;
+
+CREATE TRIGGER part_supplier_audit_delete AFTER DELETE ON part_supplier
+BEGIN
+    INSERT INTO audit_log (actor, action, entity, entity_id, tenant_id, before_json, after_json)
+    VALUES (
+        'system', 'purge', 'part_supplier', OLD.part_id || ':' || OLD.supplier_id, OLD.tenant_id,
+        json_object('part_id', OLD.part_id, 'supplier_id', OLD.supplier_id, 'tenant_id', OLD.tenant_id),
+        NULL
+    );
+END;
+
+
+-- A soft delete closes the current history version and a restore opens a new one.
+DROP TRIGGER dim_supplier_v1_history_update;
+
+CREATE TRIGGER dim_supplier_v1_history_update AFTER UPDATE ON dim_supplier_v1
+WHEN OLD.supplier_code IS NOT NEW.supplier_code
+    OR OLD.tenant_id IS NOT NEW.tenant_id
+    OR OLD.legal_name IS NOT NEW.legal_name
+    OR OLD.dba_name IS NOT NEW.dba_name
+    OR OLD.country IS NOT NEW.country
+    OR OLD.region IS NOT NEW.region
+    OR OLD.address_line1 IS NOT NEW.address_line1
+    OR OLD.address_line2 IS NOT NEW.address_line2
+    OR OLD.city IS NOT NEW.city
+    OR OLD.state IS NOT NEW.state
+    OR OLD.postal_code IS NOT NEW.postal_code
+    OR OLD.contact_email IS NOT NEW.contact_email
+    OR OLD.contact_phone IS NOT NEW.contact_phone
+    OR OLD.preferred_currency IS NOT NEW.preferred_currency
+    OR OLD.incoterms IS NOT NEW.incoterms
+    OR OLD.lead_time_days_avg IS NOT NEW.lead_time_days_avg
+    OR OLD.lead_time_days_p95 IS NOT NEW.lead_time_days_p95
+    OR OLD.on_time_delivery_rate IS NOT NEW.on_time_delivery_rate
+    OR OLD.defect_rate_ppm IS NOT NEW.defect_rate_ppm
+    OR OLD.capacity_units_per_week IS NOT NEW.capacity_units_per_week
+    OR OLD.risk_score IS NOT NEW.risk_score
+    OR OLD.financial_risk_tier IS NOT NEW.financial_risk_tier
+    OR OLD.certifications IS NOT NEW.certifications
+    OR OLD.compliance_flags IS NOT NEW.compliance_flags
+    OR OLD.approved_status IS NOT NEW.approved_status
+    OR OLD.contracts IS NOT NEW.contracts
+    OR OLD.terms_version IS NOT NEW.terms_version
+    OR OLD.lat IS NOT NEW.lat
+    OR OLD.lon IS NOT NEW.lon
+    OR OLD.deleted_at IS NOT NEW.deleted_at
+BEGIN
+    UPDATE dim_supplier_v1_history
+    SET valid_to = max(strftime('%Y-%m-%d %H:%M:%f', CASE WHEN NEW.deleted_at IS NOT OLD.deleted_at THEN COALESCE(NEW.deleted_at, 'now') WHEN NEW.source_timestamp IS NOT OLD.source_timestamp THEN COALESCE(NEW.source_timestamp, 'now') ELSE 'now' END), valid_from),
+        is_current = 0
+    WHERE supplier_id = NEW.supplier_id AND is_current;
+
+    INSERT INTO dim_supplier_v1_history
+    (
+        supplier_id, supplier_code, tenant_id, legal_name, dba_name, country,
+        region, address_line1, address_line2, city, state, postal_code,
+        contact_email, contact_phone, preferred_currency, incoterms, lead_time_days_avg, lead_time_days_p95,
+        on_time_delivery_rate, defect_rate_ppm, capacity_units_per_week, risk_score, financial_risk_tier, certifications,
+        compliance_flags, approved_status, contracts, terms_version, lat, lon,
+        data_source, source_timestamp, ingestion_timestamp, schema_version,
+        valid_from
+    )
+    SELECT
+        NEW.supplier_id, NEW.supplier_code, NEW.tenant_id, NEW.legal_name, NEW.dba_name, NEW.country,
+        NEW.region, NEW.address_line1, NEW.address_line2, NEW.city, NEW.state, NEW.postal_code,
+        NEW.contact_email, NEW.contact_phone, NEW.preferred_currency, NEW.incoterms, NEW.lead_time_days_avg, NEW.lead_time_days_p95,
+        NEW.on_time_delivery_rate, NEW.defect_rate_ppm, NEW.capacity_units_per_week, NEW.risk_score, NEW.financial_risk_tier, NEW.certifications,
+        NEW.compliance_flags, NEW.approved_status, NEW.contracts, NEW.terms_version, NEW.lat, NEW.lon,
+        NEW.data_source, NEW.source_timestamp, NEW.ingestion_timestamp, NEW.schema_version,
+        max(
+            strftime('%Y-%m-%d %H:%M:%f', CASE WHEN NEW.deleted_at IS NOT OLD.deleted_at THEN COALESCE(NEW.deleted_at, 'now') WHEN NEW.source_timestamp IS NOT OLD.source_timestamp THEN COALESCE(NEW.source_timestamp, 'now') ELSE 'now' END),
+            COALESCE((SELECT max(valid_to) FROM dim_supplier_v1_history WHERE supplier_id = NEW.supplier_id), '')
+        )
+    WHERE NEW.deleted_at IS NULL;
+END;
+
+DROP TRIGGER dim_part_v1_history_update;
+
+CREATE TRIGGER dim_part_v1_history_update AFTER UPDATE ON dim_part_v1
+WHEN OLD.tenant_id IS NOT NEW.tenant_id
+    OR OLD.part_number IS NOT NEW.part_number
+    OR OLD.description IS NOT NEW.description
+    OR OLD.category IS NOT NEW.category
+    OR OLD.lifecycle_status IS NOT NEW.lifecycle_status
+    OR OLD.uom IS NOT NEW.uom
+    OR OLD.spec_hash IS NOT NEW.spec_hash
+    OR OLD.bom_compatibility IS NOT NEW.bom_compatibility
+    OR OLD.default_supplier_id IS NOT NEW.default_supplier_id
+    OR OLD.qualified_supplier_ids IS NOT NEW.qualified_supplier_ids
+    OR OLD.unit_cost IS NOT NEW.unit_cost
+    OR OLD.moq IS NOT NEW.moq
+    OR OLD.lead_time_days_avg IS NOT NEW.lead_time_days_avg
+    OR OLD.lead_time_days_p95 IS NOT NEW.lead_time_days_p95
+    OR OLD.quality_grade IS NOT NEW.quality_grade
+    OR OLD.compliance_flags IS NOT NEW.compliance_flags
+    OR OLD.hazard_class IS NOT NEW.hazard_class
+    OR OLD.last_price_change IS NOT NEW.last_price_change
+    OR OLD.deleted_at IS NOT NEW.deleted_at
+BEGIN
+    UPDATE dim_part_v1_history
+    SET valid_to = max(strftime('%Y-%m-%d %H:%M:%f', CASE WHEN NEW.deleted_at IS NOT OLD.deleted_at THEN COALESCE(NEW.deleted_at, 'now') WHEN NEW.source_timestamp IS NOT OLD.source_timestamp THEN COALESCE(NEW.source_timestamp, 'now') ELSE 'now' END), valid_from),
+        is_current = 0
+    WHERE part_id = NEW.part_id AND is_current;
+
+    INSERT INTO dim_part_v1_history
+    (
+        part_id, tenant_id, part_number, description, category, lifecycle_status,
+        uom, spec_hash, bom_compatibility, default_supplier_id, qualified_supplier_ids, unit_cost,
+        moq, lead_time_days_avg, lead_time_days_p95, quality_grade, compliance_flags, hazard_class,
+        last_price_change, data_source, source_timestamp, ingestion_timestamp, schema_version,
+        valid_from
+    )
+    SELECT
+        NEW.part_id, NEW.tenant_id, NEW.part_number, NEW.description, NEW.category, NEW.lifecycle_status,
+        NEW.uom, NEW.spec_hash, NEW.bom_compatibility, NEW.default_supplier_id, NEW.qualified_supplier_ids, NEW.unit_cost,
+        NEW.moq, NEW.lead_time_days_avg, NEW.lead_time_days_p95, NEW.quality_grade, NEW.compliance_flags, NEW.hazard_class,
+        NEW.last_price_change, NEW.data_source, NEW.source_timestamp, NEW.ingestion_timestamp, NEW.schema_version,
+        max(
+            strftime('%Y-%m-%d %H:%M:%f', CASE WHEN NEW.deleted_at IS NOT OLD.deleted_at THEN COALESCE(NEW.deleted_at, 'now') WHEN NEW.source_timestamp IS NOT OLD.source_timestamp THEN COALESCE(NEW.source_timestamp, 'now') ELSE 'now' END),
+            COALESCE((SELECT max(valid_to) FROM dim_part_v1_history WHERE part_id = NEW.part_id), '')
+        )
+    WHERE NEW.deleted_at IS NULL;
+END;
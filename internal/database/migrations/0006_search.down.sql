@@ -0,0 +1,15 @@
+DROP TRIGGER dim_part_v1_search_delete;
+
+DROP TRIGGER dim_part_v1_search_update;
+
+DROP TRIGGER dim_part_v1_search_insert;
+
+DROP TRIGGER dim_supplier_v1_search_delete;
+
+DROP TRIGGER dim_supplier_v1_search_update;
+
+DROP TRIGGER dim_supplier_v1_search_insert;
+
+DROP TABLE part_search;
+
+DROP TABLE supplier_search;
@@ -0,0 +1,70 @@
+-- Full-text search over supplier names and city, and part numbers and
+-- descriptions. The FTS5 tables are external content tables over the dim
+-- tables, keyed by their rowid, so only the index is stored. Triggers keep the
+-- index in sync; a migration that rebuilds a dim table must run the 'rebuild'
+-- command afterwards because the rowids change. Prefix indexes make short
+-- prefix queries (acm*) cheap.
+CREATE VIRTUAL TABLE supplier_search USING fts5
+(
+    supplier_id UNINDEXED,
+    legal_name,
+    dba_name,
+    city,
+    content = 'dim_supplier_v1',
+    tokenize = 'unicode61 remove_diacritics 2',
+    prefix = '2 3'
+);
+
+CREATE VIRTUAL TABLE part_search USING fts5
+(
+    part_id UNINDEXED,
+    part_number,
+    description,
+    content = 'dim_part_v1',
+    tokenize = 'unicode61 remove_diacritics 2',
+    prefix = '2 3'
+);
+
+INSERT INTO supplier_search (supplier_search) VALUES ('rebuild');
+
+INSERT INTO part_search (part_search) VALUES ('rebuild');
+
+CREATE TRIGGER dim_supplier_v1_search_insert AFTER INSERT ON dim_supplier_v1
+BEGIN
+    INSERT INTO supplier_search (rowid, supplier_id, legal_name, dba_name, city)
+    VALUES (NEW.rowid, NEW.supplier_id, NEW.legal_name, NEW.dba_name, NEW.city);
+END;
+
+CREATE TRIGGER dim_supplier_v1_search_update AFTER UPDATE OF supplier_id, legal_name, dba_name, city ON dim_supplier_v1
+BEGIN
+    INSERT INTO supplier_search (supplier_search, rowid, supplier_id, legal_name, dba_name, city)
+    VALUES ('delete', OLD.rowid, OLD.supplier_id, OLD.legal_name, OLD.dba_name, OLD.city);
+    INSERT INTO supplier_search (rowid, supplier_id, legal_name, dba_name, city)
+    VALUES (NEW.rowid, NEW.supplier_id, NEW.legal_name, NEW.dba_name, NEW.city);
+END;
+
+CREATE TRIGGER dim_supplier_v1_search_delete AFTER DELETE ON dim_supplier_v1
+BEGIN
+    INSERT INTO supplier_search (supplier_search, rowid, supplier_id, legal_name, dba_name, city)
+    VALUES ('delete', OLD.rowid, OLD.supplier_id, OLD.legal_name, OLD.dba_name, OLD.city);
+END;
+
+CREATE TRIGGER dim_part_v1_search_insert AFTER INSERT ON dim_part_v1
+BEGIN
+    INSERT INTO part_search (rowid, part_id, part_number, description)
+    VALUES (NEW.rowid, NEW.part_id, NEW.part_number, NEW.description);
+END;
+
+CREATE TRIGGER dim_part_v1_search_update AFTER UPDATE OF part_id, part_number, description ON dim_part_v1
+BEGIN
+    INSERT INTO part_search (part_search, rowid, part_id, part_number, description)
+    VALUES ('delete', OLD.rowid, OLD.part_id, OLD.part_number, OLD.description);
+    INSERT INTO part_search (rowid, part_id, part_number, description)
+    VALUES (NEW.rowid, NEW.part_id, NEW.part_number, NEW.description);
+END;
+
+CREATE TRIGGER dim_part_v1_search_delete AFTER DELETE ON dim_part_v1
+BEGIN
+    INSERT INTO part_search (part_search, rowid, part_id, part_number, description)
+    VALUES ('delete', OLD.rowid, OLD.part_id, OLD.part_number, OLD.description);
+END;
@@ -0,0 +1,15 @@
+-- api_key holds the static keys API callers authenticate with. Only the
+-- SHA-256 of a key is stored; the key itself is shown once, when it is made.
+-- A key acts for one tenant in one role: reader, writer or admin.
+CREATE TABLE api_key
+(
+    key_id TEXT PRIMARY KEY,
+    key_hash TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    tenant_id TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('reader', 'writer', 'admin')),
+    created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    revoked_at DATETIME
+);
+
+CREATE INDEX idx_api_key_tenant ON api_key (tenant_id, key_id);
@@ -0,0 +1,3 @@
+DROP TRIGGER part_supplier_tenant_update;
+
+DROP TRIGGER part_supplier_tenant_insert;
@@ -0,0 +1,21 @@
+-- A part may only be linked to suppliers of its own tenant. The foreign keys
+-- only check that the part and the supplier exist, and their IDs are unique
+-- across tenants, so links that cross tenants are dropped and triggers refuse
+-- new ones, whether they come from the API, a bulk load or an ingest.
+DELETE FROM part_supplier
+WHERE NOT EXISTS (SELECT 1 FROM dim_part_v1 p WHERE p.part_id = part_supplier.part_id AND p.tenant_id = part_supplier.tenant_id)
+    OR NOT EXISTS (SELECT 1 FROM dim_supplier_v1 s WHERE s.supplier_id = part_supplier.supplier_id AND s.tenant_id = part_supplier.tenant_id);
+
+CREATE TRIGGER part_supplier_tenant_insert BEFORE INSERT ON part_supplier
+WHEN NOT EXISTS (SELECT 1 FROM dim_part_v1 WHERE part_id = NEW.part_id AND tenant_id = NEW.tenant_id)
+    OR NOT EXISTS (SELECT 1 FROM dim_supplier_v1 WHERE supplier_id = NEW.supplier_id AND tenant_id = NEW.tenant_id)
+BEGIN
+    SELECT RAISE(ABORT, 'part_supplier: the part and the supplier must belong to the tenant');
+END;
+
+CREATE TRIGGER part_supplier_tenant_update BEFORE UPDATE ON part_supplier
+WHEN NOT EXISTS (SELECT 1 FROM dim_part_v1 WHERE part_id = NEW.part_id AND tenant_id = NEW.tenant_id)
+    OR NOT EXISTS (SELECT 1 FROM dim_supplier_v1 WHERE supplier_id = NEW.supplier_id AND tenant_id = NEW.tenant_id)
+BEGIN
+    SELECT RAISE(ABORT, 'part_supplier: the part and the supplier must belong to the tenant');
+END;
@@ -0,0 +1,7 @@
+DROP TRIGGER dim_part_v1_version;
+
+DROP TRIGGER dim_supplier_v1_version;
+
+ALTER TABLE dim_part_v1 DROP COLUMN version;
+
+ALTER TABLE dim_supplier_v1 DROP COLUMN version;
@@ -0,0 +1,20 @@
+-- version counts the writes to a supplier or part, so a read-modify-write such
+-- as PATCH can update the row only if it is still the version it read.
+-- Triggers bump it after every update that did not set it, which covers the
+-- API, upserts, bulk loads, soft deletes and restores alike. The bump itself
+-- changes no column the history, audit or search triggers watch.
+ALTER TABLE dim_supplier_v1 ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE dim_part_v1 ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TRIGGER dim_supplier_v1_version AFTER UPDATE ON dim_supplier_v1
+WHEN NEW.version = OLD.version
+BEGIN
+    UPDATE dim_supplier_v1 SET version = OLD.version + 1 WHERE rowid = NEW.rowid;
+END;
+
+CREATE TRIGGER dim_part_v1_version AFTER UPDATE ON dim_part_v1
+WHEN NEW.version = OLD.version
+BEGIN
+    UPDATE dim_part_v1 SET version = OLD.version + 1 WHERE rowid = NEW.rowid;
+END;
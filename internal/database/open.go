@@ -0,0 +1,20 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Open opens the SQLite database at path with foreign key enforcement turned
+// on. SQLite enables foreign keys per connection, so the setting is passed in
+// the DSN where the driver applies it to every connection in the pool. path
+// may be a file name, ":memory:" or a file: URI with its own parameters.
+func Open(path string) (*sql.DB, error) {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return sql.Open("sqlite3", path+sep+"_foreign_keys=on")
+}
@@ -34,14 +34,15 @@ INSERT INTO dim_supplier_v1
     data_source,
     source_timestamp,
     ingestion_timestamp,
-    schema_version
+    schema_version,
+    modified_by
     )
 VALUES
     (
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
-        ?, ?, ?, ?
+        ?, ?, ?, ?, ?
 );
 
 -- name: CreatePart :execrows
@@ -69,17 +70,474 @@ INSERT INTO dim_part_v1
     data_source,
     source_timestamp,
     ingestion_timestamp,
-    schema_version
+    schema_version,
+    modified_by
     )
 VALUES
     (
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
         ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
-        ?, ?, ?
+        ?, ?, ?, ?
 );
 
--- name: DeleteSupplier :exec
-DELETE FROM dim_supplier_v1 WHERE supplier_id = ?;
+-- name: DeleteSupplier :execrows
+-- DeleteSupplier soft-deletes a supplier. It returns 0 if the supplier does
+-- not exist or is already deleted.
+UPDATE dim_supplier_v1
+SET deleted_at = strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    deleted_by = sqlc.arg(deleted_by)
+WHERE tenant_id = sqlc.arg(tenant_id) AND supplier_id = sqlc.arg(supplier_id) AND deleted_at IS NULL;
+
+-- name: DeletePart :execrows
+-- DeletePart soft-deletes a part. It returns 0 if the part does not exist or
+-- is already deleted.
+UPDATE dim_part_v1
+SET deleted_at = strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    deleted_by = sqlc.arg(deleted_by)
+WHERE tenant_id = sqlc.arg(tenant_id) AND part_id = sqlc.arg(part_id) AND deleted_at IS NULL;
+
+-- name: RestoreSupplier :execrows
+-- RestoreSupplier undoes a soft delete. It returns 0 if the supplier is not
+-- deleted.
+UPDATE dim_supplier_v1
+SET deleted_at = NULL,
+    deleted_by = NULL,
+    modified_by = sqlc.arg(modified_by)
+WHERE tenant_id = sqlc.arg(tenant_id) AND supplier_id = sqlc.arg(supplier_id) AND deleted_at IS NOT NULL;
+
+-- name: RestorePart :execrows
+-- RestorePart undoes a soft delete. It returns 0 if the part is not deleted.
+UPDATE dim_part_v1
+SET deleted_at = NULL,
+    deleted_by = NULL,
+    modified_by = sqlc.arg(modified_by)
+WHERE tenant_id = sqlc.arg(tenant_id) AND part_id = sqlc.arg(part_id) AND deleted_at IS NOT NULL;
+
+-- name: UpdateSupplier :execrows
+-- UpdateSupplier replaces every field of a live supplier. It returns 0 if the
+-- supplier does not exist, is deleted or belongs to another tenant, or when
+-- version is set and the supplier is no longer at that version.
+UPDATE dim_supplier_v1
+SET supplier_code = sqlc.arg(supplier_code),
+    legal_name = sqlc.arg(legal_name),
+    dba_name = sqlc.arg(dba_name),
+    country = sqlc.arg(country),
+    region = sqlc.arg(region),
+    address_line1 = sqlc.arg(address_line1),
+    address_line2 = sqlc.arg(address_line2),
+    city = sqlc.arg(city),
+    state = sqlc.arg(state),
+    postal_code = sqlc.arg(postal_code),
+    contact_email = sqlc.arg(contact_email),
+    contact_phone = sqlc.arg(contact_phone),
+    preferred_currency = sqlc.arg(preferred_currency),
+    incoterms = sqlc.arg(incoterms),
+    lead_time_days_avg = sqlc.arg(lead_time_days_avg),
+    lead_time_days_p95 = sqlc.arg(lead_time_days_p95),
+    on_time_delivery_rate = sqlc.arg(on_time_delivery_rate),
+    defect_rate_ppm = sqlc.arg(defect_rate_ppm),
+    capacity_units_per_week = sqlc.arg(capacity_units_per_week),
+    risk_score = sqlc.arg(risk_score),
+    financial_risk_tier = sqlc.arg(financial_risk_tier),
+    certifications = sqlc.arg(certifications),
+    compliance_flags = sqlc.arg(compliance_flags),
+    approved_status = sqlc.arg(approved_status),
+    contracts = sqlc.arg(contracts),
+    terms_version = sqlc.arg(terms_version),
+    lat = sqlc.arg(lat),
+    lon = sqlc.arg(lon),
+    data_source = sqlc.arg(data_source),
+    source_timestamp = sqlc.arg(source_timestamp),
+    ingestion_timestamp = sqlc.arg(ingestion_timestamp),
+    schema_version = sqlc.arg(schema_version),
+    modified_by = sqlc.arg(modified_by)
+WHERE tenant_id = sqlc.arg(tenant_id) AND supplier_id = sqlc.arg(supplier_id) AND deleted_at IS NULL
+    AND (sqlc.narg(version) IS NULL OR version = sqlc.narg(version));
+
+-- name: UpdatePart :execrows
+-- UpdatePart replaces every field of a live part, like UpdateSupplier.
+UPDATE dim_part_v1
+SET part_number = sqlc.arg(part_number),
+    description = sqlc.arg(description),
+    category = sqlc.arg(category),
+    lifecycle_status = sqlc.arg(lifecycle_status),
+    uom = sqlc.arg(uom),
+    spec_hash = sqlc.arg(spec_hash),
+    bom_compatibility = sqlc.arg(bom_compatibility),
+    default_supplier_id = sqlc.arg(default_supplier_id),
+    qualified_supplier_ids = sqlc.arg(qualified_supplier_ids),
+    unit_cost = sqlc.arg(unit_cost),
+    moq = sqlc.arg(moq),
+    lead_time_days_avg = sqlc.arg(lead_time_days_avg),
+    lead_time_days_p95 = sqlc.arg(lead_time_days_p95),
+    quality_grade = sqlc.arg(quality_grade),
+    compliance_flags = sqlc.arg(compliance_flags),
+    hazard_class = sqlc.arg(hazard_class),
+    last_price_change = sqlc.arg(last_price_change),
+    data_source = sqlc.arg(data_source),
+    source_timestamp = sqlc.arg(source_timestamp),
+    ingestion_timestamp = sqlc.arg(ingestion_timestamp),
+    schema_version = sqlc.arg(schema_version),
+    modified_by = sqlc.arg(modified_by)
+WHERE tenant_id = sqlc.arg(tenant_id) AND part_id = sqlc.arg(part_id) AND deleted_at IS NULL
+    AND (sqlc.narg(version) IS NULL OR version = sqlc.narg(version));
+
+-- name: PurgeSupplier :execrows
+-- PurgeSupplier removes a supplier for good, applying the foreign key delete
+-- policy to its parts and links.
+DELETE FROM dim_supplier_v1 WHERE tenant_id = ? AND supplier_id = ?;
+
+-- name: PurgePart :execrows
+-- PurgePart removes a part and its links for good.
+DELETE FROM dim_part_v1 WHERE tenant_id = ? AND part_id = ?;
+
+-- name: UpsertSupplier :execrows
+INSERT INTO dim_supplier_v1
+    (
+    supplier_id,
+    supplier_code,
+    tenant_id,
+    legal_name,
+    dba_name,
+    country,
+    region,
+    address_line1,
+    address_line2,
+    city,
+    state,
+    postal_code,
+    contact_email,
+    contact_phone,
+    preferred_currency,
+    incoterms,
+    lead_time_days_avg,
+    lead_time_days_p95,
+    on_time_delivery_rate,
+    defect_rate_ppm,
+    capacity_units_per_week,
+    risk_score,
+    financial_risk_tier,
+    certifications,
+    compliance_flags,
+    approved_status,
+    contracts,
+    terms_version,
+    lat,
+    lon,
+    data_source,
+    source_timestamp,
+    ingestion_timestamp,
+    schema_version,
+    modified_by
+    )
+VALUES
+    (
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?, ?
+)
+ON CONFLICT (supplier_id) DO UPDATE SET
+    supplier_code = excluded.supplier_code,
+    tenant_id = excluded.tenant_id,
+    legal_name = excluded.legal_name,
+    dba_name = excluded.dba_name,
+    country = excluded.country,
+    region = excluded.region,
+    address_line1 = excluded.address_line1,
+    address_line2 = excluded.address_line2,
+    city = excluded.city,
+    state = excluded.state,
+    postal_code = excluded.postal_code,
+    contact_email = excluded.contact_email,
+    contact_phone = excluded.contact_phone,
+    preferred_currency = excluded.preferred_currency,
+    incoterms = excluded.incoterms,
+    lead_time_days_avg = excluded.lead_time_days_avg,
+    lead_time_days_p95 = excluded.lead_time_days_p95,
+    on_time_delivery_rate = excluded.on_time_delivery_rate,
+    defect_rate_ppm = excluded.defect_rate_ppm,
+    capacity_units_per_week = excluded.capacity_units_per_week,
+    risk_score = excluded.risk_score,
+    financial_risk_tier = excluded.financial_risk_tier,
+    certifications = excluded.certifications,
+    compliance_flags = excluded.compliance_flags,
+    approved_status = excluded.approved_status,
+    contracts = excluded.contracts,
+    terms_version = excluded.terms_version,
+    lat = excluded.lat,
+    lon = excluded.lon,
+    data_source = excluded.data_source,
+    source_timestamp = excluded.source_timestamp,
+    ingestion_timestamp = excluded.ingestion_timestamp,
+    schema_version = excluded.schema_version,
+    modified_by = excluded.modified_by
+WHERE dim_supplier_v1.tenant_id = excluded.tenant_id AND (dim_supplier_v1.source_timestamp IS NULL
+    OR julianday(excluded.source_timestamp) > julianday(dim_supplier_v1.source_timestamp));
+
+-- name: UpsertPart :execrows
+INSERT INTO dim_part_v1
+    (
+    part_id,
+    tenant_id,
+    part_number,
+    description,
+    category,
+    lifecycle_status,
+    uom,
+    spec_hash,
+    bom_compatibility,
+    default_supplier_id,
+    qualified_supplier_ids,
+    unit_cost,
+    moq,
+    lead_time_days_avg,
+    lead_time_days_p95,
+    quality_grade,
+    compliance_flags,
+    hazard_class,
+    last_price_change,
+    data_source,
+    source_timestamp,
+    ingestion_timestamp,
+    schema_version,
+    modified_by
+    )
+VALUES
+    (
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+        ?, ?, ?, ?
+)
+ON CONFLICT (part_id) DO UPDATE SET
+    tenant_id = excluded.tenant_id,
+    part_number = excluded.part_number,
+    description = excluded.description,
+    category = excluded.category,
+    lifecycle_status = excluded.lifecycle_status,
+    uom = excluded.uom,
+    spec_hash = excluded.spec_hash,
+    bom_compatibility = excluded.bom_compatibility,
+    default_supplier_id = excluded.default_supplier_id,
+    qualified_supplier_ids = excluded.qualified_supplier_ids,
+    unit_cost = excluded.unit_cost,
+    moq = excluded.moq,
+    lead_time_days_avg = excluded.lead_time_days_avg,
+    lead_time_days_p95 = excluded.lead_time_days_p95,
+    quality_grade = excluded.quality_grade,
+    compliance_flags = excluded.compliance_flags,
+    hazard_class = excluded.hazard_class,
+    last_price_change = excluded.last_price_change,
+    data_source = excluded.data_source,
+    source_timestamp = excluded.source_timestamp,
+    ingestion_timestamp = excluded.ingestion_timestamp,
+    schema_version = excluded.schema_version,
+    modified_by = excluded.modified_by
+WHERE dim_part_v1.tenant_id = excluded.tenant_id AND (dim_part_v1.source_timestamp IS NULL
+    OR julianday(excluded.source_timestamp) > julianday(dim_part_v1.source_timestamp));
+
+-- name: SupplierExists :one
+SELECT EXISTS (SELECT 1 FROM dim_supplier_v1 WHERE tenant_id = ? AND supplier_id = ?);
+
+-- name: PartExists :one
+SELECT EXISTS (SELECT 1 FROM dim_part_v1 WHERE tenant_id = ? AND part_id = ?);
+
+-- name: GetSupplier :one
+SELECT * FROM dim_supplier_v1 WHERE tenant_id = ? AND supplier_id = ? AND deleted_at IS NULL;
+
+-- name: GetSupplierByCode :one
+SELECT * FROM dim_supplier_v1
+WHERE tenant_id = ? AND supplier_code = ? AND deleted_at IS NULL
+ORDER BY supplier_id
+LIMIT 1;
+
+-- name: GetPart :one
+SELECT * FROM dim_part_v1 WHERE tenant_id = ? AND part_id = ? AND deleted_at IS NULL;
+
+-- name: GetPartByNumber :one
+SELECT * FROM dim_part_v1
+WHERE tenant_id = ? AND part_number = ? AND deleted_at IS NULL
+ORDER BY part_id
+LIMIT 1;
+
+-- name: ListSuppliers :many
+-- ListSuppliers pages through a tenant's suppliers in supplier_id order.
+-- Pass the last supplier_id of the previous page as after (empty for the first
+-- page); NULL filters are ignored. Soft-deleted suppliers are skipped.
+SELECT * FROM dim_supplier_v1
+WHERE tenant_id = sqlc.arg(tenant_id)
+    AND deleted_at IS NULL
+    AND (sqlc.narg(country) IS NULL OR country = sqlc.narg(country))
+    AND (sqlc.narg(region) IS NULL OR region = sqlc.narg(region))
+    AND (sqlc.narg(approved_status) IS NULL OR approved_status = sqlc.narg(approved_status))
+    AND (sqlc.narg(min_risk_score) IS NULL OR risk_score >= sqlc.narg(min_risk_score))
+    AND (sqlc.narg(max_risk_score) IS NULL OR risk_score <= sqlc.narg(max_risk_score))
+    AND supplier_id > sqlc.arg(after)
+ORDER BY supplier_id
+LIMIT sqlc.arg(page_size);
+
+-- name: CountSuppliers :one
+SELECT COUNT(*) FROM dim_supplier_v1
+WHERE tenant_id = sqlc.arg(tenant_id)
+    AND deleted_at IS NULL
+    AND (sqlc.narg(country) IS NULL OR country = sqlc.narg(country))
+    AND (sqlc.narg(region) IS NULL OR region = sqlc.narg(region))
+    AND (sqlc.narg(approved_status) IS NULL OR approved_status = sqlc.narg(approved_status))
+    AND (sqlc.narg(min_risk_score) IS NULL OR risk_score >= sqlc.narg(min_risk_score))
+    AND (sqlc.narg(max_risk_score) IS NULL OR risk_score <= sqlc.narg(max_risk_score));
+
+-- name: ListParts :many
+-- ListParts pages through a tenant's parts in part_id order, like ListSuppliers.
+SELECT * FROM dim_part_v1
+WHERE tenant_id = sqlc.arg(tenant_id)
+    AND deleted_at IS NULL
+    AND (sqlc.narg(category) IS NULL OR category = sqlc.narg(category))
+    AND (sqlc.narg(lifecycle_status) IS NULL OR lifecycle_status = sqlc.narg(lifecycle_status))
+    AND part_id > sqlc.arg(after)
+ORDER BY part_id
+LIMIT sqlc.arg(page_size);
+
+-- name: CountParts :one
+SELECT COUNT(*) FROM dim_part_v1
+WHERE tenant_id = sqlc.arg(tenant_id)
+    AND deleted_at IS NULL
+    AND (sqlc.narg(category) IS NULL OR category = sqlc.narg(category))
+    AND (sqlc.narg(lifecycle_status) IS NULL OR lifecycle_status = sqlc.narg(lifecycle_status));
+
+-- name: AddPartSupplier :exec
+-- AddPartSupplier fails unless the part and the supplier belong to the tenant.
+INSERT INTO part_supplier (part_id, supplier_id, tenant_id)
+VALUES (?, ?, ?)
+ON CONFLICT (part_id, supplier_id) DO NOTHING;
+
+-- name: RemovePartSupplier :exec
+DELETE FROM part_supplier WHERE tenant_id = ? AND part_id = ? AND supplier_id = ?;
+
+-- name: ClearPartSuppliers :exec
+-- ClearPartSuppliers removes every supplier link of a part.
+DELETE FROM part_supplier WHERE tenant_id = ? AND part_id = ?;
+
+-- name: ListSupplierParts :many
+-- ListSupplierParts returns the parts a supplier is qualified for.
+SELECT dim_part_v1.* FROM dim_part_v1
+JOIN part_supplier ON part_supplier.part_id = dim_part_v1.part_id AND part_supplier.tenant_id = dim_part_v1.tenant_id
+WHERE part_supplier.tenant_id = ? AND part_supplier.supplier_id = ? AND dim_part_v1.deleted_at IS NULL
+ORDER BY dim_part_v1.part_id;
+
+-- name: ListPartSuppliers :many
+-- ListPartSuppliers returns the suppliers qualified for a part.
+SELECT dim_supplier_v1.* FROM dim_supplier_v1
+JOIN part_supplier ON part_supplier.supplier_id = dim_supplier_v1.supplier_id AND part_supplier.tenant_id = dim_supplier_v1.tenant_id
+WHERE part_supplier.tenant_id = ? AND part_supplier.part_id = ? AND dim_supplier_v1.deleted_at IS NULL
+ORDER BY dim_supplier_v1.supplier_id;
+
+-- name: GetSupplierAsOf :one
+-- GetSupplierAsOf returns the version of a supplier that was current at as_of.
+SELECT * FROM dim_supplier_v1_history
+WHERE tenant_id = sqlc.arg(tenant_id)
+    AND supplier_id = sqlc.arg(supplier_id)
+    AND valid_from <= strftime('%Y-%m-%d %H:%M:%f', sqlc.arg(as_of))
+    AND (valid_to IS NULL OR valid_to > strftime('%Y-%m-%d %H:%M:%f', sqlc.arg(as_of)));
+
+-- name: GetPartAsOf :one
+-- GetPartAsOf returns the version of a part that was current at as_of.
+SELECT * FROM dim_part_v1_history
+WHERE tenant_id = sqlc.arg(tenant_id)
+    AND part_id = sqlc.arg(part_id)
+    AND valid_from <= strftime('%Y-%m-%d %H:%M:%f', sqlc.arg(as_of))
+    AND (valid_to IS NULL OR valid_to > strftime('%Y-%m-%d %H:%M:%f', sqlc.arg(as_of)));
+
+-- name: ListSupplierHistory :many
+SELECT * FROM dim_supplier_v1_history
+WHERE tenant_id = ? AND supplier_id = ?
+ORDER BY valid_from, history_id;
+
+-- name: ListPartHistory :many
+SELECT * FROM dim_part_v1_history
+WHERE tenant_id = ? AND part_id = ?
+ORDER BY valid_from, history_id;
+
+-- name: ListAuditLog :many
+-- ListAuditLog returns every change to one entity, oldest first.
+SELECT * FROM audit_log
+WHERE tenant_id = ? AND entity = ? AND entity_id = ?
+ORDER BY audit_id;
+
+-- name: ListTenantAuditLog :many
+-- ListTenantAuditLog pages through a tenant's changes in the order they
+-- happened. Pass the last audit_id of the previous page as after.
+SELECT * FROM audit_log
+WHERE tenant_id = sqlc.arg(tenant_id) AND audit_id > sqlc.arg(after)
+ORDER BY audit_id
+LIMIT sqlc.arg(page_size);
+
+-- name: SearchSuppliers :many
+-- SearchSuppliers runs an FTS5 query over supplier legal name, DBA name and
+-- city within a tenant, best match first. Matches in the legal name weigh the
+-- most. snippet marks the matched terms with [ and ].
+SELECT
+    dim_supplier_v1.supplier_id,
+    dim_supplier_v1.legal_name,
+    dim_supplier_v1.dba_name,
+    dim_supplier_v1.city,
+    snippet(supplier_search, -1, '[', ']', '...', 12) AS snippet,
+    bm25(supplier_search, 0.0, 10.0, 5.0, 1.0) AS rank
+FROM supplier_search
+JOIN dim_supplier_v1 ON dim_supplier_v1.supplier_id = supplier_search.supplier_id
+WHERE supplier_search MATCH sqlc.arg(query)
+    AND dim_supplier_v1.tenant_id = sqlc.arg(tenant_id)
+    AND dim_supplier_v1.deleted_at IS NULL
+ORDER BY rank, dim_supplier_v1.supplier_id
+LIMIT sqlc.arg(page_size);
+
+-- name: SearchParts :many
+-- SearchParts runs an FTS5 query over part number and description within a
+-- tenant, best match first. Matches in the part number weigh the most.
+-- snippet marks the matched terms with [ and ].
+SELECT
+    dim_part_v1.part_id,
+    dim_part_v1.part_number,
+    dim_part_v1.description,
+    snippet(part_search, -1, '[', ']', '...', 12) AS snippet,
+    bm25(part_search, 0.0, 5.0, 1.0) AS rank
+FROM part_search
+JOIN dim_part_v1 ON dim_part_v1.part_id = part_search.part_id
+WHERE part_search MATCH sqlc.arg(query)
+    AND dim_part_v1.tenant_id = sqlc.arg(tenant_id)
+    AND dim_part_v1.deleted_at IS NULL
+ORDER BY rank, dim_part_v1.part_id
+LIMIT sqlc.arg(page_size);
+
+-- name: SummarizeParts :many
+-- SummarizeParts aggregates a tenant's parts by category and lifecycle
+-- status: how many there are, their average unit cost and lead time, and how
+-- many distinct default suppliers they use.
+SELECT
+    category,
+    lifecycle_status,
+    COUNT(*) AS parts,
+    CAST(COALESCE(AVG(unit_cost), 0) AS REAL) AS avg_unit_cost,
+    CAST(COALESCE(AVG(lead_time_days_avg), 0) AS REAL) AS avg_lead_time_days,
+    COUNT(DISTINCT default_supplier_id) AS suppliers
+FROM dim_part_v1
+WHERE tenant_id = ? AND deleted_at IS NULL
+GROUP BY category, lifecycle_status
+ORDER BY category, lifecycle_status;
+
+-- name: CreateAPIKey :exec
+INSERT INTO api_key (key_id, key_hash, name, tenant_id, role) VALUES (?, ?, ?, ?, ?);
+
+-- name: GetAPIKeyByHash :one
+-- GetAPIKeyByHash finds the live key with the SHA-256 hash key_hash.
+SELECT * FROM api_key WHERE key_hash = ? AND revoked_at IS NULL;
+
+-- name: ListAPIKeys :many
+-- ListAPIKeys returns a tenant's keys, revoked ones included, oldest first.
+SELECT * FROM api_key WHERE tenant_id = ? ORDER BY created_at, key_id;
 
--- name: DeletePart :exec
-DELETE FROM dim_part_v1 WHERE part_id = ?;
+-- name: RevokeAPIKey :execrows
+-- RevokeAPIKey stops a key from authenticating. It returns 0 if the key does
+-- not exist or is already revoked.
+UPDATE api_key
+SET revoked_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+WHERE key_id = ? AND revoked_at IS NULL;
@@ -0,0 +1,539 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+)
+
+func seedQueries(t *testing.T) *db.Queries {
+	t.Helper()
+	ctx := context.Background()
+	conn := openTestDB(t)
+	if err := Migrate(ctx, conn); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	q := db.New(conn)
+
+	countries := []string{"US", "DE", "JP"}
+	for i := 0; i < 9; i++ {
+		_, err := q.CreateSupplier(ctx, db.CreateSupplierParams{
+			SupplierID:     fmt.Sprintf("SUP-%02d", i),
+			SupplierCode:   sql.NullString{String: fmt.Sprintf("C%02d", i), Valid: true},
+			TenantID:       "tenant_acme",
+			LegalName:      fmt.Sprintf("Supplier %d", i),
+			Country:        sql.NullString{String: countries[i%3], Valid: true},
+			ApprovedStatus: sql.NullString{String: "APPROVED", Valid: i%2 == 0},
+			RiskScore:      sql.NullFloat64{Float64: float64(i * 10), Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("create supplier: %v", err)
+		}
+	}
+	for _, tenant := range []string{"tenant_acme", "tenant_globex"} {
+		for i := 0; i < 5; i++ {
+			_, err := q.CreatePart(ctx, db.CreatePartParams{
+				PartID:          fmt.Sprintf("%s-PART-%02d", tenant, i),
+				TenantID:        tenant,
+				PartNumber:      fmt.Sprintf("P-%06d", i),
+				Description:     "part",
+				Category:        sql.NullString{String: []string{"FASTENER", "ELECTRICAL"}[i%2], Valid: true},
+				LifecycleStatus: sql.NullString{String: "ACTIVE", Valid: true},
+			})
+			if err != nil {
+				t.Fatalf("create part: %v", err)
+			}
+		}
+	}
+	return q
+}
+
+func TestGetQueries(t *testing.T) {
+	ctx := context.Background()
+	q := seedQueries(t)
+
+	sup, err := q.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-03"})
+	if err != nil {
+		t.Fatalf("get supplier: %v", err)
+	}
+	if sup.LegalName != "Supplier 3" || sup.Country.String != "US" {
+		t.Errorf("unexpected supplier %+v", sup)
+	}
+
+	sup, err = q.GetSupplierByCode(ctx, db.GetSupplierByCodeParams{TenantID: "tenant_acme", SupplierCode: sql.NullString{String: "C05", Valid: true}})
+	if err != nil {
+		t.Fatalf("get supplier by code: %v", err)
+	}
+	if sup.SupplierID != "SUP-05" {
+		t.Errorf("expected SUP-05, got %s", sup.SupplierID)
+	}
+
+	if _, err := q.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "missing"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	// the same part number exists in both tenants
+	part, err := q.GetPartByNumber(ctx, db.GetPartByNumberParams{TenantID: "tenant_globex", PartNumber: "P-000002"})
+	if err != nil {
+		t.Fatalf("get part by number: %v", err)
+	}
+	if part.PartID != "tenant_globex-PART-02" {
+		t.Errorf("expected globex part, got %s", part.PartID)
+	}
+
+	part, err = q.GetPart(ctx, db.GetPartParams{TenantID: "tenant_globex", PartID: part.PartID})
+	if err != nil || part.TenantID != "tenant_globex" {
+		t.Errorf("get part: %+v %v", part, err)
+	}
+}
+
+func TestListSuppliersFiltersAndPages(t *testing.T) {
+	ctx := context.Background()
+	q := seedQueries(t)
+
+	// risk scores 20..70 are SUP-02..SUP-07
+	filter := db.ListSuppliersParams{
+		TenantID:     "tenant_acme",
+		MinRiskScore: sql.NullFloat64{Float64: 20, Valid: true},
+		MaxRiskScore: sql.NullFloat64{Float64: 70, Valid: true},
+		PageSize:     4,
+	}
+	var ids []string
+	for {
+		page, err := q.ListSuppliers(ctx, filter)
+		if err != nil {
+			t.Fatalf("list suppliers: %v", err)
+		}
+		for _, s := range page {
+			ids = append(ids, s.SupplierID)
+		}
+		if len(page) < int(filter.PageSize) {
+			break
+		}
+		filter.After = page[len(page)-1].SupplierID
+	}
+	if got := strings.Join(ids, ","); got != "SUP-02,SUP-03,SUP-04,SUP-05,SUP-06,SUP-07" {
+		t.Errorf("unexpected pages: %s", got)
+	}
+
+	count, err := q.CountSuppliers(ctx, db.CountSuppliersParams{
+		TenantID:       "tenant_acme",
+		Country:        sql.NullString{String: "US", Valid: true},
+		ApprovedStatus: sql.NullString{String: "APPROVED", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("count suppliers: %v", err)
+	}
+	// US is SUP-00, SUP-03, SUP-06; approved are the even ones
+	if count != 2 {
+		t.Errorf("expected 2 approved US suppliers, got %d", count)
+	}
+
+	count, err = q.CountSuppliers(ctx, db.CountSuppliersParams{TenantID: "tenant_globex"})
+	if err != nil || count != 0 {
+		t.Errorf("expected no suppliers for another tenant, got %d %v", count, err)
+	}
+}
+
+func TestListPartsFiltersAndPages(t *testing.T) {
+	ctx := context.Background()
+	q := seedQueries(t)
+
+	page, err := q.ListParts(ctx, db.ListPartsParams{
+		TenantID: "tenant_acme",
+		Category: sql.NullString{String: "FASTENER", Valid: true},
+		PageSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("list parts: %v", err)
+	}
+	if len(page) != 2 || page[0].PartID != "tenant_acme-PART-00" || page[1].PartID != "tenant_acme-PART-02" {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+
+	page, err = q.ListParts(ctx, db.ListPartsParams{
+		TenantID: "tenant_acme",
+		Category: sql.NullString{String: "FASTENER", Valid: true},
+		After:    page[1].PartID,
+		PageSize: 2,
+	})
+	if err != nil || len(page) != 1 || page[0].PartID != "tenant_acme-PART-04" {
+		t.Fatalf("unexpected second page: %+v %v", page, err)
+	}
+
+	count, err := q.CountParts(ctx, db.CountPartsParams{
+		TenantID:        "tenant_acme",
+		LifecycleStatus: sql.NullString{String: "ACTIVE", Valid: true},
+	})
+	if err != nil || count != 5 {
+		t.Errorf("expected 5 active parts, got %d %v", count, err)
+	}
+}
+
+func TestReadQueriesUseIndexes(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	if err := Migrate(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+
+	for query, index := range map[string]string{
+		"SELECT * FROM dim_supplier_v1 WHERE supplier_code = 'C01'":                                     "idx_supplier_code",
+		"SELECT * FROM dim_part_v1 WHERE tenant_id = 't' AND part_number = 'P-1'":                       "idx_part_tenant_part_number",
+		"SELECT * FROM dim_supplier_v1 WHERE tenant_id = 't' AND supplier_id > '' ORDER BY supplier_id": "idx_supplier_tenant",
+	} {
+		rows, err := conn.Query("EXPLAIN QUERY PLAN " + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var plan strings.Builder
+		for rows.Next() {
+			var id, parent, notused int
+			var detail string
+			if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+				t.Fatal(err)
+			}
+			plan.WriteString(detail + "\n")
+		}
+		rows.Close()
+		if !strings.Contains(plan.String(), index) {
+			t.Errorf("expected %q to use %s, plan:\n%s", query, index, plan.String())
+		}
+	}
+}
+
+func TestPartSupplierLinksAndDeletePolicy(t *testing.T) {
+	ctx := context.Background()
+	q := seedQueries(t)
+
+	for _, link := range []db.AddPartSupplierParams{
+		{PartID: "tenant_acme-PART-00", SupplierID: "SUP-01", TenantID: "tenant_acme"},
+		{PartID: "tenant_acme-PART-00", SupplierID: "SUP-02", TenantID: "tenant_acme"},
+		{PartID: "tenant_acme-PART-01", SupplierID: "SUP-01", TenantID: "tenant_acme"},
+		{PartID: "tenant_acme-PART-01", SupplierID: "SUP-01", TenantID: "tenant_acme"}, // duplicate is ignored
+	} {
+		if err := q.AddPartSupplier(ctx, link); err != nil {
+			t.Fatalf("add part supplier: %v", err)
+		}
+	}
+
+	sups, err := q.ListPartSuppliers(ctx, db.ListPartSuppliersParams{TenantID: "tenant_acme", PartID: "tenant_acme-PART-00"})
+	if err != nil || len(sups) != 2 || sups[0].SupplierID != "SUP-01" || sups[1].SupplierID != "SUP-02" {
+		t.Fatalf("unexpected part suppliers: %+v %v", sups, err)
+	}
+	ps, err := q.ListSupplierParts(ctx, db.ListSupplierPartsParams{TenantID: "tenant_acme", SupplierID: "SUP-01"})
+	if err != nil || len(ps) != 2 {
+		t.Fatalf("expected 2 parts for SUP-01, got %d %v", len(ps), err)
+	}
+
+	// a link needs a part and a supplier of its own tenant
+	for _, link := range []db.AddPartSupplierParams{
+		{PartID: "tenant_acme-PART-02", SupplierID: "missing", TenantID: "tenant_acme"},
+		{PartID: "tenant_globex-PART-02", SupplierID: "SUP-01", TenantID: "tenant_globex"},
+		{PartID: "tenant_globex-PART-02", SupplierID: "SUP-01", TenantID: "tenant_acme"},
+	} {
+		err = q.AddPartSupplier(ctx, link)
+		if err == nil || !strings.Contains(err.Error(), "must belong to the tenant") {
+			t.Errorf("expected %+v to be refused, got %v", link, err)
+		}
+	}
+	ps, err = q.ListSupplierParts(ctx, db.ListSupplierPartsParams{TenantID: "tenant_globex", SupplierID: "SUP-01"})
+	if err != nil || len(ps) != 0 {
+		t.Errorf("expected another tenant to see no links, got %d %v", len(ps), err)
+	}
+	sups, err = q.ListPartSuppliers(ctx, db.ListPartSuppliersParams{TenantID: "tenant_globex", PartID: "tenant_acme-PART-00"})
+	if err != nil || len(sups) != 0 {
+		t.Errorf("expected another tenant to see no links, got %d %v", len(sups), err)
+	}
+	_, err = q.CreatePart(ctx, db.CreatePartParams{
+		PartID: "orphan", TenantID: "tenant_acme", PartNumber: "P-X", Description: "orphan",
+		DefaultSupplierID: sql.NullString{String: "missing", Valid: true},
+	})
+	if err == nil {
+		t.Error("expected foreign key error for unknown default supplier")
+	}
+
+	// purging a supplier removes its links and clears it as a default supplier
+	_, err = q.CreatePart(ctx, db.CreatePartParams{
+		PartID: "defaulted", TenantID: "tenant_acme", PartNumber: "P-D", Description: "defaulted",
+		DefaultSupplierID: sql.NullString{String: "SUP-01", Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.PurgeSupplier(ctx, db.PurgeSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-01"}); err != nil {
+		t.Fatalf("purge supplier: %v", err)
+	}
+	ps, err = q.ListSupplierParts(ctx, db.ListSupplierPartsParams{TenantID: "tenant_acme", SupplierID: "SUP-01"})
+	if err != nil || len(ps) != 0 {
+		t.Errorf("expected links to be deleted with the supplier, got %d %v", len(ps), err)
+	}
+	part, err := q.GetPart(ctx, db.GetPartParams{TenantID: "tenant_acme", PartID: "defaulted"})
+	if err != nil || part.DefaultSupplierID.Valid {
+		t.Errorf("expected default supplier to be cleared, got %+v %v", part.DefaultSupplierID, err)
+	}
+
+	// purging a part removes its links
+	if _, err := q.PurgePart(ctx, db.PurgePartParams{TenantID: "tenant_acme", PartID: "tenant_acme-PART-00"}); err != nil {
+		t.Fatalf("purge part: %v", err)
+	}
+	ps, err = q.ListSupplierParts(ctx, db.ListSupplierPartsParams{TenantID: "tenant_acme", SupplierID: "SUP-02"})
+	if err != nil || len(ps) != 0 {
+		t.Errorf("expected links to be deleted with the part, got %d %v", len(ps), err)
+	}
+}
+
+func TestSupplierHistoryAsOf(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	if err := Migrate(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	q := db.New(conn)
+
+	q1 := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	q2 := time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC)
+	q3 := time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC)
+	upsert := func(tier string, at time.Time, schemaVersion string) {
+		t.Helper()
+		_, err := q.UpsertSupplier(ctx, db.UpsertSupplierParams{
+			SupplierID:        "SUP-1",
+			TenantID:          "tenant_acme",
+			LegalName:         "Acme",
+			FinancialRiskTier: sql.NullString{String: tier, Valid: true},
+			SourceTimestamp:   sql.NullTime{Time: at, Valid: true},
+			SchemaVersion:     sql.NullString{String: schemaVersion, Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	upsert("LOW", q1, "1.0.0")
+	upsert("HIGH", q2, "1.0.0")
+	// only load metadata changes: no new version
+	upsert("HIGH", q3, "1.1.0")
+
+	history, err := q.ListSupplierHistory(ctx, db.ListSupplierHistoryParams{TenantID: "tenant_acme", SupplierID: "SUP-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(history))
+	}
+	if !history[0].ValidFrom.Equal(q1) || !history[0].ValidTo.Time.Equal(q2) || history[0].IsCurrent {
+		t.Errorf("unexpected first version %v - %v current=%v", history[0].ValidFrom, history[0].ValidTo, history[0].IsCurrent)
+	}
+	if !history[1].IsCurrent || history[1].ValidTo.Valid {
+		t.Errorf("expected second version to be open and current")
+	}
+
+	for _, tt := range []struct {
+		asOf time.Time
+		tier string
+	}{
+		{q1, "LOW"},
+		{q1.Add(30 * 24 * time.Hour), "LOW"},
+		{q2.Add(-time.Millisecond), "LOW"},
+		{q2, "HIGH"},
+		{q2.In(time.FixedZone("PST", -8*3600)), "HIGH"},
+		{q3.Add(24 * time.Hour), "HIGH"},
+	} {
+		v, err := q.GetSupplierAsOf(ctx, db.GetSupplierAsOfParams{TenantID: "tenant_acme", SupplierID: "SUP-1", AsOf: tt.asOf})
+		if err != nil {
+			t.Fatalf("as of %v: %v", tt.asOf, err)
+		}
+		if v.FinancialRiskTier.String != tt.tier {
+			t.Errorf("as of %v: expected %s, got %s", tt.asOf, tt.tier, v.FinancialRiskTier.String)
+		}
+	}
+
+	if _, err := q.GetSupplierAsOf(ctx, db.GetSupplierAsOfParams{TenantID: "tenant_acme", SupplierID: "SUP-1", AsOf: q1.Add(-time.Hour)}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected no version before the first load, got %v", err)
+	}
+
+	// purging closes the current version but keeps the history
+	if _, err := q.PurgeSupplier(ctx, db.PurgeSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.GetSupplierAsOf(ctx, db.GetSupplierAsOfParams{TenantID: "tenant_acme", SupplierID: "SUP-1", AsOf: time.Now().Add(time.Hour)}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected no version after delete, got %v", err)
+	}
+	v, err := q.GetSupplierAsOf(ctx, db.GetSupplierAsOfParams{TenantID: "tenant_acme", SupplierID: "SUP-1", AsOf: q3})
+	if err != nil || v.FinancialRiskTier.String != "HIGH" {
+		t.Errorf("expected deleted supplier to stay queryable in the past: %+v %v", v, err)
+	}
+}
+
+func TestPartHistoryRecordsDefaultSupplierNullified(t *testing.T) {
+	ctx := context.Background()
+	q := seedQueries(t)
+
+	_, err := q.CreatePart(ctx, db.CreatePartParams{
+		PartID: "P-DEFAULT", TenantID: "tenant_acme", PartNumber: "P-D", Description: "defaulted",
+		DefaultSupplierID: sql.NullString{String: "SUP-01", Valid: true},
+		SourceTimestamp:   sql.NullTime{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.PurgeSupplier(ctx, db.PurgeSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-01"}); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := q.ListPartHistory(ctx, db.ListPartHistoryParams{TenantID: "tenant_acme", PartID: "P-DEFAULT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].DefaultSupplierID.String != "SUP-01" || history[1].DefaultSupplierID.Valid {
+		t.Errorf("expected the cleared default supplier to be a new version, got %+v", history)
+	}
+
+	v, err := q.GetPartAsOf(ctx, db.GetPartAsOfParams{TenantID: "tenant_acme", PartID: "P-DEFAULT", AsOf: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil || v.DefaultSupplierID.String != "SUP-01" {
+		t.Errorf("expected SUP-01 as of June, got %+v %v", v.DefaultSupplierID, err)
+	}
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	q := seedQueries(t)
+	by := sql.NullString{String: "alice", Valid: true}
+
+	n, err := q.DeleteSupplier(ctx, db.DeleteSupplierParams{DeletedBy: by, TenantID: "tenant_acme", SupplierID: "SUP-00"})
+	if err != nil || n != 1 {
+		t.Fatalf("delete supplier: %d %v", n, err)
+	}
+	if n, err := q.DeleteSupplier(ctx, db.DeleteSupplierParams{DeletedBy: by, TenantID: "tenant_acme", SupplierID: "SUP-00"}); err != nil || n != 0 {
+		t.Errorf("expected deleting twice to affect no rows, got %d %v", n, err)
+	}
+
+	// soft-deleted rows are hidden from reads
+	if _, err := q.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-00"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected deleted supplier to be hidden, got %v", err)
+	}
+	if _, err := q.GetSupplierByCode(ctx, db.GetSupplierByCodeParams{TenantID: "tenant_acme", SupplierCode: sql.NullString{String: "C00", Valid: true}}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected deleted supplier to be hidden by code, got %v", err)
+	}
+	count, err := q.CountSuppliers(ctx, db.CountSuppliersParams{TenantID: "tenant_acme"})
+	if err != nil || count != 8 {
+		t.Errorf("expected 8 suppliers, got %d %v", count, err)
+	}
+	page, err := q.ListSuppliers(ctx, db.ListSuppliersParams{TenantID: "tenant_acme", PageSize: 1})
+	if err != nil || len(page) != 1 || page[0].SupplierID != "SUP-01" {
+		t.Errorf("expected listing to skip SUP-00, got %+v %v", page, err)
+	}
+
+	n, err = q.DeletePart(ctx, db.DeletePartParams{DeletedBy: by, TenantID: "tenant_acme", PartID: "tenant_acme-PART-00"})
+	if err != nil || n != 1 {
+		t.Fatalf("delete part: %d %v", n, err)
+	}
+	if _, err := q.GetPartByNumber(ctx, db.GetPartByNumberParams{TenantID: "tenant_acme", PartNumber: "P-000000"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected deleted part to be hidden by number, got %v", err)
+	}
+	count, err = q.CountParts(ctx, db.CountPartsParams{TenantID: "tenant_acme"})
+	if err != nil || count != 4 {
+		t.Errorf("expected 4 parts, got %d %v", count, err)
+	}
+
+	// restoring brings the row back and clears the deletion
+	n, err = q.RestoreSupplier(ctx, db.RestoreSupplierParams{ModifiedBy: by, TenantID: "tenant_acme", SupplierID: "SUP-00"})
+	if err != nil || n != 1 {
+		t.Fatalf("restore supplier: %d %v", n, err)
+	}
+	sup, err := q.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-00"})
+	if err != nil || sup.DeletedAt.Valid || sup.DeletedBy.Valid || sup.ModifiedBy != by {
+		t.Errorf("expected restored supplier, got %+v %v", sup, err)
+	}
+	if n, err := q.RestoreSupplier(ctx, db.RestoreSupplierParams{ModifiedBy: by, TenantID: "tenant_acme", SupplierID: "SUP-01"}); err != nil || n != 0 {
+		t.Errorf("expected restoring a live supplier to affect no rows, got %d %v", n, err)
+	}
+
+	// the history has a gap while the supplier was deleted
+	history, err := q.ListSupplierHistory(ctx, db.ListSupplierHistoryParams{TenantID: "tenant_acme", SupplierID: "SUP-00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || !history[0].ValidTo.Valid || !history[1].IsCurrent {
+		t.Errorf("expected a closed and a current version, got %+v", history)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	if err := Migrate(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	q := db.New(conn)
+	alice := sql.NullString{String: "alice", Valid: true}
+	bob := sql.NullString{String: "bob", Valid: true}
+
+	_, err := q.CreateSupplier(ctx, db.CreateSupplierParams{
+		SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Acme", ModifiedBy: alice,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = q.UpsertSupplier(ctx, db.UpsertSupplierParams{
+		SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Acme Corp", ModifiedBy: bob,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.DeleteSupplier(ctx, db.DeleteSupplierParams{DeletedBy: alice, TenantID: "tenant_acme", SupplierID: "SUP-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.RestoreSupplier(ctx, db.RestoreSupplierParams{ModifiedBy: bob, TenantID: "tenant_acme", SupplierID: "SUP-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.PurgeSupplier(ctx, db.PurgeSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := q.ListAuditLog(ctx, db.ListAuditLogParams{TenantID: "tenant_acme", Entity: "supplier", EntityID: "SUP-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ action, actor string }{
+		{"create", "alice"}, {"update", "bob"}, {"delete", "alice"}, {"restore", "bob"}, {"purge", "system"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d audit entries, got %+v", len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Action != w.action || e.Actor != w.actor || e.TenantID != "tenant_acme" {
+			t.Errorf("entry %d: expected %s by %s, got %s by %s", i, w.action, w.actor, e.Action, e.Actor)
+		}
+	}
+	if entries[0].BeforeJson.Valid || !strings.Contains(entries[0].AfterJson.String, `"legal_name":"Acme"`) {
+		t.Errorf("create: unexpected images %+v", entries[0])
+	}
+	if !strings.Contains(entries[1].BeforeJson.String, `"legal_name":"Acme"`) || !strings.Contains(entries[1].AfterJson.String, `"legal_name":"Acme Corp"`) {
+		t.Errorf("update: unexpected images %+v", entries[1])
+	}
+	if !entries[4].BeforeJson.Valid || entries[4].AfterJson.Valid {
+		t.Errorf("purge: unexpected images %+v", entries[4])
+	}
+
+	// keyset pagination over the tenant's log
+	first, err := q.ListTenantAuditLog(ctx, db.ListTenantAuditLogParams{TenantID: "tenant_acme", PageSize: 3})
+	if err != nil || len(first) != 3 {
+		t.Fatalf("first page: %d %v", len(first), err)
+	}
+	rest, err := q.ListTenantAuditLog(ctx, db.ListTenantAuditLogParams{TenantID: "tenant_acme", After: first[2].AuditID, PageSize: 3})
+	if err != nil || len(rest) != 2 || rest[0].AuditID <= first[2].AuditID {
+		t.Errorf("second page: %+v %v", rest, err)
+	}
+
+	// the log is append-only
+	if _, err := conn.Exec("UPDATE audit_log SET actor = 'mallory'"); err == nil {
+		t.Error("expected audit_log updates to be rejected")
+	}
+}
@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+)
+
+// ErrNotFound is returned by a Repository when a row does not exist, is
+// soft-deleted, or belongs to another tenant. The three cases are
+// indistinguishable on purpose so callers cannot probe other tenants' IDs.
+var ErrNotFound = errors.New("not found")
+
+// ErrNoTenant is returned by NewRepository for an empty tenant ID.
+var ErrNoTenant = errors.New("tenant ID is required")
+
+// Repository runs the queries for a single tenant. Every query is filtered by
+// the tenant, and the TenantID of any params passed in is overwritten, so a
+// Repository can neither read nor change another tenant's rows.
+type Repository struct {
+	q      *db.Queries
+	tenant string
+}
+
+// NewRepository scopes q to tenantID.
+func NewRepository(q *db.Queries, tenantID string) (*Repository, error) {
+	if tenantID == "" {
+		return nil, ErrNoTenant
+	}
+	return &Repository{q: q, tenant: tenantID}, nil
+}
+
+// TenantID returns the tenant the repository is scoped to.
+func (r *Repository) TenantID() string {
+	return r.tenant
+}
+
+// one maps sql.ErrNoRows to ErrNotFound.
+func one[T any](row T, err error) (T, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return row, ErrNotFound
+	}
+	return row, err
+}
+
+// affected maps a write that changed no rows to ErrNotFound.
+func affected(n int64, err error) error {
+	if err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *Repository) CreateSupplier(ctx context.Context, arg db.CreateSupplierParams) error {
+	arg.TenantID = r.tenant
+	_, err := r.q.CreateSupplier(ctx, arg)
+	return err
+}
+
+func (r *Repository) CreatePart(ctx context.Context, arg db.CreatePartParams) error {
+	arg.TenantID = r.tenant
+	_, err := r.q.CreatePart(ctx, arg)
+	return err
+}
+
+// UpsertSupplier reports whether the row was inserted or replaced. A supplier
+// ID taken by another tenant is left alone and reported as unchanged.
+func (r *Repository) UpsertSupplier(ctx context.Context, arg db.UpsertSupplierParams) (bool, error) {
+	arg.TenantID = r.tenant
+	n, err := r.q.UpsertSupplier(ctx, arg)
+	return n > 0, err
+}
+
+// UpsertPart behaves like UpsertSupplier.
+func (r *Repository) UpsertPart(ctx context.Context, arg db.UpsertPartParams) (bool, error) {
+	arg.TenantID = r.tenant
+	n, err := r.q.UpsertPart(ctx, arg)
+	return n > 0, err
+}
+
+func (r *Repository) DeleteSupplier(ctx context.Context, supplierID string, by sql.NullString) error {
+	return affected(r.q.DeleteSupplier(ctx, db.DeleteSupplierParams{DeletedBy: by, TenantID: r.tenant, SupplierID: supplierID}))
+}
+
+func (r *Repository) DeletePart(ctx context.Context, partID string, by sql.NullString) error {
+	return affected(r.q.DeletePart(ctx, db.DeletePartParams{DeletedBy: by, TenantID: r.tenant, PartID: partID}))
+}
+
+func (r *Repository) RestoreSupplier(ctx context.Context, supplierID string, by sql.NullString) error {
+	return affected(r.q.RestoreSupplier(ctx, db.RestoreSupplierParams{ModifiedBy: by, TenantID: r.tenant, SupplierID: supplierID}))
+}
+
+func (r *Repository) RestorePart(ctx context.Context, partID string, by sql.NullString) error {
+	return affected(r.q.RestorePart(ctx, db.RestorePartParams{ModifiedBy: by, TenantID: r.tenant, PartID: partID}))
+}
+
+func (r *Repository) PurgeSupplier(ctx context.Context, supplierID string) error {
+	return affected(r.q.PurgeSupplier(ctx, db.PurgeSupplierParams{TenantID: r.tenant, SupplierID: supplierID}))
+}
+
+func (r *Repository) PurgePart(ctx context.Context, partID string) error {
+	return affected(r.q.PurgePart(ctx, db.PurgePartParams{TenantID: r.tenant, PartID: partID}))
+}
+
+// SupplierExists also counts soft-deleted suppliers.
+func (r *Repository) SupplierExists(ctx context.Context, supplierID string) (bool, error) {
+	n, err := r.q.SupplierExists(ctx, db.SupplierExistsParams{TenantID: r.tenant, SupplierID: supplierID})
+	return n == 1, err
+}
+
+// PartExists also counts soft-deleted parts.
+func (r *Repository) PartExists(ctx context.Context, partID string) (bool, error) {
+	n, err := r.q.PartExists(ctx, db.PartExistsParams{TenantID: r.tenant, PartID: partID})
+	return n == 1, err
+}
+
+func (r *Repository) GetSupplier(ctx context.Context, supplierID string) (db.DimSupplierV1, error) {
+	return one(r.q.GetSupplier(ctx, db.GetSupplierParams{TenantID: r.tenant, SupplierID: supplierID}))
+}
+
+func (r *Repository) GetSupplierByCode(ctx context.Context, code string) (db.DimSupplierV1, error) {
+	return one(r.q.GetSupplierByCode(ctx, db.GetSupplierByCodeParams{TenantID: r.tenant, SupplierCode: sql.NullString{String: code, Valid: true}}))
+}
+
+func (r *Repository) GetPart(ctx context.Context, partID string) (db.DimPartV1, error) {
+	return one(r.q.GetPart(ctx, db.GetPartParams{TenantID: r.tenant, PartID: partID}))
+}
+
+func (r *Repository) GetPartByNumber(ctx context.Context, partNumber string) (db.DimPartV1, error) {
+	return one(r.q.GetPartByNumber(ctx, db.GetPartByNumberParams{TenantID: r.tenant, PartNumber: partNumber}))
+}
+
+func (r *Repository) ListSuppliers(ctx context.Context, arg db.ListSuppliersParams) ([]db.DimSupplierV1, error) {
+	arg.TenantID = r.tenant
+	return r.q.ListSuppliers(ctx, arg)
+}
+
+func (r *Repository) CountSuppliers(ctx context.Context, arg db.CountSuppliersParams) (int64, error) {
+	arg.TenantID = r.tenant
+	return r.q.CountSuppliers(ctx, arg)
+}
+
+func (r *Repository) ListParts(ctx context.Context, arg db.ListPartsParams) ([]db.DimPartV1, error) {
+	arg.TenantID = r.tenant
+	return r.q.ListParts(ctx, arg)
+}
+
+func (r *Repository) CountParts(ctx context.Context, arg db.CountPartsParams) (int64, error) {
+	arg.TenantID = r.tenant
+	return r.q.CountParts(ctx, arg)
+}
+
+// AddPartSupplier links a part to a supplier. Both must belong to the tenant;
+// the foreign keys alone would accept a link to another tenant's supplier.
+func (r *Repository) AddPartSupplier(ctx context.Context, partID, supplierID string) error {
+	ok, err := r.PartExists(ctx, partID)
+	if err == nil && ok {
+		ok, err = r.SupplierExists(ctx, supplierID)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFound
+	}
+	return r.q.AddPartSupplier(ctx, db.AddPartSupplierParams{PartID: partID, SupplierID: supplierID, TenantID: r.tenant})
+}
+
+func (r *Repository) RemovePartSupplier(ctx context.Context, partID, supplierID string) error {
+	return r.q.RemovePartSupplier(ctx, db.RemovePartSupplierParams{TenantID: r.tenant, PartID: partID, SupplierID: supplierID})
+}
+
+func (r *Repository) ListSupplierParts(ctx context.Context, supplierID string) ([]db.DimPartV1, error) {
+	return r.q.ListSupplierParts(ctx, db.ListSupplierPartsParams{TenantID: r.tenant, SupplierID: supplierID})
+}
+
+func (r *Repository) ListPartSuppliers(ctx context.Context, partID string) ([]db.DimSupplierV1, error) {
+	return r.q.ListPartSuppliers(ctx, db.ListPartSuppliersParams{TenantID: r.tenant, PartID: partID})
+}
+
+func (r *Repository) GetSupplierAsOf(ctx context.Context, supplierID string, asOf time.Time) (db.DimSupplierV1History, error) {
+	return one(r.q.GetSupplierAsOf(ctx, db.GetSupplierAsOfParams{TenantID: r.tenant, SupplierID: supplierID, AsOf: asOf}))
+}
+
+func (r *Repository) GetPartAsOf(ctx context.Context, partID string, asOf time.Time) (db.DimPartV1History, error) {
+	return one(r.q.GetPartAsOf(ctx, db.GetPartAsOfParams{TenantID: r.tenant, PartID: partID, AsOf: asOf}))
+}
+
+func (r *Repository) ListSupplierHistory(ctx context.Context, supplierID string) ([]db.DimSupplierV1History, error) {
+	return r.q.ListSupplierHistory(ctx, db.ListSupplierHistoryParams{TenantID: r.tenant, SupplierID: supplierID})
+}
+
+func (r *Repository) ListPartHistory(ctx context.Context, partID string) ([]db.DimPartV1History, error) {
+	return r.q.ListPartHistory(ctx, db.ListPartHistoryParams{TenantID: r.tenant, PartID: partID})
+}
+
+func (r *Repository) ListAuditLog(ctx context.Context, entity, entityID string) ([]db.AuditLog, error) {
+	return r.q.ListAuditLog(ctx, db.ListAuditLogParams{TenantID: r.tenant, Entity: entity, EntityID: entityID})
+}
+
+func (r *Repository) ListTenantAuditLog(ctx context.Context, after, pageSize int64) ([]db.AuditLog, error) {
+	return r.q.ListTenantAuditLog(ctx, db.ListTenantAuditLogParams{TenantID: r.tenant, After: after, PageSize: pageSize})
+}
+
+// SearchSuppliers takes user input and builds the query with SearchQuery.
+func (r *Repository) SearchSuppliers(ctx context.Context, text string, limit int64) ([]db.SearchSuppliersRow, error) {
+	query := SearchQuery(text)
+	if query == "" {
+		return nil, nil
+	}
+	return r.q.SearchSuppliers(ctx, db.SearchSuppliersParams{Query: query, TenantID: r.tenant, PageSize: limit})
+}
+
+// SearchParts takes user input and builds the query with SearchQuery.
+func (r *Repository) SearchParts(ctx context.Context, text string, limit int64) ([]db.SearchPartsRow, error) {
+	query := SearchQuery(text)
+	if query == "" {
+		return nil, nil
+	}
+	return r.q.SearchParts(ctx, db.SearchPartsParams{Query: query, TenantID: r.tenant, PageSize: limit})
+}
+
+func (r *Repository) SummarizeParts(ctx context.Context) ([]db.SummarizePartsRow, error) {
+	return r.q.SummarizeParts(ctx, r.tenant)
+}
@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+)
+
+// seedTenant creates a supplier, a part linked to it and their history and
+// audit rows for the repository's tenant.
+func seedTenant(t *testing.T, r *Repository, name, supplierID, partID string) {
+	t.Helper()
+	ctx := context.Background()
+	err := r.CreateSupplier(ctx, db.CreateSupplierParams{
+		SupplierID:   supplierID,
+		SupplierCode: sql.NullString{String: "CODE-" + supplierID, Valid: true},
+		LegalName:    name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.CreatePart(ctx, db.CreatePartParams{
+		PartID:          partID,
+		PartNumber:      "NUM-" + partID,
+		Description:     "hex bolt",
+		Category:        sql.NullString{String: "FASTENER", Valid: true},
+		LifecycleStatus: sql.NullString{String: "ACTIVE", Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddPartSupplier(ctx, partID, supplierID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewRepositoryRequiresTenant(t *testing.T) {
+	if _, err := NewRepository(nil, ""); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("expected ErrNoTenant, got %v", err)
+	}
+}
+
+func TestRepositoryTenantIsolation(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	if err := Migrate(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	q := db.New(conn)
+	acme, err := NewRepository(q, "tenant_acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	globex, err := NewRepository(q, "tenant_globex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedTenant(t, acme, "Acme Fasteners", "SUP-A", "PART-A")
+	seedTenant(t, globex, "Globex Fasteners", "SUP-G", "PART-G")
+
+	// params naming another tenant are rescoped to the repository's own
+	err = globex.CreateSupplier(ctx, db.CreateSupplierParams{SupplierID: "SUP-SNEAKY", TenantID: "tenant_acme", LegalName: "Sneaky"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := acme.GetSupplier(ctx, "SUP-SNEAKY"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a supplier created by globex to stay in globex, got %v", err)
+	}
+
+	// every query from globex against acme's rows finds nothing
+	by := sql.NullString{String: "mallory", Valid: true}
+	notFound := map[string]func() error{
+		"DeleteSupplier":  func() error { return globex.DeleteSupplier(ctx, "SUP-A", by) },
+		"DeletePart":      func() error { return globex.DeletePart(ctx, "PART-A", by) },
+		"RestoreSupplier": func() error { return globex.RestoreSupplier(ctx, "SUP-A", by) },
+		"RestorePart":     func() error { return globex.RestorePart(ctx, "PART-A", by) },
+		"PurgeSupplier":   func() error { return globex.PurgeSupplier(ctx, "SUP-A") },
+		"PurgePart":       func() error { return globex.PurgePart(ctx, "PART-A") },
+		"GetSupplier": func() error {
+			_, err := globex.GetSupplier(ctx, "SUP-A")
+			return err
+		},
+		"GetSupplierByCode": func() error {
+			_, err := globex.GetSupplierByCode(ctx, "CODE-SUP-A")
+			return err
+		},
+		"GetPart": func() error {
+			_, err := globex.GetPart(ctx, "PART-A")
+			return err
+		},
+		"GetPartByNumber": func() error {
+			_, err := globex.GetPartByNumber(ctx, "NUM-PART-A")
+			return err
+		},
+		"GetSupplierAsOf": func() error {
+			_, err := globex.GetSupplierAsOf(ctx, "SUP-A", time.Now().Add(time.Hour))
+			return err
+		},
+		"GetPartAsOf": func() error {
+			_, err := globex.GetPartAsOf(ctx, "PART-A", time.Now().Add(time.Hour))
+			return err
+		},
+		"AddPartSupplier (their part)":     func() error { return globex.AddPartSupplier(ctx, "PART-A", "SUP-G") },
+		"AddPartSupplier (their supplier)": func() error { return globex.AddPartSupplier(ctx, "PART-G", "SUP-A") },
+	}
+	for name, run := range notFound {
+		if err := run(); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound, got %v", name, err)
+		}
+	}
+
+	empty := map[string]func() (int, error){
+		"SupplierExists":    func() (int, error) { ok, err := globex.SupplierExists(ctx, "SUP-A"); return count(ok), err },
+		"PartExists":        func() (int, error) { ok, err := globex.PartExists(ctx, "PART-A"); return count(ok), err },
+		"ListSupplierParts": func() (int, error) { rows, err := globex.ListSupplierParts(ctx, "SUP-A"); return len(rows), err },
+		"ListPartSuppliers": func() (int, error) { rows, err := globex.ListPartSuppliers(ctx, "PART-A"); return len(rows), err },
+		"ListSupplierHistory": func() (int, error) {
+			rows, err := globex.ListSupplierHistory(ctx, "SUP-A")
+			return len(rows), err
+		},
+		"ListPartHistory": func() (int, error) { rows, err := globex.ListPartHistory(ctx, "PART-A"); return len(rows), err },
+		"ListAuditLog": func() (int, error) {
+			rows, err := globex.ListAuditLog(ctx, "supplier", "SUP-A")
+			return len(rows), err
+		},
+		"SearchSuppliers": func() (int, error) { rows, err := globex.SearchSuppliers(ctx, "acme", 10); return len(rows), err },
+		"SearchParts":     func() (int, error) { rows, err := globex.SearchParts(ctx, "NUM-PART-A", 10); return len(rows), err },
+		"UpsertSupplier": func() (int, error) {
+			changed, err := globex.UpsertSupplier(ctx, db.UpsertSupplierParams{SupplierID: "SUP-A", LegalName: "Hijacked"})
+			return count(changed), err
+		},
+		"UpsertPart": func() (int, error) {
+			changed, err := globex.UpsertPart(ctx, db.UpsertPartParams{PartID: "PART-A", PartNumber: "X", Description: "hijacked"})
+			return count(changed), err
+		},
+	}
+	for name, run := range empty {
+		if n, err := run(); err != nil || n != 0 {
+			t.Errorf("%s: expected nothing from another tenant, got %d %v", name, n, err)
+		}
+	}
+
+	// listings, counts, the audit log and the summary only see the tenant's rows
+	sups, err := globex.ListSuppliers(ctx, db.ListSuppliersParams{TenantID: "tenant_acme", PageSize: 10})
+	if err != nil || len(sups) != 2 || sups[0].SupplierID != "SUP-G" || sups[1].SupplierID != "SUP-SNEAKY" {
+		t.Errorf("ListSuppliers: unexpected %+v %v", sups, err)
+	}
+	if n, err := globex.CountSuppliers(ctx, db.CountSuppliersParams{}); err != nil || n != 2 {
+		t.Errorf("CountSuppliers: expected 2, got %d %v", n, err)
+	}
+	parts, err := globex.ListParts(ctx, db.ListPartsParams{PageSize: 10})
+	if err != nil || len(parts) != 1 || parts[0].PartID != "PART-G" {
+		t.Errorf("ListParts: unexpected %+v %v", parts, err)
+	}
+	if n, err := globex.CountParts(ctx, db.CountPartsParams{}); err != nil || n != 1 {
+		t.Errorf("CountParts: expected 1, got %d %v", n, err)
+	}
+	entries, err := globex.ListTenantAuditLog(ctx, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.TenantID != "tenant_globex" {
+			t.Errorf("ListTenantAuditLog: got an entry of %s", e.TenantID)
+		}
+	}
+	summary, err := globex.SummarizeParts(ctx)
+	if err != nil || len(summary) != 1 || summary[0].Parts != 1 {
+		t.Errorf("SummarizeParts: unexpected %+v %v", summary, err)
+	}
+
+	// removing acme's link from globex does nothing
+	if err := globex.RemovePartSupplier(ctx, "PART-A", "SUP-A"); err != nil {
+		t.Fatal(err)
+	}
+
+	// acme's rows are untouched
+	sup, err := acme.GetSupplier(ctx, "SUP-A")
+	if err != nil || sup.LegalName != "Acme Fasteners" {
+		t.Errorf("expected acme's supplier to be untouched, got %+v %v", sup, err)
+	}
+	part, err := acme.GetPart(ctx, "PART-A")
+	if err != nil || part.PartNumber != "NUM-PART-A" {
+		t.Errorf("expected acme's part to be untouched, got %+v %v", part, err)
+	}
+	if links, err := acme.ListPartSuppliers(ctx, "PART-A"); err != nil || len(links) != 1 {
+		t.Errorf("expected acme's link to be untouched, got %+v %v", links, err)
+	}
+	if rows, err := acme.SearchSuppliers(ctx, "acme", 10); err != nil || len(rows) != 1 || rows[0].SupplierID != "SUP-A" {
+		t.Errorf("expected acme to find only its own supplier, got %+v %v", rows, err)
+	}
+
+	// the owner can still do everything globex could not
+	if err := acme.DeleteSupplier(ctx, "SUP-A", by); err != nil {
+		t.Errorf("delete own supplier: %v", err)
+	}
+	if err := acme.RestoreSupplier(ctx, "SUP-A", by); err != nil {
+		t.Errorf("restore own supplier: %v", err)
+	}
+	if err := acme.PurgePart(ctx, "PART-A"); err != nil {
+		t.Errorf("purge own part: %v", err)
+	}
+}
+
+func count(ok bool) int {
+	if ok {
+		return 1
+	}
+	return 0
+}
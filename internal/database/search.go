@@ -0,0 +1,23 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchQuery turns free text typed by a user into an FTS5 query for
+// SearchSuppliers and SearchParts. Each word becomes a quoted prefix term, so
+// "acme elec" matches "Acme Electronics" and punctuation in the input cannot
+// be read as FTS5 syntax. All terms must match. It returns "" when the text
+// has no words, which callers should treat as no search rather than passing
+// it to MATCH.
+func SearchQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + w + `"*`
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+)
+
+func TestSearchQuery(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"acme", `"acme"*`},
+		{"  Acme, elec ", `"Acme"* "elec"*`},
+		{"P-000123", `"P"* "000123"*`},
+		{`"quoted" OR NOT*`, `"quoted"* "OR"* "NOT"*`},
+		{"Zürich", `"Zürich"*`},
+		{" -*() ", ""},
+	} {
+		if got := SearchQuery(tt.in); got != tt.want {
+			t.Errorf("SearchQuery(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchSuppliersAndParts(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	if err := Migrate(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	q := db.New(conn)
+
+	for _, s := range []db.CreateSupplierParams{
+		{SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Acme Electronics GmbH", City: sql.NullString{String: "München", Valid: true}},
+		{SupplierID: "SUP-2", TenantID: "tenant_acme", LegalName: "Globex Fasteners", DbaName: sql.NullString{String: "Acme Bolts", Valid: true}},
+		{SupplierID: "SUP-3", TenantID: "tenant_acme", LegalName: "Initech", City: sql.NullString{String: "Acmeville", Valid: true}},
+		{SupplierID: "SUP-4", TenantID: "tenant_globex", LegalName: "Acme Electronics Inc"},
+	} {
+		if _, err := q.CreateSupplier(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(text string) []db.SearchSuppliersRow {
+		t.Helper()
+		rows, err := q.SearchSuppliers(ctx, db.SearchSuppliersParams{Query: SearchQuery(text), TenantID: "tenant_acme", PageSize: 10})
+		if err != nil {
+			t.Fatalf("search %q: %v", text, err)
+		}
+		return rows
+	}
+
+	// prefix matches in every column, ranked by column weight, scoped to the tenant
+	rows := search("acm")
+	if len(rows) != 3 || rows[0].SupplierID != "SUP-1" || rows[1].SupplierID != "SUP-2" || rows[2].SupplierID != "SUP-3" {
+		t.Fatalf("expected SUP-1, SUP-2, SUP-3, got %+v", rows)
+	}
+	if rows[0].Snippet != "[Acme] Electronics GmbH" {
+		t.Errorf("unexpected snippet %q", rows[0].Snippet)
+	}
+	if rows[0].Rank >= rows[2].Rank {
+		t.Errorf("expected legal name match to rank first: %v >= %v", rows[0].Rank, rows[2].Rank)
+	}
+
+	// diacritics are folded
+	if rows := search("munchen"); len(rows) != 1 || rows[0].SupplierID != "SUP-1" {
+		t.Errorf("expected diacritic-insensitive match, got %+v", rows)
+	}
+
+	// the index follows updates and soft deletes
+	_, err := q.UpsertSupplier(ctx, db.UpsertSupplierParams{SupplierID: "SUP-3", TenantID: "tenant_acme", LegalName: "Initech", City: sql.NullString{String: "Austin", Valid: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := search("acmeville"); len(rows) != 0 {
+		t.Errorf("expected updated city to leave the index, got %+v", rows)
+	}
+	if rows := search("austin"); len(rows) != 1 {
+		t.Errorf("expected updated city to be indexed, got %+v", rows)
+	}
+	if _, err := q.DeleteSupplier(ctx, db.DeleteSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if rows := search("bolts"); len(rows) != 0 {
+		t.Errorf("expected soft-deleted supplier to be hidden, got %+v", rows)
+	}
+	if _, err := q.PurgeSupplier(ctx, db.PurgeSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if rows := search("electronics"); len(rows) != 0 {
+		t.Errorf("expected purged supplier to leave the index, got %+v", rows)
+	}
+
+	_, err = q.CreatePart(ctx, db.CreatePartParams{PartID: "PART-1", TenantID: "tenant_acme", PartNumber: "P-000123", Description: "Hex bolt M8 zinc plated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, err := q.SearchParts(ctx, db.SearchPartsParams{Query: SearchQuery("hex bol"), TenantID: "tenant_acme", PageSize: 10})
+	if err != nil || len(parts) != 1 || parts[0].Snippet != "[Hex] [bolt] M8 zinc plated" {
+		t.Errorf("unexpected part search result %+v %v", parts, err)
+	}
+	parts, err = q.SearchParts(ctx, db.SearchPartsParams{Query: SearchQuery("P-000123"), TenantID: "tenant_acme", PageSize: 10})
+	if err != nil || len(parts) != 1 {
+		t.Errorf("expected lookup by part number, got %+v %v", parts, err)
+	}
+}
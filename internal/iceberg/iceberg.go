@@ -0,0 +1,488 @@
+// Package iceberg writes synthetic data as a local Iceberg (format v2) table:
+// Parquet data files plus JSON table metadata and Avro manifests, laid out the
+// way a Hadoop catalog expects. Every Append commits a new snapshot, so earlier
+// versions of the table stay readable for time travel.
+package iceberg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const partitionFieldIDStart = 1000
+
+type tableMetadata struct {
+	FormatVersion      int                 `json:"format-version"`
+	TableUUID          string              `json:"table-uuid"`
+	Location           string              `json:"location"`
+	LastSequenceNumber int64               `json:"last-sequence-number"`
+	LastUpdatedMs      int64               `json:"last-updated-ms"`
+	LastColumnID       int                 `json:"last-column-id"`
+	CurrentSchemaID    int                 `json:"current-schema-id"`
+	Schemas            []schemaJSON        `json:"schemas"`
+	DefaultSpecID      int                 `json:"default-spec-id"`
+	PartitionSpecs     []partitionSpecJSON `json:"partition-specs"`
+	LastPartitionID    int                 `json:"last-partition-id"`
+	DefaultSortOrderID int                 `json:"default-sort-order-id"`
+	SortOrders         []sortOrderJSON     `json:"sort-orders"`
+	Properties         map[string]string   `json:"properties"`
+	CurrentSnapshotID  *int64              `json:"current-snapshot-id,omitempty"`
+	Snapshots          []snapshotJSON      `json:"snapshots"`
+	SnapshotLog        []snapshotLogJSON   `json:"snapshot-log"`
+	MetadataLog        []metadataLogJSON   `json:"metadata-log"`
+	Refs               map[string]refJSON  `json:"refs"`
+}
+
+type partitionSpecJSON struct {
+	SpecID int                  `json:"spec-id"`
+	Fields []partitionFieldJSON `json:"fields"`
+}
+
+type partitionFieldJSON struct {
+	Name      string `json:"name"`
+	Transform string `json:"transform"`
+	SourceID  int    `json:"source-id"`
+	FieldID   int    `json:"field-id"`
+}
+
+type sortOrderJSON struct {
+	OrderID int   `json:"order-id"`
+	Fields  []any `json:"fields"`
+}
+
+type snapshotJSON struct {
+	SnapshotID       int64             `json:"snapshot-id"`
+	ParentSnapshotID *int64            `json:"parent-snapshot-id,omitempty"`
+	SequenceNumber   int64             `json:"sequence-number"`
+	TimestampMs      int64             `json:"timestamp-ms"`
+	ManifestList     string            `json:"manifest-list"`
+	Summary          map[string]string `json:"summary"`
+	SchemaID         int               `json:"schema-id"`
+}
+
+type snapshotLogJSON struct {
+	TimestampMs int64 `json:"timestamp-ms"`
+	SnapshotID  int64 `json:"snapshot-id"`
+}
+
+type metadataLogJSON struct {
+	TimestampMs  int64  `json:"timestamp-ms"`
+	MetadataFile string `json:"metadata-file"`
+}
+
+type refJSON struct {
+	SnapshotID int64  `json:"snapshot-id"`
+	Type       string `json:"type"`
+}
+
+// Snapshot describes one committed version of a table.
+type Snapshot struct {
+	SnapshotID       int64
+	ParentSnapshotID int64
+	SequenceNumber   int64
+	Timestamp        time.Time
+	AddedFiles       int
+	AddedRecords     int64
+	TotalRecords     int64
+}
+
+// DataFile is a Parquet file that belongs to a snapshot.
+type DataFile struct {
+	// Path is the file's local path; the manifests hold its file:// URI.
+	Path        string
+	Partition   map[string]string
+	RecordCount int64
+	SizeBytes   int64
+}
+
+// Table is a local Iceberg table rooted at a directory.
+type Table struct {
+	location string
+	version  int
+	schema   Schema
+	meta     *tableMetadata
+}
+
+// ErrCommitConflict is returned when another writer committed a new metadata
+// version between Open and Append.
+var ErrCommitConflict = errors.New("iceberg: concurrent commit, reopen the table and retry")
+
+// Open loads the table at location, creating it with schema and identity
+// partitioning on partitionBy if it does not exist yet. An existing table
+// must have the same schema.
+func Open(location string, schema Schema, partitionBy ...string) (*Table, error) {
+	abs, err := filepath.Abs(location)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{location: abs}
+
+	hint, err := os.ReadFile(t.metadataPath("version-hint.text"))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return t, t.create(schema, partitionBy)
+	case err != nil:
+		return nil, err
+	}
+
+	t.version, err = strconv.Atoi(strings.TrimSpace(string(hint)))
+	if err != nil {
+		return nil, fmt.Errorf("parse version hint: %w", err)
+	}
+	raw, err := os.ReadFile(t.metadataPath(fmt.Sprintf("v%d.metadata.json", t.version)))
+	if err != nil {
+		return nil, err
+	}
+	t.meta = &tableMetadata{}
+	if err := json.Unmarshal(raw, t.meta); err != nil {
+		return nil, fmt.Errorf("parse table metadata: %w", err)
+	}
+	current := slices.IndexFunc(t.meta.Schemas, func(s schemaJSON) bool { return s.SchemaID == t.meta.CurrentSchemaID })
+	if current < 0 {
+		return nil, fmt.Errorf("table metadata has no schema %d", t.meta.CurrentSchemaID)
+	}
+	t.schema, err = schemaFromJSON(t.meta.Schemas[current])
+	if err != nil {
+		return nil, err
+	}
+	if !t.schema.equal(schema) {
+		return nil, fmt.Errorf("table %s exists with a different schema", abs)
+	}
+	return t, nil
+}
+
+func (t *Table) create(schema Schema, partitionBy []string) error {
+	spec := partitionSpecJSON{SpecID: 0, Fields: []partitionFieldJSON{}}
+	for i, name := range partitionBy {
+		f, ok := schema.Field(name)
+		if !ok {
+			return fmt.Errorf("partition column %s not in schema", name)
+		}
+		if f.Type != String {
+			return fmt.Errorf("partition column %s must be a string", name)
+		}
+		spec.Fields = append(spec.Fields, partitionFieldJSON{
+			Name: name, Transform: "identity", SourceID: f.id, FieldID: partitionFieldIDStart + i,
+		})
+	}
+
+	for _, dir := range []string{t.metadataPath(""), filepath.Join(t.location, "data")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	t.schema = schema
+	t.meta = &tableMetadata{
+		FormatVersion:   2,
+		TableUUID:       uuid.NewString(),
+		Location:        fileURI(t.location),
+		LastUpdatedMs:   time.Now().UnixMilli(),
+		LastColumnID:    schema.lastColumnID,
+		Schemas:         []schemaJSON{schema.toJSON(0)},
+		PartitionSpecs:  []partitionSpecJSON{spec},
+		LastPartitionID: partitionFieldIDStart + len(spec.Fields) - 1,
+		SortOrders:      []sortOrderJSON{{OrderID: 0, Fields: []any{}}},
+		Properties:      map[string]string{"write.format.default": "parquet"},
+		Snapshots:       []snapshotJSON{},
+		SnapshotLog:     []snapshotLogJSON{},
+		MetadataLog:     []metadataLogJSON{},
+		Refs:            map[string]refJSON{},
+	}
+	return t.commit(t.meta)
+}
+
+// Location returns the table's root directory.
+func (t *Table) Location() string { return t.location }
+
+// Append writes rows as new data files (one per partition) and commits a
+// snapshot that contains them plus every file of the current snapshot.
+// Each row holds one value per schema column, in column order.
+func (t *Table) Append(rows [][]any) (Snapshot, error) {
+	if len(rows) == 0 {
+		return Snapshot{}, fmt.Errorf("nothing to append")
+	}
+	for i, row := range rows {
+		if len(row) != len(t.schema.Fields) {
+			return Snapshot{}, fmt.Errorf("row %d has %d values, schema has %d columns", i, len(row), len(t.schema.Fields))
+		}
+	}
+
+	now := time.Now()
+	seq := t.meta.LastSequenceNumber + 1
+	snapID := rand.Int63()
+	spec := t.meta.PartitionSpecs[0].Fields
+
+	// 1. group rows by partition value and write one parquet file per group
+	groups := map[string][][]any{}
+	partitions := map[string]map[string]any{}
+	for _, row := range rows {
+		part := map[string]any{}
+		var dirs []string
+		for _, pf := range spec {
+			v, _ := row[t.schema.index(pf.SourceID)].(string)
+			part[pf.Name] = v
+			// escaped like Hive, so a value cannot add path elements
+			dirs = append(dirs, pf.Name+"="+url.PathEscape(v))
+		}
+		key := filepath.Join(dirs...)
+		groups[key] = append(groups[key], row)
+		partitions[key] = part
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var entries []manifestEntry
+	var addedRecords, addedBytes int64
+	for _, key := range keys {
+		dir := filepath.Join(t.location, "data", key)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return Snapshot{}, err
+		}
+		path := filepath.Join(dir, fmt.Sprintf("00000-%d-%s.parquet", seq, uuid.NewString()))
+		size, err := writeParquet(path, t.schema, groups[key])
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("write data file: %w", err)
+		}
+		entries = append(entries, manifestEntry{
+			Status:     statusAdded,
+			SnapshotID: &snapID,
+			DataFile: dataFile{
+				FilePath:        fileURI(path),
+				FileFormat:      "PARQUET",
+				Partition:       partitions[key],
+				RecordCount:     int64(len(groups[key])),
+				FileSizeInBytes: size,
+			},
+		})
+		addedRecords += int64(len(groups[key]))
+		addedBytes += size
+	}
+
+	// 2. write a manifest for the new files
+	manifestPath := t.metadataPath(uuid.NewString() + "-m0.avro")
+	manifestLen, err := writeManifest(manifestPath, t.meta, entries)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	manifests := []manifestFile{{
+		Path:              fileURI(manifestPath),
+		Length:            manifestLen,
+		SequenceNumber:    seq,
+		MinSequenceNumber: seq,
+		AddedSnapshotID:   snapID,
+		AddedFilesCount:   len(entries),
+		AddedRowsCount:    addedRecords,
+	}}
+
+	// 3. carry forward the manifests of the current snapshot
+	var parentID *int64
+	var totalRecords, totalFiles int64
+	if cur := t.currentSnapshot(); cur != nil {
+		parentID = &cur.SnapshotID
+		prev, err := readManifestList(localPath(cur.ManifestList))
+		if err != nil {
+			return Snapshot{}, err
+		}
+		manifests = append(manifests, prev...)
+		totalRecords, _ = strconv.ParseInt(cur.Summary["total-records"], 10, 64)
+		totalFiles, _ = strconv.ParseInt(cur.Summary["total-data-files"], 10, 64)
+	}
+
+	snap := snapshotJSON{
+		SnapshotID:       snapID,
+		ParentSnapshotID: parentID,
+		SequenceNumber:   seq,
+		TimestampMs:      now.UnixMilli(),
+		ManifestList:     fileURI(t.metadataPath(fmt.Sprintf("snap-%d-1-%s.avro", snapID, uuid.NewString()))),
+		SchemaID:         0,
+		Summary: map[string]string{
+			"operation":               "append",
+			"added-data-files":        strconv.Itoa(len(entries)),
+			"added-records":           strconv.FormatInt(addedRecords, 10),
+			"added-files-size":        strconv.FormatInt(addedBytes, 10),
+			"changed-partition-count": strconv.Itoa(len(entries)),
+			"total-records":           strconv.FormatInt(totalRecords+addedRecords, 10),
+			"total-data-files":        strconv.FormatInt(totalFiles+int64(len(entries)), 10),
+		},
+	}
+	if _, err := writeManifestList(localPath(snap.ManifestList), snap, manifests); err != nil {
+		return Snapshot{}, err
+	}
+
+	// 4. commit new table metadata
+	next := *t.meta
+	next.LastSequenceNumber = seq
+	next.LastUpdatedMs = now.UnixMilli()
+	next.CurrentSnapshotID = &snapID
+	next.Snapshots = append(append([]snapshotJSON{}, t.meta.Snapshots...), snap)
+	next.SnapshotLog = append(append([]snapshotLogJSON{}, t.meta.SnapshotLog...), snapshotLogJSON{TimestampMs: snap.TimestampMs, SnapshotID: snapID})
+	next.MetadataLog = append(append([]metadataLogJSON{}, t.meta.MetadataLog...), metadataLogJSON{
+		TimestampMs:  t.meta.LastUpdatedMs,
+		MetadataFile: fileURI(t.metadataPath(fmt.Sprintf("v%d.metadata.json", t.version))),
+	})
+	next.Refs = map[string]refJSON{"main": {SnapshotID: snapID, Type: "branch"}}
+
+	if err := t.commit(&next); err != nil {
+		return Snapshot{}, err
+	}
+	return toSnapshot(snap), nil
+}
+
+// commit writes meta as the next metadata version. The version file is
+// created exclusively, so a concurrent writer loses with ErrCommitConflict.
+func (t *Table) commit(meta *tableMetadata) error {
+	raw, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	version := t.version + 1
+	f, err := os.OpenFile(t.metadataPath(fmt.Sprintf("v%d.metadata.json", version)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return ErrCommitConflict
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(raw); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(t.metadataPath("version-hint.text"), []byte(strconv.Itoa(version)), 0644); err != nil {
+		return err
+	}
+	t.version = version
+	t.meta = meta
+	return nil
+}
+
+// fileURI returns the file:// URI of a local path, the form Iceberg readers
+// expect in metadata and manifests.
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// localPath returns the local path of a file:// URI. Tables written before
+// paths were URIs hold bare paths, which are returned as they are.
+func localPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func (t *Table) metadataPath(name string) string {
+	return filepath.Join(t.location, "metadata", name)
+}
+
+func (t *Table) currentSnapshot() *snapshotJSON {
+	if t.meta.CurrentSnapshotID == nil {
+		return nil
+	}
+	return t.snapshot(*t.meta.CurrentSnapshotID)
+}
+
+func (t *Table) snapshot(id int64) *snapshotJSON {
+	for i := range t.meta.Snapshots {
+		if t.meta.Snapshots[i].SnapshotID == id {
+			return &t.meta.Snapshots[i]
+		}
+	}
+	return nil
+}
+
+// Snapshots returns every snapshot of the table, oldest first.
+func (t *Table) Snapshots() []Snapshot {
+	out := make([]Snapshot, len(t.meta.Snapshots))
+	for i, s := range t.meta.Snapshots {
+		out[i] = toSnapshot(s)
+	}
+	return out
+}
+
+// SnapshotAsOf returns the snapshot that was current at ts.
+func (t *Table) SnapshotAsOf(ts time.Time) (Snapshot, bool) {
+	var found *snapshotJSON
+	for i, s := range t.meta.Snapshots {
+		if s.TimestampMs <= ts.UnixMilli() {
+			found = &t.meta.Snapshots[i]
+		}
+	}
+	if found == nil {
+		return Snapshot{}, false
+	}
+	return toSnapshot(*found), true
+}
+
+// DataFiles lists the data files visible in snapshot id.
+func (t *Table) DataFiles(id int64) ([]DataFile, error) {
+	snap := t.snapshot(id)
+	if snap == nil {
+		return nil, fmt.Errorf("snapshot %d not found", id)
+	}
+
+	manifests, err := readManifestList(localPath(snap.ManifestList))
+	if err != nil {
+		return nil, err
+	}
+
+	var out []DataFile
+	for _, m := range manifests {
+		entries, err := readManifest(localPath(m.Path))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			part := map[string]string{}
+			for k, v := range e.DataFile.Partition {
+				if s, ok := v.(string); ok {
+					part[k] = s
+				}
+			}
+			out = append(out, DataFile{
+				Path:        localPath(e.DataFile.FilePath),
+				Partition:   part,
+				RecordCount: e.DataFile.RecordCount,
+				SizeBytes:   e.DataFile.FileSizeInBytes,
+			})
+		}
+	}
+	return out, nil
+}
+
+func toSnapshot(s snapshotJSON) Snapshot {
+	out := Snapshot{
+		SnapshotID:     s.SnapshotID,
+		SequenceNumber: s.SequenceNumber,
+		Timestamp:      time.UnixMilli(s.TimestampMs),
+	}
+	if s.ParentSnapshotID != nil {
+		out.ParentSnapshotID = *s.ParentSnapshotID
+	}
+	out.AddedFiles, _ = strconv.Atoi(s.Summary["added-data-files"])
+	out.AddedRecords, _ = strconv.ParseInt(s.Summary["added-records"], 10, 64)
+	out.TotalRecords, _ = strconv.ParseInt(s.Summary["total-records"], 10, 64)
+	return out
+}
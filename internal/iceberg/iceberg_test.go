@@ -0,0 +1,199 @@
+package iceberg
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/parquet/file"
+
+	"github.com/bitterfq/data-ingestion-go/internal/parts"
+	"github.com/bitterfq/data-ingestion-go/internal/suppliers"
+)
+
+func TestAppendSnapshots(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "dim_supplier_v1")
+
+	table, err := Open(location, SupplierSchema, "tenant_id")
+	if err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+
+	first, err := table.Append(SupplierRows(suppliers.GenerateSuppliers("tenant_acme", 5)))
+	if err != nil {
+		t.Fatalf("first append: %v", err)
+	}
+
+	// reopen to make sure state is read back from disk
+	table, err = Open(location, SupplierSchema, "tenant_id")
+	if err != nil {
+		t.Fatalf("reopen table: %v", err)
+	}
+	rows := append(
+		SupplierRows(suppliers.GenerateSuppliers("tenant_acme", 3)),
+		SupplierRows(suppliers.GenerateSuppliers("tenant_globex", 2))...,
+	)
+	second, err := table.Append(rows)
+	if err != nil {
+		t.Fatalf("second append: %v", err)
+	}
+
+	if second.ParentSnapshotID != first.SnapshotID {
+		t.Errorf("expected parent %d, got %d", first.SnapshotID, second.ParentSnapshotID)
+	}
+	if second.TotalRecords != 10 {
+		t.Errorf("expected 10 total records, got %d", second.TotalRecords)
+	}
+	if n := len(table.Snapshots()); n != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", n)
+	}
+
+	// time travel: the first snapshot only sees its own file
+	files, err := table.DataFiles(first.SnapshotID)
+	if err != nil {
+		t.Fatalf("data files: %v", err)
+	}
+	if len(files) != 1 || files[0].RecordCount != 5 {
+		t.Errorf("expected 1 file with 5 records in first snapshot, got %+v", files)
+	}
+
+	files, err = table.DataFiles(second.SnapshotID)
+	if err != nil {
+		t.Fatalf("data files: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files in second snapshot, got %d", len(files))
+	}
+
+	var total int64
+	tenants := map[string]bool{}
+	for _, f := range files {
+		tenants[f.Partition["tenant_id"]] = true
+		total += f.RecordCount
+
+		rdr, err := file.OpenParquetFile(f.Path, false)
+		if err != nil {
+			t.Fatalf("open parquet: %v", err)
+		}
+		if rdr.NumRows() != f.RecordCount {
+			t.Errorf("%s: manifest says %d rows, parquet has %d", f.Path, f.RecordCount, rdr.NumRows())
+		}
+		if id := rdr.MetaData().Schema.Column(0).SchemaNode().FieldID(); id != 1 {
+			t.Errorf("expected supplier_id field id 1, got %d", id)
+		}
+		rdr.Close()
+	}
+	if total != 10 || !tenants["tenant_acme"] || !tenants["tenant_globex"] {
+		t.Errorf("unexpected files: total=%d tenants=%v", total, tenants)
+	}
+
+	if snap, ok := table.SnapshotAsOf(first.Timestamp); !ok || snap.SnapshotID != first.SnapshotID {
+		t.Errorf("expected snapshot as of first commit to be %d, got %d", first.SnapshotID, snap.SnapshotID)
+	}
+}
+
+func TestMetadataLayout(t *testing.T) {
+	location := t.TempDir()
+	sups := suppliers.GenerateSuppliers("tenant_acme", 2)
+	ids := []string{sups[0].SupplierID, sups[1].SupplierID}
+
+	table, err := Open(location, PartSchema, "tenant_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Append(PartRows(parts.GenerateParts(4, "tenant_acme", ids))); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	hint, err := os.ReadFile(filepath.Join(location, "metadata", "version-hint.text"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(hint) != "2" {
+		t.Errorf("expected version hint 2, got %q", hint)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(location, "metadata", "v2.metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta map[string]any
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta["format-version"] != float64(2) {
+		t.Errorf("expected format-version 2, got %v", meta["format-version"])
+	}
+	if meta["current-snapshot-id"] == nil {
+		t.Error("expected current-snapshot-id to be set")
+	}
+	// readers resolve paths as URIs
+	snaps := meta["snapshots"].([]any)
+	for _, uri := range []any{meta["location"], snaps[0].(map[string]any)["manifest-list"]} {
+		if s, _ := uri.(string); !strings.HasPrefix(s, "file:///") {
+			t.Errorf("expected a file URI, got %v", uri)
+		}
+	}
+}
+
+func TestPartitionEscaping(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "table")
+	table, err := Open(location, SupplierSchema, "tenant_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := table.Append(SupplierRows(suppliers.GenerateSuppliers("../../escape", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := table.DataFiles(snap.SnapshotID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Partition["tenant_id"] != "../../escape" {
+		t.Fatalf("unexpected files %+v", files)
+	}
+	rel, err := filepath.Rel(filepath.Join(table.Location(), "data"), files[0].Path)
+	if err != nil || strings.HasPrefix(rel, "..") || strings.Count(rel, string(filepath.Separator)) != 1 {
+		t.Errorf("expected the file in one partition directory under data, got %s", rel)
+	}
+}
+
+func TestOpenCurrentSchema(t *testing.T) {
+	location := t.TempDir()
+	if _, err := Open(location, SupplierSchema, "tenant_id"); err != nil {
+		t.Fatal(err)
+	}
+	// an older schema listed first must not be taken for the current one
+	path := filepath.Join(location, "metadata", "v1.metadata.json")
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta tableMetadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		t.Fatal(err)
+	}
+	meta.Schemas = append([]schemaJSON{PartSchema.toJSON(1)}, meta.Schemas...)
+	if raw, err = json.Marshal(meta); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(location, SupplierSchema, "tenant_id"); err != nil {
+		t.Errorf("expected the current schema to match: %v", err)
+	}
+}
+
+func TestOpenSchemaMismatch(t *testing.T) {
+	location := t.TempDir()
+	if _, err := Open(location, SupplierSchema, "tenant_id"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(location, PartSchema, "tenant_id"); err == nil {
+		t.Error("expected error opening table with a different schema")
+	}
+}
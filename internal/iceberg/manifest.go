@@ -0,0 +1,233 @@
+package iceberg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hamba/avro/v2/ocf"
+)
+
+// manifestListSchema is the v2 manifest_file schema, without the optional
+// partition summaries.
+const manifestListSchema = `{
+  "type": "record",
+  "name": "manifest_file",
+  "fields": [
+    {"name": "manifest_path", "type": "string", "field-id": 500},
+    {"name": "manifest_length", "type": "long", "field-id": 501},
+    {"name": "partition_spec_id", "type": "int", "field-id": 502},
+    {"name": "content", "type": "int", "field-id": 517},
+    {"name": "sequence_number", "type": "long", "field-id": 515},
+    {"name": "min_sequence_number", "type": "long", "field-id": 516},
+    {"name": "added_snapshot_id", "type": "long", "field-id": 503},
+    {"name": "added_files_count", "type": "int", "field-id": 504},
+    {"name": "existing_files_count", "type": "int", "field-id": 505},
+    {"name": "deleted_files_count", "type": "int", "field-id": 506},
+    {"name": "added_rows_count", "type": "long", "field-id": 512},
+    {"name": "existing_rows_count", "type": "long", "field-id": 513},
+    {"name": "deleted_rows_count", "type": "long", "field-id": 514}
+  ]
+}`
+
+type manifestFile struct {
+	Path               string `avro:"manifest_path"`
+	Length             int64  `avro:"manifest_length"`
+	PartitionSpecID    int    `avro:"partition_spec_id"`
+	Content            int    `avro:"content"`
+	SequenceNumber     int64  `avro:"sequence_number"`
+	MinSequenceNumber  int64  `avro:"min_sequence_number"`
+	AddedSnapshotID    int64  `avro:"added_snapshot_id"`
+	AddedFilesCount    int    `avro:"added_files_count"`
+	ExistingFilesCount int    `avro:"existing_files_count"`
+	DeletedFilesCount  int    `avro:"deleted_files_count"`
+	AddedRowsCount     int64  `avro:"added_rows_count"`
+	ExistingRowsCount  int64  `avro:"existing_rows_count"`
+	DeletedRowsCount   int64  `avro:"deleted_rows_count"`
+}
+
+type manifestEntry struct {
+	Status             int      `avro:"status"`
+	SnapshotID         *int64   `avro:"snapshot_id"`
+	SequenceNumber     *int64   `avro:"sequence_number"`
+	FileSequenceNumber *int64   `avro:"file_sequence_number"`
+	DataFile           dataFile `avro:"data_file"`
+}
+
+type dataFile struct {
+	Content         int            `avro:"content"`
+	FilePath        string         `avro:"file_path"`
+	FileFormat      string         `avro:"file_format"`
+	Partition       map[string]any `avro:"partition"`
+	RecordCount     int64          `avro:"record_count"`
+	FileSizeInBytes int64          `avro:"file_size_in_bytes"`
+}
+
+// manifest entry statuses
+const (
+	statusExisting = 0
+	statusAdded    = 1
+)
+
+// manifestEntrySchema builds the v2 manifest_entry schema for a partition spec.
+// The partition struct differs per spec, so the schema cannot be a constant.
+func manifestEntrySchema(spec []partitionFieldJSON) string {
+	type avroField struct {
+		Name    string `json:"name"`
+		Type    any    `json:"type"`
+		Default any    `json:"default"`
+		FieldID int    `json:"field-id"`
+	}
+	var partFields []avroField
+	for _, pf := range spec {
+		partFields = append(partFields, avroField{Name: pf.Name, Type: []string{"null", "string"}, FieldID: pf.FieldID})
+	}
+	if partFields == nil {
+		partFields = []avroField{}
+	}
+
+	optLong := []string{"null", "long"}
+	s := map[string]any{
+		"type": "record",
+		"name": "manifest_entry",
+		"fields": []any{
+			map[string]any{"name": "status", "type": "int", "field-id": 0},
+			map[string]any{"name": "snapshot_id", "type": optLong, "default": nil, "field-id": 1},
+			map[string]any{"name": "sequence_number", "type": optLong, "default": nil, "field-id": 3},
+			map[string]any{"name": "file_sequence_number", "type": optLong, "default": nil, "field-id": 4},
+			map[string]any{"name": "data_file", "field-id": 2, "type": map[string]any{
+				"type": "record",
+				"name": "r2",
+				"fields": []any{
+					map[string]any{"name": "content", "type": "int", "field-id": 134},
+					map[string]any{"name": "file_path", "type": "string", "field-id": 100},
+					map[string]any{"name": "file_format", "type": "string", "field-id": 101},
+					map[string]any{"name": "partition", "field-id": 102, "type": map[string]any{
+						"type":   "record",
+						"name":   "r102",
+						"fields": partFields,
+					}},
+					map[string]any{"name": "record_count", "type": "long", "field-id": 103},
+					map[string]any{"name": "file_size_in_bytes", "type": "long", "field-id": 104},
+				},
+			}},
+		},
+	}
+	raw, _ := json.Marshal(s)
+	return string(raw)
+}
+
+func writeManifest(filename string, meta *tableMetadata, entries []manifestEntry) (int64, error) {
+	schemaRaw, _ := json.Marshal(meta.Schemas[0])
+	specRaw, _ := json.Marshal(meta.PartitionSpecs[0].Fields)
+
+	return writeAvro(filename, manifestEntrySchema(meta.PartitionSpecs[0].Fields), map[string][]byte{
+		"schema":            schemaRaw,
+		"schema-id":         []byte("0"),
+		"partition-spec":    specRaw,
+		"partition-spec-id": []byte("0"),
+		"format-version":    []byte("2"),
+		"content":           []byte("data"),
+	}, func(enc *ocf.Encoder) error {
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func writeManifestList(filename string, snap snapshotJSON, manifests []manifestFile) (int64, error) {
+	parent := "null"
+	if snap.ParentSnapshotID != nil {
+		parent = strconv.FormatInt(*snap.ParentSnapshotID, 10)
+	}
+	return writeAvro(filename, manifestListSchema, map[string][]byte{
+		"snapshot-id":        []byte(strconv.FormatInt(snap.SnapshotID, 10)),
+		"parent-snapshot-id": []byte(parent),
+		"sequence-number":    []byte(strconv.FormatInt(snap.SequenceNumber, 10)),
+		"format-version":     []byte("2"),
+	}, func(enc *ocf.Encoder) error {
+		for _, m := range manifests {
+			if err := enc.Encode(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func writeAvro(filename, schema string, meta map[string][]byte, encode func(*ocf.Encoder) error) (int64, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	enc, err := ocf.NewEncoder(schema, f,
+		ocf.WithMetadata(meta),
+		ocf.WithSchemaMarshaler(ocf.FullSchemaMarshaler),
+		ocf.WithCodec(ocf.Deflate))
+	if err != nil {
+		return 0, fmt.Errorf("avro encoder: %w", err)
+	}
+	if err := encode(enc); err != nil {
+		return 0, fmt.Errorf("encode %s: %w", filename, err)
+	}
+	if err := enc.Close(); err != nil {
+		return 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func readManifestList(filename string) ([]manifestFile, error) {
+	var out []manifestFile
+	err := readAvro(filename, func(dec *ocf.Decoder) error {
+		var m manifestFile
+		if err := dec.Decode(&m); err != nil {
+			return err
+		}
+		out = append(out, m)
+		return nil
+	})
+	return out, err
+}
+
+func readManifest(filename string) ([]manifestEntry, error) {
+	var out []manifestEntry
+	err := readAvro(filename, func(dec *ocf.Decoder) error {
+		var e manifestEntry
+		if err := dec.Decode(&e); err != nil {
+			return err
+		}
+		out = append(out, e)
+		return nil
+	})
+	return out, err
+}
+
+func readAvro(filename string, decode func(*ocf.Decoder) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec, err := ocf.NewDecoder(f)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", filename, err)
+	}
+	for dec.HasNext() {
+		if err := decode(dec); err != nil {
+			return fmt.Errorf("decode %s: %w", filename, err)
+		}
+	}
+	return dec.Error()
+}
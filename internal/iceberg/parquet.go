@@ -0,0 +1,150 @@
+package iceberg
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+func fieldIDMeta(id int) arrow.Metadata {
+	return arrow.NewMetadata([]string{"PARQUET:field_id"}, []string{strconv.Itoa(id)})
+}
+
+// arrowSchema converts s to an Arrow schema carrying Iceberg field ids so the
+// Parquet columns can be resolved by id rather than by name.
+func (s Schema) arrowSchema() *arrow.Schema {
+	fields := make([]arrow.Field, len(s.Fields))
+	for i, f := range s.Fields {
+		var dt arrow.DataType
+		switch f.Type {
+		case String:
+			dt = arrow.BinaryTypes.String
+		case Long:
+			dt = arrow.PrimitiveTypes.Int64
+		case Double:
+			dt = arrow.PrimitiveTypes.Float64
+		case Date:
+			dt = arrow.FixedWidthTypes.Date32
+		case Timestamptz:
+			dt = &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+		case StringList:
+			dt = arrow.ListOfField(arrow.Field{
+				Name:     "element",
+				Type:     arrow.BinaryTypes.String,
+				Metadata: fieldIDMeta(f.elementID),
+			})
+		}
+		fields[i] = arrow.Field{Name: f.Name, Type: dt, Nullable: !f.Required, Metadata: fieldIDMeta(f.id)}
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// writeParquet writes rows to filename as a snappy-compressed Parquet file and
+// returns the file size in bytes.
+func writeParquet(filename string, s Schema, rows [][]any) (int64, error) {
+	rb := array.NewRecordBuilder(memory.DefaultAllocator, s.arrowSchema())
+	defer rb.Release()
+
+	for r, row := range rows {
+		if len(row) != len(s.Fields) {
+			return 0, fmt.Errorf("row %d has %d values, schema has %d columns", r, len(row), len(s.Fields))
+		}
+		for i, f := range s.Fields {
+			if err := appendValue(rb.Field(i), f, row[i]); err != nil {
+				return 0, fmt.Errorf("row %d: %w", r, err)
+			}
+		}
+	}
+	rec := rb.NewRecord()
+	defer rec.Release()
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy))
+	fw, err := pqarrow.NewFileWriter(rec.Schema(), file, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return 0, err
+	}
+	if err := fw.Write(rec); err != nil {
+		fw.Close()
+		return 0, err
+	}
+	if err := fw.Close(); err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func appendValue(b array.Builder, f Field, v any) error {
+	if v == nil {
+		if f.Required {
+			return fmt.Errorf("required field %s is null", f.Name)
+		}
+		b.AppendNull()
+		return nil
+	}
+
+	var ok bool
+	switch f.Type {
+	case String:
+		var s string
+		if s, ok = v.(string); ok {
+			b.(*array.StringBuilder).Append(s)
+		}
+	case Long:
+		switch n := v.(type) {
+		case int64:
+			b.(*array.Int64Builder).Append(n)
+			ok = true
+		case int:
+			b.(*array.Int64Builder).Append(int64(n))
+			ok = true
+		}
+	case Double:
+		var d float64
+		if d, ok = v.(float64); ok {
+			b.(*array.Float64Builder).Append(d)
+		}
+	case Date:
+		var t time.Time
+		if t, ok = v.(time.Time); ok {
+			b.(*array.Date32Builder).Append(arrow.Date32FromTime(t))
+		}
+	case Timestamptz:
+		var t time.Time
+		if t, ok = v.(time.Time); ok {
+			b.(*array.TimestampBuilder).Append(arrow.Timestamp(t.UnixMicro()))
+		}
+	case StringList:
+		var list []string
+		if list, ok = v.([]string); ok {
+			lb := b.(*array.ListBuilder)
+			lb.Append(true)
+			vb := lb.ValueBuilder().(*array.StringBuilder)
+			for _, s := range list {
+				vb.Append(s)
+			}
+		}
+	}
+	if !ok {
+		return fmt.Errorf("field %s: cannot store %T as %s", f.Name, v, f.Type)
+	}
+	return nil
+}
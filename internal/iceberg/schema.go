@@ -0,0 +1,150 @@
+package iceberg
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Type is an Iceberg column type. Only the types needed by the dim tables are supported.
+type Type string
+
+const (
+	String      Type = "string"
+	Long        Type = "long"
+	Double      Type = "double"
+	Date        Type = "date"
+	Timestamptz Type = "timestamptz"
+	// StringList is list<string> with required elements.
+	StringList Type = "list<string>"
+)
+
+// Field is a single column in a table schema.
+type Field struct {
+	Name     string
+	Type     Type
+	Required bool
+
+	id        int
+	elementID int
+}
+
+// ID returns the Iceberg field id assigned by NewSchema.
+func (f Field) ID() int { return f.id }
+
+// Schema is an ordered set of columns with stable field ids.
+type Schema struct {
+	Fields []Field
+
+	lastColumnID int
+}
+
+// NewSchema assigns field ids 1..n in column order, followed by ids for list elements.
+func NewSchema(fields ...Field) Schema {
+	s := Schema{Fields: make([]Field, len(fields))}
+	copy(s.Fields, fields)
+	next := 1
+	for i := range s.Fields {
+		s.Fields[i].id = next
+		next++
+	}
+	for i := range s.Fields {
+		if s.Fields[i].Type == StringList {
+			s.Fields[i].elementID = next
+			next++
+		}
+	}
+	s.lastColumnID = next - 1
+	return s
+}
+
+// Field returns the column named name.
+func (s Schema) Field(name string) (Field, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+func (s Schema) index(id int) int {
+	for i, f := range s.Fields {
+		if f.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+type schemaJSON struct {
+	Type     string      `json:"type"`
+	SchemaID int         `json:"schema-id"`
+	Fields   []fieldJSON `json:"fields"`
+}
+
+type fieldJSON struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Type     any    `json:"type"`
+}
+
+type listJSON struct {
+	Type            string `json:"type"`
+	ElementID       int    `json:"element-id"`
+	Element         string `json:"element"`
+	ElementRequired bool   `json:"element-required"`
+}
+
+func (s Schema) toJSON(schemaID int) schemaJSON {
+	out := schemaJSON{Type: "struct", SchemaID: schemaID}
+	for _, f := range s.Fields {
+		fj := fieldJSON{ID: f.id, Name: f.Name, Required: f.Required, Type: string(f.Type)}
+		if f.Type == StringList {
+			fj.Type = listJSON{Type: "list", ElementID: f.elementID, Element: "string", ElementRequired: true}
+		}
+		out.Fields = append(out.Fields, fj)
+	}
+	return out
+}
+
+func schemaFromJSON(sj schemaJSON) (Schema, error) {
+	var s Schema
+	for _, fj := range sj.Fields {
+		f := Field{Name: fj.Name, Required: fj.Required, id: fj.ID}
+		switch t := fj.Type.(type) {
+		case string:
+			f.Type = Type(t)
+		case map[string]any:
+			raw, _ := json.Marshal(t)
+			var lj listJSON
+			if err := json.Unmarshal(raw, &lj); err != nil || lj.Type != "list" || lj.Element != "string" {
+				return s, fmt.Errorf("unsupported type for field %s", fj.Name)
+			}
+			f.Type = StringList
+			f.elementID = lj.ElementID
+		default:
+			return s, fmt.Errorf("unsupported type for field %s", fj.Name)
+		}
+		if f.id > s.lastColumnID {
+			s.lastColumnID = f.id
+		}
+		if f.elementID > s.lastColumnID {
+			s.lastColumnID = f.elementID
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	return s, nil
+}
+
+func (s Schema) equal(o Schema) bool {
+	if len(s.Fields) != len(o.Fields) {
+		return false
+	}
+	for i := range s.Fields {
+		if s.Fields[i] != o.Fields[i] {
+			return false
+		}
+	}
+	return true
+}
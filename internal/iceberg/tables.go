@@ -0,0 +1,176 @@
+package iceberg
+
+import (
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/parts"
+	"github.com/bitterfq/data-ingestion-go/internal/suppliers"
+)
+
+// SupplierSchema mirrors dim_supplier_v1.
+var SupplierSchema = NewSchema(
+	Field{Name: "supplier_id", Type: String, Required: true},
+	Field{Name: "supplier_code", Type: String},
+	Field{Name: "tenant_id", Type: String, Required: true},
+	Field{Name: "legal_name", Type: String, Required: true},
+	Field{Name: "dba_name", Type: String},
+	Field{Name: "country", Type: String},
+	Field{Name: "region", Type: String},
+	Field{Name: "address_line1", Type: String},
+	Field{Name: "address_line2", Type: String},
+	Field{Name: "city", Type: String},
+	Field{Name: "state", Type: String},
+	Field{Name: "postal_code", Type: String},
+	Field{Name: "contact_email", Type: String},
+	Field{Name: "contact_phone", Type: String},
+	Field{Name: "preferred_currency", Type: String},
+	Field{Name: "incoterms", Type: String},
+	Field{Name: "lead_time_days_avg", Type: Long},
+	Field{Name: "lead_time_days_p95", Type: Long},
+	Field{Name: "on_time_delivery_rate", Type: Double},
+	Field{Name: "defect_rate_ppm", Type: Long},
+	Field{Name: "capacity_units_per_week", Type: Long},
+	Field{Name: "risk_score", Type: Double},
+	Field{Name: "financial_risk_tier", Type: String},
+	Field{Name: "certifications", Type: StringList},
+	Field{Name: "compliance_flags", Type: StringList},
+	Field{Name: "approved_status", Type: String},
+	Field{Name: "contracts", Type: StringList},
+	Field{Name: "terms_version", Type: String},
+	Field{Name: "lat", Type: Double},
+	Field{Name: "lon", Type: Double},
+	Field{Name: "data_source", Type: String},
+	Field{Name: "source_timestamp", Type: Timestamptz},
+	Field{Name: "ingestion_timestamp", Type: Timestamptz},
+	Field{Name: "schema_version", Type: String},
+)
+
+// PartSchema mirrors dim_part_v1.
+var PartSchema = NewSchema(
+	Field{Name: "part_id", Type: String, Required: true},
+	Field{Name: "tenant_id", Type: String, Required: true},
+	Field{Name: "part_number", Type: String, Required: true},
+	Field{Name: "description", Type: String, Required: true},
+	Field{Name: "category", Type: String},
+	Field{Name: "lifecycle_status", Type: String},
+	Field{Name: "uom", Type: String},
+	Field{Name: "spec_hash", Type: String},
+	Field{Name: "bom_compatibility", Type: StringList},
+	Field{Name: "default_supplier_id", Type: String},
+	Field{Name: "qualified_supplier_ids", Type: StringList},
+	Field{Name: "unit_cost", Type: Double},
+	Field{Name: "moq", Type: Long},
+	Field{Name: "lead_time_days_avg", Type: Long},
+	Field{Name: "lead_time_days_p95", Type: Long},
+	Field{Name: "quality_grade", Type: String},
+	Field{Name: "compliance_flags", Type: StringList},
+	Field{Name: "hazard_class", Type: String},
+	Field{Name: "last_price_change", Type: Date},
+	Field{Name: "data_source", Type: String},
+	Field{Name: "source_timestamp", Type: Timestamptz},
+	Field{Name: "ingestion_timestamp", Type: Timestamptz},
+	Field{Name: "schema_version", Type: String},
+)
+
+// SupplierRows converts suppliers to rows matching SupplierSchema.
+func SupplierRows(sups []suppliers.Supplier) [][]any {
+	rows := make([][]any, len(sups))
+	for i, sup := range sups {
+		var lat, lon any
+		if sup.GeoCoords != nil {
+			lat, lon = sup.GeoCoords.Lat, sup.GeoCoords.Lon
+		}
+		rows[i] = []any{
+			sup.SupplierID,
+			optString(sup.SupplierCode),
+			sup.TenantID,
+			sup.LegalName,
+			optString(sup.DBAName),
+			optString(sup.Country),
+			optString(sup.Region),
+			optString(sup.AddressLine1),
+			optString(sup.AddressLine2),
+			optString(sup.City),
+			optString(sup.State),
+			optString(sup.PostalCode),
+			optString(sup.ContactEmail),
+			optString(sup.ContactPhone),
+			optString(sup.PreferredCurrency),
+			optString(sup.Incoterms),
+			sup.LeadTimeDaysAvg,
+			sup.LeadTimeDaysP95,
+			sup.OnTimeDeliveryRate,
+			sup.DefectRatePPM,
+			sup.CapacityUnitsPerWeek,
+			sup.RiskScore,
+			optString(sup.FinancialRiskTier),
+			optList(sup.Certifications),
+			optList(sup.ComplianceFlags),
+			optString(sup.ApprovedStatus),
+			optList(sup.Contracts),
+			optString(sup.TermsVersion),
+			lat,
+			lon,
+			optString(sup.DataSource),
+			optTime(sup.SourceTimestamp),
+			optTime(sup.IngestionTimestamp),
+			optString(sup.SchemaVersion),
+		}
+	}
+	return rows
+}
+
+// PartRows converts parts to rows matching PartSchema.
+func PartRows(ps []parts.Part) [][]any {
+	rows := make([][]any, len(ps))
+	for i, part := range ps {
+		rows[i] = []any{
+			part.PartID,
+			part.TenantID,
+			part.PartNumber,
+			part.Description,
+			optString(part.Category),
+			optString(part.LifecycleStatus),
+			optString(part.Uom),
+			optString(part.SpecHash),
+			optList(part.BomCompatibility),
+			optString(part.DefaultSupplierID),
+			optList(part.QualifiedSupplierIDs),
+			part.UnitCost,
+			part.Moq,
+			part.LeadTimeDaysAvg,
+			part.LeadTimeDaysP95,
+			optString(part.QualityGrade),
+			optList(part.ComplianceFlags),
+			optString(part.HazardClass),
+			optTime(part.LastPriceChange),
+			optString(part.DataSource),
+			optTime(part.SourceTimestamp),
+			optTime(part.IngestionTimestamp),
+			optString(part.SchemaVersion),
+		}
+	}
+	return rows
+}
+
+// empty values become nulls, matching how the generator fills the sqlite tables
+func optString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func optList(l []string) any {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+func optTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,78 @@
+// Package logging configures log/slog for the commands and carries a
+// request's logger through its context. Library packages take a
+// *slog.Logger in their options and log nothing when it is nil.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Config picks the level and format of a logger.
+type Config struct {
+	// Level is the lowest level logged.
+	Level slog.Level
+	// Format is "json", the default, or "text".
+	Format string
+}
+
+// ConfigFromEnv reads LOG_LEVEL (debug, info, warn or error; default info)
+// and LOG_FORMAT (json or text; default json).
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Format: os.Getenv("LOG_FORMAT")}
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if err := cfg.Level.UnmarshalText([]byte(v)); err != nil {
+			return Config{}, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// New returns a logger writing to w.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	switch strings.ToLower(cfg.Format) {
+	case "", "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q; want json or text", cfg.Format)
+}
+
+// FromEnv returns a logger writing to stderr as configured by the
+// environment, for the commands.
+func FromEnv() (*slog.Logger, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return New(os.Stderr, cfg)
+}
+
+// OrDiscard returns l, or a logger that drops everything when l is nil.
+func OrDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return l
+}
+
+type contextKey struct{}
+
+// NewContext returns ctx carrying l.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or slog.Default().
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
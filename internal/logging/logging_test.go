@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_FORMAT", "text")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != slog.LevelDebug || cfg.Format != "text" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	t.Setenv("LOG_LEVEL", "loud")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("expected an unknown level to be rejected")
+	}
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(&buf, Config{Level: slog.LevelWarn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("dropped")
+	l.Warn("kept", "rows", 3)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected one JSON record, got %q: %v", buf.String(), err)
+	}
+	if rec["msg"] != "kept" || rec["rows"] != 3.0 {
+		t.Errorf("unexpected record %v", rec)
+	}
+
+	buf.Reset()
+	l, _ = New(&buf, Config{Format: "text"})
+	l.Info("hello")
+	if !strings.Contains(buf.String(), "msg=hello") {
+		t.Errorf("expected a text record, got %q", buf.String())
+	}
+
+	if _, err := New(&buf, Config{Format: "xml"}); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()) != slog.Default() {
+		t.Error("expected the default logger without one in the context")
+	}
+	l := slog.New(slog.DiscardHandler)
+	if FromContext(NewContext(context.Background(), l)) != l {
+		t.Error("expected the logger carried by the context")
+	}
+	if OrDiscard(nil).Enabled(context.Background(), slog.LevelError) {
+		t.Error("expected OrDiscard(nil) to drop everything")
+	}
+}
@@ -0,0 +1,151 @@
+// Package metrics collects the Prometheus metrics of the server and the
+// generator. Library packages take a *Metrics in their options and record
+// nothing when it is nil.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Metrics holds a registry and the collectors recorded into it. The zero of
+// *Metrics, nil, records nothing.
+type Metrics struct {
+	reg          *prometheus.Registry
+	requests     *prometheus.CounterVec
+	latency      *prometheus.HistogramVec
+	rows         *prometheus.CounterVec
+	queries      *prometheus.HistogramVec
+	transactions *prometheus.HistogramVec
+	generated    *prometheus.CounterVec
+	throughput   *prometheus.GaugeVec
+}
+
+// New returns Metrics registered in a fresh registry, which also collects the
+// Go runtime and process metrics.
+func New() *Metrics {
+	m := &Metrics{
+		reg: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by route and status code.",
+		}, []string{"route", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time to serve an HTTP request, by route and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "status"}),
+		rows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ingest_rows_total",
+			Help: "Rows written to the store, by entity and the source they came from.",
+		}, []string{"entity", "source"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "snowflake_query_duration_seconds",
+			Help:    "Time Snowflake queries take, by phase: running the query, then fetching its rows.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+		}, []string{"phase"}),
+		transactions: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_transaction_duration_seconds",
+			Help:    "Time bulk load transactions take, by database, table and outcome.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		}, []string{"system", "table", "outcome"}),
+		generated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "generator_rows_total",
+			Help: "Rows the generator produced or loaded, by entity and stage.",
+		}, []string{"entity", "stage"}),
+		throughput: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "generator_rows_per_second",
+			Help: "Throughput of the generator's last run, by entity and stage.",
+		}, []string{"entity", "stage"}),
+	}
+	m.reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.latency, m.rows, m.queries, m.transactions, m.generated, m.throughput,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.reg, promhttp.HandlerOpts{Registry: m.reg})
+}
+
+// Push sends the metrics to the Prometheus Pushgateway at url under job, for
+// commands that exit before they could be scraped.
+func (m *Metrics) Push(ctx context.Context, url, job string) error {
+	return push.New(url, job).Gatherer(m.reg).PushContext(ctx)
+}
+
+// WatchDB collects the connection pool stats of conn, labelled db_name=name.
+func (m *Metrics) WatchDB(name string, conn *sql.DB) error {
+	if m == nil {
+		return nil
+	}
+	return m.reg.Register(collectors.NewDBStatsCollector(conn, name))
+}
+
+// ObserveRequest records a served request. route is the pattern the request
+// matched, or empty when it matched none.
+func (m *Metrics) ObserveRequest(route string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	if route == "" {
+		route = "unmatched"
+	}
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(route, code).Inc()
+	m.latency.WithLabelValues(route, code).Observe(d.Seconds())
+}
+
+// AddRows counts n rows of entity, such as "suppliers", written from source,
+// such as "snowflake", "api" or "generator".
+func (m *Metrics) AddRows(entity, source string, n int64) {
+	if m == nil {
+		return
+	}
+	m.rows.WithLabelValues(entity, source).Add(float64(n))
+}
+
+// ObserveQuery records a phase of a Snowflake query: "query" for running it
+// or "fetch" for reading its rows.
+func (m *Metrics) ObserveQuery(phase string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.queries.WithLabelValues(phase).Observe(d.Seconds())
+}
+
+// ObserveTransaction records a bulk load transaction on table of system, such
+// as "sqlite", which committed unless err is not nil.
+func (m *Metrics) ObserveTransaction(system, table string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	outcome := "commit"
+	if err != nil {
+		outcome = "rollback"
+	}
+	m.transactions.WithLabelValues(system, table, outcome).Observe(d.Seconds())
+}
+
+// ObserveGenerator records a stage of a generator run, such as "generate" or
+// "load", that handled rows of entity in d.
+func (m *Metrics) ObserveGenerator(entity, stage string, rows int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.generated.WithLabelValues(entity, stage).Add(float64(rows))
+	if d > 0 {
+		m.throughput.WithLabelValues(entity, stage).Set(float64(rows) / d.Seconds())
+	}
+}
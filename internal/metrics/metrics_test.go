@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNilRecordsNothing(t *testing.T) {
+	var m *Metrics
+	m.ObserveRequest("GET /suppliers", 200, time.Second)
+	m.AddRows("suppliers", "api", 1)
+	m.ObserveQuery("query", time.Second)
+	m.ObserveTransaction("sqlite", "dim_supplier_v1", time.Second, nil)
+	m.ObserveGenerator("suppliers", "generate", 10, time.Second)
+	if err := m.WatchDB("sqlite", nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := New()
+	m.ObserveRequest("GET /suppliers", 200, 10*time.Millisecond)
+	m.ObserveRequest("GET /suppliers", 200, 20*time.Millisecond)
+	m.ObserveRequest("", 404, time.Millisecond)
+	m.AddRows("suppliers", "snowflake", 5)
+	m.AddRows("suppliers", "snowflake", 2)
+	m.ObserveTransaction("sqlite", "dim_supplier_v1", time.Millisecond, errors.New("boom"))
+	m.ObserveGenerator("parts", "load", 1000, 2*time.Second)
+
+	if got := testutil.ToFloat64(m.requests.WithLabelValues("GET /suppliers", "200")); got != 2 {
+		t.Errorf("expected 2 requests, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues("unmatched", "404")); got != 1 {
+		t.Errorf("expected an unmatched request, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.rows.WithLabelValues("suppliers", "snowflake")); got != 7 {
+		t.Errorf("expected 7 rows, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.throughput.WithLabelValues("parts", "load")); got != 500 {
+		t.Errorf("expected 500 rows/sec, got %v", got)
+	}
+
+	conn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := m.WatchDB("sqlite", conn); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.WatchDB("sqlite", conn); err == nil {
+		t.Error("expected a second pool of the same name to be rejected")
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`http_request_duration_seconds_count{route="GET /suppliers",status="200"} 2`,
+		`db_transaction_duration_seconds_count{outcome="rollback",system="sqlite",table="dim_supplier_v1"} 1`,
+		`go_sql_open_connections{db_name="sqlite"}`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the exposition", want)
+		}
+	}
+}
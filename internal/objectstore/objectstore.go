@@ -0,0 +1,159 @@
+// Package objectstore uploads generated and exported files to S3-compatible object storage.
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Config holds the settings needed to reach an S3-compatible bucket.
+// Endpoint and UsePathStyle are only needed for non-AWS stores such as MinIO.
+type Config struct {
+	Bucket          string
+	Prefix          string
+	Region          string
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	UsePathStyle    bool
+
+	// PartSize is the multipart chunk size in bytes. Files larger than this are
+	// uploaded in parts; zero uses the SDK default (5 MiB).
+	PartSize int64
+}
+
+// ConfigFromEnv reads a Config from S3_* environment variables.
+// Missing credentials fall back to the default AWS credential chain.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Bucket:          os.Getenv("S3_BUCKET"),
+		Prefix:          os.Getenv("S3_PREFIX"),
+		Region:          os.Getenv("S3_REGION"),
+		Endpoint:        os.Getenv("S3_ENDPOINT"),
+		AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("S3_SESSION_TOKEN"),
+	}
+	cfg.UsePathStyle, _ = strconv.ParseBool(os.Getenv("S3_USE_PATH_STYLE"))
+	if mb, err := strconv.ParseInt(os.Getenv("S3_PART_SIZE_MB"), 10, 64); err == nil {
+		cfg.PartSize = mb * 1024 * 1024
+	}
+	return cfg
+}
+
+// Sink uploads objects to a bucket.
+type Sink interface {
+	Upload(ctx context.Context, key string, body io.Reader) error
+	UploadFile(ctx context.Context, filename, key string) error
+}
+
+// S3Sink is a Sink backed by an S3-compatible bucket.
+type S3Sink struct {
+	client   *s3.Client
+	uploader *manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+// NewS3Sink builds an S3 client from cfg and returns a sink that writes to cfg.Bucket.
+func NewS3Sink(ctx context.Context, cfg Config) (*S3Sink, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	opts := []func(*config.LoadOptions) error{config.WithRegion(cfg.Region)}
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, cfg.SessionToken)))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+		// many S3-compatible stores reject the newer default checksum trailers
+		o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+	})
+
+	uploader := manager.NewUploader(client, func(u *manager.Uploader) {
+		if cfg.PartSize > 0 {
+			u.PartSize = cfg.PartSize
+		}
+	})
+
+	return &S3Sink{
+		client:   client,
+		uploader: uploader,
+		bucket:   cfg.Bucket,
+		prefix:   strings.Trim(cfg.Prefix, "/"),
+	}, nil
+}
+
+// Upload streams body to key under the sink's prefix, switching to a multipart
+// upload once the body exceeds the configured part size.
+func (s *S3Sink) Upload(ctx context.Context, key string, body io.Reader) error {
+	_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(key)),
+		Body:   body,
+	})
+	if err != nil {
+		return fmt.Errorf("upload s3://%s/%s: %w", s.bucket, s.objectKey(key), err)
+	}
+	return nil
+}
+
+// UploadFile uploads the local file at filename to key.
+func (s *S3Sink) UploadFile(ctx context.Context, filename, key string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", filename, err)
+	}
+	defer f.Close()
+
+	return s.Upload(ctx, key, f)
+}
+
+func (s *S3Sink) objectKey(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
+
+// PartitionKey returns the Hive-style key used for every uploaded file:
+//
+//	<entity>/tenant_id=<tenant>/dt=<YYYY-MM-DD>/<name>
+//
+// so query engines can prune by tenant and ingestion date.
+func PartitionKey(entity, tenant string, t time.Time, name string) string {
+	return path.Join(
+		entity,
+		"tenant_id="+tenant,
+		"dt="+t.UTC().Format("2006-01-02"),
+		path.Base(name),
+	)
+}
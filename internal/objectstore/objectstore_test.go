@@ -0,0 +1,211 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a minimal in-process S3 server supporting path-style PutObject,
+// GetObject and the multipart upload calls used by the SDK uploader.
+type fakeS3 struct {
+	mu         sync.Mutex
+	objects    map[string][]byte
+	uploads    map[string]map[int][]byte
+	multiparts int
+	nextID     int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	f := &fakeS3{objects: map[string][]byte{}, uploads: map[string]map[int][]byte{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.uploads[id] = map[int][]byte{}
+		f.multiparts++
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			UploadID string   `xml:"UploadId"`
+		}{UploadID: id})
+
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		parts, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			http.Error(w, "NoSuchUpload", http.StatusNotFound)
+			return
+		}
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		body, _ := io.ReadAll(r.Body)
+		parts[n] = body
+		w.Header().Set("ETag", fmt.Sprintf("\"part-%d\"", n))
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		id := query.Get("uploadId")
+		parts, ok := f.uploads[id]
+		if !ok {
+			http.Error(w, "NoSuchUpload", http.StatusNotFound)
+			return
+		}
+		nums := make([]int, 0, len(parts))
+		for n := range parts {
+			nums = append(nums, n)
+		}
+		sort.Ints(nums)
+		var buf bytes.Buffer
+		for _, n := range nums {
+			buf.Write(parts[n])
+		}
+		f.objects[key] = buf.Bytes()
+		delete(f.uploads, id)
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Key     string   `xml:"Key"`
+			ETag    string   `xml:"ETag"`
+		}{Key: key, ETag: "\"complete\""})
+
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+		w.Header().Set("ETag", "\"object\"")
+
+	case r.Method == http.MethodGet:
+		obj, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(obj)
+
+	default:
+		http.Error(w, "NotImplemented", http.StatusNotImplemented)
+	}
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeS3) object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[key]
+	return obj, ok
+}
+
+// multipartCount returns how many multipart uploads were started.
+func (f *fakeS3) multipartCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.multiparts
+}
+
+func newTestSink(t *testing.T, srv *httptest.Server, prefix string) *S3Sink {
+	t.Helper()
+	sink, err := NewS3Sink(context.Background(), Config{
+		Bucket:          "test-bucket",
+		Prefix:          prefix,
+		Endpoint:        srv.URL,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		UsePathStyle:    true,
+	})
+	if err != nil {
+		t.Fatalf("new sink: %v", err)
+	}
+	return sink
+}
+
+func TestPartitionKey(t *testing.T) {
+	ts := time.Date(2025, 9, 24, 23, 30, 0, 0, time.FixedZone("PDT", -7*3600))
+	got := PartitionKey("suppliers", "tenant_acme", ts, "data/suppliers.csv")
+	want := "suppliers/tenant_id=tenant_acme/dt=2025-09-25/suppliers.csv"
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	sink := newTestSink(t, srv, "/exports/")
+
+	filename := filepath.Join(t.TempDir(), "suppliers.csv")
+	content := []byte("supplier_id,tenant_id\nS1,tenant_acme\n")
+	if err := os.WriteFile(filename, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	key := PartitionKey("suppliers", "tenant_acme", time.Now(), filename)
+	if err := sink.UploadFile(context.Background(), filename, key); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	got, ok := fake.object("test-bucket/exports/" + key)
+	if !ok {
+		t.Fatalf("expected object %s to exist", key)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("expected %q, got %q", content, got)
+	}
+	if n := fake.multipartCount(); n != 0 {
+		t.Errorf("expected single-part upload, got %d multipart uploads", n)
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	sink := newTestSink(t, srv, "")
+
+	// 11 MiB forces three 5 MiB parts
+	content := bytes.Repeat([]byte("0123456789abcdef"), 11*1024*1024/16)
+	if err := sink.Upload(context.Background(), "parts/big.csv", bytes.NewReader(content)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	got, ok := fake.object("test-bucket/parts/big.csv")
+	if !ok {
+		t.Fatal("expected multipart object to exist")
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("multipart object differs: expected %d bytes, got %d", len(content), len(got))
+	}
+	if n := fake.multipartCount(); n != 1 {
+		t.Errorf("expected 1 multipart upload, got %d", n)
+	}
+}
+
+func TestNewS3SinkRequiresBucket(t *testing.T) {
+	if _, err := NewS3Sink(context.Background(), Config{}); err == nil {
+		t.Error("expected error for missing bucket")
+	}
+}
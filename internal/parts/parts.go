@@ -4,12 +4,27 @@ package parts
 import (
 	"encoding/csv"
 	"fmt"
+	"log/slog"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v7"
+
+	"github.com/bitterfq/data-ingestion-go/internal/logging"
+)
+
+// The vocabularies of the coded part fields. The generator draws from them and
+// the API accepts only these values.
+var (
+	Categories        = []string{"ELECTRICAL", "MECHANICAL", "RAW_MATERIAL", "OTHER"}
+	LifecycleStatuses = []string{"NEW", "ACTIVE", "NRND", "EOL"}
+	Uoms              = []string{"EA", "KG", "M"}
+	QualityGrades     = []string{"A", "B", "C"}
+	ComplianceFlags   = []string{"ROHS", "REACH", "ITAR"}
+	HazardClasses     = []string{"flammable", "toxic", "corrosive"}
 )
 
 // Part represents a part entity with identity, description, supplier, cost, compliance, and metadata fields.
@@ -43,12 +58,7 @@ type Part struct {
 // The data is randomly generated for testing or demo purposes.
 func GeneratePart(tenant string, supplierIDs []string) Part {
 
-	categories := []string{"ELECTRICAL", "MECHANICAL", "RAW_MATERIAL", "OTHER"}
-	lifecycle_status := []string{"NEW", "ACTIVE", "NRND", "EOL"}
-	uoms := []string{"EA", "KG", "M"}
-	grades := []string{"A", "B", "C"}
-	flags := []string{"ROHS", "REACH", "ITAR"}
-	hazards := []string{"", "flammable", "toxic", "corrosive"}
+	hazards := append([]string{""}, HazardClasses...)
 
 	default_supplier_id := ""
 	qualified_supplier_ids := []string{}
@@ -68,9 +78,9 @@ func GeneratePart(tenant string, supplierIDs []string) Part {
 		TenantID:             tenant,
 		PartNumber:           "P-" + gofakeit.Numerify("######"),
 		Description:          gofakeit.Sentence(5),
-		Category:             gofakeit.RandomString(categories),
-		LifecycleStatus:      gofakeit.RandomString(lifecycle_status),
-		Uom:                  gofakeit.RandomString(uoms),
+		Category:             gofakeit.RandomString(Categories),
+		LifecycleStatus:      gofakeit.RandomString(LifecycleStatuses),
+		Uom:                  gofakeit.RandomString(Uoms),
 		SpecHash:             gofakeit.UUID(),
 		BomCompatibility:     []string{gofakeit.LetterN(3), gofakeit.LetterN(3)},
 		DefaultSupplierID:    default_supplier_id,
@@ -79,11 +89,11 @@ func GeneratePart(tenant string, supplierIDs []string) Part {
 		Moq:                  gofakeit.Number(1, 500),
 		LeadTimeDaysAvg:      gofakeit.Number(2, 60),
 		LeadTimeDaysP95:      gofakeit.Number(5, 90),
-		QualityGrade:         gofakeit.RandomString(grades),
+		QualityGrade:         gofakeit.RandomString(QualityGrades),
 
 		// GenerateParts creates and returns a slice of synthetic Parts.
 		// The number of parts generated is specified by count.
-		ComplianceFlags:    flags,
+		ComplianceFlags:    slices.Clone(ComplianceFlags),
 		HazardClass:        gofakeit.RandomString(hazards),
 		LastPriceChange:    time.Now(),
 		DataSource:         "synthetic.v1",
@@ -102,11 +112,13 @@ func GenerateParts(count int, tenant string, supplierIDs []string) []Part {
 }
 
 // PartsWriter writes a slice of Part records to a CSV file with the given filename.
-// Returns true if the file was written successfully, false otherwise.
-func PartsWriter(filename string, parts []Part) bool {
+// Returns true if the file was written successfully, false otherwise; the
+// error is logged to logger, which may be nil.
+func PartsWriter(logger *slog.Logger, filename string, parts []Part) bool {
+	logger = logging.OrDiscard(logger)
 	file, err := os.Create(filename)
 	if err != nil {
-		fmt.Println("error creating file:", err)
+		logger.Error("failed to create file", "file", filename, "err", err)
 		return false
 	}
 	defer file.Close()
@@ -126,7 +138,7 @@ func PartsWriter(filename string, parts []Part) bool {
 		"data_source", "source_timestamp", "ingestion_timestamp", "schema_version",
 	}
 	if err := w.Write(header); err != nil {
-		fmt.Println("error writing header:", err)
+		logger.Error("failed to write header", "file", filename, "err", err)
 		return false
 	}
 
@@ -158,18 +170,18 @@ func PartsWriter(filename string, parts []Part) bool {
 			part.SchemaVersion,
 		}
 		if err := w.Write(row); err != nil {
-			fmt.Println("error writing row:", err)
+			logger.Error("failed to write row", "file", filename, "err", err)
 			return false
 		}
 	}
 
 	w.Flush()
 	if err := w.Error(); err != nil {
-		fmt.Println("error flushing data to file:", err)
+		logger.Error("failed to flush csv", "file", filename, "err", err)
 		return false
 	}
 
-	fmt.Println("Parts csv file:", filename)
+	logger.Info("wrote csv", "file", filename, "rows", len(parts))
 	return true
 
 }
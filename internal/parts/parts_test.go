@@ -44,7 +44,7 @@ func TestWritePartsToCSV(t *testing.T) {
 	num := 5
 	parts := GenerateParts(num, tenant, supplierIDs)
 	filename := "test_parts.csv"
-	success := PartsWriter(filename, parts)
+	success := PartsWriter(nil, filename, parts)
 	if !success {
 		t.Error("expected PartsWriter to return true")
 	}
@@ -55,3 +55,11 @@ func TestWritePartsToCSV(t *testing.T) {
 	// Clean up
 	os.Remove(filename)
 }
+
+func TestGeneratePartOwnsItsLists(t *testing.T) {
+	a, b := GeneratePart("tenant_acme", nil), GeneratePart("tenant_acme", nil)
+	a.ComplianceFlags[0] = "CHANGED"
+	if b.ComplianceFlags[0] == "CHANGED" || ComplianceFlags[0] == "CHANGED" {
+		t.Error("expected each part to have its own compliance flags")
+	}
+}
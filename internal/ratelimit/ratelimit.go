@@ -0,0 +1,290 @@
+// Package ratelimit limits how often and how many at once each tenant may call
+// each route. Rules give a token bucket per tenant and route, refilled at a
+// steady rate up to a burst, and a cap on requests in flight. The rules of a
+// Limiter can be replaced while it serves, so an operator can tighten or
+// relax them without a restart.
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultRules protect the warehouse when no rules are configured: each
+// tenant may sync its suppliers from Snowflake once a minute, and only one
+// sync of any tenant runs at a time.
+var DefaultRules = []Rule{
+	{Route: "GET /fetch-and-insert", Rate: 1.0 / 60, Burst: 1, Concurrency: 1},
+}
+
+// DefaultFailureRules limit failed authentications when the tenant is the
+// client's address: ten at once, then one every six seconds.
+var DefaultFailureRules = []Rule{
+	{Rate: 1.0 / 6, Burst: 10},
+}
+
+// sweepInterval is how often buckets that have refilled, and so are no
+// different from new ones, are dropped.
+const sweepInterval = time.Minute
+
+// Rule limits the requests of one route, or of every route when Route is
+// empty, by one tenant, or by each tenant when TenantID is empty. A request
+// follows the most specific rule that matches it: tenant and route, then
+// route, then tenant, then neither.
+type Rule struct {
+	// Route is a route pattern such as "GET /suppliers/{id}".
+	Route    string `json:"route,omitempty"`
+	TenantID string `json:"tenant_id,omitempty"`
+	// Rate is the requests per second each tenant may make to each route;
+	// zero leaves the rate unlimited.
+	Rate float64 `json:"rate,omitempty"`
+	// Burst is how many requests a tenant may make at once after being
+	// idle. Zero means the rate rounded up, and at least 1.
+	Burst int `json:"burst,omitempty"`
+	// Concurrency caps the requests the rule admits that are in flight at
+	// once, across tenants when TenantID is empty; zero leaves it unlimited.
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+func (r Rule) String() string {
+	s := r.Route
+	if s == "" {
+		s = "every route"
+	}
+	if r.TenantID != "" {
+		s += " for " + r.TenantID
+	}
+	return s
+}
+
+// Validate reports whether the rule limits something with non-negative
+// numbers.
+func (r Rule) Validate() error {
+	switch {
+	case r.Rate < 0 || math.IsInf(r.Rate, 0) || math.IsNaN(r.Rate):
+		return fmt.Errorf("ratelimit: %s: rate must be a positive number", r)
+	case r.Burst < 0:
+		return fmt.Errorf("ratelimit: %s: burst must be positive", r)
+	case r.Concurrency < 0:
+		return fmt.Errorf("ratelimit: %s: concurrency must be positive", r)
+	case r.Rate == 0 && r.Concurrency == 0:
+		return fmt.Errorf("ratelimit: %s: rate or concurrency is required", r)
+	case r.Rate == 0 && r.Burst > 0:
+		return fmt.Errorf("ratelimit: %s: burst needs a rate", r)
+	}
+	return nil
+}
+
+// burst returns the bucket size.
+func (r Rule) burst() float64 {
+	if r.Burst > 0 {
+		return float64(r.Burst)
+	}
+	return math.Max(1, math.Ceil(r.Rate))
+}
+
+// Load reads a JSON list of rules and validates them.
+func Load(r io.Reader) ([]Rule, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var rules []Rule
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("ratelimit: parse rules: %w", err)
+	}
+	seen := map[scope]bool{}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[rule.scope()] {
+			return nil, fmt.Errorf("ratelimit: %s: more than one rule", rule)
+		}
+		seen[rule.scope()] = true
+	}
+	return rules, nil
+}
+
+// LoadFile reads rules from a JSON file.
+func LoadFile(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// scope is what a rule applies to.
+type scope struct{ route, tenantID string }
+
+func (r Rule) scope() scope { return scope{r.Route, r.TenantID} }
+
+// bucket holds one tenant's tokens for one route.
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// Error is returned by Acquire for a request over its limit.
+type Error struct {
+	Rule Rule
+	// Concurrent is true when the rule's requests in flight are at its cap,
+	// false when the tenant is out of tokens.
+	Concurrent bool
+	// RetryAfter is how long until the request could be admitted.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Concurrent {
+		return fmt.Sprintf("%s allows %d requests at once", e.Rule, e.Rule.Concurrency)
+	}
+	return fmt.Sprintf("%s allows %g requests a second, in bursts of %g", e.Rule, e.Rule.Rate, e.Rule.burst())
+}
+
+// Limiter admits requests by its rules. It is safe for concurrent use.
+type Limiter struct {
+	mu       sync.Mutex
+	rules    map[scope]Rule
+	buckets  map[scope]*bucket
+	inflight map[scope]int
+	swept    time.Time
+	now      func() time.Time
+}
+
+// New returns a Limiter enforcing rules, which must be valid.
+func New(rules []Rule) *Limiter {
+	l := &Limiter{buckets: map[scope]*bucket{}, inflight: map[scope]int{}, now: time.Now}
+	l.SetRules(rules)
+	return l
+}
+
+// SetRules replaces the rules. Tenants keep the tokens they have, up to the
+// new burst, and requests in flight count against the new concurrency caps
+// of their rule's route and tenant.
+func (l *Limiter) SetRules(rules []Rule) {
+	m := make(map[scope]Rule, len(rules))
+	for _, r := range rules {
+		m[r.scope()] = r
+	}
+	l.mu.Lock()
+	l.rules = m
+	l.mu.Unlock()
+}
+
+// Rules returns the rules in force, in no particular order.
+func (l *Limiter) Rules() []Rule {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rules := make([]Rule, 0, len(l.rules))
+	for _, r := range l.rules {
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// rule returns the rule a request of tenantID to route follows.
+func (l *Limiter) rule(tenantID, route string) (Rule, bool) {
+	for _, s := range []scope{{route, tenantID}, {route, ""}, {"", tenantID}, {"", ""}} {
+		if r, ok := l.rules[s]; ok {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Acquire admits a request of tenantID to route, or returns an *Error saying
+// when to retry. An admitted request must call release when it finishes.
+func (l *Limiter) Acquire(tenantID, route string) (release func(), err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if now.Sub(l.swept) >= sweepInterval {
+		l.sweep(now)
+	}
+	rule, ok := l.rule(tenantID, route)
+	if !ok {
+		return func() {}, nil
+	}
+
+	// the cap counts the rule's requests; the bucket is always the tenant's
+	sem := rule.scope()
+	if rule.Concurrency > 0 && l.inflight[sem] >= rule.Concurrency {
+		return nil, &Error{Rule: rule, Concurrent: true, RetryAfter: time.Second}
+	}
+	if rule.Rate > 0 {
+		key := scope{route, tenantID}
+		b, ok := l.buckets[key]
+		if !ok {
+			b = &bucket{tokens: rule.burst(), at: now}
+			l.buckets[key] = b
+		}
+		b.tokens, b.at = b.refill(rule, now), now
+		if b.tokens < 1 {
+			return nil, &Error{Rule: rule, RetryAfter: rule.wait(b.tokens)}
+		}
+		b.tokens--
+	}
+	if rule.Concurrency == 0 {
+		return func() {}, nil
+	}
+	l.inflight[sem]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.inflight[sem]--; l.inflight[sem] <= 0 {
+				delete(l.inflight, sem)
+			}
+		})
+	}, nil
+}
+
+// Check returns the *Error Acquire would for a request of tenantID to route
+// now, or nil, without admitting or counting the request. With Acquire
+// charged only for the requests that turn out badly, it refuses a client
+// that has had too many of them before its next one does any work.
+func (l *Limiter) Check(tenantID, route string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rule, ok := l.rule(tenantID, route)
+	if !ok {
+		return nil
+	}
+	if rule.Concurrency > 0 && l.inflight[rule.scope()] >= rule.Concurrency {
+		return &Error{Rule: rule, Concurrent: true, RetryAfter: time.Second}
+	}
+	if b, ok := l.buckets[scope{route, tenantID}]; ok && rule.Rate > 0 {
+		if tokens := b.refill(rule, l.now()); tokens < 1 {
+			return &Error{Rule: rule, RetryAfter: rule.wait(tokens)}
+		}
+	}
+	return nil
+}
+
+// refill returns the tokens the bucket holds at now under rule.
+func (b *bucket) refill(rule Rule, now time.Time) float64 {
+	return math.Min(rule.burst(), b.tokens+now.Sub(b.at).Seconds()*rule.Rate)
+}
+
+// wait returns how long until a bucket holding tokens holds one.
+func (r Rule) wait(tokens float64) time.Duration {
+	return time.Duration((1 - tokens) / r.Rate * float64(time.Second))
+}
+
+// sweep drops buckets that have refilled, which Acquire would recreate full.
+func (l *Limiter) sweep(now time.Time) {
+	l.swept = now
+	for key, b := range l.buckets {
+		rule, ok := l.rule(key.tenantID, key.route)
+		if !ok || rule.Rate == 0 || b.refill(rule, now) >= rule.burst() {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package ratelimit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clock is a settable time source.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestLimiter(rules []Rule) (*Limiter, *clock) {
+	c := &clock{t: time.Date(2025, 9, 24, 10, 0, 0, 0, time.UTC)}
+	l := New(rules)
+	l.now = c.now
+	return l, c
+}
+
+// limitError returns the *Error of err, failing the test without one.
+func limitError(t *testing.T, err error) *Error {
+	t.Helper()
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("expected a limit error, got %v", err)
+	}
+	return e
+}
+
+func TestLoad(t *testing.T) {
+	rules, err := Load(strings.NewReader(`[
+		{"rate": 10, "burst": 20},
+		{"route": "GET /fetch-and-insert", "rate": 0.0167, "concurrency": 1},
+		{"route": "GET /suppliers", "tenant_id": "tenant_acme", "rate": 100}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 3 || rules[1].Concurrency != 1 || rules[2].TenantID != "tenant_acme" {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	for _, in := range []string{
+		`[{"route": "GET /parts"}]`,
+		`[{"rate": -1}]`,
+		`[{"concurrency": 2, "burst": 5}]`,
+		`[{"rate": 1, "per": "minute"}]`,
+		`[{"rate": 1}, {"rate": 2}]`,
+		`{"rate": 1}`,
+	} {
+		if _, err := Load(strings.NewReader(in)); err == nil {
+			t.Errorf("%s: expected an error", in)
+		}
+	}
+	for _, r := range append(DefaultRules, DefaultFailureRules...) {
+		if err := r.Validate(); err != nil {
+			t.Errorf("default rule %s: %v", r, err)
+		}
+	}
+}
+
+func TestRate(t *testing.T) {
+	l, c := newTestLimiter([]Rule{{Rate: 2, Burst: 3}})
+
+	// a burst, then one token every half second
+	for i := range 3 {
+		if _, err := l.Acquire("tenant_acme", "GET /parts"); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	e := limitError(t, func() error { _, err := l.Acquire("tenant_acme", "GET /parts"); return err }())
+	if e.Concurrent || e.RetryAfter != 500*time.Millisecond {
+		t.Errorf("unexpected error %+v", e)
+	}
+
+	// each tenant and route has its own bucket
+	if _, err := l.Acquire("tenant_bolt", "GET /parts"); err != nil {
+		t.Errorf("expected another tenant to be admitted: %v", err)
+	}
+	if _, err := l.Acquire("tenant_acme", "GET /suppliers"); err != nil {
+		t.Errorf("expected another route to be admitted: %v", err)
+	}
+
+	c.t = c.t.Add(500 * time.Millisecond)
+	if _, err := l.Acquire("tenant_acme", "GET /parts"); err != nil {
+		t.Errorf("expected a refilled token: %v", err)
+	}
+	if _, err := l.Acquire("tenant_acme", "GET /parts"); err == nil {
+		t.Error("expected the bucket to be empty again")
+	}
+
+	// idle buckets never hold more than the burst
+	c.t = c.t.Add(time.Hour)
+	for range 3 {
+		l.Acquire("tenant_acme", "GET /parts")
+	}
+	if _, err := l.Acquire("tenant_acme", "GET /parts"); err == nil {
+		t.Error("expected the burst to cap the tokens")
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	l, _ := newTestLimiter([]Rule{{Route: "GET /fetch-and-insert", Concurrency: 1}})
+
+	release, err := l.Acquire("tenant_acme", "GET /fetch-and-insert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cap is shared by every tenant the rule covers
+	e := limitError(t, func() error { _, err := l.Acquire("tenant_bolt", "GET /fetch-and-insert"); return err }())
+	if !e.Concurrent || e.RetryAfter <= 0 {
+		t.Errorf("unexpected error %+v", e)
+	}
+	if _, err := l.Acquire("tenant_acme", "GET /parts"); err != nil {
+		t.Errorf("expected routes without a rule to be unlimited: %v", err)
+	}
+	release()
+	release() // a second call is harmless
+	if _, err := l.Acquire("tenant_bolt", "GET /fetch-and-insert"); err != nil {
+		t.Errorf("expected the slot to be free: %v", err)
+	}
+	if _, err := l.Acquire("tenant_acme", "GET /fetch-and-insert"); err == nil {
+		t.Error("expected a second release not to free another slot")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	l, c := newTestLimiter([]Rule{{Rate: 1, Burst: 2}, {Route: "GET /fetch-and-insert", Concurrency: 1}})
+
+	// checking counts nothing
+	for range 5 {
+		if err := l.Check("192.0.2.1", ""); err != nil {
+			t.Fatalf("expected a fresh client to pass: %v", err)
+		}
+	}
+	l.Acquire("192.0.2.1", "")
+	l.Acquire("192.0.2.1", "")
+	e := limitError(t, l.Check("192.0.2.1", ""))
+	if e.Concurrent || e.RetryAfter != time.Second {
+		t.Errorf("unexpected error %+v", e)
+	}
+	if err := l.Check("192.0.2.2", ""); err != nil {
+		t.Errorf("expected another client to pass: %v", err)
+	}
+	c.t = c.t.Add(time.Second)
+	if err := l.Check("192.0.2.1", ""); err != nil {
+		t.Errorf("expected a refilled token: %v", err)
+	}
+
+	release, _ := l.Acquire("tenant_acme", "GET /fetch-and-insert")
+	if e := limitError(t, l.Check("tenant_bolt", "GET /fetch-and-insert")); !e.Concurrent {
+		t.Errorf("unexpected error %+v", e)
+	}
+	release()
+	if err := l.Check("tenant_bolt", "GET /fetch-and-insert"); err != nil {
+		t.Errorf("expected the slot to be free: %v", err)
+	}
+}
+
+func TestMostSpecificRule(t *testing.T) {
+	l, _ := newTestLimiter([]Rule{
+		{Rate: 1},
+		{TenantID: "tenant_big", Rate: 5},
+		{Route: "GET /parts", Rate: 2},
+		{Route: "GET /parts", TenantID: "tenant_big", Rate: 3},
+	})
+	admitted := func(tenantID, route string) int {
+		n := 0
+		for range 10 {
+			if _, err := l.Acquire(tenantID, route); err == nil {
+				n++
+			}
+		}
+		return n
+	}
+	for _, c := range []struct {
+		tenantID, route string
+		want            int
+	}{
+		{"tenant_acme", "GET /suppliers", 1},
+		{"tenant_big", "GET /suppliers", 5},
+		{"tenant_acme", "GET /parts", 2},
+		{"tenant_big", "GET /parts", 3},
+	} {
+		if got := admitted(c.tenantID, c.route); got != c.want {
+			t.Errorf("%s %s: admitted %d, want %d", c.tenantID, c.route, got, c.want)
+		}
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	l, c := newTestLimiter([]Rule{{Rate: 1, Burst: 5, Concurrency: 2}})
+	release, _ := l.Acquire("tenant_acme", "GET /parts")
+	defer release()
+	l.Acquire("tenant_acme", "GET /parts")
+
+	// a request in flight counts against the new cap
+	l.SetRules([]Rule{{Rate: 1, Burst: 2, Concurrency: 2}})
+	if e := limitError(t, func() error { _, err := l.Acquire("tenant_acme", "GET /parts"); return err }()); !e.Concurrent {
+		t.Errorf("expected the cap to be reached, got %v", e)
+	}
+
+	l.SetRules(nil)
+	for range 10 {
+		if _, err := l.Acquire("tenant_acme", "GET /parts"); err != nil {
+			t.Fatalf("expected no rules to admit everything: %v", err)
+		}
+	}
+	if len(l.Rules()) != 0 {
+		t.Errorf("unexpected rules %v", l.Rules())
+	}
+
+	// a sweep forgets buckets that have refilled
+	l.SetRules([]Rule{{Rate: 1}})
+	l.Acquire("tenant_acme", "GET /parts")
+	c.t = c.t.Add(sweepInterval)
+	l.Acquire("tenant_bolt", "GET /parts")
+	if len(l.buckets) != 1 {
+		t.Errorf("expected only the new bucket after a sweep, got %d", len(l.buckets))
+	}
+}
@@ -0,0 +1,292 @@
+// Package retention removes old rows from the local SQLite database according
+// to configurable rules. A rule applies to one table, optionally one tenant,
+// and removes rows whose timestamp column is older than an age, or history
+// versions beyond the newest N per row.
+//
+// Rows are deleted in small batches, each in its own transaction, so the
+// server and loaders can write between batches instead of waiting for one
+// long delete. Deleting from the dim tables is a purge: it fires the same
+// triggers as PurgeSupplier and PurgePart, so links, history, search and the
+// audit log stay consistent.
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBatchSize is the number of rows deleted per transaction.
+const DefaultBatchSize = 500
+
+// sampleSize is the number of keys a Result lists.
+const sampleSize = 10
+
+// table describes what rules may do to one table.
+type table struct {
+	// key identifies a row in results; for history tables it is also the row
+	// whose versions are counted.
+	key string
+	// columns are the timestamps age rules may use.
+	columns []string
+	// versioned tables accept KeepVersions.
+	versioned bool
+}
+
+var tables = map[string]table{
+	"dim_supplier_v1":         {key: "supplier_id", columns: []string{"ingestion_timestamp", "source_timestamp", "deleted_at"}},
+	"dim_part_v1":             {key: "part_id", columns: []string{"ingestion_timestamp", "source_timestamp", "deleted_at"}},
+	"dim_supplier_v1_history": {key: "supplier_id", columns: []string{"valid_to"}, versioned: true},
+	"dim_part_v1_history":     {key: "part_id", columns: []string{"valid_to"}, versioned: true},
+	"audit_log":               {key: "audit_id", columns: []string{"occurred_at"}},
+}
+
+// Age is a duration that also accepts whole days, such as "90d", in JSON.
+type Age time.Duration
+
+// ParseAge parses a Go duration or a number of days followed by "d".
+func ParseAge(s string) (Age, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return Age(time.Duration(n) * 24 * time.Hour), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return Age(d), nil
+}
+
+func (a *Age) UnmarshalText(text []byte) error {
+	v, err := ParseAge(string(text))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+func (a Age) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(a).String()), nil
+}
+
+// Rule removes rows from Table. Set Column and OlderThan to remove rows whose
+// Column is older than OlderThan; rows where it is NULL are kept, so a
+// deleted_at rule only removes soft-deleted rows. Set KeepVersions on a
+// history table to remove all but its newest KeepVersions versions per row;
+// the current version is always kept.
+type Rule struct {
+	Table string `json:"table"`
+	// TenantID limits the rule to one tenant; empty applies it to all.
+	TenantID     string `json:"tenant_id,omitempty"`
+	Column       string `json:"column,omitempty"`
+	OlderThan    Age    `json:"older_than,omitempty"`
+	KeepVersions int    `json:"keep_versions,omitempty"`
+}
+
+func (r Rule) String() string {
+	s := r.Table
+	if r.TenantID != "" {
+		s += " tenant " + r.TenantID
+	}
+	if r.KeepVersions > 0 {
+		return fmt.Sprintf("%s: keep %d versions", s, r.KeepVersions)
+	}
+	return fmt.Sprintf("%s: %s older than %s", s, r.Column, time.Duration(r.OlderThan))
+}
+
+// Validate reports whether the rule names a known table and exactly one of a
+// column age or a version count that the table supports.
+func (r Rule) Validate() error {
+	t, ok := tables[r.Table]
+	if !ok {
+		return fmt.Errorf("retention: unknown table %q", r.Table)
+	}
+	switch {
+	case r.KeepVersions < 0:
+		return fmt.Errorf("retention: %s: keep_versions must be positive", r.Table)
+	case r.KeepVersions > 0 && r.Column != "":
+		return fmt.Errorf("retention: %s: set either column or keep_versions, not both", r.Table)
+	case r.KeepVersions > 0:
+		if !t.versioned {
+			return fmt.Errorf("retention: %s: keep_versions only applies to history tables", r.Table)
+		}
+		return nil
+	case r.Column == "":
+		return fmt.Errorf("retention: %s: column or keep_versions is required", r.Table)
+	}
+	for _, c := range t.columns {
+		if c == r.Column {
+			if r.OlderThan <= 0 {
+				return fmt.Errorf("retention: %s: older_than is required", r.Table)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("retention: %s: column must be one of %s", r.Table, strings.Join(t.columns, ", "))
+}
+
+// Load reads a JSON list of rules and validates them.
+func Load(r io.Reader) ([]Rule, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var rules []Rule
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("retention: parse rules: %w", err)
+	}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// LoadFile reads rules from a JSON file.
+func LoadFile(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Options tunes Run.
+type Options struct {
+	// BatchSize is the number of rows deleted per transaction. Zero means
+	// DefaultBatchSize.
+	BatchSize int
+	// Pause is slept between batches to leave room for other writers.
+	Pause time.Duration
+	// DryRun counts and samples the rows each rule matches without deleting.
+	DryRun bool
+	// Now is the time ages are measured from. Zero means time.Now().
+	Now time.Time
+}
+
+// Result reports what one rule matched and removed.
+type Result struct {
+	Rule Rule
+	// Matched is the number of rows the rule matched before it ran.
+	Matched int64
+	// Deleted is the number of rows removed; always zero on a dry run.
+	Deleted int64
+	// Sample holds the keys of up to ten matched rows.
+	Sample   []string
+	Batches  int
+	Duration time.Duration
+}
+
+// Run applies the rules in order. It stops at the first failing rule and
+// returns the results so far with the error; batches already committed stay
+// deleted.
+func Run(ctx context.Context, conn *sql.DB, rules []Rule, opts Options) ([]Result, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	var results []Result
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return results, err
+		}
+		res, err := run(ctx, conn, rule, opts)
+		results = append(results, res)
+		if err != nil {
+			return results, fmt.Errorf("retention: %s: %w", rule, err)
+		}
+	}
+	return results, nil
+}
+
+func run(ctx context.Context, conn *sql.DB, rule Rule, opts Options) (Result, error) {
+	start := time.Now()
+	res := Result{Rule: rule}
+	t := tables[rule.Table]
+	match, args := matchSQL(rule, t, opts.Now)
+
+	err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+rule.Table+" WHERE rowid IN ("+match+")", args...).Scan(&res.Matched)
+	if err != nil {
+		return res, err
+	}
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE rowid IN (%s) ORDER BY %s LIMIT %d",
+		t.key, rule.Table, match, t.key, sampleSize), args...)
+	if err != nil {
+		return res, err
+	}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return res, err
+		}
+		res.Sample = append(res.Sample, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || opts.DryRun {
+		res.Duration = time.Since(start)
+		return res, err
+	}
+
+	del := "DELETE FROM " + rule.Table + " WHERE rowid IN (" + match + " LIMIT ?)"
+	batchArgs := append(args, opts.BatchSize)
+	for {
+		r, err := conn.ExecContext(ctx, del, batchArgs...)
+		if err != nil {
+			return res, err
+		}
+		n, err := r.RowsAffected()
+		if err != nil {
+			return res, err
+		}
+		res.Deleted += n
+		res.Batches++
+		res.Duration = time.Since(start)
+		if n < int64(opts.BatchSize) {
+			return res, nil
+		}
+		if opts.Pause > 0 {
+			select {
+			case <-ctx.Done():
+				return res, ctx.Err()
+			case <-time.After(opts.Pause):
+			}
+		}
+	}
+}
+
+// matchSQL returns a query selecting the rowids the rule removes.
+func matchSQL(rule Rule, t table, now time.Time) (string, []any) {
+	where := "1"
+	var args []any
+	if rule.TenantID != "" {
+		where = "tenant_id = ?"
+		args = append(args, rule.TenantID)
+	}
+
+	if rule.KeepVersions > 0 {
+		args = append(args, rule.KeepVersions)
+		return fmt.Sprintf(`SELECT history_id FROM (
+    SELECT history_id, is_current,
+        row_number() OVER (PARTITION BY %s ORDER BY valid_from DESC, history_id DESC) AS version
+    FROM %s WHERE %s
+) WHERE version > ? AND NOT is_current`, t.key, rule.Table, where), args
+	}
+
+	// timestamps are stored as text in more than one format; julianday reads them all
+	cutoff := now.Add(-time.Duration(rule.OlderThan)).UTC().Format("2006-01-02 15:04:05.000")
+	args = append(args, cutoff)
+	return fmt.Sprintf("SELECT rowid FROM %s WHERE %s AND julianday(%s) < julianday(?)", rule.Table, where, rule.Column), args
+}
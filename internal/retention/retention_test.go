@@ -0,0 +1,222 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+)
+
+func openTestDB(t *testing.T) (*sql.DB, *db.Queries) {
+	t.Helper()
+	conn, err := database.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+	if err := database.Migrate(context.Background(), conn); err != nil {
+		t.Fatal(err)
+	}
+	return conn, db.New(conn)
+}
+
+func count(t *testing.T, conn *sql.DB, query string) int {
+	t.Helper()
+	var n int
+	if err := conn.QueryRow(query).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"90d":   90 * 24 * time.Hour,
+		"0d":    0,
+		"36h":   36 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	} {
+		if got, err := ParseAge(in); err != nil || time.Duration(got) != want {
+			t.Errorf("ParseAge(%q) = %v, %v, want %v", in, time.Duration(got), err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "-1d", "1.5d", "-1h", "soon"} {
+		if _, err := ParseAge(in); err == nil {
+			t.Errorf("ParseAge(%q): expected an error", in)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	rules, err := Load(strings.NewReader(`[
+		{"table": "dim_part_v1", "tenant_id": "tenant_acme", "column": "ingestion_timestamp", "older_than": "365d"},
+		{"table": "dim_supplier_v1_history", "keep_versions": 5}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Rule{
+		{Table: "dim_part_v1", TenantID: "tenant_acme", Column: "ingestion_timestamp", OlderThan: Age(365 * 24 * time.Hour)},
+		{Table: "dim_supplier_v1_history", KeepVersions: 5},
+	}
+	if len(rules) != len(want) || rules[0] != want[0] || rules[1] != want[1] {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+
+	for _, bad := range []string{
+		`[{"table": "part_supplier", "column": "created_at", "older_than": "1d"}]`,
+		`[{"table": "dim_part_v1", "column": "unit_cost", "older_than": "1d"}]`,
+		`[{"table": "dim_part_v1", "column": "deleted_at"}]`,
+		`[{"table": "dim_part_v1", "keep_versions": 3}]`,
+		`[{"table": "dim_part_v1_history", "keep_versions": 3, "column": "valid_to", "older_than": "1d"}]`,
+		`[{"table": "dim_part_v1"}]`,
+		`[{"table": "dim_part_v1", "column": "deleted_at", "older_than": "1d", "typo": 1}]`,
+	} {
+		if _, err := Load(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+}
+
+func TestRunAgeRules(t *testing.T) {
+	ctx := context.Background()
+	conn, q := openTestDB(t)
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for _, s := range []struct {
+		id, tenant string
+		ingested   time.Time
+	}{
+		{"SUP-OLD", "tenant_acme", old},
+		{"SUP-NEW", "tenant_acme", recent},
+		{"SUP-OTHER", "tenant_globex", old},
+	} {
+		_, err := q.CreateSupplier(ctx, db.CreateSupplierParams{
+			SupplierID: s.id, TenantID: s.tenant, LegalName: s.id,
+			IngestionTimestamp: sql.NullTime{Time: s.ingested, Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules := []Rule{{Table: "dim_supplier_v1", TenantID: "tenant_acme", Column: "ingestion_timestamp", OlderThan: Age(365 * 24 * time.Hour)}}
+	now := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	// a dry run reports without deleting
+	results, err := Run(ctx, conn, rules, Options{DryRun: true, Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.Matched != 1 || r.Deleted != 0 || len(r.Sample) != 1 || r.Sample[0] != "SUP-OLD" {
+		t.Errorf("dry run: unexpected result %+v", r)
+	}
+	if n := count(t, conn, "SELECT COUNT(*) FROM dim_supplier_v1"); n != 3 {
+		t.Fatalf("dry run deleted rows: %d left", n)
+	}
+
+	results, err = Run(ctx, conn, rules, Options{Now: now})
+	if err != nil || results[0].Deleted != 1 {
+		t.Fatalf("run: %+v %v", results, err)
+	}
+	if n := count(t, conn, "SELECT COUNT(*) FROM dim_supplier_v1 WHERE supplier_id = 'SUP-OLD'"); n != 0 {
+		t.Error("expected SUP-OLD to be purged")
+	}
+	// the purge went through the triggers
+	if n := count(t, conn, "SELECT COUNT(*) FROM audit_log WHERE entity_id = 'SUP-OLD' AND action = 'purge'"); n != 1 {
+		t.Errorf("expected a purge audit entry, got %d", n)
+	}
+
+	// only soft-deleted rows match a deleted_at rule
+	by := sql.NullString{String: "alice", Valid: true}
+	if _, err := q.DeleteSupplier(ctx, db.DeleteSupplierParams{DeletedBy: by, TenantID: "tenant_acme", SupplierID: "SUP-NEW"}); err != nil {
+		t.Fatal(err)
+	}
+	results, err = Run(ctx, conn, []Rule{{Table: "dim_supplier_v1", Column: "deleted_at", OlderThan: Age(30 * 24 * time.Hour)}},
+		Options{Now: time.Now().Add(31 * 24 * time.Hour)})
+	if err != nil || results[0].Deleted != 1 || results[0].Sample[0] != "SUP-NEW" {
+		t.Errorf("deleted_at rule: %+v %v", results, err)
+	}
+	if n := count(t, conn, "SELECT COUNT(*) FROM dim_supplier_v1"); n != 1 {
+		t.Errorf("expected only SUP-OTHER to remain, got %d rows", n)
+	}
+
+	// audit entries age out too
+	results, err = Run(ctx, conn, []Rule{{Table: "audit_log", Column: "occurred_at", OlderThan: Age(time.Hour)}},
+		Options{Now: time.Now().Add(2 * time.Hour)})
+	if err != nil || results[0].Deleted == 0 {
+		t.Errorf("audit_log rule: %+v %v", results, err)
+	}
+	if n := count(t, conn, "SELECT COUNT(*) FROM audit_log"); n != 0 {
+		t.Errorf("expected the audit log to be emptied, got %d", n)
+	}
+}
+
+func TestRunKeepVersions(t *testing.T) {
+	ctx := context.Background()
+	conn, q := openTestDB(t)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, id := range []string{"SUP-1", "SUP-2"} {
+		for i := 0; i < 4; i++ {
+			_, err := q.UpsertSupplier(ctx, db.UpsertSupplierParams{
+				SupplierID: id, TenantID: "tenant_acme", LegalName: fmt.Sprintf("%s v%d", id, i),
+				SourceTimestamp: sql.NullTime{Time: start.AddDate(0, i, 0), Valid: true},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := q.PurgeSupplier(ctx, db.PurgeSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Run(ctx, conn, []Rule{{Table: "dim_supplier_v1_history", KeepVersions: 2}}, Options{BatchSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.Matched != 4 || r.Deleted != 4 || r.Batches != 5 {
+		t.Errorf("unexpected result %+v", r)
+	}
+	for id, want := range map[string]string{"SUP-1": "SUP-1 v2,SUP-1 v3", "SUP-2": "SUP-2 v2,SUP-2 v3"} {
+		history, err := q.ListSupplierHistory(ctx, db.ListSupplierHistoryParams{TenantID: "tenant_acme", SupplierID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, h := range history {
+			names = append(names, h.LegalName)
+		}
+		if got := strings.Join(names, ","); got != want {
+			t.Errorf("%s: expected versions %s, got %s", id, want, got)
+		}
+	}
+}
+
+func TestRunBatches(t *testing.T) {
+	ctx := context.Background()
+	conn, q := openTestDB(t)
+	for i := 0; i < 25; i++ {
+		_, err := q.CreatePart(ctx, db.CreatePartParams{
+			PartID: fmt.Sprintf("PART-%02d", i), TenantID: "tenant_acme", PartNumber: "P", Description: "part",
+			SourceTimestamp: sql.NullTime{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	results, err := Run(ctx, conn, []Rule{{Table: "dim_part_v1", Column: "source_timestamp", OlderThan: Age(24 * time.Hour)}},
+		Options{BatchSize: 10, Pause: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := results[0]
+	if r.Matched != 25 || r.Deleted != 25 || r.Batches != 3 || len(r.Sample) != 10 || r.Sample[0] != "PART-00" {
+		t.Errorf("unexpected result %+v", r)
+	}
+}
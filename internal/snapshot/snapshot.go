@@ -0,0 +1,308 @@
+// Package snapshot takes labeled point-in-time copies of the SQLite database
+// and restores them, both while other connections keep using the database.
+//
+// Snapshots are written with VACUUM INTO, which reads the database in a
+// single transaction, so a snapshot is consistent even while the server is
+// writing. Restores copy the snapshot over the live database with SQLite's
+// online backup API: other connections see the restored data on their next
+// query instead of holding on to a replaced file.
+//
+// A snapshot is a plain SQLite file, optionally gzipped, named
+// <created>_<label>.db[.gz] so the directory listing is the catalog.
+package snapshot
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+)
+
+// DefaultDir is where snapshots are kept when no directory is given.
+const DefaultDir = "data/snapshots"
+
+// timeFormat sorts lexically in creation order.
+const timeFormat = "20060102T150405.000Z"
+
+var (
+	labelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	namePattern  = regexp.MustCompile(`^(\d{8}T\d{6}\.\d{3}Z)_([A-Za-z0-9][A-Za-z0-9_-]*)\.db(\.gz)?$`)
+)
+
+// ErrNotFound is returned when no snapshot matches a name or label.
+var ErrNotFound = errors.New("snapshot not found")
+
+// Snapshot describes one snapshot file.
+type Snapshot struct {
+	Name       string
+	Label      string
+	CreatedAt  time.Time
+	Compressed bool
+	Size       int64
+	Path       string
+}
+
+// Options tunes Create.
+type Options struct {
+	// Compress gzips the snapshot.
+	Compress bool
+}
+
+// Create writes a snapshot of the database behind conn into dir. label names
+// the snapshot, for example "pre-sync", and may contain letters, digits, '-'
+// and '_'.
+func Create(ctx context.Context, conn *sql.DB, dir, label string, opts Options) (Snapshot, error) {
+	if !labelPattern.MatchString(label) {
+		return Snapshot{}, fmt.Errorf("invalid snapshot label %q: use letters, digits, '-' and '_'", label)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Snapshot{}, err
+	}
+
+	created := time.Now().UTC()
+	name := created.Format(timeFormat) + "_" + label + ".db"
+	if opts.Compress {
+		name += ".gz"
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return Snapshot{}, fmt.Errorf("snapshot %s already exists", name)
+	}
+
+	// VACUUM INTO refuses to overwrite, and the final name only appears once
+	// the snapshot is complete
+	tmp := filepath.Join(dir, "."+name+".tmp")
+	os.Remove(tmp)
+	if _, err := conn.ExecContext(ctx, "VACUUM INTO ?", tmp); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, fmt.Errorf("vacuum into %s: %w", tmp, err)
+	}
+	if opts.Compress {
+		gz := tmp + ".gz"
+		err := compress(tmp, gz)
+		os.Remove(tmp)
+		if err != nil {
+			os.Remove(gz)
+			return Snapshot{}, err
+		}
+		tmp = gz
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return Snapshot{}, err
+	}
+	return stat(dir, name)
+}
+
+// List returns the snapshots in dir, oldest first. A missing directory has no
+// snapshots.
+func List(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snaps []Snapshot
+	for _, e := range entries {
+		if e.IsDir() || !namePattern.MatchString(e.Name()) {
+			continue
+		}
+		s, err := stat(dir, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, s)
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Name < snaps[j].Name })
+	return snaps, nil
+}
+
+// Find returns the snapshot named ref, or else the newest one labeled ref.
+func Find(dir, ref string) (Snapshot, error) {
+	snaps, err := List(dir)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	for _, s := range snaps {
+		if s.Name == ref {
+			return s, nil
+		}
+	}
+	for i := len(snaps) - 1; i >= 0; i-- {
+		if snaps[i].Label == ref {
+			return snaps[i], nil
+		}
+	}
+	return Snapshot{}, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+// Delete removes a snapshot.
+func Delete(s Snapshot) error {
+	return os.Remove(s.Path)
+}
+
+// Restore replaces the contents of the database behind conn with snapshot s.
+// The snapshot is checked with PRAGMA integrity_check first, so a damaged
+// snapshot leaves the database untouched. The schema comes back as it was
+// when the snapshot was taken; run the migrations afterwards to bring it up
+// to date.
+func Restore(ctx context.Context, conn *sql.DB, s Snapshot) error {
+	path := s.Path
+	if s.Compressed {
+		tmp, err := os.CreateTemp(filepath.Dir(s.Path), "."+s.Name+".*.restore")
+		if err != nil {
+			return err
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err := decompress(s.Path, tmp.Name()); err != nil {
+			return fmt.Errorf("decompress %s: %w", s.Name, err)
+		}
+		path = tmp.Name()
+	}
+
+	src, err := database.Open("file:" + path + "?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := integrityCheck(ctx, src); err != nil {
+		return fmt.Errorf("snapshot %s: %w", s.Name, err)
+	}
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+	dstConn, err := conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	return dstConn.Raw(func(dc any) error {
+		return srcConn.Raw(func(sc any) error {
+			b, err := dc.(*sqlite3.SQLiteConn).Backup("main", sc.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return fmt.Errorf("start restore: %w", err)
+			}
+			// copy every page in one step so no reader sees a half-restored database
+			if _, err := b.Step(-1); err != nil {
+				b.Finish()
+				return fmt.Errorf("restore %s: %w", s.Name, err)
+			}
+			return b.Finish()
+		})
+	})
+}
+
+func integrityCheck(ctx context.Context, conn *sql.DB) error {
+	rows, err := conn.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func stat(dir, name string) (Snapshot, error) {
+	m := namePattern.FindStringSubmatch(name)
+	if m == nil {
+		return Snapshot{}, fmt.Errorf("not a snapshot: %s", name)
+	}
+	created, err := time.Parse(timeFormat, m[1])
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot %s: %w", name, err)
+	}
+	path := filepath.Join(dir, name)
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{
+		Name:       name,
+		Label:      m[2],
+		CreatedAt:  created,
+		Compressed: m[3] != "",
+		Size:       fi.Size(),
+		Path:       path,
+	}, nil
+}
+
+func compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func decompress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	zr, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, zr); err != nil {
+		out.Close()
+		return err
+	}
+	if err := zr.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,168 @@
+package snapshot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+)
+
+// openDB migrates a database file and returns two pools on it: one standing
+// in for the running server and one for the snapshot command.
+func openDB(t *testing.T) (server, cli *sql.DB) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.db")
+	for _, p := range []**sql.DB{&server, &cli} {
+		conn, err := database.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		*p = conn
+	}
+	if err := database.Migrate(context.Background(), server); err != nil {
+		t.Fatal(err)
+	}
+	return server, cli
+}
+
+func countSuppliers(t *testing.T, conn *sql.DB) int {
+	t.Helper()
+	var n int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM dim_supplier_v1").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func addSupplier(t *testing.T, conn *sql.DB, id string) {
+	t.Helper()
+	_, err := conn.Exec("INSERT INTO dim_supplier_v1 (supplier_id, tenant_id, legal_name) VALUES (?, 'tenant_acme', ?)", id, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateListRestore(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		t.Run(map[bool]string{false: "plain", true: "gzip"}[compress], func(t *testing.T) {
+			ctx := context.Background()
+			server, cli := openDB(t)
+			dir := t.TempDir()
+			addSupplier(t, server, "SUP-1")
+
+			snap, err := Create(ctx, cli, dir, "pre-sync", Options{Compress: compress})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snap.Label != "pre-sync" || snap.Compressed != compress || snap.Size == 0 {
+				t.Errorf("unexpected snapshot %+v", snap)
+			}
+
+			// a bad sync
+			addSupplier(t, server, "SUP-2")
+			if _, err := server.Exec("DELETE FROM dim_supplier_v1 WHERE supplier_id = 'SUP-1'"); err != nil {
+				t.Fatal(err)
+			}
+
+			found, err := Find(dir, "pre-sync")
+			if err != nil || found != snap {
+				t.Fatalf("find: %+v %v", found, err)
+			}
+			if err := Restore(ctx, cli, found); err != nil {
+				t.Fatal(err)
+			}
+
+			// the server's pool sees the restored data without reopening
+			var id string
+			if err := server.QueryRow("SELECT supplier_id FROM dim_supplier_v1").Scan(&id); err != nil || id != "SUP-1" || countSuppliers(t, server) != 1 {
+				t.Errorf("expected only SUP-1 after restore, got %q %v", id, err)
+			}
+		})
+	}
+}
+
+func TestListAndFind(t *testing.T) {
+	ctx := context.Background()
+	_, cli := openDB(t)
+	dir := t.TempDir()
+
+	if snaps, err := List(filepath.Join(dir, "missing")); err != nil || len(snaps) != 0 {
+		t.Errorf("expected no snapshots in a missing directory, got %v %v", snaps, err)
+	}
+
+	var made []Snapshot
+	for _, label := range []string{"nightly", "pre-sync", "nightly"} {
+		s, err := Create(ctx, cli, dir, label, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		made = append(made, s)
+	}
+	// files that are not snapshots are ignored
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644)
+
+	snaps, err := List(dir)
+	if err != nil || len(snaps) != 3 {
+		t.Fatalf("expected 3 snapshots, got %v %v", snaps, err)
+	}
+	for i := range made {
+		if snaps[i] != made[i] {
+			t.Errorf("snapshot %d: expected %+v, got %+v", i, made[i], snaps[i])
+		}
+	}
+
+	if s, err := Find(dir, "nightly"); err != nil || s != made[2] {
+		t.Errorf("expected the newest nightly, got %+v %v", s, err)
+	}
+	if s, err := Find(dir, made[0].Name); err != nil || s != made[0] {
+		t.Errorf("expected lookup by name, got %+v %v", s, err)
+	}
+	if _, err := Find(dir, "weekly"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := Delete(made[1]); err != nil {
+		t.Fatal(err)
+	}
+	if snaps, _ := List(dir); len(snaps) != 2 {
+		t.Errorf("expected 2 snapshots after delete, got %d", len(snaps))
+	}
+}
+
+func TestCreateRejectsBadLabel(t *testing.T) {
+	_, cli := openDB(t)
+	for _, label := range []string{"", "../x", "a b", "-x"} {
+		if _, err := Create(context.Background(), cli, t.TempDir(), label, Options{}); err == nil {
+			t.Errorf("expected label %q to be rejected", label)
+		}
+	}
+}
+
+func TestRestoreDamagedSnapshot(t *testing.T) {
+	ctx := context.Background()
+	server, cli := openDB(t)
+	dir := t.TempDir()
+	addSupplier(t, server, "SUP-1")
+
+	for _, compress := range []bool{false, true} {
+		snap, err := Create(ctx, cli, dir, "damaged", Options{Compress: compress})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(snap.Path, []byte("not a database"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := Restore(ctx, cli, snap); err == nil {
+			t.Errorf("compress=%v: expected restoring a damaged snapshot to fail", compress)
+		}
+		if countSuppliers(t, server) != 1 {
+			t.Errorf("compress=%v: expected the database to be untouched", compress)
+		}
+		Delete(snap)
+	}
+}
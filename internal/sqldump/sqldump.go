@@ -0,0 +1,426 @@
+// Package sqldump exports tables from the local SQLite database as portable SQL scripts.
+package sqldump
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect selects the SQL flavour of the generated script.
+type Dialect string
+
+const (
+	SQLite    Dialect = "sqlite"
+	Postgres  Dialect = "postgres"
+	Snowflake Dialect = "snowflake"
+)
+
+// ParseDialect returns the Dialect matching name.
+func ParseDialect(name string) (Dialect, error) {
+	switch d := Dialect(strings.ToLower(name)); d {
+	case SQLite, Postgres, Snowflake:
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown dialect %q (want sqlite, postgres or snowflake)", name)
+}
+
+// DefaultBatchSize is the number of rows per INSERT statement when Options.BatchSize is zero.
+const DefaultBatchSize = 500
+
+// Options controls what Dump writes.
+type Options struct {
+	Dialect Dialect
+	// Tables limits the dump to the named tables. Empty means the data
+	// tables: the live dim tables and part_supplier. Derived and internal
+	// tables, such as the history and audit log, are only dumped when named,
+	// and api_key and schema_migrations never are.
+	Tables []string
+	// BatchSize is the number of rows per multi-row INSERT.
+	BatchSize int
+	// DropTables emits DROP TABLE IF EXISTS before each CREATE TABLE.
+	DropTables bool
+}
+
+// neverDumped holds credentials and migration bookkeeping. A restored
+// schema_migrations would claim indexes, triggers and search tables the dump
+// does not create.
+var neverDumped = map[string]bool{"api_key": true, "schema_migrations": true}
+
+// isDataTable reports whether name is dumped by default.
+func isDataTable(name string) bool {
+	return name == "part_supplier" || strings.HasPrefix(name, "dim_") && !strings.HasSuffix(name, "_history")
+}
+
+// querier runs the dump's reads, all in one transaction.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+type column struct {
+	name     string
+	declType string
+	notNull  bool
+	pk       int
+}
+
+type foreignKey struct {
+	from, table, to string
+}
+
+type table struct {
+	name    string
+	columns []column
+	fks     []foreignKey
+}
+
+// Dump writes CREATE TABLE statements and batched INSERTs for every selected
+// table in conn to w. Tables are ordered so that foreign key targets come first.
+// Every table is read in one transaction, so the dump is a consistent snapshot
+// even while other connections write.
+func Dump(ctx context.Context, conn *sql.DB, w io.Writer, opts Options) error {
+	if opts.Dialect == "" {
+		opts.Dialect = SQLite
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	tables, err := loadTables(ctx, tx, opts.Tables)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "-- data-ingestion-go dump (%s dialect) generated %s\n", opts.Dialect, time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintln(bw, "BEGIN;")
+
+	if opts.DropTables {
+		for i := len(tables) - 1; i >= 0; i-- {
+			fmt.Fprintf(bw, "DROP TABLE IF EXISTS %s;\n", quoteIdent(tables[i].name))
+		}
+	}
+
+	for _, t := range tables {
+		fmt.Fprintln(bw)
+		writeCreateTable(bw, t, opts.Dialect)
+		if err := writeInserts(ctx, tx, bw, t, opts); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(bw)
+	fmt.Fprintln(bw, "COMMIT;")
+	return bw.Flush()
+}
+
+func loadTables(ctx context.Context, conn querier, only []string) ([]table, error) {
+	// table_list reports FTS and other virtual tables, and the shadow tables
+	// that back them, under their own types. They are derived from the other
+	// tables, so they are skipped.
+	rows, err := conn.QueryContext(ctx,
+		"SELECT name FROM pragma_table_list WHERE schema = 'main' AND type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(only) > 0 {
+		exists := map[string]bool{}
+		for _, n := range names {
+			exists[n] = true
+		}
+		for _, n := range only {
+			if !exists[n] {
+				return nil, fmt.Errorf("table %q does not exist", n)
+			}
+			if neverDumped[n] {
+				return nil, fmt.Errorf("table %q is never dumped", n)
+			}
+		}
+		names = only
+	} else {
+		names = slices.DeleteFunc(names, func(n string) bool { return !isDataTable(n) })
+	}
+
+	byName := map[string]*table{}
+	for _, name := range names {
+		t, err := describeTable(ctx, conn, name)
+		if err != nil {
+			return nil, err
+		}
+		byName[name] = &t
+	}
+
+	return sortByDependency(names, byName), nil
+}
+
+func describeTable(ctx context.Context, conn querier, name string) (table, error) {
+	t := table{name: name}
+
+	rows, err := conn.QueryContext(ctx, "SELECT name, type, \"notnull\", pk FROM pragma_table_info(?)", name)
+	if err != nil {
+		return t, fmt.Errorf("describe %s: %w", name, err)
+	}
+	for rows.Next() {
+		var c column
+		if err := rows.Scan(&c.name, &c.declType, &c.notNull, &c.pk); err != nil {
+			rows.Close()
+			return t, err
+		}
+		t.columns = append(t.columns, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return t, err
+	}
+
+	rows, err = conn.QueryContext(ctx, "SELECT \"from\", \"table\", \"to\" FROM pragma_foreign_key_list(?)", name)
+	if err != nil {
+		return t, fmt.Errorf("foreign keys of %s: %w", name, err)
+	}
+	for rows.Next() {
+		var fk foreignKey
+		var to sql.NullString
+		if err := rows.Scan(&fk.from, &fk.table, &to); err != nil {
+			rows.Close()
+			return t, err
+		}
+		fk.to = to.String
+		t.fks = append(t.fks, fk)
+	}
+	rows.Close()
+	return t, rows.Err()
+}
+
+// sortByDependency orders tables so referenced tables are created and filled
+// before the tables pointing at them. Cycles fall back to name order.
+func sortByDependency(names []string, byName map[string]*table) []table {
+	sort.Strings(names)
+	var out []table
+	visited := map[string]bool{}
+	var visit func(string)
+	visit = func(name string) {
+		t, ok := byName[name]
+		if !ok || visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, fk := range t.fks {
+			visit(fk.table)
+		}
+		out = append(out, *t)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return out
+}
+
+func writeCreateTable(w io.Writer, t table, d Dialect) {
+	var defs []string
+	var pks []string
+	for _, c := range t.columns {
+		def := "    " + strings.TrimSpace(quoteIdent(c.name)+" "+columnType(c.declType, d))
+		if c.notNull {
+			def += " NOT NULL"
+		}
+		defs = append(defs, def)
+		if c.pk > 0 {
+			pks = append(pks, quoteIdent(c.name))
+		}
+	}
+	if len(pks) > 0 {
+		defs = append(defs, "    PRIMARY KEY ("+strings.Join(pks, ", ")+")")
+	}
+	for _, fk := range t.fks {
+		ref := quoteIdent(fk.table)
+		if fk.to != "" {
+			ref += "(" + quoteIdent(fk.to) + ")"
+		}
+		defs = append(defs, "    FOREIGN KEY ("+quoteIdent(fk.from)+") REFERENCES "+ref)
+	}
+	fmt.Fprintf(w, "CREATE TABLE %s\n(\n%s\n);\n", quoteIdent(t.name), strings.Join(defs, ",\n"))
+}
+
+func writeInserts(ctx context.Context, conn querier, w io.Writer, t table, opts Options) error {
+	names := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		names[i] = quoteIdent(c.name)
+	}
+	cols := strings.Join(names, ", ")
+
+	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s ORDER BY rowid", cols, quoteIdent(t.name)))
+	if err != nil {
+		// WITHOUT ROWID tables have no rowid; fall back to natural order
+		rows, err = conn.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", cols, quoteIdent(t.name)))
+		if err != nil {
+			return fmt.Errorf("read %s: %w", t.name, err)
+		}
+	}
+	defer rows.Close()
+
+	vals := make([]any, len(t.columns))
+	ptrs := make([]any, len(t.columns))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+
+	n := 0
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("scan %s: %w", t.name, err)
+		}
+		if n%opts.BatchSize == 0 {
+			if n > 0 {
+				fmt.Fprintln(w, ";")
+			}
+			fmt.Fprintf(w, "INSERT INTO %s (%s) VALUES\n", quoteIdent(t.name), cols)
+		} else {
+			fmt.Fprintln(w, ",")
+		}
+
+		lits := make([]string, len(vals))
+		for i, v := range vals {
+			lits[i] = literal(v, t.columns[i].declType, opts.Dialect)
+		}
+		fmt.Fprintf(w, "    (%s)", strings.Join(lits, ", "))
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", t.name, err)
+	}
+	if n > 0 {
+		fmt.Fprintln(w, ";")
+	}
+	return nil
+}
+
+// columnType maps a SQLite declared type to the target dialect using SQLite's
+// affinity rules, keeping date and time types distinct.
+func columnType(declType string, d Dialect) string {
+	if d == SQLite {
+		return declType
+	}
+
+	upper := strings.ToUpper(declType)
+	switch {
+	case upper == "DATE":
+		return "DATE"
+	case strings.Contains(upper, "TIMESTAMP") || strings.Contains(upper, "DATETIME"):
+		if d == Postgres {
+			return "TIMESTAMPTZ"
+		}
+		return "TIMESTAMP_TZ"
+	case strings.Contains(upper, "INT"):
+		if d == Postgres {
+			return "BIGINT"
+		}
+		return "NUMBER(38,0)"
+	case strings.Contains(upper, "CHAR"), strings.Contains(upper, "CLOB"), strings.Contains(upper, "TEXT"):
+		if d == Postgres {
+			return "TEXT"
+		}
+		return "VARCHAR"
+	case upper == "", strings.Contains(upper, "BLOB"):
+		if d == Postgres {
+			return "BYTEA"
+		}
+		return "BINARY"
+	case strings.Contains(upper, "REAL"), strings.Contains(upper, "FLOA"), strings.Contains(upper, "DOUB"):
+		if d == Postgres {
+			return "DOUBLE PRECISION"
+		}
+		return "FLOAT"
+	case strings.Contains(upper, "BOOL"):
+		return "BOOLEAN"
+	default:
+		if d == Postgres {
+			return "NUMERIC"
+		}
+		return "NUMBER"
+	}
+}
+
+func literal(v any, declType string, d Dialect) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		if d == SQLite {
+			if v {
+				return "1"
+			}
+			return "0"
+		}
+		return strconv.FormatBool(v)
+	case time.Time:
+		if d != SQLite && strings.EqualFold(declType, "DATE") {
+			return quoteString(v.Format("2006-01-02"))
+		}
+		return quoteString(v.Format("2006-01-02 15:04:05.999999999-07:00"))
+	case []byte:
+		switch d {
+		case Postgres:
+			return "'\\x" + hex.EncodeToString(v) + "'::bytea"
+		case Snowflake:
+			return "TO_BINARY('" + hex.EncodeToString(v) + "', 'HEX')"
+		}
+		return "X'" + hex.EncodeToString(v) + "'"
+	case string:
+		return quoteString(v)
+	default:
+		return quoteString(fmt.Sprint(v))
+	}
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+var plainIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// quoteIdent leaves lower-case identifiers bare so Snowflake resolves them
+// case-insensitively, and double-quotes anything else.
+func quoteIdent(name string) string {
+	if plainIdent.MatchString(name) && !reserved[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+var reserved = map[string]bool{
+	"from": true, "to": true, "table": true, "order": true, "group": true,
+	"select": true, "where": true, "user": true, "default": true, "check": true,
+}
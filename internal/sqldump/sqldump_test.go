@@ -0,0 +1,158 @@
+package sqldump
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	conn, err := database.Open(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+
+	if err := database.Migrate(context.Background(), conn); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+
+	ts := time.Date(2025, 9, 24, 10, 30, 0, 0, time.UTC)
+	for i, name := range []string{"Acme", "O'Brien & Sons", "Globex"} {
+		_, err := conn.Exec(`INSERT INTO dim_supplier_v1 (supplier_id, tenant_id, legal_name, risk_score, lead_time_days_avg, source_timestamp)
+			VALUES (?, 'tenant_acme', ?, ?, ?, ?)`, "S"+string(rune('1'+i)), name, 12.5, 30, ts)
+		if err != nil {
+			t.Fatalf("insert supplier: %v", err)
+		}
+	}
+	_, err = conn.Exec(`INSERT INTO dim_part_v1 (part_id, tenant_id, part_number, description, default_supplier_id, last_price_change)
+		VALUES ('P1', 'tenant_acme', 'P-000001', 'bolt', 'S1', ?)`, ts)
+	if err != nil {
+		t.Fatalf("insert part: %v", err)
+	}
+	return conn
+}
+
+func TestDumpSQLiteRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := setupTestDB(t)
+
+	var buf bytes.Buffer
+	if err := Dump(ctx, src, &buf, Options{Dialect: SQLite, BatchSize: 2}); err != nil {
+		t.Fatalf("dump: %v", err)
+	}
+	script := buf.String()
+
+	// suppliers must be created before the parts that reference them
+	if strings.Index(script, "CREATE TABLE dim_supplier_v1") > strings.Index(script, "CREATE TABLE dim_part_v1") {
+		t.Error("expected dim_supplier_v1 to be created before dim_part_v1")
+	}
+	// 3 suppliers in batches of 2 and 1 part
+	if got := strings.Count(script, "INSERT INTO dim_supplier_v1 ") + strings.Count(script, "INSERT INTO dim_part_v1 "); got != 3 {
+		t.Errorf("expected 3 dim INSERT statements, got %d", got)
+	}
+	// search indexes are derived, not data
+	if strings.Contains(script, "supplier_search") {
+		t.Error("expected FTS tables to be skipped")
+	}
+
+	dst, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst.SetMaxOpenConns(1)
+	defer dst.Close()
+	if _, err := dst.Exec(script); err != nil {
+		t.Fatalf("replay dump: %v\n%s", err, script)
+	}
+
+	var count int
+	if err := dst.QueryRow("SELECT COUNT(*) FROM dim_supplier_v1").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 suppliers, got %d", count)
+	}
+
+	var name string
+	var risk float64
+	var ts time.Time
+	err = dst.QueryRow("SELECT legal_name, risk_score, source_timestamp FROM dim_supplier_v1 WHERE supplier_id = 'S2'").Scan(&name, &risk, &ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "O'Brien & Sons" || risk != 12.5 || !ts.Equal(time.Date(2025, 9, 24, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected round-tripped row: %q %v %v", name, risk, ts)
+	}
+}
+
+func TestDumpDialects(t *testing.T) {
+	src := setupTestDB(t)
+
+	tests := []struct {
+		dialect Dialect
+		want    []string
+	}{
+		{Postgres, []string{"risk_score DOUBLE PRECISION", "source_timestamp TIMESTAMPTZ", "lead_time_days_avg BIGINT", "'2025-09-24'"}},
+		{Snowflake, []string{"risk_score FLOAT", "source_timestamp TIMESTAMP_TZ", "lead_time_days_avg NUMBER(38,0)", "legal_name VARCHAR NOT NULL"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Dump(context.Background(), src, &buf, Options{Dialect: tt.dialect, Tables: []string{"dim_supplier_v1", "dim_part_v1"}}); err != nil {
+			t.Fatalf("%s dump: %v", tt.dialect, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s: expected output to contain %q", tt.dialect, want)
+			}
+		}
+	}
+}
+
+func TestDumpUnknownTable(t *testing.T) {
+	src := setupTestDB(t)
+	if err := Dump(context.Background(), src, &bytes.Buffer{}, Options{Tables: []string{"nope"}}); err == nil {
+		t.Error("expected error for unknown table")
+	}
+}
+
+func TestDumpDefaultTables(t *testing.T) {
+	src := setupTestDB(t)
+	if _, err := src.Exec(`INSERT INTO api_key (key_id, key_hash, name, tenant_id, role) VALUES ('k1', 'hash', 'etl', 'tenant_acme', 'admin')`); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Dump(context.Background(), src, &buf, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	script := buf.String()
+	for _, name := range []string{"dim_supplier_v1", "dim_part_v1", "part_supplier"} {
+		if !strings.Contains(script, "CREATE TABLE "+name+"\n") {
+			t.Errorf("expected %s to be dumped", name)
+		}
+	}
+	for _, name := range []string{"api_key", "schema_migrations", "audit_log", "dim_supplier_v1_history"} {
+		if strings.Contains(script, "CREATE TABLE "+name+"\n") {
+			t.Errorf("expected %s to be skipped", name)
+		}
+	}
+
+	// derived tables are dumped on request, credentials never
+	if err := Dump(context.Background(), src, &bytes.Buffer{}, Options{Tables: []string{"audit_log"}}); err != nil {
+		t.Errorf("expected a named audit_log to be dumped: %v", err)
+	}
+	for _, name := range []string{"api_key", "schema_migrations"} {
+		if err := Dump(context.Background(), src, &bytes.Buffer{}, Options{Tables: []string{name}}); err == nil {
+			t.Errorf("expected %s to be refused", name)
+		}
+	}
+}
@@ -0,0 +1,564 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/duckdb/duckdb-go/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bitterfq/data-ingestion-go/internal/bulkload"
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/logging"
+	"github.com/bitterfq/data-ingestion-go/internal/metrics"
+	"github.com/bitterfq/data-ingestion-go/internal/tracing"
+)
+
+//go:embed duckdb_migrations/*.sql
+var duckdbMigrations embed.FS
+
+var _ Store = (*duckdbStore)(nil)
+
+// duckdbStore keeps the tables in an embedded DuckDB database. Bulk loads
+// stream rows through DuckDB's appender into a staging table and merge them
+// with a single upsert. Search needs the SQLite FTS5 index and is unsupported.
+type duckdbStore struct {
+	conn    *sql.DB
+	log     *slog.Logger
+	tracer  trace.Tracer
+	metrics *metrics.Metrics
+}
+
+func openDuckDB(ctx context.Context, path string, opts Options) (*duckdbStore, error) {
+	connector, err := duckdb.NewConnector(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	// closing the DB closes the connector and with it the database
+	conn := sql.OpenDB(connector)
+	m, err := database.NewMigratorFS(conn, duckdbMigrations, "duckdb_migrations")
+	if err == nil {
+		_, err = m.Up(ctx)
+	}
+	if err == nil {
+		err = opts.Metrics.WatchDB("duckdb", conn)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &duckdbStore{
+		conn:    conn,
+		log:     logging.OrDiscard(opts.Logger),
+		tracer:  tracing.Tracer(opts.TracerProvider, "internal/storage"),
+		metrics: opts.Metrics,
+	}, nil
+}
+
+func (s *duckdbStore) Ping(ctx context.Context) error {
+	return ping(ctx, s.conn)
+}
+
+func (s *duckdbStore) Close() error {
+	return s.conn.Close()
+}
+
+func (s *duckdbStore) LoadSuppliers(ctx context.Context, rows []db.CreateSupplierParams) (bulkload.Stats, error) {
+	return s.load(ctx, "dim_supplier_v1", []string{"supplier_id"}, bulkload.SupplierColumns, true, len(rows), func(i int, dst []any) {
+		bulkload.SupplierValues(&rows[i], dst)
+	})
+}
+
+func (s *duckdbStore) LoadParts(ctx context.Context, rows []db.CreatePartParams) (bulkload.Stats, error) {
+	return s.load(ctx, "dim_part_v1", []string{"part_id"}, bulkload.PartColumns, true, len(rows), func(i int, dst []any) {
+		bulkload.PartValues(&rows[i], dst)
+	})
+}
+
+func (s *duckdbStore) LoadPartSuppliers(ctx context.Context, rows []db.AddPartSupplierParams) (bulkload.Stats, error) {
+	return s.load(ctx, "part_supplier", []string{"part_id", "supplier_id"}, bulkload.PartSupplierColumns, false, len(rows), func(i int, dst []any) {
+		bulkload.PartSupplierValues(&rows[i], dst)
+	})
+}
+
+// load appends n rows to a temporary staging table and merges it into table
+// in one transaction. When newerWins is set an existing row is replaced only
+// by a row of the same tenant with a newer source_timestamp; otherwise
+// existing rows are kept.
+// If the input holds a key more than once, only its newest row is merged.
+func (s *duckdbStore) load(ctx context.Context, table string, key, columns []string, newerWins bool, n int, values func(i int, dst []any)) (stats bulkload.Stats, err error) {
+	start := time.Now()
+	stats = bulkload.Stats{Table: table}
+	if n == 0 {
+		return stats, nil
+	}
+	ctx, span := s.tracer.Start(ctx, "duckdb.load", trace.WithAttributes(
+		attribute.String("db.system.name", "duckdb"),
+		attribute.String("db.collection.name", table),
+		attribute.Int("rows", n),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Int64("inserted", stats.Inserted), attribute.Int64("updated", stats.Updated), attribute.Int64("unchanged", stats.Unchanged))
+		tracing.End(span, err)
+		s.metrics.ObserveTransaction("duckdb", table, time.Since(start), err)
+	}()
+
+	// the staging table is temporary, so every statement must use the same connection
+	c, err := s.conn.Conn(ctx)
+	if err != nil {
+		return stats, err
+	}
+	defer c.Close()
+
+	stage := "stage_" + table
+	_, err = c.ExecContext(ctx, fmt.Sprintf("CREATE OR REPLACE TEMP TABLE %s AS SELECT %s FROM %s LIMIT 0", stage, strings.Join(columns, ", "), table))
+	if err != nil {
+		return stats, fmt.Errorf("create %s: %w", stage, err)
+	}
+	defer c.ExecContext(context.WithoutCancel(ctx), "DROP TABLE IF EXISTS "+stage)
+
+	_, appendSpan := s.tracer.Start(ctx, "duckdb.append", trace.WithAttributes(attribute.Int("rows", n)))
+	err = c.Raw(func(dc any) error {
+		a, err := duckdb.NewAppender(dc.(driver.Conn), "temp", "main", stage)
+		if err != nil {
+			return err
+		}
+		vals := make([]any, len(columns))
+		row := make([]driver.Value, len(columns))
+		for i := 0; i < n; i++ {
+			values(i, vals)
+			for j, v := range vals {
+				row[j] = v
+			}
+			if err := a.AppendRow(row...); err != nil {
+				a.Close()
+				return err
+			}
+		}
+		return a.Close()
+	})
+	tracing.End(appendSpan, err)
+	if err != nil {
+		return stats, fmt.Errorf("append %s: %w", table, err)
+	}
+
+	mctx, mergeSpan := s.tracer.Start(ctx, "duckdb.merge")
+	inserted, changed, err := s.merge(mctx, c, table, stage, key, columns, newerWins)
+	tracing.End(mergeSpan, err)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Rows = int64(n)
+	stats.Inserted = inserted
+	stats.Updated = changed - inserted
+	stats.Unchanged = stats.Rows - changed
+	stats.Duration = time.Since(start)
+	s.log.InfoContext(ctx, "bulk load finished", "load", stats)
+	return stats, nil
+}
+
+// strayLinks finds a staged part_supplier link whose part or supplier is
+// missing or belongs to another tenant, which SQLite's triggers refuse.
+const strayLinks = `SELECT part_id, supplier_id, tenant_id FROM %s s
+WHERE NOT EXISTS (SELECT 1 FROM dim_part_v1 p WHERE p.part_id = s.part_id AND p.tenant_id = s.tenant_id)
+    OR NOT EXISTS (SELECT 1 FROM dim_supplier_v1 d WHERE d.supplier_id = s.supplier_id AND d.tenant_id = s.tenant_id)
+LIMIT 1`
+
+// merge upserts the staged rows into table in one transaction and returns
+// how many keys were new and how many rows changed.
+func (s *duckdbStore) merge(ctx context.Context, c *sql.Conn, table, stage string, key, columns []string, newerWins bool) (inserted, changed int64, err error) {
+	tx, err := c.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	if table == "part_supplier" {
+		var partID, supplierID, tenantID string
+		err := tx.QueryRowContext(ctx, fmt.Sprintf(strayLinks, stage)).Scan(&partID, &supplierID, &tenantID)
+		if err == nil {
+			return 0, 0, fmt.Errorf("%w: part %s and supplier %s do not both belong to tenant %s", ErrConflict, partID, supplierID, tenantID)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, fmt.Errorf("check %s: %w", table, err)
+		}
+	}
+
+	match := make([]string, len(key))
+	for i, k := range key {
+		match[i] = fmt.Sprintf("t.%s = s.%s", k, k)
+	}
+	err = tx.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM (SELECT DISTINCT %s FROM %s) s WHERE NOT EXISTS (SELECT 1 FROM %s t WHERE %s)",
+		strings.Join(key, ", "), stage, table, strings.Join(match, " AND "))).Scan(&inserted)
+	if err != nil {
+		return 0, 0, fmt.Errorf("count new %s rows: %w", table, err)
+	}
+
+	res, err := tx.ExecContext(ctx, mergeSQL(table, stage, key, columns, newerWins))
+	if err != nil {
+		return 0, 0, fmt.Errorf("merge %s: %w", table, err)
+	}
+	if changed, err = res.RowsAffected(); err != nil {
+		return 0, 0, err
+	}
+	return inserted, changed, tx.Commit()
+}
+
+// mergeSQL upserts the newest staged row for each key into table.
+func mergeSQL(table, stage string, key, columns []string, newerWins bool) string {
+	cols := strings.Join(columns, ", ")
+	keys := strings.Join(key, ", ")
+	if !newerWins {
+		return fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s QUALIFY row_number() OVER (PARTITION BY %s) = 1 ON CONFLICT (%s) DO NOTHING",
+			table, cols, cols, stage, keys, keys)
+	}
+
+	isKey := map[string]bool{}
+	for _, k := range key {
+		isKey[k] = true
+	}
+	var set []string
+	for _, c := range columns {
+		if !isKey[c] {
+			set = append(set, fmt.Sprintf("%s = excluded.%s", c, c))
+		}
+	}
+	set = append(set, fmt.Sprintf("version = %s.version + 1", table))
+	return fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s
+QUALIFY row_number() OVER (PARTITION BY %s ORDER BY source_timestamp DESC NULLS LAST) = 1
+ON CONFLICT (%s) DO UPDATE SET %s
+WHERE %s.tenant_id = excluded.tenant_id AND (%s.source_timestamp IS NULL OR excluded.source_timestamp > %s.source_timestamp)`,
+		table, cols, cols, stage, keys, keys, strings.Join(set, ", "), table, table, table)
+}
+
+// insertRow inserts one row with the given columns.
+func insertRow(ctx context.Context, e execer, table string, columns []string, values func(dst []any)) (int64, error) {
+	args := make([]any, len(columns))
+	values(args)
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	return execIn(ctx, e, query, args...)
+}
+
+func (s *duckdbStore) CreateSupplier(ctx context.Context, arg db.CreateSupplierParams) (int64, error) {
+	return insertRow(ctx, s.conn, "dim_supplier_v1", bulkload.SupplierColumns, func(dst []any) { bulkload.SupplierValues(&arg, dst) })
+}
+
+func (s *duckdbStore) CreatePart(ctx context.Context, arg db.CreatePartParams) (int64, error) {
+	return s.inTx(ctx, func(tx *sql.Tx) (int64, error) {
+		n, err := insertRow(ctx, tx, "dim_part_v1", bulkload.PartColumns, func(dst []any) { bulkload.PartValues(&arg, dst) })
+		if err != nil {
+			return n, err
+		}
+		return n, addPartSuppliersIn(ctx, tx, arg.TenantID, arg.PartID, arg.QualifiedSupplierIds)
+	})
+}
+
+// inTx runs write in a transaction, which it commits if write succeeds.
+func (s *duckdbStore) inTx(ctx context.Context, write func(tx *sql.Tx) (int64, error)) (int64, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	n, err := write(tx)
+	if err != nil {
+		return n, err
+	}
+	return n, tx.Commit()
+}
+
+// addPartSuppliersIn links a part to the suppliers in its
+// qualified_supplier_ids. DuckDB has no foreign keys or triggers, so a
+// supplier that does not exist or belongs to another tenant is checked for
+// here and is a conflict, as on SQLite.
+func addPartSuppliersIn(ctx context.Context, tx *sql.Tx, tenantID, partID string, ids sql.NullString) error {
+	for _, link := range partSuppliers(tenantID, partID, ids) {
+		var exists bool
+		err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM dim_supplier_v1 WHERE supplier_id = ? AND tenant_id = ?)",
+			link.SupplierID, link.TenantID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: supplier %s does not belong to tenant %s", ErrConflict, link.SupplierID, link.TenantID)
+		}
+		_, err = execIn(ctx, tx, "INSERT INTO part_supplier (part_id, supplier_id, tenant_id) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+			link.PartID, link.SupplierID, link.TenantID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exec runs a single-row write and returns the number of rows it changed.
+// Constraint violations are wrapped in ErrConflict.
+func (s *duckdbStore) exec(ctx context.Context, query string, args ...any) (int64, error) {
+	return execIn(ctx, s.conn, query, args...)
+}
+
+// execer is a *sql.DB or a *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// execIn is exec on e.
+func execIn(ctx context.Context, e execer, query string, args ...any) (int64, error) {
+	res, err := e.ExecContext(ctx, query, args...)
+	var derr *duckdb.Error
+	if errors.As(err, &derr) && derr.Type == duckdb.ErrorTypeConstraint {
+		return 0, fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// updateSQL replaces every column of a live row but its key and tenant. The
+// arguments are the columns in order, then the tenant and the key.
+func updateSQL(table, key string, columns []string) string {
+	var set []string
+	for _, c := range columns {
+		if c != key && c != "tenant_id" {
+			set = append(set, c+" = ?")
+		}
+	}
+	return fmt.Sprintf("UPDATE %s SET %s, version = version + 1 WHERE tenant_id = ? AND %s = ? AND deleted_at IS NULL AND (?::BIGINT IS NULL OR version = ?)",
+		table, strings.Join(set, ", "), key)
+}
+
+var (
+	updateSupplier = updateSQL("dim_supplier_v1", "supplier_id", bulkload.SupplierColumns)
+	updatePart     = updateSQL("dim_part_v1", "part_id", bulkload.PartColumns)
+)
+
+func (s *duckdbStore) UpdateSupplier(ctx context.Context, arg db.UpdateSupplierParams) (int64, error) {
+	return s.exec(ctx, updateSupplier,
+		arg.SupplierCode, arg.LegalName, arg.DbaName,
+		arg.Country, arg.Region, arg.AddressLine1, arg.AddressLine2, arg.City, arg.State, arg.PostalCode,
+		arg.ContactEmail, arg.ContactPhone, arg.PreferredCurrency, arg.Incoterms,
+		arg.LeadTimeDaysAvg, arg.LeadTimeDaysP95, arg.OnTimeDeliveryRate, arg.DefectRatePpm,
+		arg.CapacityUnitsPerWeek, arg.RiskScore, arg.FinancialRiskTier,
+		arg.Certifications, arg.ComplianceFlags, arg.ApprovedStatus, arg.Contracts, arg.TermsVersion,
+		arg.Lat, arg.Lon, arg.DataSource, arg.SourceTimestamp, arg.IngestionTimestamp, arg.SchemaVersion,
+		arg.ModifiedBy,
+		arg.TenantID, arg.SupplierID, arg.Version, arg.Version)
+}
+
+func (s *duckdbStore) UpdatePart(ctx context.Context, arg db.UpdatePartParams) (int64, error) {
+	return s.inTx(ctx, func(tx *sql.Tx) (int64, error) {
+		n, err := execIn(ctx, tx, updatePart,
+			arg.PartNumber, arg.Description, arg.Category, arg.LifecycleStatus,
+			arg.Uom, arg.SpecHash, arg.BomCompatibility, arg.DefaultSupplierID, arg.QualifiedSupplierIds,
+			arg.UnitCost, arg.Moq, arg.LeadTimeDaysAvg, arg.LeadTimeDaysP95, arg.QualityGrade,
+			arg.ComplianceFlags, arg.HazardClass, arg.LastPriceChange,
+			arg.DataSource, arg.SourceTimestamp, arg.IngestionTimestamp, arg.SchemaVersion,
+			arg.ModifiedBy,
+			arg.TenantID, arg.PartID, arg.Version, arg.Version)
+		if err != nil || n == 0 {
+			return n, err
+		}
+		if _, err := execIn(ctx, tx, "DELETE FROM part_supplier WHERE tenant_id = ? AND part_id = ?", arg.TenantID, arg.PartID); err != nil {
+			return n, err
+		}
+		return n, addPartSuppliersIn(ctx, tx, arg.TenantID, arg.PartID, arg.QualifiedSupplierIds)
+	})
+}
+
+// The read queries select the columns loads write plus the soft-delete and
+// version columns, which is the field order of db.DimSupplierV1 and
+// db.DimPartV1.
+var (
+	selectSuppliers = "SELECT " + strings.Join(bulkload.SupplierColumns, ", ") + ", deleted_at, deleted_by, version FROM dim_supplier_v1"
+	selectParts     = "SELECT " + strings.Join(bulkload.PartColumns, ", ") + ", deleted_at, deleted_by, version FROM dim_part_v1"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSupplier(row scanner) (db.DimSupplierV1, error) {
+	var i db.DimSupplierV1
+	err := row.Scan(
+		&i.SupplierID, &i.SupplierCode, &i.TenantID, &i.LegalName, &i.DbaName,
+		&i.Country, &i.Region, &i.AddressLine1, &i.AddressLine2, &i.City, &i.State, &i.PostalCode,
+		&i.ContactEmail, &i.ContactPhone, &i.PreferredCurrency, &i.Incoterms,
+		&i.LeadTimeDaysAvg, &i.LeadTimeDaysP95, &i.OnTimeDeliveryRate, &i.DefectRatePpm,
+		&i.CapacityUnitsPerWeek, &i.RiskScore, &i.FinancialRiskTier,
+		&i.Certifications, &i.ComplianceFlags, &i.ApprovedStatus, &i.Contracts, &i.TermsVersion,
+		&i.Lat, &i.Lon, &i.DataSource, &i.SourceTimestamp, &i.IngestionTimestamp, &i.SchemaVersion,
+		&i.ModifiedBy, &i.DeletedAt, &i.DeletedBy, &i.Version,
+	)
+	return i, err
+}
+
+func scanPart(row scanner) (db.DimPartV1, error) {
+	var i db.DimPartV1
+	err := row.Scan(
+		&i.PartID, &i.TenantID, &i.PartNumber, &i.Description, &i.Category, &i.LifecycleStatus,
+		&i.Uom, &i.SpecHash, &i.BomCompatibility, &i.DefaultSupplierID, &i.QualifiedSupplierIds,
+		&i.UnitCost, &i.Moq, &i.LeadTimeDaysAvg, &i.LeadTimeDaysP95, &i.QualityGrade,
+		&i.ComplianceFlags, &i.HazardClass, &i.LastPriceChange,
+		&i.DataSource, &i.SourceTimestamp, &i.IngestionTimestamp, &i.SchemaVersion,
+		&i.ModifiedBy, &i.DeletedAt, &i.DeletedBy, &i.Version,
+	)
+	return i, err
+}
+
+// list runs query and scans every row with scan.
+func list[T any](ctx context.Context, conn *sql.DB, scan func(scanner) (T, error), query string, args ...any) ([]T, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []T
+	for rows.Next() {
+		i, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+func (s *duckdbStore) SupplierExists(ctx context.Context, arg db.SupplierExistsParams) (int64, error) {
+	var n int64
+	err := s.conn.QueryRowContext(ctx, "SELECT CAST(EXISTS (SELECT 1 FROM dim_supplier_v1 WHERE tenant_id = ? AND supplier_id = ?) AS BIGINT)",
+		arg.TenantID, arg.SupplierID).Scan(&n)
+	return n, err
+}
+
+func (s *duckdbStore) PartExists(ctx context.Context, arg db.PartExistsParams) (int64, error) {
+	var n int64
+	err := s.conn.QueryRowContext(ctx, "SELECT CAST(EXISTS (SELECT 1 FROM dim_part_v1 WHERE tenant_id = ? AND part_id = ?) AS BIGINT)",
+		arg.TenantID, arg.PartID).Scan(&n)
+	return n, err
+}
+
+func (s *duckdbStore) GetSupplier(ctx context.Context, arg db.GetSupplierParams) (db.DimSupplierV1, error) {
+	return scanSupplier(s.conn.QueryRowContext(ctx, selectSuppliers+" WHERE tenant_id = ? AND supplier_id = ? AND deleted_at IS NULL", arg.TenantID, arg.SupplierID))
+}
+
+func (s *duckdbStore) GetPart(ctx context.Context, arg db.GetPartParams) (db.DimPartV1, error) {
+	return scanPart(s.conn.QueryRowContext(ctx, selectParts+" WHERE tenant_id = ? AND part_id = ? AND deleted_at IS NULL", arg.TenantID, arg.PartID))
+}
+
+func (s *duckdbStore) ListSuppliers(ctx context.Context, arg db.ListSuppliersParams) ([]db.DimSupplierV1, error) {
+	return list(ctx, s.conn, scanSupplier, selectSuppliers+`
+WHERE tenant_id = ?
+    AND deleted_at IS NULL
+    AND (?::VARCHAR IS NULL OR country = ?)
+    AND (?::VARCHAR IS NULL OR region = ?)
+    AND (?::VARCHAR IS NULL OR approved_status = ?)
+    AND (?::DOUBLE IS NULL OR risk_score >= ?)
+    AND (?::DOUBLE IS NULL OR risk_score <= ?)
+    AND supplier_id > ?
+ORDER BY supplier_id
+LIMIT ?`,
+		arg.TenantID,
+		arg.Country, arg.Country,
+		arg.Region, arg.Region,
+		arg.ApprovedStatus, arg.ApprovedStatus,
+		arg.MinRiskScore, arg.MinRiskScore,
+		arg.MaxRiskScore, arg.MaxRiskScore,
+		arg.After,
+		arg.PageSize)
+}
+
+func (s *duckdbStore) ListParts(ctx context.Context, arg db.ListPartsParams) ([]db.DimPartV1, error) {
+	return list(ctx, s.conn, scanPart, selectParts+`
+WHERE tenant_id = ?
+    AND deleted_at IS NULL
+    AND (?::VARCHAR IS NULL OR category = ?)
+    AND (?::VARCHAR IS NULL OR lifecycle_status = ?)
+    AND part_id > ?
+ORDER BY part_id
+LIMIT ?`,
+		arg.TenantID,
+		arg.Category, arg.Category,
+		arg.LifecycleStatus, arg.LifecycleStatus,
+		arg.After,
+		arg.PageSize)
+}
+
+func (s *duckdbStore) DeleteSupplier(ctx context.Context, arg db.DeleteSupplierParams) (int64, error) {
+	return s.exec(ctx, "UPDATE dim_supplier_v1 SET deleted_at = ?, deleted_by = ?, version = version + 1 WHERE tenant_id = ? AND supplier_id = ? AND deleted_at IS NULL",
+		time.Now().UTC(), arg.DeletedBy, arg.TenantID, arg.SupplierID)
+}
+
+func (s *duckdbStore) DeletePart(ctx context.Context, arg db.DeletePartParams) (int64, error) {
+	return s.exec(ctx, "UPDATE dim_part_v1 SET deleted_at = ?, deleted_by = ?, version = version + 1 WHERE tenant_id = ? AND part_id = ? AND deleted_at IS NULL",
+		time.Now().UTC(), arg.DeletedBy, arg.TenantID, arg.PartID)
+}
+
+func (s *duckdbStore) RestoreSupplier(ctx context.Context, arg db.RestoreSupplierParams) (int64, error) {
+	return s.exec(ctx, "UPDATE dim_supplier_v1 SET deleted_at = NULL, deleted_by = NULL, modified_by = ?, version = version + 1 WHERE tenant_id = ? AND supplier_id = ? AND deleted_at IS NOT NULL",
+		arg.ModifiedBy, arg.TenantID, arg.SupplierID)
+}
+
+func (s *duckdbStore) RestorePart(ctx context.Context, arg db.RestorePartParams) (int64, error) {
+	return s.exec(ctx, "UPDATE dim_part_v1 SET deleted_at = NULL, deleted_by = NULL, modified_by = ?, version = version + 1 WHERE tenant_id = ? AND part_id = ? AND deleted_at IS NOT NULL",
+		arg.ModifiedBy, arg.TenantID, arg.PartID)
+}
+
+func (s *duckdbStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (db.ApiKey, error) {
+	return db.ApiKey{}, fmt.Errorf("duckdb: get api key: %w", errors.ErrUnsupported)
+}
+
+func (s *duckdbStore) SearchSuppliers(ctx context.Context, arg db.SearchSuppliersParams) ([]db.SearchSuppliersRow, error) {
+	return nil, fmt.Errorf("duckdb: search suppliers: %w", errors.ErrUnsupported)
+}
+
+func (s *duckdbStore) SearchParts(ctx context.Context, arg db.SearchPartsParams) ([]db.SearchPartsRow, error) {
+	return nil, fmt.Errorf("duckdb: search parts: %w", errors.ErrUnsupported)
+}
+
+const summarizeParts = `SELECT
+    category,
+    lifecycle_status,
+    COUNT(*) AS parts,
+    COALESCE(AVG(unit_cost), 0) AS avg_unit_cost,
+    COALESCE(AVG(lead_time_days_avg), 0) AS avg_lead_time_days,
+    COUNT(DISTINCT default_supplier_id) AS suppliers
+FROM dim_part_v1
+WHERE tenant_id = ? AND deleted_at IS NULL
+GROUP BY category, lifecycle_status
+ORDER BY category NULLS FIRST, lifecycle_status NULLS FIRST`
+
+func (s *duckdbStore) SummarizeParts(ctx context.Context, tenantID string) ([]db.SummarizePartsRow, error) {
+	rows, err := s.conn.QueryContext(ctx, summarizeParts, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []db.SummarizePartsRow
+	for rows.Next() {
+		var i db.SummarizePartsRow
+		if err := rows.Scan(
+			&i.Category,
+			&i.LifecycleStatus,
+			&i.Parts,
+			&i.AvgUnitCost,
+			&i.AvgLeadTimeDays,
+			&i.Suppliers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,5 @@
+DROP TABLE part_supplier;
+
+DROP TABLE dim_part_v1;
+
+DROP TABLE dim_supplier_v1;
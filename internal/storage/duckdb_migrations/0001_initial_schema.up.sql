@@ -0,0 +1,83 @@
+-- The DuckDB schema has the same tables and columns as the SQLite one, so
+-- rows move between backends unchanged. It has no triggers, so there is no
+-- history, audit log or search index, and no foreign keys: DuckDB cannot
+-- cascade deletes or update rows that other rows reference, and upserts
+-- update in place.
+CREATE TABLE dim_supplier_v1
+(
+    supplier_id VARCHAR PRIMARY KEY,
+    supplier_code VARCHAR,
+    tenant_id VARCHAR NOT NULL,
+    legal_name VARCHAR NOT NULL,
+    dba_name VARCHAR,
+    country VARCHAR,
+    region VARCHAR,
+    address_line1 VARCHAR,
+    address_line2 VARCHAR,
+    city VARCHAR,
+    state VARCHAR,
+    postal_code VARCHAR,
+    contact_email VARCHAR,
+    contact_phone VARCHAR,
+    preferred_currency VARCHAR,
+    incoterms VARCHAR,
+    lead_time_days_avg BIGINT,
+    lead_time_days_p95 BIGINT,
+    on_time_delivery_rate DOUBLE,
+    defect_rate_ppm BIGINT,
+    capacity_units_per_week BIGINT,
+    risk_score DOUBLE,
+    financial_risk_tier VARCHAR,
+    certifications VARCHAR,
+    compliance_flags VARCHAR,
+    approved_status VARCHAR,
+    contracts VARCHAR,
+    terms_version VARCHAR,
+    lat DOUBLE,
+    lon DOUBLE,
+    data_source VARCHAR,
+    source_timestamp TIMESTAMP,
+    ingestion_timestamp TIMESTAMP,
+    schema_version VARCHAR,
+    modified_by VARCHAR,
+    deleted_at TIMESTAMP,
+    deleted_by VARCHAR
+);
+
+CREATE TABLE dim_part_v1
+(
+    part_id VARCHAR PRIMARY KEY,
+    tenant_id VARCHAR NOT NULL,
+    part_number VARCHAR NOT NULL,
+    description VARCHAR NOT NULL,
+    category VARCHAR,
+    lifecycle_status VARCHAR,
+    uom VARCHAR,
+    spec_hash VARCHAR,
+    bom_compatibility VARCHAR,
+    default_supplier_id VARCHAR,
+    qualified_supplier_ids VARCHAR,
+    unit_cost DOUBLE,
+    moq BIGINT,
+    lead_time_days_avg BIGINT,
+    lead_time_days_p95 BIGINT,
+    quality_grade VARCHAR,
+    compliance_flags VARCHAR,
+    hazard_class VARCHAR,
+    last_price_change DATE,
+    data_source VARCHAR,
+    source_timestamp TIMESTAMP,
+    ingestion_timestamp TIMESTAMP,
+    schema_version VARCHAR,
+    modified_by VARCHAR,
+    deleted_at TIMESTAMP,
+    deleted_by VARCHAR
+);
+
+CREATE TABLE part_supplier
+(
+    part_id VARCHAR NOT NULL,
+    supplier_id VARCHAR NOT NULL,
+    tenant_id VARCHAR NOT NULL,
+    PRIMARY KEY (part_id, supplier_id)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE dim_part_v1 DROP COLUMN version;
+
+ALTER TABLE dim_supplier_v1 DROP COLUMN version;
@@ -0,0 +1,5 @@
+-- version counts the writes to a supplier or part, as on SQLite. Without
+-- triggers, every statement that updates a row bumps it itself.
+ALTER TABLE dim_supplier_v1 ADD COLUMN version BIGINT DEFAULT 1;
+
+ALTER TABLE dim_part_v1 ADD COLUMN version BIGINT DEFAULT 1;
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/bitterfq/data-ingestion-go/internal/bulkload"
+	"github.com/bitterfq/data-ingestion-go/internal/database"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+)
+
+var _ Store = (*sqliteStore)(nil)
+
+// sqliteStore runs the sqlc queries directly and loads through bulkload.
+type sqliteStore struct {
+	*db.Queries
+	*bulkload.Loader
+	conn *sql.DB
+}
+
+func openSQLite(ctx context.Context, path string, opts Options) (*sqliteStore, error) {
+	conn, err := database.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// every connection to an in-memory database gets its own empty copy
+	if path == ":memory:" || strings.Contains(path, "mode=memory") {
+		conn.SetMaxOpenConns(1)
+	}
+	if err := database.Migrate(ctx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if opts.Load.Logger == nil {
+		opts.Load.Logger = opts.Logger
+	}
+	if opts.Load.TracerProvider == nil {
+		opts.Load.TracerProvider = opts.TracerProvider
+	}
+	if opts.Load.Metrics == nil {
+		opts.Load.Metrics = opts.Metrics
+	}
+	if err := opts.Metrics.WatchDB("sqlite", conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &sqliteStore{Queries: db.New(conn), Loader: bulkload.New(conn, opts.Load), conn: conn}, nil
+}
+
+func (s *sqliteStore) Ping(ctx context.Context) error {
+	return ping(ctx, s.conn)
+}
+
+func (s *sqliteStore) Close() error {
+	return s.conn.Close()
+}
+
+func (s *sqliteStore) CreateSupplier(ctx context.Context, arg db.CreateSupplierParams) (int64, error) {
+	return conflict(s.Queries.CreateSupplier(ctx, arg))
+}
+
+func (s *sqliteStore) CreatePart(ctx context.Context, arg db.CreatePartParams) (int64, error) {
+	return conflict(s.inTx(ctx, func(q *db.Queries) (int64, error) {
+		n, err := q.CreatePart(ctx, arg)
+		if err != nil {
+			return n, err
+		}
+		return n, addPartSuppliers(ctx, q, arg.TenantID, arg.PartID, arg.QualifiedSupplierIds)
+	}))
+}
+
+func (s *sqliteStore) UpdateSupplier(ctx context.Context, arg db.UpdateSupplierParams) (int64, error) {
+	return conflict(s.Queries.UpdateSupplier(ctx, arg))
+}
+
+func (s *sqliteStore) UpdatePart(ctx context.Context, arg db.UpdatePartParams) (int64, error) {
+	return conflict(s.inTx(ctx, func(q *db.Queries) (int64, error) {
+		n, err := q.UpdatePart(ctx, arg)
+		if err != nil || n == 0 {
+			return n, err
+		}
+		err = q.ClearPartSuppliers(ctx, db.ClearPartSuppliersParams{TenantID: arg.TenantID, PartID: arg.PartID})
+		if err != nil {
+			return n, err
+		}
+		return n, addPartSuppliers(ctx, q, arg.TenantID, arg.PartID, arg.QualifiedSupplierIds)
+	}))
+}
+
+// inTx runs write in a transaction, which it commits if write succeeds.
+func (s *sqliteStore) inTx(ctx context.Context, write func(q *db.Queries) (int64, error)) (int64, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	n, err := write(s.Queries.WithTx(tx))
+	if err != nil {
+		return n, err
+	}
+	return n, tx.Commit()
+}
+
+// addPartSuppliers links a part to the suppliers in its
+// qualified_supplier_ids. A supplier of another tenant is refused by a
+// trigger, which conflict reports as ErrConflict.
+func addPartSuppliers(ctx context.Context, q *db.Queries, tenantID, partID string, ids sql.NullString) error {
+	for _, link := range partSuppliers(tenantID, partID, ids) {
+		if err := q.AddPartSupplier(ctx, link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conflict wraps constraint violations in ErrConflict.
+func conflict(n int64, err error) (int64, error) {
+	var serr sqlite3.Error
+	if errors.As(err, &serr) && serr.Code == sqlite3.ErrConstraint {
+		return n, fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	return n, err
+}
@@ -0,0 +1,148 @@
+// Package storage puts the supplier and part store behind an interface so the
+// generator and the server can run against different databases. SQLite is the
+// default and supports everything; DuckDB is an embedded columnar option for
+// analytical workloads such as aggregating tens of millions of parts.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bitterfq/data-ingestion-go/internal/bulkload"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/metrics"
+)
+
+// DefaultDSN is the database used when no DSN is configured.
+const DefaultDSN = "sqlite:data/data.db"
+
+// ErrConflict is wrapped by errors from writes that violate a key or foreign
+// key constraint, such as creating a supplier whose ID is taken.
+var ErrConflict = errors.New("storage: conflict")
+
+// Store reads and writes suppliers and parts. Methods shared with db.Queries
+// have the same signatures and semantics; GetSupplier and GetPart return
+// sql.ErrNoRows for a missing row. Backends that cannot run an operation
+// return an error wrapping errors.ErrUnsupported.
+type Store interface {
+	// LoadSuppliers, LoadParts and LoadPartSuppliers upsert rows in bulk in a
+	// single transaction. A row whose key exists only replaces it when it has
+	// the same tenant and a newer source_timestamp. A link whose part or
+	// supplier belongs to another tenant fails the load.
+	LoadSuppliers(ctx context.Context, rows []db.CreateSupplierParams) (bulkload.Stats, error)
+	LoadParts(ctx context.Context, rows []db.CreatePartParams) (bulkload.Stats, error)
+	LoadPartSuppliers(ctx context.Context, rows []db.AddPartSupplierParams) (bulkload.Stats, error)
+
+	// SupplierExists and PartExists return 1 when the tenant has the row,
+	// soft-deleted or not, and 0 otherwise.
+	SupplierExists(ctx context.Context, arg db.SupplierExistsParams) (int64, error)
+	PartExists(ctx context.Context, arg db.PartExistsParams) (int64, error)
+	GetSupplier(ctx context.Context, arg db.GetSupplierParams) (db.DimSupplierV1, error)
+	GetPart(ctx context.Context, arg db.GetPartParams) (db.DimPartV1, error)
+	ListSuppliers(ctx context.Context, arg db.ListSuppliersParams) ([]db.DimSupplierV1, error)
+	ListParts(ctx context.Context, arg db.ListPartsParams) ([]db.DimPartV1, error)
+
+	CreateSupplier(ctx context.Context, arg db.CreateSupplierParams) (int64, error)
+	// CreatePart and UpdatePart also write the part_supplier links of
+	// qualified_supplier_ids, in the same transaction as the part; an
+	// update replaces the links the part had. A supplier that is missing or
+	// belongs to another tenant is ErrConflict.
+	CreatePart(ctx context.Context, arg db.CreatePartParams) (int64, error)
+	UpdateSupplier(ctx context.Context, arg db.UpdateSupplierParams) (int64, error)
+	UpdatePart(ctx context.Context, arg db.UpdatePartParams) (int64, error)
+	DeleteSupplier(ctx context.Context, arg db.DeleteSupplierParams) (int64, error)
+	DeletePart(ctx context.Context, arg db.DeletePartParams) (int64, error)
+	RestoreSupplier(ctx context.Context, arg db.RestoreSupplierParams) (int64, error)
+	RestorePart(ctx context.Context, arg db.RestorePartParams) (int64, error)
+
+	SearchSuppliers(ctx context.Context, arg db.SearchSuppliersParams) ([]db.SearchSuppliersRow, error)
+	SearchParts(ctx context.Context, arg db.SearchPartsParams) ([]db.SearchPartsRow, error)
+	SummarizeParts(ctx context.Context, tenantID string) ([]db.SummarizePartsRow, error)
+
+	// GetAPIKeyByHash finds the live API key with the given SHA-256 hash,
+	// for auth.APIKeys. Keys are managed with cmd/apikey, on SQLite only.
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (db.ApiKey, error)
+
+	// Ping checks that the database answers a query.
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// Options tunes a Store.
+type Options struct {
+	// Load tunes bulk loads into SQLite. DuckDB ignores it.
+	Load bulkload.Options
+	// Logger gets a record for every bulk load; it is the default for
+	// Load.Logger. Nil logs nothing.
+	Logger *slog.Logger
+	// TracerProvider gets the spans of bulk loads; it is the default for
+	// Load.TracerProvider. Nil means the global provider.
+	TracerProvider trace.TracerProvider
+	// Metrics gets the duration of bulk load transactions and the stats of
+	// the connection pool; it is the default for Load.Metrics. Nil records
+	// nothing.
+	Metrics *metrics.Metrics
+}
+
+// Open opens the store named by dsn and applies its pending migrations. The
+// DSN is "sqlite:<path>" or "duckdb:<path>"; anything without one of those
+// schemes is a SQLite path. An empty DuckDB path is an in-memory database.
+func Open(ctx context.Context, dsn string, opts Options) (Store, error) {
+	backend, path := ParseDSN(dsn)
+	if backend == "duckdb" {
+		s, err := openDuckDB(ctx, path, opts)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	s, err := openSQLite(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ParseDSN splits dsn into a backend name and the path passed to its driver.
+func ParseDSN(dsn string) (backend, path string) {
+	if scheme, rest, ok := strings.Cut(dsn, ":"); ok {
+		switch scheme {
+		case "sqlite", "sqlite3":
+			return "sqlite", rest
+		case "duckdb":
+			return "duckdb", rest
+		}
+	}
+	return "sqlite", dsn
+}
+
+// partSuppliers returns the part_supplier links of a part to the suppliers
+// in its qualified_supplier_ids, which is stored as "[SUP-1 SUP-2]". Each
+// supplier is linked once.
+func partSuppliers(tenantID, partID string, ids sql.NullString) []db.AddPartSupplierParams {
+	if !ids.Valid {
+		return nil
+	}
+	var links []db.AddPartSupplierParams
+	seen := map[string]bool{}
+	for _, id := range strings.Fields(strings.Trim(ids.String, "[]")) {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		links = append(links, db.AddPartSupplierParams{PartID: partID, SupplierID: id, TenantID: tenantID})
+	}
+	return links
+}
+
+// ping reads a table, which unlike sql.DB.Ping reaches the database file and
+// its schema rather than only checking a pooled connection.
+func ping(ctx context.Context, conn *sql.DB) error {
+	var exists bool
+	return conn.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM dim_supplier_v1)").Scan(&exists)
+}
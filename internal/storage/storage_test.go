@@ -0,0 +1,670 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitterfq/data-ingestion-go/internal/bulkload"
+	"github.com/bitterfq/data-ingestion-go/internal/database/db"
+	"github.com/bitterfq/data-ingestion-go/internal/metrics"
+	"github.com/bitterfq/data-ingestion-go/internal/parts"
+	"github.com/bitterfq/data-ingestion-go/internal/suppliers"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestParseDSN(t *testing.T) {
+	for _, tt := range []struct{ dsn, backend, path string }{
+		{"sqlite:data/data.db", "sqlite", "data/data.db"},
+		{"sqlite3::memory:", "sqlite", ":memory:"},
+		{"duckdb:data/data.duckdb", "duckdb", "data/data.duckdb"},
+		{"duckdb:", "duckdb", ""},
+		{"data/data.db", "sqlite", "data/data.db"},
+		{"file:data.db?mode=ro", "sqlite", "file:data.db?mode=ro"},
+	} {
+		backend, path := ParseDSN(tt.dsn)
+		if backend != tt.backend || path != tt.path {
+			t.Errorf("ParseDSN(%q) = %q, %q, want %q, %q", tt.dsn, backend, path, tt.backend, tt.path)
+		}
+	}
+}
+
+func TestOpenMissingDirectory(t *testing.T) {
+	if _, err := Open(context.Background(), "duckdb:"+filepath.Join(t.TempDir(), "missing", "x.duckdb"), Options{}); err == nil {
+		t.Error("expected error for a database in a missing directory")
+	}
+}
+
+func TestPing(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Store) {
+		if err := s.Ping(context.Background()); err != nil {
+			t.Errorf("ping: %v", err)
+		}
+		s.Close()
+		if err := s.Ping(context.Background()); err == nil {
+			t.Error("expected a closed store to fail its ping")
+		}
+	})
+}
+
+// forEachBackend runs test against a fresh store of every backend.
+func forEachBackend(t *testing.T, test func(t *testing.T, s Store)) {
+	for _, backend := range []string{"sqlite", "duckdb"} {
+		t.Run(backend, func(t *testing.T) {
+			s, err := Open(context.Background(), backend+":"+filepath.Join(t.TempDir(), "test."+backend), Options{})
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			t.Cleanup(func() { s.Close() })
+			test(t, s)
+		})
+	}
+}
+
+func generate(nSuppliers, nParts int) ([]db.CreateSupplierParams, []db.CreatePartParams, []db.AddPartSupplierParams) {
+	sups := suppliers.GenerateSuppliers("tenant_acme", nSuppliers)
+	supRows := make([]db.CreateSupplierParams, len(sups))
+	ids := make([]string, len(sups))
+	for i, sup := range sups {
+		supRows[i] = bulkload.SupplierParams(sup)
+		ids[i] = sup.SupplierID
+	}
+	ps := parts.GenerateParts(nParts, "tenant_acme", ids)
+	partRows := make([]db.CreatePartParams, len(ps))
+	var links []db.AddPartSupplierParams
+	for i, p := range ps {
+		partRows[i] = bulkload.PartParams(p)
+		links = append(links, bulkload.PartSupplierParams(p)...)
+	}
+	return supRows, partRows, links
+}
+
+func TestLoad(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		sups, ps, links := generate(10, 200)
+
+		stats, err := s.LoadSuppliers(ctx, sups)
+		if err != nil {
+			t.Fatalf("load suppliers: %v", err)
+		}
+		if stats.Rows != 10 || stats.Inserted != 10 {
+			t.Errorf("suppliers: unexpected stats %v", stats)
+		}
+		if stats, err = s.LoadParts(ctx, ps); err != nil || stats.Inserted != 200 {
+			t.Fatalf("load parts: %v %v", stats, err)
+		}
+		if stats, err = s.LoadPartSuppliers(ctx, links); err != nil || stats.Inserted != int64(len(links)) {
+			t.Fatalf("load links: %v %v", stats, err)
+		}
+
+		// reloading changes nothing
+		stats, err = s.LoadSuppliers(ctx, sups)
+		if err != nil || stats.Inserted != 0 || stats.Updated != 0 || stats.Unchanged != 10 {
+			t.Errorf("reload: unexpected stats %v %v", stats, err)
+		}
+		if stats, err = s.LoadPartSuppliers(ctx, links); err != nil || stats.Unchanged != int64(len(links)) {
+			t.Errorf("reload links: unexpected stats %v %v", stats, err)
+		}
+
+		// a newer version replaces the row, an older one is skipped, a new key is inserted
+		newer, older, added := sups[0], sups[1], sups[2]
+		newer.LegalName = "Newer Name"
+		newer.SourceTimestamp.Time = newer.SourceTimestamp.Time.Add(time.Hour)
+		older.LegalName = "Older Name"
+		older.SourceTimestamp.Time = older.SourceTimestamp.Time.Add(-time.Hour)
+		added.SupplierID = "SUP-NEW"
+		stats, err = s.LoadSuppliers(ctx, []db.CreateSupplierParams{newer, older, added})
+		if err != nil || stats.Inserted != 1 || stats.Updated != 1 || stats.Unchanged != 1 {
+			t.Errorf("mixed load: unexpected stats %v %v", stats, err)
+		}
+
+		// another tenant cannot overwrite the row, however new its version
+		other := newer
+		other.TenantID = "tenant_globex"
+		other.SourceTimestamp.Time = other.SourceTimestamp.Time.Add(time.Hour)
+		stats, err = s.LoadSuppliers(ctx, []db.CreateSupplierParams{other})
+		if err != nil || stats.Updated != 0 || stats.Unchanged != 1 {
+			t.Errorf("cross-tenant load: unexpected stats %v %v", stats, err)
+		}
+	})
+}
+
+func TestLoadSpans(t *testing.T) {
+	for backend, children := range map[string][]string{
+		"sqlite": {"sqlite.batch"},
+		"duckdb": {"duckdb.append", "duckdb.merge"},
+	} {
+		t.Run(backend, func(t *testing.T) {
+			exp := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+			s, err := Open(context.Background(), backend+":"+filepath.Join(t.TempDir(), "test."+backend), Options{TracerProvider: tp})
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			defer s.Close()
+			sups, _, _ := generate(10, 0)
+			if _, err := s.LoadSuppliers(context.Background(), sups); err != nil {
+				t.Fatal(err)
+			}
+
+			spans := map[string]tracetest.SpanStub{}
+			for _, span := range exp.GetSpans() {
+				spans[span.Name] = span
+			}
+			load, ok := spans[backend+".load"]
+			if !ok {
+				t.Fatalf("expected a %s.load span, got %v", backend, spans)
+			}
+			for _, name := range children {
+				if spans[name].Parent.SpanID() != load.SpanContext.SpanID() {
+					t.Errorf("expected a %s span under the load", name)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMetrics(t *testing.T) {
+	for _, backend := range []string{"sqlite", "duckdb"} {
+		t.Run(backend, func(t *testing.T) {
+			m := metrics.New()
+			s, err := Open(context.Background(), backend+":"+filepath.Join(t.TempDir(), "test."+backend), Options{Metrics: m})
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			defer s.Close()
+			sups, _, _ := generate(10, 0)
+			if _, err := s.LoadSuppliers(context.Background(), sups); err != nil {
+				t.Fatal(err)
+			}
+
+			w := httptest.NewRecorder()
+			m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			for _, want := range []string{
+				fmt.Sprintf(`db_transaction_duration_seconds_count{outcome="commit",system=%q,table="dim_supplier_v1"} 1`, backend),
+				fmt.Sprintf(`go_sql_open_connections{db_name=%q}`, backend),
+			} {
+				if !strings.Contains(w.Body.String(), want) {
+					t.Errorf("expected %q in the exposition", want)
+				}
+			}
+		})
+	}
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		by := sql.NullString{String: "alice", Valid: true}
+		_, err := s.CreateSupplier(ctx, db.CreateSupplierParams{SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Acme", ModifiedBy: by})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.CreatePart(ctx, db.CreatePartParams{PartID: "PART-1", TenantID: "tenant_acme", PartNumber: "P-1", Description: "bolt"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, step := range []struct {
+			name string
+			run  func() (int64, error)
+			want int64
+		}{
+			{"delete supplier", func() (int64, error) {
+				return s.DeleteSupplier(ctx, db.DeleteSupplierParams{DeletedBy: by, TenantID: "tenant_acme", SupplierID: "SUP-1"})
+			}, 1},
+			{"delete supplier again", func() (int64, error) {
+				return s.DeleteSupplier(ctx, db.DeleteSupplierParams{DeletedBy: by, TenantID: "tenant_acme", SupplierID: "SUP-1"})
+			}, 0},
+			{"restore supplier", func() (int64, error) {
+				return s.RestoreSupplier(ctx, db.RestoreSupplierParams{ModifiedBy: by, TenantID: "tenant_acme", SupplierID: "SUP-1"})
+			}, 1},
+			{"restore live supplier", func() (int64, error) {
+				return s.RestoreSupplier(ctx, db.RestoreSupplierParams{ModifiedBy: by, TenantID: "tenant_acme", SupplierID: "SUP-1"})
+			}, 0},
+			{"delete part", func() (int64, error) {
+				return s.DeletePart(ctx, db.DeletePartParams{DeletedBy: by, TenantID: "tenant_acme", PartID: "PART-1"})
+			}, 1},
+			{"restore part", func() (int64, error) {
+				return s.RestorePart(ctx, db.RestorePartParams{ModifiedBy: by, TenantID: "tenant_acme", PartID: "PART-1"})
+			}, 1},
+			{"delete other tenant's part", func() (int64, error) {
+				return s.DeletePart(ctx, db.DeletePartParams{DeletedBy: by, TenantID: "tenant_globex", PartID: "PART-1"})
+			}, 0},
+			{"delete missing part", func() (int64, error) {
+				return s.DeletePart(ctx, db.DeletePartParams{DeletedBy: by, TenantID: "tenant_acme", PartID: "missing"})
+			}, 0},
+		} {
+			if n, err := step.run(); err != nil || n != step.want {
+				t.Errorf("%s: expected %d rows, got %d %v", step.name, step.want, n, err)
+			}
+		}
+	})
+}
+
+func TestGetListUpdate(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		for i, country := range []string{"US", "DE", "US"} {
+			_, err := s.CreateSupplier(ctx, db.CreateSupplierParams{
+				SupplierID: fmt.Sprintf("SUP-%d", i+1), TenantID: "tenant_acme", LegalName: "Acme",
+				Country:   sql.NullString{String: country, Valid: true},
+				RiskScore: sql.NullFloat64{Float64: float64(i * 10), Valid: true},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := s.CreateSupplier(ctx, db.CreateSupplierParams{SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Again"})
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("duplicate create: expected ErrConflict, got %v", err)
+		}
+		if _, err := s.CreatePart(ctx, db.CreatePartParams{PartID: "PART-1", TenantID: "tenant_acme", PartNumber: "P-1", Description: "bolt"}); err != nil {
+			t.Fatal(err)
+		}
+
+		sup, err := s.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-2"})
+		if err != nil || sup.Country.String != "DE" || sup.RiskScore.Float64 != 10 {
+			t.Errorf("get: unexpected supplier %+v %v", sup, err)
+		}
+		if _, err := s.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_globex", SupplierID: "SUP-2"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("get other tenant's supplier: expected sql.ErrNoRows, got %v", err)
+		}
+
+		us := sql.NullString{String: "US", Valid: true}
+		page, err := s.ListSuppliers(ctx, db.ListSuppliersParams{TenantID: "tenant_acme", Country: us, PageSize: 1})
+		if err != nil || len(page) != 1 || page[0].SupplierID != "SUP-1" {
+			t.Fatalf("list: unexpected page %+v %v", page, err)
+		}
+		page, err = s.ListSuppliers(ctx, db.ListSuppliersParams{TenantID: "tenant_acme", Country: us, After: "SUP-1", PageSize: 10})
+		if err != nil || len(page) != 1 || page[0].SupplierID != "SUP-3" {
+			t.Errorf("list after: unexpected page %+v %v", page, err)
+		}
+		page, err = s.ListSuppliers(ctx, db.ListSuppliersParams{TenantID: "tenant_acme", MinRiskScore: sql.NullFloat64{Float64: 5, Valid: true}, PageSize: 10})
+		if err != nil || len(page) != 2 {
+			t.Errorf("list by risk: unexpected page %+v %v", page, err)
+		}
+
+		update := db.UpdateSupplierParams{LegalName: "Acme Renamed", TenantID: "tenant_acme", SupplierID: "SUP-1"}
+		if n, err := s.UpdateSupplier(ctx, update); err != nil || n != 1 {
+			t.Fatalf("update: %d %v", n, err)
+		}
+		sup, err = s.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-1"})
+		if err != nil || sup.LegalName != "Acme Renamed" || sup.Country.Valid {
+			t.Errorf("update replaces every field, got %+v %v", sup, err)
+		}
+		update.TenantID = "tenant_globex"
+		if n, err := s.UpdateSupplier(ctx, update); err != nil || n != 0 {
+			t.Errorf("update other tenant's supplier: %d %v", n, err)
+		}
+
+		if n, err := s.UpdatePart(ctx, db.UpdatePartParams{PartNumber: "P-1", Description: "hex bolt", TenantID: "tenant_acme", PartID: "PART-1"}); err != nil || n != 1 {
+			t.Fatalf("update part: %d %v", n, err)
+		}
+		ps, err := s.ListParts(ctx, db.ListPartsParams{TenantID: "tenant_acme", PageSize: 10})
+		if err != nil || len(ps) != 1 || ps[0].Description != "hex bolt" {
+			t.Errorf("list parts: %+v %v", ps, err)
+		}
+	})
+}
+
+func TestUpdateVersion(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		ts := sql.NullTime{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+		if _, err := s.CreateSupplier(ctx, db.CreateSupplierParams{SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Acme", SourceTimestamp: ts}); err != nil {
+			t.Fatal(err)
+		}
+		version := func() int64 {
+			t.Helper()
+			sup, err := s.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return sup.Version
+		}
+		v := version()
+
+		// every write moves the version on
+		update := db.UpdateSupplierParams{LegalName: "Acme Renamed", TenantID: "tenant_acme", SupplierID: "SUP-1", SourceTimestamp: ts}
+		if n, err := s.UpdateSupplier(ctx, update); err != nil || n != 1 {
+			t.Fatalf("update: %d %v", n, err)
+		}
+		_, err := s.LoadSuppliers(ctx, []db.CreateSupplierParams{{SupplierID: "SUP-1", TenantID: "tenant_acme", LegalName: "Acme Loaded",
+			SourceTimestamp: sql.NullTime{Time: ts.Time.Add(time.Hour), Valid: true}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.DeleteSupplier(ctx, db.DeleteSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-1"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.RestoreSupplier(ctx, db.RestoreSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-1"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := version(); got != v+4 {
+			t.Errorf("expected version %d after four writes, got %d", v+4, got)
+		}
+
+		// an update at an old version changes nothing
+		update.Version = sql.NullInt64{Int64: v, Valid: true}
+		if n, err := s.UpdateSupplier(ctx, update); err != nil || n != 0 {
+			t.Errorf("stale update: expected 0 rows, got %d %v", n, err)
+		}
+		update.Version = sql.NullInt64{Int64: v + 4, Valid: true}
+		if n, err := s.UpdateSupplier(ctx, update); err != nil || n != 1 {
+			t.Errorf("current update: expected 1 row, got %d %v", n, err)
+		}
+	})
+}
+
+func TestPartSupplierLinks(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		for _, id := range []string{"SUP-1", "SUP-2", "SUP-3"} {
+			if _, err := s.CreateSupplier(ctx, db.CreateSupplierParams{SupplierID: id, TenantID: "tenant_acme", LegalName: "Acme"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		links := func() []string {
+			var conn *sql.DB
+			switch s := s.(type) {
+			case *sqliteStore:
+				conn = s.conn
+			case *duckdbStore:
+				conn = s.conn
+			}
+			rows, err := conn.QueryContext(ctx, "SELECT supplier_id FROM part_supplier WHERE part_id = 'PART-1' ORDER BY supplier_id")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			var ids []string
+			for rows.Next() {
+				var id string
+				rows.Scan(&id)
+				ids = append(ids, id)
+			}
+			return ids
+		}
+		list := func(ids string) sql.NullString { return sql.NullString{String: ids, Valid: true} }
+
+		_, err := s.CreatePart(ctx, db.CreatePartParams{PartID: "PART-1", TenantID: "tenant_acme", PartNumber: "P-1", Description: "bolt",
+			QualifiedSupplierIds: list("[SUP-2 SUP-1 SUP-2]")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := links(); !slices.Equal(got, []string{"SUP-1", "SUP-2"}) {
+			t.Errorf("create: unexpected links %v", got)
+		}
+
+		update := db.UpdatePartParams{PartNumber: "P-1", Description: "bolt", TenantID: "tenant_acme", PartID: "PART-1", QualifiedSupplierIds: list("[SUP-3]")}
+		if n, err := s.UpdatePart(ctx, update); err != nil || n != 1 {
+			t.Fatalf("update: %d %v", n, err)
+		}
+		if got := links(); !slices.Equal(got, []string{"SUP-3"}) {
+			t.Errorf("update: unexpected links %v", got)
+		}
+
+		// an unknown supplier rolls back the part with its links
+		update.QualifiedSupplierIds = list("[SUP-1 SUP-9]")
+		update.Description = "rolled back"
+		if _, err := s.UpdatePart(ctx, update); !errors.Is(err, ErrConflict) {
+			t.Errorf("update with an unknown supplier: expected ErrConflict, got %v", err)
+		}
+		p, err := s.GetPart(ctx, db.GetPartParams{TenantID: "tenant_acme", PartID: "PART-1"})
+		if err != nil || p.Description != "bolt" {
+			t.Errorf("expected the failed update to roll back, got %+v %v", p, err)
+		}
+		if got := links(); !slices.Equal(got, []string{"SUP-3"}) {
+			t.Errorf("failed update: unexpected links %v", got)
+		}
+		_, err = s.CreatePart(ctx, db.CreatePartParams{PartID: "PART-2", TenantID: "tenant_acme", PartNumber: "P-2", Description: "nut",
+			QualifiedSupplierIds: list("[SUP-9]")})
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("create with an unknown supplier: expected ErrConflict, got %v", err)
+		}
+		if _, err := s.GetPart(ctx, db.GetPartParams{TenantID: "tenant_acme", PartID: "PART-2"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expected the failed create to roll back, got %v", err)
+		}
+
+		update.QualifiedSupplierIds = sql.NullString{}
+		if _, err := s.UpdatePart(ctx, update); err != nil {
+			t.Fatal(err)
+		}
+		if got := links(); len(got) != 0 {
+			t.Errorf("expected clearing the list to remove the links, got %v", got)
+		}
+	})
+}
+
+func TestTenantIsolation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		list := func(ids string) sql.NullString { return sql.NullString{String: ids, Valid: true} }
+		ts := sql.NullTime{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+		newer := sql.NullTime{Time: ts.Time.Add(time.Hour), Valid: true}
+		for _, tenant := range []struct{ id, supplier, part string }{
+			{"tenant_acme", "SUP-A", "PART-A"},
+			{"tenant_globex", "SUP-G", "PART-G"},
+		} {
+			_, err := s.CreateSupplier(ctx, db.CreateSupplierParams{SupplierID: tenant.supplier, TenantID: tenant.id, LegalName: "Acme Fasteners", SourceTimestamp: ts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.CreatePart(ctx, db.CreatePartParams{PartID: tenant.part, TenantID: tenant.id, PartNumber: "NUM-" + tenant.part, Description: "hex bolt",
+				Category: list("FASTENER"), QualifiedSupplierIds: list("[" + tenant.supplier + "]"), SourceTimestamp: ts})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		by := sql.NullString{String: "mallory", Valid: true}
+
+		// globex cannot see or change acme's rows
+		rows := map[string]func() (int64, error){
+			"SupplierExists": func() (int64, error) {
+				return s.SupplierExists(ctx, db.SupplierExistsParams{TenantID: "tenant_globex", SupplierID: "SUP-A"})
+			},
+			"PartExists": func() (int64, error) {
+				return s.PartExists(ctx, db.PartExistsParams{TenantID: "tenant_globex", PartID: "PART-A"})
+			},
+			"UpdateSupplier": func() (int64, error) {
+				return s.UpdateSupplier(ctx, db.UpdateSupplierParams{LegalName: "Hijacked", TenantID: "tenant_globex", SupplierID: "SUP-A"})
+			},
+			"UpdatePart": func() (int64, error) {
+				return s.UpdatePart(ctx, db.UpdatePartParams{PartNumber: "X", Description: "hijacked", TenantID: "tenant_globex", PartID: "PART-A"})
+			},
+			"DeleteSupplier": func() (int64, error) {
+				return s.DeleteSupplier(ctx, db.DeleteSupplierParams{DeletedBy: by, TenantID: "tenant_globex", SupplierID: "SUP-A"})
+			},
+			"DeletePart": func() (int64, error) {
+				return s.DeletePart(ctx, db.DeletePartParams{DeletedBy: by, TenantID: "tenant_globex", PartID: "PART-A"})
+			},
+			"RestoreSupplier": func() (int64, error) {
+				return s.RestoreSupplier(ctx, db.RestoreSupplierParams{ModifiedBy: by, TenantID: "tenant_globex", SupplierID: "SUP-A"})
+			},
+			"RestorePart": func() (int64, error) {
+				return s.RestorePart(ctx, db.RestorePartParams{ModifiedBy: by, TenantID: "tenant_globex", PartID: "PART-A"})
+			},
+			"LoadSuppliers": func() (int64, error) {
+				stats, err := s.LoadSuppliers(ctx, []db.CreateSupplierParams{{SupplierID: "SUP-A", TenantID: "tenant_globex", LegalName: "Hijacked", SourceTimestamp: newer}})
+				return stats.Inserted + stats.Updated, err
+			},
+			"LoadParts": func() (int64, error) {
+				stats, err := s.LoadParts(ctx, []db.CreatePartParams{{PartID: "PART-A", TenantID: "tenant_globex", PartNumber: "X", Description: "hijacked", SourceTimestamp: newer}})
+				return stats.Inserted + stats.Updated, err
+			},
+			"ListSuppliers": func() (int64, error) {
+				sups, err := s.ListSuppliers(ctx, db.ListSuppliersParams{TenantID: "tenant_globex", After: "SUP-G", PageSize: 10})
+				return int64(len(sups)), err
+			},
+			"ListParts": func() (int64, error) {
+				ps, err := s.ListParts(ctx, db.ListPartsParams{TenantID: "tenant_globex", After: "PART-G", PageSize: 10})
+				return int64(len(ps)), err
+			},
+		}
+		for name, run := range rows {
+			if n, err := run(); err != nil || n != 0 {
+				t.Errorf("%s: expected nothing from another tenant, got %d %v", name, n, err)
+			}
+		}
+		if _, err := s.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_globex", SupplierID: "SUP-A"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetSupplier: expected sql.ErrNoRows, got %v", err)
+		}
+		if _, err := s.GetPart(ctx, db.GetPartParams{TenantID: "tenant_globex", PartID: "PART-A"}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetPart: expected sql.ErrNoRows, got %v", err)
+		}
+
+		// no path links a part to another tenant's supplier
+		_, err := s.CreatePart(ctx, db.CreatePartParams{PartID: "PART-X", TenantID: "tenant_globex", PartNumber: "P-X", Description: "bolt",
+			QualifiedSupplierIds: list("[SUP-A]")})
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("CreatePart: expected ErrConflict, got %v", err)
+		}
+		_, err = s.UpdatePart(ctx, db.UpdatePartParams{PartNumber: "NUM-PART-G", Description: "hex bolt", TenantID: "tenant_globex", PartID: "PART-G",
+			QualifiedSupplierIds: list("[SUP-G SUP-A]")})
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("UpdatePart: expected ErrConflict, got %v", err)
+		}
+		for _, link := range []db.AddPartSupplierParams{
+			{PartID: "PART-G", SupplierID: "SUP-A", TenantID: "tenant_globex"},
+			{PartID: "PART-G", SupplierID: "SUP-A", TenantID: "tenant_acme"},
+			{PartID: "PART-A", SupplierID: "SUP-G", TenantID: "tenant_acme"},
+		} {
+			if _, err := s.LoadPartSuppliers(ctx, []db.AddPartSupplierParams{link}); err == nil {
+				t.Errorf("LoadPartSuppliers: expected %+v to be refused", link)
+			}
+		}
+
+		// search and the summary only see the tenant's rows
+		if _, duck := s.(*duckdbStore); !duck {
+			sups, err := s.SearchSuppliers(ctx, db.SearchSuppliersParams{Query: `"acme"*`, TenantID: "tenant_globex", PageSize: 10})
+			if err != nil || len(sups) != 1 || sups[0].SupplierID != "SUP-G" {
+				t.Errorf("SearchSuppliers: unexpected %+v %v", sups, err)
+			}
+			ps, err := s.SearchParts(ctx, db.SearchPartsParams{Query: `"hex"*`, TenantID: "tenant_globex", PageSize: 10})
+			if err != nil || len(ps) != 1 || ps[0].PartID != "PART-G" {
+				t.Errorf("SearchParts: unexpected %+v %v", ps, err)
+			}
+		}
+		summary, err := s.SummarizeParts(ctx, "tenant_globex")
+		if err != nil || len(summary) != 1 || summary[0].Parts != 1 || summary[0].Suppliers != 0 {
+			t.Errorf("SummarizeParts: unexpected %+v %v", summary, err)
+		}
+
+		// acme's rows are untouched
+		sup, err := s.GetSupplier(ctx, db.GetSupplierParams{TenantID: "tenant_acme", SupplierID: "SUP-A"})
+		if err != nil || sup.LegalName != "Acme Fasteners" {
+			t.Errorf("expected acme's supplier to be untouched, got %+v %v", sup, err)
+		}
+		part, err := s.GetPart(ctx, db.GetPartParams{TenantID: "tenant_acme", PartID: "PART-A"})
+		if err != nil || part.Description != "hex bolt" || part.QualifiedSupplierIds != list("[SUP-A]") {
+			t.Errorf("expected acme's part to be untouched, got %+v %v", part, err)
+		}
+	})
+}
+
+func TestSummarizeParts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		var rows []db.CreatePartParams
+		for i, p := range []struct {
+			category string
+			cost     float64
+			supplier string
+		}{
+			{"FASTENER", 1, "SUP-1"}, {"FASTENER", 3, "SUP-2"}, {"FASTENER", 5, "SUP-1"}, {"ELECTRICAL", 10, "SUP-1"},
+		} {
+			rows = append(rows, db.CreatePartParams{
+				PartID:            string(rune('A' + i)),
+				TenantID:          "tenant_acme",
+				PartNumber:        "P",
+				Description:       "part",
+				Category:          sql.NullString{String: p.category, Valid: true},
+				LifecycleStatus:   sql.NullString{String: "ACTIVE", Valid: true},
+				UnitCost:          sql.NullFloat64{Float64: p.cost, Valid: true},
+				DefaultSupplierID: sql.NullString{String: p.supplier, Valid: true},
+			})
+		}
+		if s, ok := s.(*sqliteStore); ok {
+			// the part rows reference suppliers in SQLite
+			for _, id := range []string{"SUP-1", "SUP-2"} {
+				if _, err := s.CreateSupplier(ctx, db.CreateSupplierParams{SupplierID: id, TenantID: "tenant_acme", LegalName: id}); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if _, err := s.LoadParts(ctx, rows); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.DeletePart(ctx, db.DeletePartParams{TenantID: "tenant_acme", PartID: "A"}); err != nil {
+			t.Fatal(err)
+		}
+
+		summary, err := s.SummarizeParts(ctx, "tenant_acme")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []db.SummarizePartsRow{
+			{Category: sql.NullString{String: "ELECTRICAL", Valid: true}, LifecycleStatus: sql.NullString{String: "ACTIVE", Valid: true}, Parts: 1, AvgUnitCost: 10, Suppliers: 1},
+			{Category: sql.NullString{String: "FASTENER", Valid: true}, LifecycleStatus: sql.NullString{String: "ACTIVE", Valid: true}, Parts: 2, AvgUnitCost: 4, Suppliers: 2},
+		}
+		if len(summary) != len(want) {
+			t.Fatalf("expected %d groups, got %+v", len(want), summary)
+		}
+		for i := range want {
+			if summary[i] != want[i] {
+				t.Errorf("group %d: expected %+v, got %+v", i, want[i], summary[i])
+			}
+		}
+	})
+}
+
+func TestSearchSupport(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s Store) {
+		_, err := s.SearchSuppliers(context.Background(), db.SearchSuppliersParams{Query: `"acme"*`, TenantID: "tenant_acme", PageSize: 10})
+		_, duck := s.(*duckdbStore)
+		if duck != errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("unexpected search error %v", err)
+		}
+		if !duck && err != nil {
+			t.Errorf("search: %v", err)
+		}
+	})
+}
+
+// BenchmarkSummarizeParts aggregates the same parts in each backend.
+func BenchmarkSummarizeParts(b *testing.B) {
+	sups, ps, _ := generate(100, 100000)
+	for _, backend := range []string{"sqlite", "duckdb"} {
+		b.Run(backend, func(b *testing.B) {
+			ctx := context.Background()
+			s, err := Open(ctx, backend+":"+filepath.Join(b.TempDir(), "bench."+backend), Options{})
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer s.Close()
+			if _, err := s.LoadSuppliers(ctx, sups); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := s.LoadParts(ctx, ps); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.SummarizeParts(ctx, "tenant_acme"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
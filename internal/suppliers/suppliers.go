@@ -4,6 +4,7 @@ package suppliers
 import (
 	"encoding/csv"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -11,6 +12,21 @@ import (
 
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/oklog/ulid/v2"
+
+	"github.com/bitterfq/data-ingestion-go/internal/logging"
+)
+
+// The vocabularies of the coded supplier fields. The generator draws from them
+// and the API accepts only these values.
+var (
+	Countries        = []string{"US", "CN", "DE", "MX", "IN", "VN", "PL", "JP", "KR"}
+	Regions          = []string{"EMEA", "APAC", "AMERICAS"}
+	Incoterms        = []string{"DDP", "FOB", "CIF", "EXW"}
+	RiskTiers        = []string{"LOW", "MEDIUM", "HIGH"}
+	ApprovedStatuses = []string{"APPROVED", "PENDING", "SUSPENDED"}
+	Currencies       = []string{"USD", "CNY", "EUR", "INR", "JPY"}
+	Certifications   = []string{"ISO9001", "IATF16949", "AS9100", "ISO14001"}
+	ComplianceFlags  = []string{"ITAR", "REACH", "ROHS"}
 )
 
 type GeoCoords struct {
@@ -85,16 +101,6 @@ func GenerateSupplier(tenant string) Supplier {
 	onTime := gofakeit.Float64Range(60, 100)
 	risk := 100 - onTime + gofakeit.Float64Range(0, 10)
 
-	// Uniform stubs for now
-	countries := []string{"US", "CN", "DE", "MX", "IN", "VN", "PL", "JP", "KR"}
-	regions := []string{"EMEA", "APAC", "AMERICAS"}
-	incoterms := []string{"DDP", "FOB", "CIF", "EXW"}
-	tiers := []string{"LOW", "MEDIUM", "HIGH"}
-	statuses := []string{"APPROVED", "PENDING", "SUSPENDED"}
-	currencies := []string{"USD", "CNY", "EUR", "INR", "JPY"}
-	certs := []string{"ISO9001", "IATF16949", "AS9100", "ISO14001"}
-	flags := []string{"ITAR", "REACH", "ROHS"}
-
 	return Supplier{
 		// Identity
 		SupplierID:   ulid.MustNew(ulid.Timestamp(t), entropy).String(),
@@ -104,8 +110,8 @@ func GenerateSupplier(tenant string) Supplier {
 		// Names & location
 		LegalName: gofakeit.Company(),
 		DBAName:   gofakeit.CompanySuffix(),
-		Country:   gofakeit.RandomString(countries),
-		Region:    gofakeit.RandomString(regions),
+		Country:   gofakeit.RandomString(Countries),
+		Region:    gofakeit.RandomString(Regions),
 
 		// Address
 		AddressLine1: gofakeit.Street(),
@@ -119,8 +125,8 @@ func GenerateSupplier(tenant string) Supplier {
 		ContactPhone: gofakeit.Phone(),
 
 		// Commercial
-		PreferredCurrency: gofakeit.RandomString(currencies),
-		Incoterms:         gofakeit.RandomString(incoterms),
+		PreferredCurrency: gofakeit.RandomString(Currencies),
+		Incoterms:         gofakeit.RandomString(Incoterms),
 
 		// Performance & risk
 		LeadTimeDaysAvg:      gofakeit.Number(3, 90),
@@ -129,14 +135,14 @@ func GenerateSupplier(tenant string) Supplier {
 		DefectRatePPM:        gofakeit.Number(50, 1000),
 		CapacityUnitsPerWeek: gofakeit.Number(100, 10000),
 		RiskScore:            risk,
-		FinancialRiskTier:    gofakeit.RandomString(tiers),
+		FinancialRiskTier:    gofakeit.RandomString(RiskTiers),
 
 		// Certifications & compliance
-		Certifications:  []string{gofakeit.RandomString(certs)},
-		ComplianceFlags: []string{gofakeit.RandomString(flags)},
+		Certifications:  []string{gofakeit.RandomString(Certifications)},
+		ComplianceFlags: []string{gofakeit.RandomString(ComplianceFlags)},
 
 		// Status & contracts
-		ApprovedStatus: gofakeit.RandomString(statuses),
+		ApprovedStatus: gofakeit.RandomString(ApprovedStatuses),
 		Contracts:      []string{"CONTRACT_" + gofakeit.Numerify("####")},
 		TermsVersion:   gofakeit.Numerify("#.#"),
 
@@ -166,18 +172,20 @@ func GenerateSuppliers(tenant string, count int) []Supplier {
 }
 
 // SupplierWriter writes a slice of Supplier records to a CSV file with the given filename.
-// Returns true if the file was written successfully, false otherwise.
-func SupplierWriter(filename string, suppliers []Supplier) bool {
+// Returns true if the file was written successfully, false otherwise; the
+// error is logged to logger, which may be nil.
+func SupplierWriter(logger *slog.Logger, filename string, suppliers []Supplier) bool {
+	logger = logging.OrDiscard(logger)
 	//ensure the directory exists
 	dir := filepath.Dir(filename)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		fmt.Println("error creating directory:", err)
+		logger.Error("failed to create directory", "file", filename, "err", err)
 		return false
 	}
 
 	file, err := os.Create(filename)
 	if err != nil {
-		fmt.Println("error creating file:", err)
+		logger.Error("failed to create file", "file", filename, "err", err)
 		return false
 	}
 	defer file.Close()
@@ -199,7 +207,7 @@ func SupplierWriter(filename string, suppliers []Supplier) bool {
 		"data_source", "source_timestamp", "ingestion_timestamp", "schema_version",
 	}
 	if err := w.Write(header); err != nil {
-		fmt.Println("error writing header:", err)
+		logger.Error("failed to write header", "file", filename, "err", err)
 		return false
 	}
 
@@ -251,17 +259,17 @@ func SupplierWriter(filename string, suppliers []Supplier) bool {
 		)
 
 		if err := w.Write(row); err != nil {
-			fmt.Println("error writing row:", err)
+			logger.Error("failed to write row", "file", filename, "err", err)
 			return false
 		}
 	}
 
 	w.Flush()
 	if err := w.Error(); err != nil {
-		fmt.Println("error flushing writer:", err)
+		logger.Error("failed to flush csv", "file", filename, "err", err)
 		return false
 	}
 
-	fmt.Println("Suppliers csv file:", filename)
+	logger.Info("wrote csv", "file", filename, "rows", len(suppliers))
 	return true
 }
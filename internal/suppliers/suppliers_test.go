@@ -30,7 +30,7 @@ func TestWriteSuppliersToCSV(t *testing.T) {
 	num := 5
 	sups := GenerateSuppliers(tenant, num)
 	filename := "test_suppliers.csv"
-	success := SupplierWriter(filename, sups)
+	success := SupplierWriter(nil, filename, sups)
 	if !success {
 		t.Error("expected WriteSuppliersToCSV to return true")
 	}
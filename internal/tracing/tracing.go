@@ -0,0 +1,95 @@
+// Package tracing configures OpenTelemetry tracing for the commands. Library
+// packages take a trace.TracerProvider in their options and use the global
+// provider, which Setup installs, when it is nil.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Scope prefixes the instrumentation scope of every tracer in the module.
+const Scope = "github.com/bitterfq/data-ingestion-go/"
+
+// Config picks where spans go.
+type Config struct {
+	// Exporter is "none", the default, "otlp" or "stdout" ("console" is an
+	// alias, as in the OpenTelemetry spec). The OTLP exporter reads its
+	// endpoint and headers from the standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter string
+	// ServiceName names the process in every span's resource.
+	ServiceName string
+}
+
+// ConfigFromEnv reads OTEL_TRACES_EXPORTER and OTEL_SERVICE_NAME, defaulting
+// the service name to service.
+func ConfigFromEnv(service string) Config {
+	cfg := Config{Exporter: os.Getenv("OTEL_TRACES_EXPORTER"), ServiceName: os.Getenv("OTEL_SERVICE_NAME")}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = service
+	}
+	return cfg
+}
+
+// Setup installs a global tracer provider exporting as configured, and the
+// W3C trace context and baggage propagators. The returned function flushes
+// and stops the exporter; call it before exiting. The stdout exporter writes
+// to w.
+func Setup(ctx context.Context, cfg Config, w io.Writer) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch strings.ToLower(cfg.Exporter) {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout", "console":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(w))
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q; want none, otlp or stdout", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Tracer returns the tracer of a package from tp, or from the global provider
+// when tp is nil. pkg is the package's path within the module, such as
+// "internal/bulkload".
+func Tracer(tp trace.TracerProvider, pkg string) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(Scope + pkg)
+}
+
+// End ends span, recording err on it first when it is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
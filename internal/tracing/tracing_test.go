@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	shutdown, err := Setup(ctx, Config{Exporter: "stdout", ServiceName: "test"}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := Tracer(nil, "internal/tracing").Start(ctx, "work")
+	span.End()
+	if err := shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `"Name":"work"`) || !strings.Contains(out, `"test"`) {
+		t.Errorf("expected the span and service name on stdout, got %s", out)
+	}
+
+	if _, err := Setup(ctx, Config{Exporter: "none"}, nil); err != nil {
+		t.Errorf("none: %v", err)
+	}
+	if _, err := Setup(ctx, Config{Exporter: "zipkin"}, nil); err == nil {
+		t.Error("expected an unknown exporter to be rejected")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	if cfg := ConfigFromEnv("server"); cfg.Exporter != "otlp" || cfg.ServiceName != "server" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+func TestEnd(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tracer := Tracer(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)), "internal/tracing")
+	_, ok := tracer.Start(context.Background(), "ok")
+	End(ok, nil)
+	_, failed := tracer.Start(context.Background(), "failed")
+	End(failed, errors.New("boom"))
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 || spans[0].Status.Code != codes.Unset || spans[1].Status.Code != codes.Error ||
+		spans[1].Status.Description != "boom" || len(spans[1].Events) != 1 {
+		t.Errorf("unexpected spans %+v", spans)
+	}
+	if spans[0].InstrumentationScope.Name != Scope+"internal/tracing" {
+		t.Errorf("unexpected scope %q", spans[0].InstrumentationScope.Name)
+	}
+}